@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+	"github.com/mintecr7/concurrency-with-go/pkg/counter"
+	"github.com/mintecr7/concurrency-with-go/pkg/loadingcache"
+	"github.com/mintecr7/concurrency-with-go/pkg/progress"
+	"github.com/mintecr7/concurrency-with-go/pkg/stats"
+)
+
+// ============================================================================
+// THE CRAWLER
+// ============================================================================
+//
+//	            ┌──────────── results ◄───────────────┐
+//	            ▼                                     │
+//	coordinator ──► frontier (bounded) ──► worker ×N ─┴─► pages: loadingcache
+//	 owns seen,                              │ pulse        │ singleflight per URL
+//	 pending, report                         ▼              ▼
+//	                                      heartbeat     rate limiter ──► HTTP
+//
+// - coordinator: the only goroutine that touches the seen set, the pending
+//   list and the report - state owned by one goroutine and fed by a channel
+//   (ch02). It hands URLs to the frontier and takes results back in the
+//   same select, so it never blocks a worker and a worker never blocks it:
+//   a crawler whose workers push new links into a bounded queue they also
+//   drain deadlocks as soon as the queue is full.
+// - frontier: a channel of capacity Frontier - how far the coordinator can
+//   run ahead of the workers. The pending list behind it is bounded by
+//   MaxPages: a URL is admitted at most once, and only MaxPages of them.
+// - workers: N goroutines in one conc.Group with the coordinator and the
+//   monitor. They fetch through a loadingcache: a page asked for twice at
+//   the same time - a redirect alias racing the page itself - is fetched
+//   once, and a second time not at all.
+// - rate limiter: a token bucket shared by all fetches.
+// - shutdown: cancelling ctx (Ctrl-C, -timeout) stops the coordinator,
+//   which cancels the group; the cache's Close cancels the requests in
+//   flight and waits for them. Crawl returns what was found so far.
+// - heartbeat: workers pulse; the monitor reports one that went quiet.
+// - metrics: latency histogram (pkg/stats), a striped byte counter
+//   (pkg/counter), a live progress line (pkg/progress).
+// ============================================================================
+
+// Config tunes a crawl. Zero fields take the defaults of withDefaults.
+type Config struct {
+	Workers      int           // fetching goroutines
+	Rate         float64       // requests per second for all workers; < 0 is unlimited
+	Burst        int           // requests that may go out at once after a pause
+	Frontier     int           // URLs queued between coordinator and workers
+	MaxPages     int           // URLs admitted, in all
+	FetchTimeout time.Duration // per request
+	Stall        time.Duration // a worker silent for longer is reported
+	Client       *http.Client
+	Log          io.Writer // heartbeat reports; io.Discard if nil
+	Progress     io.Writer // the live progress line; none if nil
+}
+
+func (c Config) withDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = 8
+	}
+	if c.Rate == 0 {
+		c.Rate = 100
+	}
+	if c.Burst <= 0 {
+		c.Burst = c.Workers
+	}
+	if c.Frontier <= 0 {
+		c.Frontier = 2 * c.Workers
+	}
+	if c.MaxPages <= 0 {
+		c.MaxPages = 1000
+	}
+	if c.FetchTimeout <= 0 {
+		c.FetchTimeout = 5 * time.Second
+	}
+	if c.Stall <= 0 {
+		c.Stall = time.Second
+	}
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+	if c.Log == nil {
+		c.Log = io.Discard
+	}
+	return c
+}
+
+// Report is what a crawl found and what it cost.
+type Report struct {
+	Pages      int         // distinct URLs fetched with a 2xx answer
+	Status     map[int]int // answers by status code, after redirects
+	Failed     int         // fetches with no answer (timeout, connection error)
+	Links      int         // links found on the pages
+	Duplicates int         // links to a URL already admitted
+	External   int         // links to another host, not followed
+	Skipped    int         // links not admitted because MaxPages was reached
+	Redirects  int         // redirects followed
+	Aliased    int         // redirects to a page another URL had already reached
+
+	Requests int64 // HTTP requests made
+	Shared   int64 // fetches that waited for the same URL's request in flight
+	Cached   int64 // fetches answered by the cache without a request
+	Bytes    int64
+	Stalls   int64 // heartbeat episodes
+
+	FrontierHigh int // most URLs queued in the frontier at once
+	PendingHigh  int // most URLs waiting behind it
+	Latency      stats.Snapshot
+	Elapsed      time.Duration
+}
+
+// page is one fetched URL.
+type page struct {
+	status   int
+	redirect string   // Location of a redirect, resolved
+	links    []string // hrefs, resolved, fragments removed
+}
+
+// result is what a worker hands back for one URL from the frontier.
+type result struct {
+	url       string // as admitted
+	final     string // after redirects
+	page      page
+	redirects int
+	err       error
+}
+
+type crawler struct {
+	cfg  Config
+	root *url.URL
+
+	client   *http.Client
+	frontier chan string
+	results  chan result
+	pages    *loadingcache.Cache[string, page]
+	limit    *limiter
+	hb       *heartbeat
+	latency  *stats.Histogram
+	bytes    counter.Adder
+	requests atomic.Int64
+
+	pending atomic.Int64 // for the progress line
+	report  Report       // owned by the coordinator
+}
+
+// Crawl crawls the site of start: every URL on start's host reachable from
+// it, up to cfg.MaxPages. If ctx is cancelled it stops, waits for its
+// goroutines and returns the report so far with ctx's error.
+func Crawl(ctx context.Context, cfg Config, start string) (Report, error) {
+	cfg = cfg.withDefaults()
+	root, err := url.Parse(start)
+	if err != nil || root.Host == "" {
+		return Report{}, fmt.Errorf("start URL %q: want an absolute URL", start)
+	}
+	root.Fragment = ""
+
+	began := time.Now()
+	run, stop := context.WithCancel(ctx) // ends the limiter and the group when the crawl is over
+	defer stop()
+	c := &crawler{
+		cfg:      cfg,
+		root:     root,
+		client:   noRedirects(cfg.Client),
+		frontier: make(chan string, cfg.Frontier),
+		results:  make(chan result),
+		limit:    newLimiter(run, cfg.Rate, cfg.Burst),
+		hb:       newHeartbeat(cfg.Workers, cfg.Stall, cfg.Log),
+		latency:  stats.NewLatencyHistogram(),
+		report:   Report{Status: make(map[int]int)},
+	}
+	c.pages = loadingcache.New(loadingcache.Config[string, page]{Load: c.fetch})
+
+	var bar *progress.Reporter
+	if cfg.Progress != nil {
+		bar = progress.Start(ctx, progress.Config{
+			Unit:  "URLs",
+			Out:   cfg.Progress,
+			Every: 250 * time.Millisecond,
+			Extra: func() string {
+				return fmt.Sprintf("%d queued  %d pending  %.0f req/s  %d stalls", len(c.frontier), c.pending.Load(),
+					float64(c.requests.Load())/time.Since(began).Seconds(), c.hb.stalls.Load())
+			},
+		})
+	}
+
+	g, gctx := conc.NewGroup(run)
+	g.Go(func() error {
+		defer stop() // done or cancelled: either way, everyone else stops too
+		return c.coordinate(gctx, bar)
+	})
+	for i := range cfg.Workers {
+		g.Go(func() error {
+			c.work(gctx, i)
+			return nil
+		})
+	}
+	g.Go(func() error {
+		c.hb.monitor(gctx)
+		return nil
+	})
+	err = g.Wait()
+	c.pages.Close() // cancels what is still loading and waits for it
+	if bar != nil {
+		bar.Stop()
+	}
+
+	r := c.report
+	st := c.pages.Stats()
+	r.Requests, r.Shared, r.Cached = c.requests.Load(), st.Shared, st.Hits
+	r.Bytes = c.bytes.Load()
+	r.Stalls = c.hb.stalls.Load()
+	r.Latency = c.latency.Snapshot()
+	r.Elapsed = time.Since(began)
+	return r, err
+}
+
+// ============================================================================
+// COORDINATOR
+// ============================================================================
+
+func (c *crawler) coordinate(ctx context.Context, bar *progress.Reporter) error {
+	defer close(c.frontier) // the workers' range loops end
+	seen := map[string]bool{c.root.String(): true}
+	visited := map[string]bool{} // final URLs whose page was counted
+	pending := []string{c.root.String()}
+	admitted, inFlight := 1, 0
+
+	for len(pending) > 0 || inFlight > 0 {
+		var send chan<- string // nil - a case that is never ready - when there is nothing to send
+		var next string
+		if len(pending) > 0 {
+			send, next = c.frontier, pending[0]
+		}
+		select {
+		case send <- next:
+			pending = pending[1:]
+			inFlight++
+			c.report.FrontierHigh = max(c.report.FrontierHigh, len(c.frontier))
+		case r := <-c.results:
+			inFlight--
+			if r.err != nil {
+				if ctx.Err() == nil {
+					c.report.Failed++
+					if bar != nil {
+						bar.Fail(1)
+					}
+				}
+				continue
+			}
+			c.report.Redirects += r.redirects
+			seen[r.final] = true // reached through a redirect: don't schedule it again
+			if visited[r.final] {
+				c.report.Aliased++
+				continue
+			}
+			visited[r.final] = true
+			c.report.Status[r.page.status]++
+			if r.page.status >= 200 && r.page.status < 300 {
+				c.report.Pages++
+			}
+			if bar != nil {
+				bar.Add(1)
+			}
+			for _, link := range r.page.links {
+				c.report.Links++
+				switch {
+				case !c.sameSite(link):
+					c.report.External++
+				case seen[link]:
+					c.report.Duplicates++
+				case admitted >= c.cfg.MaxPages:
+					c.report.Skipped++
+				default:
+					seen[link] = true
+					admitted++
+					pending = append(pending, link)
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		c.report.PendingHigh = max(c.report.PendingHigh, len(pending))
+		c.pending.Store(int64(len(pending)))
+	}
+	return nil
+}
+
+func (c *crawler) sameSite(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && u.Scheme == c.root.Scheme && u.Host == c.root.Host
+}
+
+// ============================================================================
+// WORKERS
+// ============================================================================
+
+const maxRedirects = 5
+
+func (c *crawler) work(ctx context.Context, id int) {
+	beat := time.NewTicker(c.cfg.Stall / 4)
+	defer beat.Stop()
+	for {
+		c.hb.pulse(id)
+		select {
+		case <-beat.C: // idle: pulse and keep waiting
+		case u, ok := <-c.frontier:
+			if !ok {
+				return
+			}
+			c.hb.working(id, u)
+			r := c.visit(ctx, u)
+			c.hb.working(id, "")
+			select {
+			case c.results <- r:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// visit fetches u, following redirects within the site through the same
+// cache: if the target is being fetched by another worker right now, this
+// one waits for that request instead of making its own.
+func (c *crawler) visit(ctx context.Context, u string) result {
+	r := result{url: u, final: u}
+	for {
+		p, err := c.pages.Get(ctx, r.final)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		if p.redirect == "" || r.redirects == maxRedirects {
+			r.page = p
+			return r
+		}
+		if !c.sameSite(p.redirect) {
+			p.links = []string{p.redirect} // counted as an external link
+			r.page = p
+			return r
+		}
+		r.redirects++
+		r.final = p.redirect
+	}
+}
+
+var hrefRE = regexp.MustCompile(`(?i)<a\s[^>]*href="([^"]*)"`)
+
+// fetch is the cache's loader: one rate-limited HTTP request.
+func (c *crawler) fetch(ctx context.Context, u string) (page, error) {
+	if err := c.limit.Wait(ctx); err != nil {
+		return page{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.FetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return page{}, err
+	}
+	c.requests.Add(1)
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return page{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return page{}, err
+	}
+	c.latency.ObserveDuration(time.Since(start))
+	c.bytes.Add(int64(len(body)))
+
+	p := page{status: resp.StatusCode}
+	base := resp.Request.URL
+	switch {
+	case p.status >= 300 && p.status < 400:
+		if loc, ok := resolve(base, resp.Header.Get("Location")); ok {
+			p.redirect = loc
+		}
+	case p.status >= 200 && p.status < 300 && strings.Contains(resp.Header.Get("Content-Type"), "html"):
+		for _, m := range hrefRE.FindAllSubmatch(body, -1) {
+			if link, ok := resolve(base, string(m[1])); ok {
+				p.links = append(p.links, link)
+			}
+		}
+	}
+	return p, nil
+}
+
+// noRedirects returns a copy of client that doesn't follow redirects: the
+// crawler follows them itself, through the cache.
+func noRedirects(client *http.Client) *http.Client {
+	cl := *client
+	cl.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return &cl
+}
+
+// resolve makes href absolute against base and drops its fragment. Only
+// http and https links count.
+func resolve(base *url.URL, href string) (string, bool) {
+	u, err := base.Parse(strings.TrimSpace(href))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	u.Fragment, u.RawFragment = "", ""
+	return u.String(), true
+}
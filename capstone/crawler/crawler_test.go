@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// CRAWLING A SITE WHOSE SHAPE IS KNOWN
+// ============================================================================
+// Each test starts a fresh fake site, crawls it and checks the report
+// against what the site expects and what the server saw.
+// ============================================================================
+
+// admittedAll is how many URLs the coordinator admitted, from the link
+// counts: the start URL and every link that was neither a duplicate, nor
+// external, nor over MaxPages.
+func admittedAll(r Report) int {
+	return 1 + r.Links - r.Duplicates - r.External - r.Skipped
+}
+
+// accounted is how many admitted URLs the report gives an outcome for.
+// Every one of them must have exactly one.
+func accounted(r Report) int {
+	n := r.Failed + r.Aliased
+	for _, count := range r.Status {
+		n += count
+	}
+	return n
+}
+
+// crawlSite starts a site, crawls it from /page/0 and stops the site. The
+// server notices a request the client gave up on a moment later, so its
+// stats are taken once nothing is in flight, or after a second.
+func crawlSite(t *testing.T, site siteConfig, cfg Config) (Report, siteStats) {
+	t.Helper()
+	s := newFakeSite(site)
+	defer s.Close()
+	r, err := Crawl(context.Background(), cfg, s.URL+"/page/0")
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	return r, s.settled(time.Second)
+}
+
+// TestFullCrawl checks that every page is fetched once and every admitted
+// URL gets exactly one outcome.
+func TestFullCrawl(t *testing.T) {
+	site := siteConfig{Pages: 200, Latency: 2 * time.Millisecond, Tarpit: 20 * time.Millisecond}
+	e := (&fakeSite{cfg: site}).expect()
+	r, st := crawlSite(t, site, Config{Workers: 8, Rate: -1})
+
+	total := 0
+	for path, n := range st.hits {
+		total += n
+		if n != 1 {
+			t.Errorf("%s requested %d times, want once: the cache should see to the aliases", path, n)
+		}
+	}
+	for i := range site.Pages {
+		if st.hits[fmt.Sprintf("/page/%d", i)] == 0 {
+			t.Errorf("/page/%d never requested", i)
+		}
+	}
+	if r.Requests != int64(total) {
+		t.Errorf("the crawler counted %d requests, the server %d", r.Requests, total)
+	}
+	if r.Pages != e.pages+e.slow {
+		t.Errorf("%d pages with a 2xx answer, want %d + %d slow ones", r.Pages, e.pages, e.slow)
+	}
+	if r.Status[http.StatusNotFound] != e.missing || r.Status[http.StatusInternalServerError] != e.broken {
+		t.Errorf("%d × 404 and %d × 500, want %d and %d",
+			r.Status[http.StatusNotFound], r.Status[http.StatusInternalServerError], e.missing, e.broken)
+	}
+	if r.Redirects != e.aliases {
+		t.Errorf("%d redirects followed, want one per /go/ URL: %d", r.Redirects, e.aliases)
+	}
+	if r.External == 0 {
+		t.Error("no links to another host counted")
+	}
+	if accounted(r) != admittedAll(r) {
+		t.Errorf("%d URLs admitted, %d outcomes: want exactly one each", admittedAll(r), accounted(r))
+	}
+	if st.maxInFlight < 2 || st.maxInFlight > 8 {
+		t.Errorf("the server saw at most %d requests at once, want 2..8 (8 workers)", st.maxInFlight)
+	}
+	if r.FrontierHigh > 16 {
+		t.Errorf("the frontier held %d URLs, capacity 16", r.FrontierHigh)
+	}
+}
+
+// TestRateLimit checks the server side of the limiter: no window holds
+// more requests than the burst plus the refill, and the crawl takes no
+// less time than the rate allows.
+func TestRateLimit(t *testing.T) {
+	const rate, burst = 200, 5
+	site := siteConfig{Pages: 40, Tarpit: 10 * time.Millisecond}
+	r, st := crawlSite(t, site, Config{Workers: 8, Rate: rate, Burst: burst})
+
+	// The most requests the server saw within any 100ms: the burst plus
+	// what the bucket refills, with a little slack for the ticker.
+	const window = 100 * time.Millisecond
+	most := 0
+	for i, at := range st.times {
+		n := 0
+		for _, u := range st.times[i:] {
+			if u.Sub(at) >= window {
+				break
+			}
+			n++
+		}
+		most = max(most, n)
+	}
+	if limit := burst + int(rate*window.Seconds()) + 2; most > limit {
+		t.Errorf("%d requests within %v, limit %d", most, window, limit)
+	}
+	least := time.Duration(float64(len(st.times)-burst) / rate * 0.9 * float64(time.Second))
+	if r.Elapsed < least {
+		t.Errorf("%d requests took %v, the rate allows no less than %v", len(st.times), r.Elapsed, least)
+	}
+}
+
+func TestMaxPages(t *testing.T) {
+	site := siteConfig{Pages: 200, Latency: time.Millisecond}
+	r, _ := crawlSite(t, site, Config{Workers: 4, Rate: -1, MaxPages: 50})
+	if admittedAll(r) != 50 || accounted(r) != 50 {
+		t.Errorf("%d URLs admitted, %d outcomes, want 50 and 50", admittedAll(r), accounted(r))
+	}
+	if r.Skipped == 0 {
+		t.Error("no link over the limit counted as skipped")
+	}
+	if r.PendingHigh > 50 {
+		t.Errorf("%d URLs waited behind the frontier, want at most 50", r.PendingHigh)
+	}
+}
+
+// TestTarpit crawls a site with a stuck backend: the heartbeat reports the
+// stalled workers, the fetch timeout ends their requests, and the rest of
+// the site is crawled regardless.
+func TestTarpit(t *testing.T) {
+	site := siteConfig{Pages: 60, Latency: time.Millisecond, Tarpit: 400 * time.Millisecond}
+	e := (&fakeSite{cfg: site}).expect()
+	var log bytes.Buffer
+	r, st := crawlSite(t, site, Config{Workers: 4, Rate: -1, FetchTimeout: 150 * time.Millisecond,
+		Stall: 50 * time.Millisecond, Log: &log})
+
+	if r.Failed != e.slow {
+		t.Errorf("%d fetches failed, want the %d /slow/ pages", r.Failed, e.slow)
+	}
+	if r.Stalls < int64(e.slow) || !strings.Contains(log.String(), "fetching ") {
+		t.Errorf("%d stalls reported, want at least %d naming the URL; log:\n%s", r.Stalls, e.slow, log.String())
+	}
+	if r.Pages != e.pages {
+		t.Errorf("%d pages crawled, want the other %d", r.Pages, e.pages)
+	}
+	if st.inFlight != 0 {
+		t.Errorf("the server has %d requests still in flight", st.inFlight)
+	}
+}
+
+// TestShutdown cancels a crawl midway: Crawl must return promptly with
+// what it found, and leave no goroutine or request behind.
+func TestShutdown(t *testing.T) {
+	site := newFakeSite(siteConfig{Pages: 2000, Latency: 5 * time.Millisecond})
+	defer site.Close()
+	transport := &http.Transport{}
+	client := &http.Client{Transport: transport}
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var cancelled time.Time // written before cancel, read after Crawl saw it
+	time.AfterFunc(100*time.Millisecond, func() {
+		cancelled = time.Now()
+		cancel()
+	})
+	r, err := Crawl(ctx, Config{Workers: 8, Rate: -1, Client: client}, site.URL+"/page/0")
+	took := time.Since(cancelled)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Crawl = %v, want Canceled", err)
+	}
+	if r.Pages == 0 || r.Pages >= 2000 {
+		t.Errorf("%d pages found, want some but not all", r.Pages)
+	}
+	if took > 100*time.Millisecond {
+		t.Errorf("Crawl returned %v after the cancel", took)
+	}
+
+	// The connections the transport keeps open hold goroutines on both
+	// ends; closing them should leave exactly what was there before.
+	transport.CloseIdleConnections()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after the crawl, %d before", n, before)
+	}
+	if n := site.settled(time.Second).inFlight; n != 0 {
+		t.Errorf("the server has %d requests still in flight", n)
+	}
+}
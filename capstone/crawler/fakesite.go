@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// ============================================================================
+// THE FAKE SITE
+// ============================================================================
+// A local server with a known shape, so a crawl of it can be checked:
+//
+//   - /page/0 .. /page/N-1: every page links to the next one (so all are
+//     reachable from /page/0) and to a few others, some of them twice or
+//     with a #fragment
+//   - /go/K redirects to /page/K: the same page under a second URL
+//   - /missing/K answers 404, /broken/K answers 500
+//   - /slow/K takes Tarpit to answer: a stuck backend
+//   - some links point to another host and must not be followed
+//
+// The server counts the requests for every path and how many it served at
+// once, which is what the tests check the crawler against.
+// ============================================================================
+
+// siteConfig shapes the fake site.
+type siteConfig struct {
+	Pages   int           // /page/0 .. /page/Pages-1
+	Latency time.Duration // added to every response
+	Tarpit  time.Duration // how long a /slow/ page takes
+}
+
+// fakeSite is a running fake site.
+type fakeSite struct {
+	*httptest.Server
+	cfg siteConfig
+
+	mu          sync.Mutex
+	hits        map[string]int
+	times       []time.Time // when each request arrived
+	inFlight    int
+	maxInFlight int
+}
+
+func newFakeSite(cfg siteConfig) *fakeSite {
+	s := &fakeSite{cfg: cfg, hits: make(map[string]int)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /page/{i}", func(w http.ResponseWriter, r *http.Request) {
+		i, err := strconv.Atoi(r.PathValue("i"))
+		if err != nil || i < 0 || i >= cfg.Pages {
+			http.NotFound(w, r)
+			return
+		}
+		s.writePage(w, fmt.Sprintf("page %d", i), s.links(i))
+	})
+	mux.HandleFunc("GET /go/{i}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page/"+r.PathValue("i"), http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("GET /broken/{i}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	})
+	mux.HandleFunc("GET /slow/{i}", func(w http.ResponseWriter, r *http.Request) {
+		if timeutil.Sleep(r.Context(), cfg.Tarpit) != nil {
+			return // the client gave up
+		}
+		s.writePage(w, "slow page", []string{"/page/0"})
+	})
+	s.Server = httptest.NewServer(s.track(mux))
+	return s
+}
+
+// track counts every request and how many run at once, and adds Latency.
+func (s *fakeSite) track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits[r.URL.Path]++
+		s.times = append(s.times, time.Now())
+		s.inFlight++
+		s.maxInFlight = max(s.maxInFlight, s.inFlight)
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		}()
+		if timeutil.Sleep(r.Context(), s.cfg.Latency) != nil {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// links returns the hrefs on /page/i.
+func (s *fakeSite) links(i int) []string {
+	n := s.cfg.Pages
+	page := func(k int) string { return "/page/" + strconv.Itoa(k%n) }
+	links := []string{
+		page(i + 1), // the chain that makes every page reachable
+		page(i*7 + 3),
+		page(i * i),
+		page(i+1) + "#top", // the same page again
+		"/go/" + strconv.Itoa((i+5)%n),
+	}
+	if i%10 == 0 {
+		links = append(links, "/missing/"+strconv.Itoa(i))
+	}
+	if i%25 == 0 {
+		links = append(links, "/broken/"+strconv.Itoa(i))
+	}
+	if i%8 == 0 {
+		links = append(links, "https://elsewhere.invalid/"+strconv.Itoa(i))
+	}
+	if i%50 == 7 {
+		links = append(links, "/slow/"+strconv.Itoa(i))
+	}
+	return links
+}
+
+func (s *fakeSite) writePage(w http.ResponseWriter, title string, links []string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	var b strings.Builder
+	fmt.Fprintf(&b, "<html><head><title>%s</title></head><body>\n", title)
+	for _, l := range links {
+		fmt.Fprintf(&b, "<a href=\"%s\">%s</a>\n", l, l)
+	}
+	b.WriteString("</body></html>\n")
+	w.Write([]byte(b.String()))
+}
+
+// expected is what a complete crawl of the site from /page/0 finds.
+type expected struct {
+	pages, missing, broken, slow, aliases int
+}
+
+func (s *fakeSite) expect() expected {
+	var e expected
+	aliases := map[int]bool{}
+	for i := range s.cfg.Pages {
+		e.pages++
+		aliases[(i+5)%s.cfg.Pages] = true
+		if i%10 == 0 {
+			e.missing++
+		}
+		if i%25 == 0 {
+			e.broken++
+		}
+		if i%50 == 7 {
+			e.slow++
+		}
+	}
+	e.aliases = len(aliases)
+	return e
+}
+
+// siteStats is a snapshot of what the server saw.
+type siteStats struct {
+	hits        map[string]int
+	times       []time.Time
+	maxInFlight int
+	inFlight    int
+}
+
+func (s *fakeSite) stats() siteStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := siteStats{hits: make(map[string]int, len(s.hits)), times: slices.Clone(s.times),
+		maxInFlight: s.maxInFlight, inFlight: s.inFlight}
+	for k, v := range s.hits {
+		st.hits[k] = v
+	}
+	return st
+}
+
+// settled returns the stats once no request is in flight, or after d.
+func (s *fakeSite) settled(d time.Duration) siteStats {
+	deadline := time.Now().Add(d)
+	st := s.stats()
+	for st.inFlight > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		st = s.stats()
+	}
+	return st
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// HEARTBEATS: NOTICING A WORKER THAT HAS GONE QUIET
+// ============================================================================
+// A worker stuck in a request looks exactly like a busy one from outside.
+// So every worker pulses: once per loop iteration, and on a ticker while it
+// waits for work. A pulse is a non-blocking send on the worker's own
+// 1-buffered channel - a worker never waits for the monitor.
+//
+// The monitor wakes up four times per stall period, drains the pulses and
+// reports a worker that has been silent for longer than the period - once
+// per episode, with what the worker was doing. Reporting is all it does:
+// the fetch timeout is what ends a stuck request. (A supervisor could
+// restart the worker instead; see the heartbeat chapter of the book.)
+// ============================================================================
+
+// heartbeat watches the pulses of n workers.
+type heartbeat struct {
+	stall  time.Duration
+	pulses []chan struct{}
+	doing  []atomic.Pointer[string] // the URL each worker is fetching
+	stalls atomic.Int64
+	log    io.Writer
+}
+
+func newHeartbeat(n int, stall time.Duration, log io.Writer) *heartbeat {
+	h := &heartbeat{stall: stall, pulses: make([]chan struct{}, n), doing: make([]atomic.Pointer[string], n), log: log}
+	for i := range h.pulses {
+		h.pulses[i] = make(chan struct{}, 1)
+	}
+	return h
+}
+
+// pulse says worker i is alive.
+func (h *heartbeat) pulse(i int) {
+	select {
+	case h.pulses[i] <- struct{}{}:
+	default: // a pulse is already waiting to be read
+	}
+}
+
+// working records what worker i is doing; "" is waiting for work.
+func (h *heartbeat) working(i int, url string) {
+	h.doing[i].Store(&url)
+}
+
+// monitor runs until ctx is done.
+func (h *heartbeat) monitor(ctx context.Context) {
+	last := make([]time.Time, len(h.pulses))
+	silent := make([]bool, len(h.pulses)) // in a reported episode
+	for i := range last {
+		last[i] = time.Now()
+	}
+	t := time.NewTicker(h.stall / 4)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			for i, p := range h.pulses {
+				select {
+				case <-p:
+					last[i], silent[i] = now, false
+					continue
+				default:
+				}
+				if quiet := now.Sub(last[i]); !silent[i] && quiet > h.stall {
+					silent[i] = true
+					h.stalls.Add(1)
+					what := "waiting"
+					if u := h.doing[i].Load(); u != nil && *u != "" {
+						what = "fetching " + *u
+					}
+					fmt.Fprintf(h.log, "heartbeat: worker %d silent for %v, %s\n", i, quiet.Round(time.Millisecond), what)
+				}
+			}
+		}
+	}
+}
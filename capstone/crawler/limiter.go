@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// ============================================================================
+// RATE LIMITER: A TOKEN BUCKET MADE OF A CHANNEL
+// ============================================================================
+// A request may go out when it can take a token from the bucket. The
+// bucket is a buffered channel holding up to burst tokens; one goroutine
+// puts a token in every 1/rate, dropping it if the bucket is full. So:
+//
+//   - after a quiet spell, burst requests go out at once
+//   - over any longer stretch, no more than rate per second
+//   - a waiting request sits in a select, so cancelling its context frees
+//     it immediately
+// ============================================================================
+
+// limiter is a token bucket. A nil *limiter doesn't limit.
+type limiter struct {
+	tokens chan struct{}
+}
+
+// newLimiter returns a limiter allowing rate requests per second with
+// bursts of burst, or nil if rate <= 0. It refills until ctx is done.
+func newLimiter(ctx context.Context, rate float64, burst int) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	l := &limiter{tokens: make(chan struct{}, max(burst, 1))}
+	for range cap(l.tokens) {
+		l.tokens <- struct{}{} // start full
+	}
+	go func() {
+		for range timeutil.Tick(ctx, time.Duration(float64(time.Second)/rate)) {
+			select {
+			case l.tokens <- struct{}{}:
+			default: // full: the token is lost, as in any bucket
+			}
+		}
+	}()
+	return l
+}
+
+// Wait takes a token, waiting for one if necessary, or returns ctx's error
+// if ctx is done first.
+func (l *limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-l.tokens:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -85,6 +85,9 @@ func runDeadlock() {
 //    lowest ID to highest ID.
 // C. Use Channels: In Go, it is often better to communicate to share memory
 //    rather than sharing memory to communicate (using Mutexes).
+// D. Timed Locks: Give up on the second lock after a deadline, release the
+//    first, back off with jitter and retry (deadlock_recovery.go:
+//    go run ./ch01_introduction recovery).
 
 // main      printSum(&a,&b)     a.lock      b.lock     printSum(&b,&a)
 //    |              |              |           |               |
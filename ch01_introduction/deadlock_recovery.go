@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/guard"
+)
+
+// Deadlock recovery :- printSum above locks its two values in opposite
+// orders and never gets out. Lock ordering prevents that; when it can't be
+// used (the order isn't known up front), the other classic answer is to
+// make the second lock TIMED: if it doesn't come within a deadline, assume
+// a deadlock, release what we hold, back off and start over.
+//
+// The backoff needs JITTER. Two goroutines that release and retry on the
+// same schedule just collide again, forever - that's the livelock from
+// livelock.go. A random wait lets one of them get both locks first.
+
+type guardedValue struct {
+	mu guard.Mutex
+	v  int
+}
+
+// lockTimeout is how long printSumRecoverable waits for its second lock
+// before it assumes a deadlock.
+const lockTimeout = 100 * time.Millisecond
+
+// printSumRecoverable is printSum with a timed second lock. It returns the
+// number of times it had to back off.
+func printSumRecoverable(name string, v1, v2 *guardedValue) (retries int) {
+	backoff := 20 * time.Millisecond
+	for {
+		v1.mu.Lock()
+		time.Sleep(50 * time.Millisecond) // the work that makes both grab their first lock
+
+		ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+		err := v2.mu.LockCtx(ctx)
+		cancel()
+		if err == nil {
+			fmt.Printf("  %s: sum=%v after %d retries\n", name, v1.v+v2.v, retries)
+			v2.mu.Unlock()
+			v1.mu.Unlock()
+			return retries
+		}
+
+		// Probably deadlocked: give up our lock so the other side can finish.
+		v1.mu.Unlock()
+		retries++
+		wait := rand.N(backoff) // full jitter: anywhere in [0, backoff)
+		fmt.Printf("  %s: second lock timed out (%v), backing off %v\n", name, err, wait.Round(time.Millisecond))
+		time.Sleep(wait)
+		backoff *= 2
+	}
+}
+
+func runDeadlockRecovery() {
+	fmt.Println("printSum with guard.Mutex: timed second lock, release, back off with jitter, retry")
+	var a, b guardedValue
+	a.v, b.v = 1, 2
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	var retriesAB, retriesBA int
+	wg.Go(func() { retriesAB = printSumRecoverable("printSum(a,b)", &a, &b) })
+	wg.Go(func() { retriesBA = printSumRecoverable("printSum(b,a)", &b, &a) })
+	wg.Wait()
+
+	fmt.Printf("both finished in %v with %d retries in total (%d + %d)\n",
+		time.Since(start).Round(time.Millisecond), retriesAB+retriesBA, retriesAB, retriesBA)
+	fmt.Println("→ No deadlock, but each retry cost a timeout. Lock ordering is still")
+	fmt.Println("  the real fix; timeouts are the fallback when ordering isn't possible.")
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
 )
 
 // Livelock :- A state where concurrent processes are constantly changing state
@@ -17,11 +20,12 @@ import (
 
 var cadence = sync.NewCond(&sync.Mutex{})
 
-func init() {
-	// This goroutine simulates the "beat" of the world.
-	// Every millisecond, it tells everyone they can try to take a step.
+// startCadence starts the goroutine that simulates the "beat" of the world:
+// every millisecond it tells everyone they can try to take a step. It
+// stops when ctx is cancelled.
+func startCadence(ctx context.Context) {
 	go func() {
-		for range time.Tick(1 * time.Millisecond) {
+		for range timeutil.Tick(ctx, 1*time.Millisecond) {
 			cadence.Broadcast()
 		}
 	}()
@@ -55,6 +59,10 @@ func tryDir(dirName string, dir *int32, out *bytes.Buffer) bool {
 }
 
 func runLivelock() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // the beat stops with the demo
+	startCadence(ctx)
+
 	var wg sync.WaitGroup
 	var left, right int32
 
@@ -1,6 +1,37 @@
 package main
 
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// demos can be picked by name, e.g. go run ./ch01_introduction race. The
+// race summarizer (go run ./cmd/lab races) runs them this way under -race.
+var demos = map[string]func(){
+	"atomicity":  demoAtomicityExamples,
+	"deadlock":   runDeadlock,
+	"recovery":   runDeadlockRecovery,
+	"livelock":   runLivelock,
+	"memsync":    memoryAccessSynchronization,
+	"race":       runRaceCondition,
+	"starvation": runStarvation,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		demo, ok := demos[os.Args[1]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown demo %q, want one of: %s\n",
+				os.Args[1], strings.Join(slices.Sorted(maps.Keys(demos)), ", "))
+			os.Exit(2)
+		}
+		demo()
+		return
+	}
+
 	// demoAtomicityExamples()
 	memoryAccessSynchronization()
 }
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/ticketlock"
 )
 
 // Starvation :- A situation where a concurrent process cannot get the
@@ -14,69 +16,112 @@ import (
 // expense of "polite" processes.
 
 var wG sync.WaitGroup // counting semaphore
-var sharedLock sync.Mutex
 
 const runtime = 1 * time.Second
 
-func runStarvation() {
-	// Greedy worker: Holds the lock for the entire duration of its work.
+// busy keeps the CPU busy for d, the way real work would. (time.Sleep
+// would park the goroutine and let the scheduler pick who runs next.)
+func busy(d time.Duration) {
+	for start := time.Now(); time.Since(start) < d; {
+	}
+}
+
+// starvationResult is how much each worker managed to do in one run.
+type starvationResult struct {
+	greedyLoops, politeLoops int
+}
+
+// runStarvationWith runs the greedy and polite workers against sharedLock
+// for the configured runtime. Any sync.Locker works, so the same workers
+// can be pitted against an unfair and a fair lock.
+func runStarvationWith(sharedLock sync.Locker) starvationResult {
+	var result starvationResult
+	deadline := time.Now().Add(runtime) // the same window for both workers
+
+	// Greedy worker: Holds the lock for the entire duration of its work,
+	// and asks for it again the moment it lets go.
 	// This minimizes the "window of opportunity" for anyone else to grab the lock.
 	greedyWorker := func() {
 		defer wG.Done()
 		var count int
-		for begin := time.Now(); time.Since(begin) <= runtime; {
+		for time.Now().Before(deadline) {
 			sharedLock.Lock()
-			time.Sleep(3 * time.Nanosecond) // Simulated work
+			busy(3 * time.Microsecond) // Simulated work
 			sharedLock.Unlock()
 			count++
 		}
-		fmt.Printf("Greedy worker was able to execute %v work loops\n", count)
+		result.greedyLoops = count
 	}
 
-	// Polite worker: Only holds the lock for exactly what it needs.
-	// It constantly releases and re-acquires, creating many windows
-	// where it might lose the lock to the greedy worker.
+	// Polite worker: Only holds the lock for exactly what it needs - the
+	// 1µs of its loop that touches shared state. Each loop is still one
+	// turn at the lock, which it has to win back from a worker that never
+	// leaves it free.
 	politeWorker := func() {
 		defer wG.Done()
 		var count int
-		for begin := time.Now(); time.Since(begin) <= runtime; {
-			sharedLock.Lock()
-			time.Sleep(1 * time.Nanosecond)
-			sharedLock.Unlock()
-
+		for time.Now().Before(deadline) {
 			sharedLock.Lock()
-			time.Sleep(1 * time.Nanosecond)
-			sharedLock.Unlock()
-
-			sharedLock.Lock()
-			time.Sleep(1 * time.Nanosecond)
+			busy(1 * time.Microsecond)
 			sharedLock.Unlock()
+			busy(1 * time.Microsecond) // work of its own, outside the lock
 
 			count++
 		}
-		fmt.Printf("Polite worker was able to execute %v work loops.\n", count)
+		result.politeLoops = count
 	}
 
 	wG.Add(2)
 	go politeWorker()
 	go greedyWorker()
-	wG.Wait()
+	wG.Wait() // each worker writes its own fields; Wait makes them visible here
+	return result
+}
+
+func runStarvation() {
+	report := func(r starvationResult) {
+		fmt.Printf("Greedy worker was able to execute %v work loops\n", r.greedyLoops)
+		fmt.Printf("Polite worker was able to execute %v work loops\n", r.politeLoops)
+		fmt.Printf("Greedy/polite work ratio: %.2fx\n", float64(r.greedyLoops)/float64(max(r.politeLoops, 1)))
+	}
+
+	fmt.Println("=== sync.Mutex (no fairness guarantee) ===")
+	report(runStarvationWith(&sync.Mutex{}))
+
+	// A ticket lock hands the lock out strictly in arrival order, so the
+	// greedy worker can no longer re-grab it the moment it lets go.
+	fmt.Println("\n=== ticketlock.Mutex (FIFO, fair) ===")
+	report(runStarvationWith(&ticketlock.Mutex{}))
 }
 
 // --- What is happening here? ---
 //
-// 1. THE RESOURCE: Both workers need the 'sharedLock' to perform their 3ns of work.
+// 1. THE RESOURCE: Both workers need the 'sharedLock' once per work loop.
 //
 // 2. THE CRITICAL SECTION:
-//    - The Greedy worker expands its critical section to cover all 3ns at once.
-//    - The Polite worker breaks its work into three 1ns sections.
+//    - The Greedy worker holds the lock for its whole 3µs of work and asks
+//      for it again the moment it lets go.
+//    - The Polite worker holds it only for the 1µs that needs it, then does
+//      1µs of work of its own before asking again.
 //
 // 3. THE IMBALANCE:
-//    Every time the Polite worker unlocks, it gives the Go scheduler a chance
-//    to hand the lock to the Greedy worker. Because the Greedy worker holds the
-//    lock longer and more consistently, it effectively "starves" the Polite worker.
+//    sync.Mutex lets a goroutine that asks for the lock take it even if
+//    another one has been waiting longer. The Greedy worker asks again the
+//    instant it unlocks, while the woken Polite worker is still getting
+//    back onto a CPU - so the Greedy worker keeps winning, and the one that
+//    needs the lock LESS gets LESS done.
 //
 // 4. THE METRIC:
 //    Starvation is identified via metrics. In the output, you will see the
-//    Greedy worker completes nearly double the work of the Polite worker in
-//    the same 1-second window.
+//    Greedy worker complete several times the work loops of the Polite
+//    worker in the same 1-second window. (It takes two CPUs: with
+//    GOMAXPROCS=1 the workers only ever take turns on the one processor,
+//    so neither is left waiting on the lock and both ratios sit near 1.)
+//
+// 5. THE FAIR LOCK:
+//    ticketlock.Mutex serves goroutines strictly in arrival order: a worker
+//    that unlocks and asks again goes to the back of the line, behind the
+//    one already waiting. The workers alternate, one loop each per turn, so
+//    the work-loop ratio drops to ~1.00x. (sync.Mutex has a fallback: a
+//    waiter that has been passed over for 1ms switches it into a FIFO
+//    "starvation mode" - which bounds the starvation but doesn't end it.)
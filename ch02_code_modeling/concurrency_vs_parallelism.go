@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"runtime"
 	"sync"
+	"text/tabwriter"
 	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+	"github.com/mintecr7/concurrency-with-go/pkg/workload"
 )
 
 // =============================================================================
@@ -56,59 +62,172 @@ func SimulateWork(id int, wg *sync.WaitGroup) {
 	time.Sleep(100 * time.Millisecond) // Simulating CPU work
 }
 
-// CPUBoundWork does actual CPU-intensive work (not just sleeping)
-// This forces the runtime to actually use CPU cores
-func CPUBoundWork(id int, wg *sync.WaitGroup) {
-	defer wg.Done()
+// =============================================================================
+// AMDAHL'S LAW: WHY SPEEDUP PLATEAUS
+// =============================================================================
+// If a fraction s of a program's work is serial, n cores can at best run it
+//
+//	S(n) = 1 / (s + (1-s)/n)
+//
+// times faster - and never more than 1/s, however many cores there are.
+// Turned around (the Karp-Flatt metric), a measured speedup S on n cores
+// implies the serial fraction
+//
+//	s = (1/S - 1/n) / (1 - 1/n)
+//
+// which also absorbs everything else that doesn't scale: scheduling,
+// memory bandwidth, other processes.
 
-	start := time.Now()
+const (
+	// demoWork is one run's total work, in workload.Spin iterations.
+	demoWork = 400_000_000
+	// demoSerialShare is the part of demoWork done before the fan-out, by
+	// one goroutine - the "s" RunDemo should measure.
+	demoSerialShare = 0.10
+)
 
-	// CPU-intensive calculation
-	sum := 0
-	for i := range 1000000000 {
-		sum += i
-	}
+// CoreTiming is one run of the workload with GOMAXPROCS set to Cores.
+type CoreTiming struct {
+	Cores   int
+	Elapsed time.Duration
+	Speedup float64 // over the 1-core run
+	// SerialFraction is the s that Amdahl's law needs to explain Speedup;
+	// undefined (0) for one core.
+	SerialFraction float64
+}
 
-	elapsed := time.Since(start)
-	fmt.Printf("Task %d finished in %v (sum=%d)\n", id, elapsed, sum)
+// ParallelismResults is what RunDemo measured.
+type ParallelismResults struct {
+	Tasks   int
+	Timings []CoreTiming // for 1..NumCPU cores
+	// SerialFraction is the mean of the per-core-count estimates, or 0 on a
+	// single-CPU machine.
+	SerialFraction float64
 }
 
-// RunDemo proves that Parallelism is a property of the Runtime, not the code.
-// We run the EXACT same code twice, but change the Runtime context.
-func RunDemo() {
-	numTasks := 4
+// amdahlSpeedup is S(n) for serial fraction s.
+func amdahlSpeedup(s float64, n int) float64 {
+	return 1 / (s + (1-s)/float64(n))
+}
 
-	// Scenario 1: Single Core - Tasks run sequentially
-	fmt.Println("=== SINGLE CORE (Concurrent but NOT Parallel) ===")
-	runtime.GOMAXPROCS(1)
+// runWorkload does the serial part, then splits the rest into tasks
+// goroutines, and returns how long it all took.
+func runWorkload(tasks int) time.Duration {
+	serial := int(demoWork * demoSerialShare)
+	chunk := (demoWork - serial) / tasks
 
 	start := time.Now()
+	workload.CPU(serial).Run() // e.g. reading input: nobody can help
 	var wg sync.WaitGroup
-	wg.Add(numTasks)
-	for i := 1; i <= numTasks; i++ {
-		go CPUBoundWork(i, &wg)
+	for range tasks {
+		wg.Go(func() { workload.CPU(chunk).Run() })
 	}
 	wg.Wait()
-	elapsed1 := time.Since(start)
-	fmt.Printf("Total time (1 core): %v\n\n", elapsed1)
-
-	// Scenario 2: Multiple Cores - Tasks can run in parallel
-	fmt.Println("=== MULTIPLE CORES (Concurrent AND Parallel) ===")
-	runtime.GOMAXPROCS(runtime.NumCPU())
-	fmt.Printf("Using %d cores\n", runtime.NumCPU())
-
-	start = time.Now()
-	wg.Add(numTasks)
-	for i := 5; i <= numTasks+4; i++ {
-		go CPUBoundWork(i, &wg)
+	return time.Since(start)
+}
+
+// RunDemo proves that Parallelism is a property of the Runtime, not the code.
+// We run the EXACT same code with GOMAXPROCS = 1, 2, ... NumCPU and watch
+// the speedup flatten out the way Amdahl's law says it must.
+func RunDemo() ParallelismResults {
+	cpus := runtime.NumCPU()
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	// Several tasks per core, so that every core count divides the work
+	// about evenly.
+	res := ParallelismResults{Tasks: 4 * cpus}
+	fmt.Printf("=== SAME CODE, 1..%d CORES (%d tasks, %.0f%% serial by design) ===\n",
+		cpus, res.Tasks, demoSerialShare*100)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "cores\ttime\tspeedup\tideal\timplied serial\t")
+	var base time.Duration // the 1-core time
+	var sum float64
+	for n := 1; n <= cpus; n++ {
+		runtime.GOMAXPROCS(n)
+		t := CoreTiming{Cores: n, Elapsed: runWorkload(res.Tasks)}
+		if n == 1 {
+			base = t.Elapsed
+		}
+		t.Speedup = float64(base) / float64(t.Elapsed)
+		implied := "-"
+		if n > 1 {
+			t.SerialFraction = (1/t.Speedup - 1/float64(n)) / (1 - 1/float64(n))
+			sum += t.SerialFraction
+			implied = fmt.Sprintf("%.1f%%", t.SerialFraction*100)
+		}
+		res.Timings = append(res.Timings, t)
+		fmt.Fprintf(tw, "%d\t%v\t%.2fx\t%dx\t%s\t\n", n, t.Elapsed.Round(time.Millisecond), t.Speedup, n, implied)
 	}
-	wg.Wait()
-	elapsed2 := time.Since(start)
-	fmt.Printf("Total time (%d cores): %v\n\n", runtime.NumCPU(), elapsed2)
-
-	// Show the speedup
-	speedup := float64(elapsed1) / float64(elapsed2)
-	fmt.Printf("=== RESULTS ===\n")
-	fmt.Printf("Speedup: %.2fx faster with multiple cores\n", speedup)
-	fmt.Printf("This proves parallelism! Same code, different execution.\n")
+	tw.Flush()
+	if cpus > 1 {
+		res.SerialFraction = sum / float64(cpus-1)
+	}
+
+	// What the law predicts further out, with the measured s (or the
+	// designed one, when one CPU can't measure anything).
+	s, from := res.SerialFraction, "measured"
+	if cpus == 1 {
+		s, from = demoSerialShare, "designed"
+		fmt.Println("\nOnly 1 CPU here: concurrent, but never parallel, so no speedup to measure.")
+	}
+	fmt.Printf("\n=== AMDAHL'S LAW with s = %.1f%% (%s) ===\n", s*100, from)
+	for _, n := range []int{2, 4, 8, 16, 64, 1024} {
+		fmt.Printf("%5d cores → %5.2fx\n", n, amdahlSpeedup(s, n))
+	}
+	if s > 0 {
+		fmt.Printf("Ceiling: %.1fx, however many cores. Shrink the serial part, not the core count.\n", 1/s)
+	}
+
+	goroutineScaling()
+	return res
+}
+
+// =============================================================================
+// MORE GOROUTINES ON ONE CORE: IO-BOUND vs CPU-BOUND
+// =============================================================================
+// Cores only help work that needs a CPU. Work that mostly WAITS (network,
+// disk - simulated here with sleeps) overlaps on a single core: while one
+// goroutine waits, the others run. So on GOMAXPROCS=1, adding goroutines
+// speeds up IO-bound work almost linearly, does nothing for CPU-bound work,
+// and helps mixed work only until the CPU part fills the core.
+
+// goroutineScaling runs 16 items of each profile on one core with 1..16
+// goroutines and prints the times.
+func goroutineScaling() {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	const items = 16
+	item := 5 * time.Millisecond
+	perMs := workload.Calibrate(time.Millisecond)
+	profiles := []workload.Profile{
+		workload.CPU(int(item/time.Millisecond) * perMs),
+		workload.IO(item),
+		workload.Mixed(int(item/time.Millisecond)*perMs/2, item/2),
+	}
+	counts := []int{1, 2, 4, 8, 16}
+
+	fmt.Printf("\n=== ONE CORE, MORE GOROUTINES (%d items of ~%v each) ===\n", items, item)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "workload\t")
+	for _, g := range counts {
+		fmt.Fprintf(tw, "%d goroutine(s)\t", g)
+	}
+	fmt.Fprintln(tw)
+	for _, p := range profiles {
+		fmt.Fprintf(tw, "%s\t", p.Kind)
+		for _, g := range counts {
+			start := time.Now()
+			conc.ForEach(context.Background(), make([]struct{}, items), g, func(context.Context, struct{}) error {
+				p.Run()
+				return nil
+			})
+			fmt.Fprintf(tw, "%v\t", time.Since(start).Round(time.Millisecond))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	fmt.Println("→ io: waits overlap, so time falls with every goroutine added.")
+	fmt.Println("  cpu: one core does all of it whatever the goroutine count.")
+	fmt.Println("  mixed: only the waiting half overlaps; the spinning half is a floor.")
 }
@@ -1,8 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+	"github.com/mintecr7/concurrency-with-go/pkg/csp"
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
 )
 
 // =============================================================================
@@ -122,9 +134,6 @@ func ChannelComposition() {
 	source2 := make(chan int)
 	source3 := make(chan int)
 
-	// Combined output
-	combined := make(chan int)
-
 	// Producer subsystems
 	go func() {
 		source1 <- 1
@@ -139,13 +148,10 @@ func ChannelComposition() {
 		close(source3)
 	}()
 
-	// Coordinator: composes inputs from multiple sources
-	go func() {
-		combined <- <-source1
-		combined <- <-source2
-		combined <- <-source3
-		close(combined)
-	}()
+	// Coordinator: composes inputs from multiple sources, in order
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // stops the coordinator if we quit reading early
+	combined := conc.Concat(ctx, source1, source2, source3)
 
 	// Consumer
 	fmt.Print("Combined output: ")
@@ -153,38 +159,106 @@ func ChannelComposition() {
 		fmt.Printf("%d ", val)
 	}
 	fmt.Println("")
+
+	// Lockstep composition: pair the i-th item of one process with the i-th
+	// of another. The longer stream's extra item is never paired.
+	names := make(chan string)
+	scores := make(chan int, 4) // room for all: the unpaired one mustn't block its sender forever
+	go func() {
+		for _, n := range []string{"west", "copy", "east"} {
+			names <- n
+		}
+		close(names)
+	}()
+	go func() {
+		for _, s := range []int{3, 1, 4, 1} {
+			scores <- s
+		}
+		close(scores)
+	}()
+	fmt.Print("Zipped output: ")
+	for p := range conc.Zip(ctx, names, scores) {
+		fmt.Printf("%s=%d ", p.First, p.Second)
+	}
+	fmt.Println("")
 }
 
 // Example 5: Web Server Pattern (From the book)
-// Demonstrates: Natural mapping of concurrent problems to Go code
+// Demonstrates: Natural mapping of concurrent problems to Go code, on real
+// sockets: net/http runs every connection in its own goroutine, and a
+// semaphore (buffered channel) bounds how many are allowed to do work
 func WebServerPattern() {
 	fmt.Println("=== Web Server Pattern ===")
 	fmt.Println("Natural concurrency: One goroutine per connection")
 
-	// Simulate incoming connections
-	connections := make(chan int)
-
-	// Connection handler (one goroutine per user)
-	handleConnection := func(connID int) {
-		fmt.Printf("Handling connection %d\n", connID)
-		time.Sleep(50 * time.Millisecond) // Simulate work
-		fmt.Printf("Connection %d complete\n", connID)
-	}
-
-	// Spawn handler goroutines (no thread pool needed!)
-	go func() {
-		for i := 1; i <= 3; i++ {
-			connections <- i
+	const (
+		limit    = 4  // requests handled at once
+		requests = 20 // sent all at once by the clients below
+	)
+	sem := make(chan struct{}, limit)
+	var inFlight, peak, accepted, shed atomic.Int64
+
+	// Connection handler: net/http has already started a goroutine for us
+	// (no thread pool needed!). Over the limit, shed load with a 503 instead
+	// of queueing without bound.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		default:
+			shed.Add(1)
+			http.Error(w, "busy, try again later", http.StatusServiceUnavailable)
+			return
 		}
-		close(connections)
-	}()
-
-	// Natural problem mapping: one goroutine per connection
-	for connID := range connections {
-		go handleConnection(connID)
+		accepted.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for old := peak.Load(); n > old && !peak.CompareAndSwap(old, n); old = peak.Load() {
+		}
+		if timeutil.Sleep(r.Context(), 50*time.Millisecond) != nil { // Simulate work
+			return // the client went away
+		}
+		fmt.Fprintf(w, "handled %s\n", r.URL.Path)
+	})
+	baseline := runtime.NumGoroutine()
+	srv := httptest.NewServer(handler)
+
+	// Clients: every request at once, each on its own connection.
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: requests}}
+	codes := make(map[int]int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range requests {
+		wg.Go(func() {
+			resp, err := client.Get(fmt.Sprintf("%s/conn/%d", srv.URL, i))
+			if err != nil {
+				fmt.Println("request failed:", err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			mu.Lock()
+			codes[resp.StatusCode]++
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	goroutines := runtime.NumGoroutine() - baseline
+
+	// Shut down both ends and let their connection goroutines exit, so the
+	// next example starts from a clean count.
+	client.CloseIdleConnections()
+	srv.Close()
+	for i := 0; i < 100 && runtime.NumGoroutine() > baseline; i++ {
+		time.Sleep(time.Millisecond)
 	}
 
-	time.Sleep(200 * time.Millisecond) // Wait for handlers
+	fmt.Printf("%d concurrent requests in %v: %d accepted (200: %d), %d shed (503: %d)\n",
+		requests, time.Since(start).Round(time.Millisecond), accepted.Load(), codes[http.StatusOK],
+		shed.Load(), codes[http.StatusServiceUnavailable])
+	fmt.Printf("At most %d handlers ran at once (limit %d)\n", peak.Load(), limit)
+	fmt.Printf("%d goroutines kept the %d connections open (server and client side)\n", goroutines, requests)
 	fmt.Println()
 }
 
@@ -193,11 +267,12 @@ func WebServerPattern() {
 func TimeoutPattern() {
 	fmt.Println("=== Timeout Pattern ===")
 
+	before := runtime.NumGoroutine()
 	slowProcess := make(chan string)
 
 	go func() {
 		time.Sleep(2 * time.Second)
-		slowProcess <- "finally done"
+		slowProcess <- "finally done" // after a timeout nobody receives: stuck forever
 	}()
 
 	select {
@@ -206,6 +281,104 @@ func TimeoutPattern() {
 	case <-time.After(500 * time.Millisecond):
 		fmt.Println("Timeout! Process took too long")
 	}
+	fmt.Printf("Goroutines: %d before, %d after - the sender was abandoned (leaked)\n",
+		before, runtime.NumGoroutine())
+
+	// The same timeout with conc.WithTimeout: the process gets a context that
+	// is cancelled when the timeout fires, and a buffered result channel, so
+	// it can always finish and exit.
+	before = runtime.NumGoroutine()
+	result, err := conc.WithTimeout(context.Background(), 500*time.Millisecond, func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(2 * time.Second):
+			return "finally done", nil
+		case <-ctx.Done():
+			return "", ctx.Err() // stop working as soon as nobody wants the answer
+		}
+	})
+	fmt.Printf("conc.WithTimeout: %q, %v\n", result, err)
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(time.Millisecond) // the cancelled goroutine is on its way out
+	}
+	fmt.Printf("Goroutines: %d before, %d after - nothing leaked\n", before, runtime.NumGoroutine())
+
+	// conc.RecvTimeout is the receive side alone: one value, or give up.
+	ticks := make(chan int)
+	_, _, err = conc.RecvTimeout(ticks, 50*time.Millisecond)
+	fmt.Println("conc.RecvTimeout on a silent channel:", err)
+	fmt.Println()
+}
+
+// Example 7: Hoare's Notation, Executed
+// Demonstrates: the paper's programs written almost symbol for symbol with
+// pkg/csp, which compiles guarded commands onto goroutines, channels and select
+func HoareNotation() {
+	fmt.Println("=== Hoare's Notation, Executed ===")
+
+	// [WEST || COPY || EAST], with COPY :: *[c:character; west?c → east!c]
+	fmt.Println("COPY :: *[c:character; west?c → east!c]")
+	west := csp.NewChan[rune]("west")
+	east := csp.NewChan[rune]("east")
+	csp.SetTrace(os.Stdout)
+	var got []rune
+	csp.Par(
+		func() { // WEST
+			for _, c := range "CSP" {
+				west.Out(c)
+			}
+			west.Close() // WEST terminates...
+		},
+		func() { // COPY
+			csp.Loop(func() []csp.Guard {
+				return []csp.Guard{csp.Input(west, func(c rune) { east.Out(c) })}
+			})
+			east.Close() // ...so west? fails, the loop ends, and COPY terminates too
+		},
+		func() { // EAST
+			for c, ok := east.In(); ok; c, ok = east.In() {
+				got = append(got, c)
+			}
+		},
+	)
+	csp.SetTrace(nil)
+	fmt.Printf("East received: %s\n", string(got))
+
+	// Hoare's bounded buffer (section 5.1), with an output guard:
+	// X :: buffer:(0..9)portion; in, out: integer; in := 0; out := 0;
+	//   *[in < out+10; producer?buffer(in mod 10) → in := in+1
+	//    □ out < in; consumer!buffer(out mod 10) → out := out+1]
+	fmt.Println("\nX :: *[in < out+10; producer?buffer(in mod 10) → in := in+1")
+	fmt.Println("      □ out < in; consumer!buffer(out mod 10) → out := out+1]")
+	producer := csp.NewChan[int]("producer")
+	consumer := csp.NewChan[int]("consumer")
+	var sum, peak int
+	csp.Par(
+		func() {
+			for i := 1; i <= 25; i++ {
+				producer.Out(i)
+			}
+			producer.Close()
+		},
+		func() { // X
+			var buffer [10]int
+			in, out := 0, 0
+			csp.Loop(func() []csp.Guard {
+				peak = max(peak, in-out)
+				return []csp.Guard{
+					csp.Input(producer, func(v int) { buffer[in%10] = v; in++ }).If(in < out+10),
+					csp.Output(consumer, buffer[out%10], func() { out++ }).If(out < in),
+				}
+			})
+			consumer.Close()
+		},
+		func() {
+			for v, ok := consumer.In(); ok; v, ok = consumer.In() {
+				sum += v
+				time.Sleep(time.Millisecond) // a slow consumer lets the buffer fill
+			}
+		},
+	)
+	fmt.Printf("Consumer got 1+...+25 = %d; the buffer held at most %d portions\n", sum, peak)
 	fmt.Println()
 }
 
@@ -219,6 +392,7 @@ func CspBasics() {
 	ChannelComposition()
 	WebServerPattern()
 	TimeoutPattern()
+	HoareNotation()
 
 	fmt.Println("Key Takeaways:")
 	fmt.Println("1. Channels = communication primitives (not shared memory)")
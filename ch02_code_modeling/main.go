@@ -1,6 +1,8 @@
 package main
 
 func main() {
-	// RunDemo()   // parallelism runtime property proof demo
+	// RunDemo()   // parallelism runtime property proof demo :: speedup sweep + Amdahl's law
 	CspBasics() // csp basics :: Share memory by communicating, don’t communicate by sharing memory
+	// ParallelSortDemo() // parallel merge/quick sort :: where the sequential cutoff pays off
+	// MutexVsMonitor()   // share memory by communicating, measured :: mutex vs state-owning goroutine
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+// =============================================================================
+// TOPIC: Parallel Sorting and the Sequential Cutoff
+// =============================================================================
+// Divide-and-conquer sorts split naturally into independent halves, so it is
+// tempting to sort each half in its own goroutine, all the way down. That is
+// concurrent code; whether it runs FASTER depends on how much work each
+// goroutine gets:
+//
+//   - Spawning, scheduling and joining a goroutine costs ~1µs. Sorting 64
+//     ints costs less than that - below some size, a goroutine is pure loss.
+//   - Above the GOMAXPROCS-th goroutine, more goroutines add no parallelism,
+//     only scheduling overhead.
+//
+// So every real parallel sort has a SEQUENTIAL CUTOFF (below it, sort in the
+// current goroutine) and a BOUND on concurrency (here: a semaphore - if no
+// slot is free, recurse sequentially instead of waiting). The demo counts
+// the goroutines each cutoff spawns; BenchmarkParallelSort times them and
+// shows the U-shaped curve: too small a cutoff drowns in overhead, too
+// large leaves cores idle.
+// =============================================================================
+
+// sorter holds what every level of the recursion shares.
+type sorter struct {
+	cutoff  int           // below this many elements, don't spawn
+	sem     chan struct{} // free slots for extra goroutines
+	spawned atomic.Int64  // goroutines started so far
+}
+
+func newSorter(cutoff int) *sorter {
+	return &sorter{cutoff: cutoff, sem: make(chan struct{}, runtime.GOMAXPROCS(0))}
+}
+
+// both runs left and right, in parallel if the slice is big enough and a
+// goroutine slot is free, otherwise one after the other.
+func (s *sorter) both(n int, left, right func()) {
+	if n >= s.cutoff {
+		select {
+		case s.sem <- struct{}{}: // got a slot: sort the left half concurrently
+			s.spawned.Add(1)
+			var wg sync.WaitGroup
+			wg.Go(func() {
+				defer func() { <-s.sem }()
+				left()
+			})
+			right()
+			wg.Wait()
+			return
+		default: // every slot busy: more goroutines wouldn't add parallelism
+		}
+	}
+	left()
+	right()
+}
+
+// -----------------------------------------------------------------------------
+// Merge sort: split in the middle, sort both halves, merge.
+// -----------------------------------------------------------------------------
+
+func (s *sorter) mergeSort(a []int) {
+	tmp := make([]int, len(a))
+	s.mergeSortInto(a, tmp)
+}
+
+func (s *sorter) mergeSortInto(a, tmp []int) {
+	if len(a) <= 32 {
+		insertionSort(a)
+		return
+	}
+	mid := len(a) / 2
+	s.both(len(a),
+		func() { s.mergeSortInto(a[:mid], tmp[:mid]) },
+		func() { s.mergeSortInto(a[mid:], tmp[mid:]) })
+
+	// Merge the sorted halves through tmp. The halves are disjoint, so the
+	// two goroutines above never touch the same elements.
+	copy(tmp, a)
+	i, j, k := 0, mid, 0
+	for i < mid && j < len(a) {
+		if tmp[j] < tmp[i] {
+			a[k] = tmp[j]
+			j++
+		} else {
+			a[k] = tmp[i]
+			i++
+		}
+		k++
+	}
+	k += copy(a[k:], tmp[i:mid])
+	copy(a[k:], tmp[j:])
+}
+
+// -----------------------------------------------------------------------------
+// Quicksort: partition around a pivot, sort both sides. The split is uneven,
+// so the cutoff is checked per side.
+// -----------------------------------------------------------------------------
+
+func (s *sorter) quickSort(a []int) {
+	if len(a) <= 32 {
+		insertionSort(a)
+		return
+	}
+	p := partition(a)
+	s.both(min(p+1, len(a)-p-1),
+		func() { s.quickSort(a[:p+1]) },
+		func() { s.quickSort(a[p+1:]) })
+}
+
+// partition is Hoare's scheme with a median-of-three pivot. It returns p
+// such that every element of a[:p+1] is ≤ every element of a[p+1:].
+func partition(a []int) int {
+	mid := len(a) / 2
+	hi := len(a) - 1
+	if a[mid] < a[0] {
+		a[mid], a[0] = a[0], a[mid]
+	}
+	if a[hi] < a[0] {
+		a[hi], a[0] = a[0], a[hi]
+	}
+	if a[hi] < a[mid] {
+		a[hi], a[mid] = a[mid], a[hi]
+	}
+	pivot := a[mid]
+
+	i, j := -1, len(a)
+	for {
+		for i++; a[i] < pivot; i++ {
+		}
+		for j--; a[j] > pivot; j-- {
+		}
+		if i >= j {
+			return j
+		}
+		a[i], a[j] = a[j], a[i]
+	}
+}
+
+func insertionSort(a []int) {
+	for i := 1; i < len(a); i++ {
+		for j := i; j > 0 && a[j] < a[j-1]; j-- {
+			a[j], a[j-1] = a[j-1], a[j]
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// The sweep
+// -----------------------------------------------------------------------------
+
+func ParallelSortDemo() {
+	const n = 2_000_000
+	input := make([]int, n)
+	for i := range input {
+		input[i] = rand.Int()
+	}
+
+	fmt.Println("=== PARALLEL SORT: SWEEPING THE SEQUENTIAL CUTOFF ===")
+	fmt.Printf("%d random ints, GOMAXPROCS=%d\n\n", n, runtime.GOMAXPROCS(0))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Cutoff\tMerge sort goroutines\tQuicksort goroutines\t")
+	for _, cutoff := range []int{64, 512, 4096, 32_768, 262_144, n + 1} {
+		merge, quick := newSorter(cutoff), newSorter(cutoff)
+		merge.mergeSort(slices.Clone(input))
+		quick.quickSort(slices.Clone(input))
+		label := fmt.Sprint(cutoff)
+		if cutoff > n {
+			label = "∞ (sequential)"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t\n", label, merge.spawned.Load(), quick.spawned.Load())
+	}
+	tw.Flush()
+	fmt.Println("→ the semaphore caps how many run at once, not how many are started;")
+	fmt.Println("  to time each cutoff against slices.Sort, run")
+	fmt.Println("  go test -bench ParallelSort ./ch02_code_modeling")
+
+	fmt.Println("\nWhat to look for in the benchmark:")
+	fmt.Println("  • Tiny cutoffs: many attempts to spawn, lots of tiny goroutines")
+	fmt.Println("  • Mid-range cutoffs (~4K-32K): the sweet spot on a multi-core machine")
+	fmt.Println("  • Huge cutoffs: only a few goroutines, cores sit idle")
+	fmt.Println("  • With -cpu 1 every cutoff is about the same or slower than")
+	fmt.Println("    sequential: concurrency without parallelism is pure overhead")
+}
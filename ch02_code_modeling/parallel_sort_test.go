@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func randomInts(n int) []int {
+	r := rand.New(rand.NewPCG(1, 2))
+	a := make([]int, n)
+	for i := range a {
+		a[i] = r.IntN(n) // duplicates too, which partition must handle
+	}
+	return a
+}
+
+type namedSort struct {
+	name string
+	fn   func([]int)
+}
+
+func sorts(s *sorter) []namedSort {
+	return []namedSort{{"merge", s.mergeSort}, {"quick", s.quickSort}}
+}
+
+func TestParallelSorts(t *testing.T) {
+	for _, n := range []int{0, 1, 31, 33, 1000, 100_000} {
+		input := randomInts(n)
+		want := slices.Sorted(slices.Values(input))
+		for _, cutoff := range []int{64, 4096, n + 1} {
+			for _, sort := range sorts(newSorter(cutoff)) {
+				a := slices.Clone(input)
+				sort.fn(a)
+				if !slices.Equal(a, want) {
+					t.Errorf("%s sort of %d ints with cutoff %d is not sorted", sort.name, n, cutoff)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkParallelSort sweeps the sequential cutoff for both sorts, with
+// slices.Sort as the sequential baseline. Run it with -cpu 1,4,... to see
+// where the parallelism starts to pay.
+func BenchmarkParallelSort(b *testing.B) {
+	const n = 1_000_000
+	input := randomInts(n)
+	a := make([]int, n)
+	b.Run("slices.Sort", func(b *testing.B) {
+		for b.Loop() {
+			copy(a, input)
+			slices.Sort(a)
+		}
+	})
+	for _, cutoff := range []int{64, 512, 4096, 32_768, 262_144, n + 1} {
+		label := fmt.Sprint(cutoff)
+		if cutoff > n {
+			label = "sequential"
+		}
+		for _, sort := range sorts(newSorter(cutoff)) {
+			b.Run(fmt.Sprintf("%s/cutoff=%s", sort.name, label), func(b *testing.B) {
+				for b.Loop() {
+					copy(a, input)
+					sort.fn(a)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package main
+
+import stateownership "github.com/mintecr7/concurrency-with-go/ch02_code_modeling/state_ownership"
+
+// MutexVsMonitor runs the same key-value store guarded by a mutex and owned
+// by a goroutine, side by side: correctness, cost per operation, and when
+// each one is the right choice.
+func MutexVsMonitor() {
+	stateownership.StateOwnershipDemo()
+}
@@ -0,0 +1,213 @@
+package stateownership
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+)
+
+// ============================================================================
+// WORKLOADS
+// ============================================================================
+
+// Workload is what each goroutine does, ops times, to a store. Name is
+// also the op= parameter of the lab's "state" bench suite.
+type Workload struct {
+	Name string
+	Run  func(s Store, goroutine, ops int)
+}
+
+// keys are the names the kv and transfer workloads use.
+var keys = func() []string {
+	k := make([]string, 64)
+	for i := range k {
+		k[i] = "k" + strconv.Itoa(i)
+	}
+	return k
+}()
+
+// Workloads lists the ways the stores are exercised.
+var Workloads = []Workload{
+	{"add", func(s Store, _, ops int) { // a counter: one key, wait for the new value
+		for range ops {
+			s.Add("n", 1)
+		}
+	}},
+	{"inc", func(s Store, _, ops int) { // a counter nobody waits for
+		for range ops {
+			s.Inc("n")
+		}
+	}},
+	{"kv", func(s Store, g, ops int) { // 64 keys, 90% Get, 10% Add
+		rng := rand.New(rand.NewPCG(uint64(g), 1))
+		for range ops {
+			k := keys[rng.IntN(len(keys))]
+			if rng.IntN(10) == 0 {
+				s.Add(k, 1)
+			} else {
+				s.Get(k)
+			}
+		}
+	}},
+	{"transfer", func(s Store, g, ops int) { // between 16 accounts
+		rng := rand.New(rand.NewPCG(uint64(g), 2))
+		for range ops {
+			s.Transfer(keys[rng.IntN(16)], keys[rng.IntN(16)], 1+rng.IntN(10))
+		}
+	}},
+}
+
+// Seed gives each of the 16 transfer accounts a balance of 1000, so that
+// transfers mostly succeed instead of failing on empty accounts.
+func Seed(s Store) {
+	for _, k := range keys[:16] {
+		s.Add(k, 1000)
+	}
+}
+
+// Drive seeds s, runs w on it from goroutines goroutines, ops operations in
+// all, and closes it. For the monitor, Close waits until every Inc has been
+// applied, so the work is done when Drive returns.
+func Drive(s Store, w Workload, goroutines, ops int) {
+	Seed(s)
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		n := ops / goroutines
+		if g < ops%goroutines {
+			n++
+		}
+		wg.Go(func() { w.Run(s, g, n) })
+	}
+	wg.Wait()
+	s.Close()
+}
+
+// ============================================================================
+// 1. BOTH KEEP THEIR INVARIANTS
+// ============================================================================
+
+// counted counts to 40000 with Inc from 16 goroutines and returns the total.
+func counted(s Store) int {
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Go(func() {
+			for range 2500 {
+				s.Inc("n")
+			}
+		})
+	}
+	wg.Wait()
+	n := s.Add("n", 0) // queued behind every Inc on the monitor
+	s.Close()
+	return n
+}
+
+// transferred runs 32000 random transfers between the 16 seeded accounts
+// and returns the sum of the balances and the lowest one. Money only moves,
+// so the sum stays 16000 and no account goes negative.
+func transferred(s Store) (total, lowest int) {
+	Seed(s)
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Go(func() { Workloads[3].Run(s, g, 2000) })
+	}
+	wg.Wait()
+	lowest = s.Get(keys[0])
+	for _, k := range keys[:16] {
+		v := s.Get(k)
+		total += v
+		lowest = min(lowest, v)
+	}
+	s.Close()
+	return total, lowest
+}
+
+func invariants() {
+	fmt.Println("\n=== Invariants (16 goroutines) ===")
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "Store\tcounter (of 40000 Incs)\ttransfer total (of 16000)\tlowest balance\n")
+	for _, st := range strategies {
+		count := counted(st.newStore(0))
+		total, lowest := transferred(st.newStore(0))
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", st.name, count, total, lowest)
+	}
+	tw.Flush()
+	fmt.Println("→ go test checks both invariants for each store; the cost per")
+	fmt.Println("  operation is measured by go test -bench . ./ch02_code_modeling/state_ownership")
+}
+
+// ============================================================================
+// 2. COST PER OPERATION
+// ============================================================================
+// comparison_test.go benchmarks every workload on both stores, with one and
+// with 16 goroutines, and with and without work in the critical section:
+//
+//	go test -run '^$' -bench . ./ch02_code_modeling/state_ownership
+//
+// For repeatable A/B numbers with a significance test, use the lab:
+//
+//	go run ./cmd/lab bench compare state impl=mutex impl=monitor
+//	go run ./cmd/lab bench compare state impl=mutex,work=2000 impl=monitor,work=2000
+
+// ============================================================================
+// 3. WHEN EACH WINS
+// ============================================================================
+
+func discussion() {
+	fmt.Println("\n=== When Each Approach Wins ===")
+	fmt.Println(`
+The mutex wins on raw cost. An uncontended Lock/Unlock is two atomic
+instructions; a request to a monitor is a channel send, a goroutine switch
+and a reply - several hundred nanoseconds. With little work per operation
+the monitor is many times slower, and it stays slower with more goroutines:
+it has exactly one worker, while the mutex lets whichever goroutine holds
+it do the work without a switch. On many cores a contended mutex gets
+slower too (the lock's cache line bounces between them), but the monitor
+pays the same line bouncing plus the switch.
+
+The gap closes as the work per operation grows. Both designs run one
+operation at a time, so once the work dominates, both cost the work - the
+overhead of the message is noise next to it (the work=2000 benchmarks).
+
+Fire-and-forget messages (inc) are where the monitor comes closest: the
+caller doesn't wait, the buffered channel absorbs bursts, and the monitor
+drains it in a tight loop.
+
+The monitor wins on structure:
+  • The state can't be touched without the owner. No lock to forget, no
+    lock order between two structures, no "I'll just read it quickly".
+  • Multi-step invariants (Transfer) are a single message, and the owner
+    can also react to other events - a ticker for expiry, a ctx.Done() for
+    shutdown - in the same select, without a second lock.
+  • It can keep state that is not safe to share at all: an open file, a
+    C library handle, a connection with its own protocol state.
+
+Rule of thumb: a small structure on a hot path → mutex (RWMutex or atomics
+if reads dominate). A stateful component with its own life cycle, events
+and invariants → a goroutine that owns it (see also pkg/serialize).`)
+}
+
+// StateOwnershipDemo compares the two stores.
+func StateOwnershipDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║    SHARED MEMORY + MUTEX vs. STATE-OWNING GOROUTINE        ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	invariants()
+	discussion()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Both are correct; they differ in cost and in structure   ║")
+	fmt.Println("║ • Mutex: cheapest per operation, the caller does the work  ║")
+	fmt.Println("║ • Monitor: a message per operation, one owner, no locks    ║")
+	fmt.Println("║ • Heavy operations or fire-and-forget narrow the gap       ║")
+	fmt.Println("║ • Choose the monitor for ownership, not for speed          ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
@@ -0,0 +1,48 @@
+package stateownership
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInvariants(t *testing.T) {
+	for _, st := range strategies {
+		if n := counted(st.newStore(0)); n != 40000 {
+			t.Errorf("%s: 40000 Incs counted to %d", st.name, n)
+		}
+		if total, lowest := transferred(st.newStore(0)); total != 16000 || lowest < 0 {
+			t.Errorf("%s: after the transfers the balances sum to %d, lowest %d; want 16000, none negative", st.name, total, lowest)
+		}
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	for _, st := range strategies {
+		s := st.newStore(0)
+		s.Add("a", 10)
+		if !s.Transfer("a", "b", 7) || s.Transfer("a", "b", 4) {
+			t.Errorf("%s: Transfer of 7 then 4 out of 10 should succeed, then fail", st.name)
+		}
+		if a, b := s.Get("a"), s.Get("b"); a != 3 || b != 7 {
+			t.Errorf("%s: a = %d, b = %d, want 3, 7", st.name, a, b)
+		}
+		s.Close()
+	}
+}
+
+func BenchmarkMutexStore(b *testing.B)   { benchmarkStore(b, strategies[0]) }
+func BenchmarkMonitorStore(b *testing.B) { benchmarkStore(b, strategies[1]) }
+
+// benchmarkStore runs every workload on st, so ns/op is the cost of one
+// operation, seeding and closing the store included.
+func benchmarkStore(b *testing.B, st strategy) {
+	for _, work := range []int{0, 2000} {
+		for _, w := range Workloads {
+			for _, goroutines := range []int{1, 16} {
+				b.Run(fmt.Sprintf("%s/goroutines=%d/work=%d", w.Name, goroutines, work), func(b *testing.B) {
+					Drive(st.newStore(work), w, goroutines, b.N)
+				})
+			}
+		}
+	}
+}
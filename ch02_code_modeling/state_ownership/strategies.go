@@ -0,0 +1,198 @@
+package stateownership
+
+import "sync"
+
+// ============================================================================
+// ONE KEY-VALUE STORE, TWO WAYS TO OWN ITS STATE
+// ============================================================================
+// "Don't communicate by sharing memory; share memory by communicating."
+// Both stores below hold a map[string]int used by many goroutines:
+//
+// 1. MutexStore   - the map is shared; a sync.Mutex makes every access
+//                   exclusive. The caller's goroutine does the work.
+// 2. MonitorStore - the map belongs to one goroutine; callers send it
+//                   requests over a channel and wait for the reply. The
+//                   owner does the work, one request at a time.
+//
+// A counter is the same store used with a single key.
+// ============================================================================
+
+// Store is a map[string]int that is safe for concurrent use.
+type Store interface {
+	Add(key string, delta int) int // adds delta and returns the new value
+	Inc(key string)                // adds 1 without waiting for the result
+	Get(key string) int
+	// Transfer moves amount from one key to another if from holds at least
+	// that much - an invariant over two keys, checked and updated at once.
+	Transfer(from, to string, amount int) bool
+	Close()
+}
+
+// strategy names a Store implementation and knows how to build one.
+type strategy struct {
+	name     string
+	newStore func(work int) Store
+}
+
+// strategies lists every implementation compared by the demo.
+var strategies = []strategy{
+	{"mutex", func(work int) Store { return NewMutexStore(work) }},
+	{"monitor", func(work int) Store { return NewMonitorStore(work, 64) }},
+}
+
+// spin burns roughly n iterations of CPU: the work done on the state while
+// it is held, standing in for validation, encoding, bookkeeping.
+//
+//go:noinline
+func spin(n int) int {
+	x := 0
+	for i := range n {
+		x += i ^ (x >> 3)
+	}
+	return x
+}
+
+// ============================================================================
+// 1. SHARED MEMORY BEHIND A MUTEX
+// ============================================================================
+
+// MutexStore guards its map with a mutex. work is spun inside every
+// critical section.
+type MutexStore struct {
+	mu   sync.Mutex
+	m    map[string]int
+	work int
+	sink int
+}
+
+func NewMutexStore(work int) *MutexStore {
+	return &MutexStore{m: make(map[string]int), work: work}
+}
+
+func (s *MutexStore) Add(key string, delta int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sink += spin(s.work)
+	s.m[key] += delta
+	return s.m[key]
+}
+
+func (s *MutexStore) Inc(key string) { s.Add(key, 1) }
+
+func (s *MutexStore) Get(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sink += spin(s.work)
+	return s.m[key]
+}
+
+func (s *MutexStore) Transfer(from, to string, amount int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sink += spin(s.work)
+	if s.m[from] < amount {
+		return false
+	}
+	s.m[from] -= amount
+	s.m[to] += amount
+	return true
+}
+
+func (s *MutexStore) Close() {}
+
+// ============================================================================
+// 2. STATE OWNED BY A GOROUTINE, REACHED BY MESSAGES
+// ============================================================================
+
+type opKind int
+
+const (
+	opAdd opKind = iota
+	opInc
+	opGet
+	opTransfer
+)
+
+// request is one message to the owner. reply is nil for Inc: nobody waits.
+type request struct {
+	op      opKind
+	key, to string
+	n       int
+	reply   chan int
+}
+
+// MonitorStore keeps its map in one goroutine, the monitor. Only that
+// goroutine ever touches the map, so there is no lock anywhere.
+type MonitorStore struct {
+	requests chan request
+	done     chan struct{}
+	replies  sync.Pool // reply channels, reused: one allocation less per call
+}
+
+// NewMonitorStore starts the owning goroutine. queue is the capacity of the
+// request channel: how far callers of Inc can run ahead of the monitor.
+func NewMonitorStore(work, queue int) *MonitorStore {
+	s := &MonitorStore{requests: make(chan request, queue), done: make(chan struct{})}
+	s.replies.New = func() any { return make(chan int, 1) }
+	go s.own(work)
+	return s
+}
+
+// own is the monitor goroutine: the map is a local variable.
+func (s *MonitorStore) own(work int) {
+	defer close(s.done)
+	m := make(map[string]int)
+	sink := 0
+	for r := range s.requests {
+		sink += spin(work)
+		switch r.op {
+		case opAdd, opInc:
+			m[r.key] += r.n
+			if r.reply != nil {
+				r.reply <- m[r.key]
+			}
+		case opGet:
+			r.reply <- m[r.key]
+		case opTransfer:
+			ok := 0
+			if m[r.key] >= r.n {
+				m[r.key] -= r.n
+				m[r.to] += r.n
+				ok = 1
+			}
+			r.reply <- ok
+		}
+	}
+	_ = sink
+}
+
+// call sends r and waits for the monitor's reply.
+func (s *MonitorStore) call(r request) int {
+	r.reply = s.replies.Get().(chan int)
+	s.requests <- r
+	v := <-r.reply
+	s.replies.Put(r.reply)
+	return v
+}
+
+func (s *MonitorStore) Add(key string, delta int) int {
+	return s.call(request{op: opAdd, key: key, n: delta})
+}
+
+func (s *MonitorStore) Inc(key string) {
+	s.requests <- request{op: opInc, key: key, n: 1}
+}
+
+func (s *MonitorStore) Get(key string) int {
+	return s.call(request{op: opGet, key: key})
+}
+
+func (s *MonitorStore) Transfer(from, to string, amount int) bool {
+	return s.call(request{op: opTransfer, key: from, to: to, n: amount}) == 1
+}
+
+// Close stops the monitor once every request sent so far is handled.
+func (s *MonitorStore) Close() {
+	close(s.requests)
+	<-s.done
+}
@@ -0,0 +1,243 @@
+// Package approxcounting compares exact and approximate ways of counting a
+// stream that many goroutines produce at once, and what each costs in
+// synchronization.
+package approxcounting
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/sketch"
+)
+
+// ============================================================================
+// COUNTING DISTINCT ITEMS FROM MANY PRODUCERS
+// ============================================================================
+// 16 producers emit user IDs (with lots of repeats) and we want the number
+// of DISTINCT users. Four ways to share the work:
+//
+// 1. Exact, one map + one mutex   - exact, memory grows with the answer,
+//                                   every Add takes the same lock
+// 2. Exact, striped map           - 64 maps, 64 locks: same memory, a
+//                                   collision only if two producers hit the
+//                                   same stripe at once
+// 3. HyperLogLog, shared          - fixed 64KB, lock-free: atomic "store the
+//                                   max" that almost never writes
+// 4. HyperLogLog per producer     - no sharing at all while counting, merge
+//                                   the 16 sketches at the end
+//
+// Accuracy vs synchronization: the exact answers need a coordination point
+// per item (a lock around a growing map). The sketch trades ~1% error for a
+// structure whose updates commute ("max" and "+" can be applied in any
+// order), and commuting updates are exactly what needs the least
+// synchronization: atomics instead of locks, or merging instead of sharing.
+// ============================================================================
+
+const (
+	producers   = 16
+	perProducer = 250_000
+	universe    = 1_000_000 // user IDs are drawn from [0, universe)
+)
+
+type distinctCounter struct {
+	name    string
+	newAdd  func() (add func(producer int, id string), count func() int, size func() string)
+	precise bool
+}
+
+var distinctCounters = []distinctCounter{
+	{"exact: map + mutex", func() (func(int, string), func() int, func() string) {
+		var mu sync.Mutex
+		seen := make(map[string]struct{})
+		return func(_ int, id string) {
+				mu.Lock()
+				seen[id] = struct{}{}
+				mu.Unlock()
+			},
+			func() int { return len(seen) },
+			func() string { return fmt.Sprintf("%d entries", len(seen)) }
+	}, true},
+	{"exact: 64 striped maps", func() (func(int, string), func() int, func() string) {
+		const stripes = 64
+		var locks [stripes]sync.Mutex
+		var maps [stripes]map[string]struct{}
+		for i := range maps {
+			maps[i] = make(map[string]struct{})
+		}
+		total := func() int {
+			n := 0
+			for _, m := range maps {
+				n += len(m)
+			}
+			return n
+		}
+		return func(_ int, id string) {
+				s := stripeOf(id, stripes)
+				locks[s].Lock()
+				maps[s][id] = struct{}{}
+				locks[s].Unlock()
+			},
+			total,
+			func() string { return fmt.Sprintf("%d entries", total()) }
+	}, true},
+	{"HLL: shared, atomic", func() (func(int, string), func() int, func() string) {
+		h := sketch.NewHyperLogLog(14)
+		return func(_ int, id string) { h.Add(id) },
+			func() int { return int(h.Count()) },
+			func() string { return fmt.Sprintf("%d KB", h.SizeBytes()/1024) }
+	}, false},
+	{"HLL: per producer + merge", func() (func(int, string), func() int, func() string) {
+		var local [producers]*sketch.HyperLogLog
+		for i := range local {
+			local[i] = sketch.NewHyperLogLog(14)
+		}
+		merged := sketch.NewHyperLogLog(14)
+		return func(p int, id string) { local[p].Add(id) }, // no sharing
+			func() int {
+				for _, l := range local {
+					merged.Merge(l)
+				}
+				return int(merged.Count())
+			},
+			func() string { return fmt.Sprintf("%d × %d KB", producers, merged.SizeBytes()/1024) }
+	}, false},
+}
+
+// stripeOf picks a stripe for id with a cheap FNV-1a hash.
+func stripeOf(id string, stripes int) int {
+	h := uint32(2166136261)
+	for i := 0; i < len(id); i++ {
+		h ^= uint32(id[i])
+		h *= 16777619
+	}
+	return int(h % uint32(stripes))
+}
+
+// streams pre-generates every producer's IDs so the timing below measures
+// counting, not random number generation.
+func streams() (ids [producers][]string, distinct int) {
+	seen := make(map[int]bool)
+	for p := range ids {
+		ids[p] = make([]string, perProducer)
+		for i := range ids[p] {
+			n := rand.N(universe)
+			seen[n] = true
+			ids[p][i] = "user-" + strconv.Itoa(n)
+		}
+	}
+	return ids, len(seen)
+}
+
+func countDistinct() {
+	fmt.Println("\n=== Distinct Users from Many Producers ===")
+
+	ids, truth := streams()
+	fmt.Printf("%d producers × %d events, %d distinct users\n\n", producers, perProducer, truth)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "Strategy\tTime\tCount\tError\tMemory")
+	for _, dc := range distinctCounters {
+		add, count, size := dc.newAdd()
+
+		start := time.Now()
+		var wg sync.WaitGroup
+		for p := range producers {
+			wg.Go(func() {
+				for _, id := range ids[p] {
+					add(p, id)
+				}
+			})
+		}
+		wg.Wait()
+		got := count()
+		elapsed := time.Since(start)
+
+		errPct := 100 * float64(got-truth) / float64(truth)
+		fmt.Fprintf(tw, "%s\t%v\t%d\t%+.2f%%\t%s\n", dc.name, elapsed.Round(time.Millisecond), got, errPct, size())
+	}
+	tw.Flush()
+}
+
+// ============================================================================
+// HOW OFTEN? HEAVY HITTERS WITH A COUNT-MIN SKETCH
+// ============================================================================
+// Page views follow a power law: a few pages get most of the traffic. A
+// count-min sketch answers "how many views did page X get?" in fixed memory
+// with lock-free atomic adds. It can only OVERcount (collisions add, never
+// subtract) and the error bound is relative to the TOTAL, so popular pages
+// are estimated almost exactly while rare ones can be far off.
+// ============================================================================
+
+func heavyHitters() {
+	fmt.Println("\n=== Page Views with a Count-Min Sketch ===")
+
+	const (
+		pages   = 100_000
+		views   = 400_000 // per producer
+		epsilon = 0.0005  // overcount ≤ 0.05% of all views...
+		delta   = 0.01    // ...with 99% probability
+	)
+	cm := sketch.NewCountMin(epsilon, delta)
+
+	// Zipf: page 0 is the most popular, page 1 next, ...
+	exact := make([]map[int]uint64, producers) // per producer: no sharing, merged below
+	var wg sync.WaitGroup
+	start := time.Now()
+	for p := range producers {
+		wg.Go(func() {
+			zipf := rand.NewZipf(rand.New(rand.NewPCG(uint64(p), 42)), 1.1, 1, pages-1)
+			exact[p] = make(map[int]uint64)
+			for range views {
+				page := int(zipf.Uint64())
+				cm.Add("page-"+strconv.Itoa(page), 1)
+				exact[p][page]++
+			}
+		})
+	}
+	wg.Wait()
+	fmt.Printf("%d views across %d pages in %v, sketch size %d KB\n\n",
+		producers*views, pages, time.Since(start).Round(time.Millisecond), cm.SizeBytes()/1024)
+
+	truth := make(map[int]uint64)
+	for _, m := range exact {
+		for page, n := range m {
+			truth[page] += n
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Page\tExact\tEstimate\tOvercount\t")
+	for _, page := range []int{0, 1, 2, 10, 100, 1000, 10_000, 50_000} {
+		est := cm.Estimate("page-" + strconv.Itoa(page))
+		fmt.Fprintf(tw, "%d\t%d\t%d\t+%d\t\n", page, truth[page], est, est-truth[page])
+	}
+	tw.Flush()
+	fmt.Printf("Guaranteed bound (99%%): overcount ≤ ε·N = %.0f\n", epsilon*float64(producers*views))
+}
+
+// ApproxCountingDemo runs the exact-vs-approximate comparisons.
+func ApproxCountingDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║        APPROXIMATE COUNTING UNDER CONCURRENCY              ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	countDistinct()
+	heavyHitters()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Exact counts need a coordination point per item          ║")
+	fmt.Println("║ • Sketches give up a little accuracy for fixed memory...   ║")
+	fmt.Println("║ • ...and updates that COMMUTE (max, +): atomics suffice    ║")
+	fmt.Println("║ • Commuting updates can skip sharing: count, then Merge    ║")
+	fmt.Println("║ • Count-min error is relative to the TOTAL: great for      ║")
+	fmt.Println("║   heavy hitters, useless for rare items                    ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ============================================================================
+// CHILD PROCESSES: EXPERIMENTS THAT NEED A FRESH RUNTIME
+// ============================================================================
+// Some experiments need runtime settings that can only be chosen at process
+// start (GODEBUG=schedtrace, GODEBUG=asyncpreemptoff) or would disturb the
+// demos around them (hundreds of OS threads). Those re-run this very binary
+// with childWorkloadEnv=<name>, and main() runs the named workload instead
+// of the demos.
+// ============================================================================
+
+// childWorkloadEnv, when set, makes main() run the named workload and exit.
+const childWorkloadEnv = "CH03_CHILD_WORKLOAD"
+
+// childWorkloads maps workload names to the function the child runs. Files
+// register theirs in init.
+var childWorkloads = map[string]func(){}
+
+// childWorkload returns the workload this process was started to run, if
+// any.
+func childWorkload() (func(), bool) {
+	name := os.Getenv(childWorkloadEnv)
+	if name == "" {
+		return nil, false
+	}
+	run, ok := childWorkloads[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown child workload %q\n", name)
+		os.Exit(2)
+	}
+	return run, true
+}
+
+// childCommand prepares this binary to run workload name, with extra
+// environment variables such as "GODEBUG=...".
+func childCommand(ctx context.Context, name string, env ...string) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find own executable: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, self)
+	cmd.Env = append(os.Environ(), childWorkloadEnv+"="+name)
+	cmd.Env = append(cmd.Env, env...)
+	return cmd, nil
+}
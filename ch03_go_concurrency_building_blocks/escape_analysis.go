@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ============================================================================
+// 5b. WHAT A GOROUTINE CLOSURE COSTS: ESCAPE ANALYSIS
+// ============================================================================
+// A variable normally lives on its function's stack and costs nothing to
+// free. But a goroutine can outlive the function that started it, so
+// anything its closure captures BY REFERENCE must survive the function's
+// return: the compiler "moves it to heap". The closure itself escapes too.
+//
+// The compiler explains every such decision with -gcflags=-m:
+//
+//	./escape_analysis.go:LINE:COL: moved to heap: total
+//	./escape_analysis.go:LINE:COL: func literal escapes to heap
+//
+// Below are the loop shapes from loopVariablePitfall/loopVariableFixed. We
+// ask the compiler what it decided for each (by building this package with
+// -m in a subprocess) and then count allocations per goroutine with
+// testing.AllocsPerRun. escape_analysis_test.go has a benchmark per
+// example that reports the same allocations, and their cost in time.
+// ============================================================================
+
+const escapeGoroutines = 100
+
+// closureNoGoroutine: the closure never leaves the function, so neither it
+// nor total escapes. Zero allocations.
+func closureNoGoroutine() int {
+	total := 0
+	add := func(v int) { total += v }
+	for i := range escapeGoroutines {
+		add(i)
+	}
+	return total
+}
+
+// sharedCapture is loopVariablePitfall's shape: every goroutine captures the
+// SAME variable. It moves to the heap once, and all goroutines share it -
+// which is exactly why they all see the last value.
+func sharedCapture() {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for range escapeGoroutines {
+		wg.Go(func() {
+			mu.Lock()
+			total++
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+}
+
+// perIterationCapture: since Go 1.22 each iteration has its OWN i. The
+// closure only reads it and nobody writes it afterwards, so the compiler
+// copies i INTO the closure: nothing moves to the heap.
+func perIterationCapture() {
+	var wg sync.WaitGroup
+	for i := range escapeGoroutines {
+		wg.Go(func() {
+			_ = i * 2
+		})
+	}
+	wg.Wait()
+}
+
+// perIterationMutated: the same loop, but the goroutine writes i. A copy
+// would no longer do, so each iteration's i moves to the heap - one extra
+// allocation per goroutine.
+func perIterationMutated() {
+	var wg sync.WaitGroup
+	for i := range escapeGoroutines {
+		wg.Go(func() {
+			i *= 2
+		})
+	}
+	wg.Wait()
+}
+
+// passByArgument is loopVariableFixed's shape: the value is copied into the
+// new goroutine's arguments, so no variable has to move to the heap.
+func passByArgument() {
+	var wg sync.WaitGroup
+	for i := range escapeGoroutines {
+		wg.Add(1)
+		go func(v int) {
+			defer wg.Done()
+			_ = v * 2
+		}(i)
+	}
+	wg.Wait()
+}
+
+// escapeDecision is one line of -gcflags=-m output about this file.
+type escapeDecision struct {
+	line int
+	msg  string
+}
+
+var escapeLine = regexp.MustCompile(`escape_analysis\.go:(\d+):\d+: (.*)`)
+
+// compilerEscapeDecisions builds this package with -gcflags=-m and returns
+// the escape decisions the compiler reports for escape_analysis.go. It
+// needs the go tool and the source tree, i.e. `go run` from the repo.
+func compilerEscapeDecisions() ([]escapeDecision, error) {
+	_, self, _, ok := runtime.Caller(0)
+	if !ok {
+		return nil, fmt.Errorf("cannot locate source file")
+	}
+	if _, err := os.Stat(self); err != nil {
+		return nil, fmt.Errorf("source not available: %w", err)
+	}
+	gotool, err := exec.LookPath("go")
+	if err != nil {
+		gotool = filepath.Join(runtime.GOROOT(), "bin", "go")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, gotool, "build", "-gcflags=-m", "-o", os.DevNull, ".")
+	cmd.Dir = filepath.Dir(self)
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out // -m output goes to stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go build -gcflags=-m: %w\n%s", err, out.String())
+	}
+
+	var decisions []escapeDecision
+	sc := bufio.NewScanner(&out)
+	for sc.Scan() {
+		m := escapeLine.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		msg := m[2]
+		if !strings.Contains(msg, "heap") && !strings.Contains(msg, "escape") {
+			continue // inlining decisions and the like
+		}
+		var line int
+		fmt.Sscan(m[1], &line)
+		decisions = append(decisions, escapeDecision{line, msg})
+	}
+	return decisions, sc.Err()
+}
+
+// functionAt returns the name of the top-level function in this file that
+// contains line, using the example functions' source positions.
+func functionAt(line int, starts map[string]int) string {
+	best, bestLine := "", 0
+	for name, start := range starts {
+		if start <= line && start > bestLine {
+			best, bestLine = name, start
+		}
+	}
+	return best
+}
+
+func escapeAnalysisDemo() {
+	fmt.Println("\n=== Escape Analysis: What Goroutine Closures Cost ===")
+
+	examples := []struct {
+		name string
+		fn   func()
+	}{
+		{"closureNoGoroutine", func() { closureNoGoroutine() }},
+		{"sharedCapture", sharedCapture},
+		{"perIterationCapture", perIterationCapture},
+		{"perIterationMutated", perIterationMutated},
+		{"passByArgument", passByArgument},
+	}
+
+	// Where does each example start? The runtime knows the line of every
+	// function's entry. compilerEscapeDecisions, which follows the examples
+	// in this file, marks where the last one ends.
+	starts := make(map[string]int)
+	for _, ex := range []any{closureNoGoroutine, sharedCapture, perIterationCapture, perIterationMutated, passByArgument, compilerEscapeDecisions} {
+		f := runtime.FuncForPC(reflect.ValueOf(ex).Pointer())
+		_, line := f.FileLine(f.Entry())
+		starts[f.Name()[strings.LastIndexByte(f.Name(), '.')+1:]] = line
+	}
+
+	decisions, err := compilerEscapeDecisions()
+	if err != nil {
+		fmt.Println("(skipping compiler output:", err, ")")
+	} else {
+		fmt.Println("What `go build -gcflags=-m` says about each example:")
+		byFunc := make(map[string][]string)
+		for _, d := range decisions {
+			if fn := functionAt(d.line, starts); fn != "" {
+				byFunc[fn] = append(byFunc[fn], fmt.Sprintf("line %d: %s", d.line, d.msg))
+			}
+		}
+		for _, ex := range examples {
+			fmt.Printf("  %s\n", ex.name)
+			if len(byFunc[ex.name]) == 0 {
+				fmt.Println("      (nothing escapes)")
+			}
+			for _, d := range byFunc[ex.name] {
+				fmt.Printf("      %s\n", d)
+			}
+		}
+	}
+
+	fmt.Printf("\nMeasured heap allocations (testing.AllocsPerRun, %d goroutines per run):\n", escapeGoroutines)
+	for _, ex := range examples {
+		allocs := testing.AllocsPerRun(20, ex.fn)
+		fmt.Printf("  %-20s %6.0f allocs/run  %5.2f per goroutine\n", ex.name, allocs, allocs/escapeGoroutines)
+	}
+
+	fmt.Println("\n→ Starting a goroutine always heap-allocates its closure: no goroutine is free")
+	fmt.Println("→ A captured variable that is WRITTEN moves to the heap: once if shared")
+	fmt.Println("  (the pitfall's shape), once per goroutine if it is a per-iteration variable")
+	fmt.Println("→ Read-only captures and arguments are copied: Go 1.22 loop variables cost")
+	fmt.Println("  nothing extra unless the goroutine modifies them")
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+// One op of these benchmarks is one call of the example, which starts
+// escapeGoroutines goroutines (none for closureNoGoroutine), so allocs/op
+// divided by escapeGoroutines is the cost per goroutine:
+//
+//	go test -run '^$' -bench Capture -benchmem ./ch03_go_concurrency_building_blocks
+
+func BenchmarkClosureNoGoroutine(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		closureNoGoroutine()
+	}
+}
+
+func BenchmarkSharedCapture(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		sharedCapture()
+	}
+}
+
+func BenchmarkPerIterationCapture(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		perIterationCapture()
+	}
+}
+
+func BenchmarkPerIterationMutated(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		perIterationMutated()
+	}
+}
+
+func BenchmarkPassByArgument(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		passByArgument()
+	}
+}
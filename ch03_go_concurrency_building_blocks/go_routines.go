@@ -1,10 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
+	"os"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/progress"
 )
 
 // ============================================================================
@@ -100,23 +109,130 @@ func closuresAndScope() {
 // 5. COMMON PITFALL: LOOP VARIABLE CAPTURE
 // ============================================================================
 
-func loopVariablePitfall() {
-	fmt.Println("\n=== Loop Variable Pitfall (WRONG) ===")
+// loopVarMode selects how captureLoopVar's goroutines see the loop
+// variable.
+type loopVarMode int
+
+const (
+	// sharedLoopVar simulates Go 1.21 and earlier: ONE variable, assigned
+	// on every iteration, captured by every goroutine.
+	sharedLoopVar loopVarMode = iota
+	// perIterationLoopVar is Go 1.22+: every iteration has its own variable.
+	perIterationLoopVar
+	// go121LoopVar runs the loop compiled with the Go 1.21 rules (see
+	// loopvar_go121.go) - the old semantics for real, with the s := s
+	// copy that every such loop needed.
+	go121LoopVar
+)
+
+func (m loopVarMode) String() string {
+	return [...]string{"shared (simulated <1.22)", "per-iteration (Go 1.22+)", "Go 1.21 rules + s := s"}[m]
+}
+
+// loopVarModeNames are the names -loopvar accepts, in the order "all" runs
+// them.
+var loopVarModeNames = []struct {
+	name string
+	mode loopVarMode
+}{{"shared", sharedLoopVar}, {"go1.21", go121LoopVar}, {"per-iteration", perIterationLoopVar}}
+
+var loopVarFlag = flag.String("loopvar", "",
+	"run only the loop-variable capture demo, in these modes: all, or a comma-separated list of shared, go1.21, per-iteration")
+
+// parseLoopVarModes turns a -loopvar value into the modes to run.
+func parseLoopVarModes(s string) ([]loopVarMode, error) {
+	var modes []loopVarMode
+	for _, name := range strings.Split(s, ",") {
+		found := false
+		for _, m := range loopVarModeNames {
+			if name == "all" || name == m.name {
+				modes = append(modes, m.mode)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("-loopvar: unknown mode %q (want all, shared, go1.21 or per-iteration)", name)
+		}
+	}
+	return modes, nil
+}
+
+// captureLoopVar starts one goroutine per value, each recording the loop
+// variable it captured. The goroutines only run once the loop is over (they
+// wait on start), which is what makes the old bug deterministic here;
+// in real programs it depends on scheduling.
+func captureLoopVar(mode loopVarMode, values []string) []string {
+	if mode == go121LoopVar {
+		return captureLoopVarGo121(values)
+	}
 
+	var mu sync.Mutex
 	var wg sync.WaitGroup
+	seen := make([]string, 0, len(values))
+	record := func(s string) {
+		mu.Lock()
+		seen = append(seen, s)
+		mu.Unlock()
+	}
+	start := make(chan struct{})
 
-	var salutation string
-	// WRONG: All goroutines reference the same loop variable
-	for _, s := range []string{"hello", "greetings", "good day"} {
+	var salutation string // shared mode: the one variable every goroutine reads
+	for _, s := range values {
 		salutation = s
 		wg.Go(func() {
-			// By the time this runs, salutation likely = "good day" (last value)
-			// time.Sleep(1 * time.Millisecond)
-			fmt.Println(salutation)
+			<-start
+			if mode == sharedLoopVar {
+				record(salutation) // by now, the last value
+			} else {
+				record(s) // this iteration's own s
+			}
 		})
 	}
+	close(start)
 	wg.Wait()
-	// Often prints "good day" three times!
+	return seen
+}
+
+// loopVariablePitfall runs captureLoopVar in each of modes and writes what
+// the goroutines saw to w, checked against what the mode must produce: the
+// last value every time for the shared variable, each value once for
+// per-iteration variables or a copy per iteration.
+func loopVariablePitfall(w io.Writer, modes []loopVarMode) {
+	fmt.Fprintln(w, "\n=== Loop Variable Capture: before and after Go 1.22 ===")
+
+	values := []string{"hello", "greetings", "good day"}
+	for _, mode := range modes {
+		seen := captureLoopVar(mode, values)
+		slices.Sort(seen)
+
+		want := slices.Sorted(slices.Values(values))
+		if mode == sharedLoopVar {
+			want = slices.Repeat([]string{values[len(values)-1]}, len(values))
+		}
+		check := "✓"
+		if !slices.Equal(seen, want) {
+			check = fmt.Sprintf("✗ want %q", want)
+		}
+		fmt.Fprintf(w, "%-26s %q %s\n", mode.String()+":", seen, check)
+	}
+	fmt.Fprintln(w, "→ Before Go 1.22 the goroutines all print \"good day\" unless the loop")
+	fmt.Fprintln(w, "  copies s first. The language version decides - go.mod's go line, or")
+	fmt.Fprintln(w, "  a file's //go:build goX.Y.")
+}
+
+// loopVarDemo runs loopVariablePitfall in the modes -loopvar names, or in
+// all of them.
+func loopVarDemo() {
+	names := *loopVarFlag
+	if names == "" {
+		names = "all"
+	}
+	modes, err := parseLoopVarModes(names)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	loopVariablePitfall(os.Stdout, modes)
 }
 
 func loopVariableFixed() {
@@ -145,16 +261,65 @@ func loopVariableFixed() {
 func goroutineLeaks() {
 	fmt.Println("\n=== Goroutine Leaks ===")
 
-	// WARNING: This goroutine will never exit and won't be garbage collected
-	go func() {
-		for {
-			// Infinite loop - goroutine leaks until process exits
-			time.Sleep(1 * time.Second)
+	const n = 100
+
+	// WARNING: these goroutines will never exit and won't be garbage collected
+	before := runtime.NumGoroutine()
+	startPollers(n, nil)
+	time.Sleep(50 * time.Millisecond) // plenty of time to exit, if they could
+	fmt.Printf("Leaky:  %d goroutines before, %d after starting %d pollers with no way to stop\n",
+		before, runtime.NumGoroutine(), n)
+	fmt.Println("        They will hang around until the program terminates.")
+
+	// FIXED: the owner closes done, and every poller returns.
+	before = runtime.NumGoroutine()
+	done := make(chan struct{})
+	startPollers(n, done)
+	during := runtime.NumGoroutine()
+	close(done)
+	after, ok := waitForGoroutines(before, time.Second)
+	fmt.Printf("Fixed:  %d goroutines before, %d while running, %d after close(done)\n", before, during, after)
+	if ok {
+		fmt.Println("        ✓ back to the baseline: nothing leaked")
+	} else {
+		fmt.Printf("        ✗ %d goroutines still running: leaked\n", after-before)
+	}
+	// Always ensure goroutines have a way to exit!
+}
+
+// poll wakes up every second until done is closed. With a nil done channel
+// that never happens: the case is never ready.
+func poll(done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(1 * time.Second):
+			// check something...
 		}
-	}()
+	}
+}
 
-	// The goroutine above will hang around until the program terminates
-	// Always ensure goroutines have a way to exit!
+// startPollers starts n goroutines running poll(done).
+func startPollers(n int, done <-chan struct{}) {
+	for range n {
+		go poll(done)
+	}
+}
+
+// waitForGoroutines polls runtime.NumGoroutine until it is at most want
+// (exiting goroutines take a moment to finish) or timeout passes. It returns
+// the last count and whether it reached want. Leak checks in tests work the
+// same way: record the count, run the code, wait for the count to return.
+func waitForGoroutines(want int, timeout time.Duration) (int, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		n := runtime.NumGoroutine()
+		if n <= want || time.Now().After(deadline) {
+			return n, n <= want
+		}
+		time.Sleep(time.Millisecond)
+	}
 }
 
 // ============================================================================
@@ -195,6 +360,77 @@ func measureGoroutineSize() {
 	fmt.Printf("Created %.3f goroutines\n", numGoroutines)
 
 	// Result: ~2-3kb per goroutine (very lightweight!)
+
+	measureStackGrowth(0, 10, 100, 1000, 4000)
+}
+
+// stackFrame is the local data each level of recurseStack keeps on its
+// stack, so that depth translates into a predictable number of bytes.
+const stackFrame = 128
+
+// recurseStack calls itself depth times, then calls park at the bottom so
+// that the goroutine stays alive with its deepest stack in place.
+//
+//go:noinline
+func recurseStack(depth int, park func()) byte {
+	var pad [stackFrame]byte
+	pad[depth%stackFrame] = byte(depth)
+	if depth == 0 {
+		park()
+		return pad[0]
+	}
+	return recurseStack(depth-1, park) + pad[depth%stackFrame]
+}
+
+// measureStackGrowth shows that the ~2KB above is only where a goroutine's
+// stack STARTS. Goroutines that recurse deeper outgrow it, and the runtime
+// copies their stack to one twice as large - as often as needed. For each
+// depth it parks 100 goroutines at the bottom of the recursion and reads
+// how much stack memory they hold (MemStats.StackInuse; StackSys is what
+// the runtime got from the OS for stacks).
+func measureStackGrowth(depths ...int) {
+	fmt.Println("\n--- Stack growth: the 2KB is only the starting size ---")
+
+	const numGoroutines = 100
+	stackStats := func() (inuse, sys uint64) {
+		runtime.GC() // also shrinks stacks of goroutines that no longer need them
+		var s runtime.MemStats
+		runtime.ReadMemStats(&s)
+		return s.StackInuse, s.StackSys
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "depth\tlocals\tstack/goroutine\tStackSys growth\t")
+	for _, depth := range depths {
+		release := make(chan struct{})
+		var parked, done sync.WaitGroup
+		parked.Add(numGoroutines)
+		done.Add(numGoroutines)
+
+		inuse0, sys0 := stackStats()
+		for range numGoroutines {
+			go func() {
+				defer done.Done()
+				recurseStack(depth, func() {
+					parked.Done()
+					<-release
+				})
+			}()
+		}
+		parked.Wait()
+		inuse1, sys1 := stackStats()
+		close(release)
+		done.Wait()
+
+		fmt.Fprintf(tw, "%d\t%d\t%.1fKB\t%+.1fMB\t\n", depth, depth*stackFrame,
+			float64(inuse1-inuse0)/numGoroutines/1024, (float64(sys1)-float64(sys0))/(1<<20))
+	}
+	tw.Flush()
+
+	fmt.Println("→ Each level needs its locals plus call overhead. The stack doubles")
+	fmt.Println("  (2KB, 4KB, 8KB, ...) whenever a call would overflow it, so the cost")
+	fmt.Println("  of a goroutine tracks how deep it goes. The GC shrinks a stack again")
+	fmt.Println("  once its goroutine is back to using a quarter of it.")
 }
 
 // ============================================================================
@@ -250,6 +486,9 @@ func schedulerDemo() {
 
 	fmt.Printf("After creating 1000 goroutines: %d running\n", runtime.NumGoroutine())
 	wg.Wait()
+
+	// Those numbers are static; ask the runtime what the scheduler is doing.
+	schedTraceExperiment()
 }
 
 // ============================================================================
@@ -303,20 +542,29 @@ func scalabilityDemo() {
 	const count = 100000
 
 	start := time.Now()
+	// Each goroutine waits up to 300ms, like a blocked network call. A
+	// reporter goroutine shows how many have finished and how many are
+	// alive; the 100,000 goroutines themselves never print.
+	bar := progress.Start(context.Background(), progress.Config{
+		Total: count,
+		Unit:  "goroutines",
+		Every: 100 * time.Millisecond,
+		Out:   os.Stdout,
+		Extra: func() string { return fmt.Sprintf("%d alive", runtime.NumGoroutine()) },
+	})
 
 	for i := range count {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			// Each goroutine does minimal work
-			_ = id * 2
-		}(i)
+		wg.Go(func() {
+			time.Sleep(time.Duration(i%300) * time.Millisecond)
+			bar.Add(1)
+		})
 	}
 
 	wg.Wait()
+	bar.Stop()
 	elapsed := time.Since(start)
 
-	fmt.Printf("Created and completed %d goroutines in %v\n", count, elapsed)
+	fmt.Printf("Created and completed %d goroutines in %v\n", count, elapsed.Round(time.Millisecond))
 	fmt.Println("Goroutines are extremely lightweight and scalable!")
 }
 
@@ -376,11 +624,14 @@ func goRoutine() {
 	anonymousGoroutines()
 	properSynchronization()
 	closuresAndScope()
-	loopVariablePitfall()
+	loopVarDemo()
 	loopVariableFixed()
+	escapeAnalysisDemo()
 	goroutineLeaks()
 	measureGoroutineSize()
 	schedulerDemo()
+	preemptionDemo()
+	yieldingDemo()
 	coroutineExplanation()
 	scalabilityDemo()
 	contextSwitchingDemo()
@@ -391,7 +642,7 @@ func goRoutine() {
 	fmt.Println("╠══════════════════════════════════════════════════════════════╣")
 	fmt.Println("║ 1. Always use sync.WaitGroup for proper synchronization      ║")
 	fmt.Println("║ 2. Pass loop variables as parameters to goroutines           ║")
-	fmt.Println("║ 3. Goroutines are ~2-3KB each (extremely lightweight)        ║")
+	fmt.Println("║ 3. Goroutines start at ~2KB of stack, growing on demand      ║")
 	fmt.Println("║ 4. Can create millions of goroutines easily                  ║")
 	fmt.Println("║ 5. Context switching is 10x faster than OS threads           ║")
 	fmt.Println("║ 6. Goroutines are NOT garbage collected - avoid leaks        ║")
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// The fixed pollers of goroutineLeaks: once done is closed, the goroutine
+// count must come back to where it was before they started.
+func TestPollersExitWhenDoneCloses(t *testing.T) {
+	const n = 100
+	before := runtime.NumGoroutine()
+	done := make(chan struct{})
+	startPollers(n, done)
+	if during := runtime.NumGoroutine(); during < before+n {
+		t.Fatalf("%d goroutines with %d pollers running, want at least %d", during, n, before+n)
+	}
+	close(done)
+	if after, ok := waitForGoroutines(before, 5*time.Second); !ok {
+		t.Fatalf("%d goroutines after close(done), want %d: %d pollers leaked", after, before, after-before)
+	}
+}
+
+// The leaky version for contrast: with a nil done channel nothing can stop
+// the pollers, and the count stays up. (They stay for the rest of the test
+// binary, an idle select each.)
+func TestPollersWithNilDoneLeak(t *testing.T) {
+	const n = 10
+	before := runtime.NumGoroutine()
+	startPollers(n, nil)
+	if after, _ := waitForGoroutines(before, 50*time.Millisecond); after < before+n {
+		t.Fatalf("%d goroutines, want at least %d: pollers with a nil done channel exited", after, before+n)
+	}
+}
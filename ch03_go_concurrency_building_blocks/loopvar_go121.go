@@ -0,0 +1,36 @@
+//go:build go1.21
+
+package main
+
+// This file is compiled with the Go 1.21 language rules: a //go:build line
+// naming an older release than go.mod's sets the language version of the
+// file, so its loops still share ONE variable across all iterations. It
+// is the real pre-1.22 behavior, not a simulation.
+
+import "sync"
+
+// captureLoopVarGo121 is the loop as it had to be written before Go 1.22:
+// s is shared by every iteration, so each one copies it into a variable
+// of its own before the goroutine captures it. Without that line every
+// goroutine would see the last value (go vet's loopclosure check reports
+// exactly that).
+func captureLoopVarGo121(values []string) []string {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make([]string, 0, len(values))
+	start := make(chan struct{})
+	for _, s := range values {
+		s := s // this iteration's copy
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			mu.Lock()
+			seen = append(seen, s)
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return seen
+}
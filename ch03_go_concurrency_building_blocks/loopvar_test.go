@@ -0,0 +1,67 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCaptureLoopVar(t *testing.T) {
+	values := []string{"hello", "greetings", "good day"}
+	for _, tc := range []struct {
+		mode loopVarMode
+		want []string // sorted
+	}{
+		{sharedLoopVar, []string{"good day", "good day", "good day"}},
+		{go121LoopVar, []string{"good day", "greetings", "hello"}},
+		{perIterationLoopVar, []string{"good day", "greetings", "hello"}},
+	} {
+		seen := captureLoopVar(tc.mode, values)
+		slices.Sort(seen)
+		if !slices.Equal(seen, tc.want) {
+			t.Errorf("%v: goroutines saw %q, want %q", tc.mode, seen, tc.want)
+		}
+	}
+}
+
+func TestLoopVariablePitfallOutput(t *testing.T) {
+	for _, tc := range []struct {
+		flag  string
+		lines []string
+	}{
+		{"shared", []string{`shared (simulated <1.22):  ["good day" "good day" "good day"] ✓`}},
+		{"go1.21", []string{`Go 1.21 rules + s := s:    ["good day" "greetings" "hello"] ✓`}},
+		{"per-iteration", []string{`per-iteration (Go 1.22+):  ["good day" "greetings" "hello"] ✓`}},
+		{"all", []string{
+			`shared (simulated <1.22):  ["good day" "good day" "good day"] ✓`,
+			`Go 1.21 rules + s := s:    ["good day" "greetings" "hello"] ✓`,
+			`per-iteration (Go 1.22+):  ["good day" "greetings" "hello"] ✓`,
+		}},
+	} {
+		modes, err := parseLoopVarModes(tc.flag)
+		if err != nil {
+			t.Fatalf("-loopvar %s: %v", tc.flag, err)
+		}
+		var out strings.Builder
+		loopVariablePitfall(&out, modes)
+		var got []string
+		for line := range strings.Lines(out.String()) {
+			if strings.Contains(line, `["`) { // a mode's line: what its goroutines saw
+				got = append(got, strings.TrimRight(line, "\n"))
+			}
+		}
+		if !slices.Equal(got, tc.lines) {
+			t.Errorf("-loopvar %s printed\n%s\nwant the mode lines\n%s", tc.flag, out.String(), strings.Join(tc.lines, "\n"))
+		}
+	}
+}
+
+func TestParseLoopVarModes(t *testing.T) {
+	modes, err := parseLoopVarModes("per-iteration,shared")
+	if err != nil || !slices.Equal(modes, []loopVarMode{perIterationLoopVar, sharedLoopVar}) {
+		t.Errorf("parseLoopVarModes(per-iteration,shared) = %v, %v", modes, err)
+	}
+	if _, err := parseLoopVarModes("go1.22"); err == nil {
+		t.Error("parseLoopVarModes accepted an unknown mode")
+	}
+}
@@ -1,14 +1,40 @@
 package main
 
 import (
-	syncpackage "learning-concurrency/ch03_go_concurrency_building_blocks/sync_package"
+	"flag"
+
+	// approxcounting "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/approx_counting"
+	// "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/netpoller"
+	// osthreads "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/os_threads"
+	// producerconsumer "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/producer_consumer"
+	// "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/spinlocks"
+	syncpackage "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/sync_package"
+	// "github.com/mintecr7/concurrency-with-go/classics"
 )
 
 func main() {
+	if run, ok := childWorkload(); ok { // we are a child process of an experiment
+		run()
+		return
+	}
+	flag.Parse()
+	if *loopVarFlag != "" { // -loopvar: just the loop-variable demo
+		loopVarDemo()
+		return
+	}
+
 	// goRoutine()
 	// syncpackage.WaitGroupDemo()
 	// syncpackage.MutexAndRWMutex()
 	// syncpackage.CondDemo()
 	syncpackage.RunOnceExamples()
 	// syncpackage.PoolDemo()
+	// syncpackage.ProfilingDemo()
+	// producerconsumer.ProducerConsumerDemo()
+	// spinlocks.QueuedLocksDemo()
+	// classics.ClassicProblemsDemo()
+	// approxcounting.ApproxCountingDemo()
+	// osthreads.LockOSThreadDemo()
+	// osthreads.ThreadGrowthDemo()
+	// netpoller.NetpollerDemo()
 }
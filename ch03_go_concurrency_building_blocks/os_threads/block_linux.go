@@ -0,0 +1,17 @@
+package osthreads
+
+import (
+	"syscall"
+	"time"
+)
+
+// blockThread sleeps in a real blocking system call. Unlike time.Sleep,
+// which parks only the goroutine, this holds the OS thread for d, the way a
+// cgo call or a blocking read of a regular file does.
+func blockThread(d time.Duration) {
+	ts := syscall.NsecToTimespec(d.Nanoseconds())
+	for syscall.Nanosleep(&ts, &ts) == syscall.EINTR {
+		// interrupted by a signal (the runtime sends them for preemption):
+		// ts now holds the remaining time
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package osthreads
+
+import "time"
+
+// blockThread stands in for a blocking system call. Off Linux it only
+// sleeps the goroutine, so the thread-hopping and thread-growth effects it
+// is used to show are weaker or absent there.
+func blockThread(d time.Duration) { time.Sleep(d) }
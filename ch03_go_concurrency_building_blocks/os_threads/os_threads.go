@@ -0,0 +1,268 @@
+// Package osthreads shows when the OS thread under a goroutine matters, and
+// how runtime.LockOSThread and a dedicated locked thread deal with it.
+package osthreads
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/lockedthread"
+)
+
+// ============================================================================
+// runtime.LockOSThread - WHEN THE THREAD MATTERS
+// ============================================================================
+// Goroutines are not threads. The scheduler runs a goroutine on whichever
+// thread (M) currently has a P, and after a blocking system call it often
+// resumes on a DIFFERENT thread than it started on. Pure Go code cannot tell.
+//
+// Code that talks to the OS or to C libraries sometimes can:
+// - THREAD-LOCAL STATE: errno-style "last error", OpenGL contexts, Linux
+//   per-thread namespaces and credentials. Set it on thread A, resume on
+//   thread B, and the state is gone - or someone else's.
+// - THREAD-AFFINE APIs: GUI toolkits that must only be called from the
+//   thread that initialized them (usually the main thread).
+//
+// runtime.LockOSThread pins the calling goroutine to its thread until
+// UnlockOSThread; no other goroutine runs there meanwhile. For thread-affine
+// APIs the idiom is a dedicated goroutine that locks itself for good and
+// executes work sent over a channel: lockedthread.Thread.
+// ============================================================================
+
+// churn keeps n goroutines busy so that the scheduler has other work to
+// hand a P to while a goroutine sits in a system call. It returns a stop
+// function.
+func churn(n int) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					for i := 0; i < 50_000; i++ { // a little CPU work
+						_ = i * i
+					}
+					time.Sleep(50 * time.Microsecond)
+				}
+			}
+		})
+	}
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// threadsSeen runs rounds of "block in a system call" on a fresh goroutine,
+// optionally locked, and returns how many distinct OS threads it ran on.
+func threadsSeen(lock bool, rounds int) int {
+	seen := make(map[int]bool)
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		if lock {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+		}
+		for range rounds {
+			seen[lockedthread.ID()] = true
+			blockThread(2 * time.Millisecond)
+		}
+	})
+	wg.Wait()
+	return len(seen)
+}
+
+// ============================================================================
+// 1. GOROUTINES HOP BETWEEN THREADS
+// ============================================================================
+
+func threadHopping() {
+	fmt.Println("\n=== 1. Goroutines Hop Between Threads ===")
+
+	stop := churn(4)
+	defer stop()
+
+	const rounds = 200
+	unlocked := threadsSeen(false, rounds)
+	locked := threadsSeen(true, rounds)
+	fmt.Printf("%d blocking syscalls, other goroutines busy:\n", rounds)
+	fmt.Printf("  unlocked goroutine ran on %d different OS thread(s)\n", unlocked)
+	fmt.Printf("  locked goroutine ran on   %d OS thread(s)\n", locked)
+	fmt.Println("→ After a blocking call the goroutine resumes on whichever thread gets a P")
+}
+
+// ============================================================================
+// 2. THREAD-LOCAL STATE: AN errno-STYLE C LIBRARY
+// ============================================================================
+// Many C APIs report failure as "return -1, details in a per-thread
+// variable" (errno, GetLastError, glGetError). The call and the read of the
+// detail must happen on the same thread.
+// ============================================================================
+
+// cLibrary simulates such a library: each OS thread has its own last error.
+type cLibrary struct {
+	mu      sync.Mutex
+	lastErr map[int]string // OS thread id → that thread's last error
+}
+
+// open fails and records why, in the calling thread's slot - like a C
+// function setting errno.
+func (c *cLibrary) open(path string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastErr[lockedthread.ID()] = "ENOENT: " + path
+	return -1
+}
+
+// lastError reads the calling thread's slot.
+func (c *cLibrary) lastError() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.lastErr[lockedthread.ID()]
+	delete(c.lastErr, lockedthread.ID())
+	return err
+}
+
+func threadLocalState() {
+	fmt.Println("\n=== 2. Thread-Local State (errno-style last error) ===")
+
+	stop := churn(4)
+	defer stop()
+
+	// Each attempt: call, do a blocking syscall in between (a log write, say),
+	// then ask for the error. Count how often the detail is lost.
+	attempt := func(lock bool) (lost int) {
+		lib := &cLibrary{lastErr: make(map[int]string)}
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			for i := range 100 {
+				if lock {
+					runtime.LockOSThread() // pin for the call + read pair only
+				}
+				path := fmt.Sprintf("/tmp/missing-%d", i)
+				if lib.open(path) < 0 {
+					blockThread(2 * time.Millisecond)
+					if lib.lastError() != "ENOENT: "+path {
+						lost++
+					}
+				}
+				if lock {
+					runtime.UnlockOSThread()
+				}
+			}
+		})
+		wg.Wait()
+		return lost
+	}
+
+	fmt.Printf("Unlocked:            %3d of 100 errors lost or wrong\n", attempt(false))
+	fmt.Printf("LockOSThread around: %3d of 100 errors lost or wrong\n", attempt(true))
+	fmt.Println("→ Lock the thread for the WHOLE sequence that relies on thread-local state")
+}
+
+// ============================================================================
+// 3. THREAD-AFFINE APIs: A MAIN-THREAD-ONLY UI TOOLKIT
+// ============================================================================
+
+var errWrongThread = errors.New("ui: called from a thread other than the UI thread")
+
+// uiToolkit simulates a GUI library that must only be used from the thread
+// that called Init.
+type uiToolkit struct {
+	owner int
+	drawn int
+}
+
+func (ui *uiToolkit) Init() { ui.owner = lockedthread.ID() }
+
+func (ui *uiToolkit) Draw(widget string) error {
+	if lockedthread.ID() != ui.owner {
+		return errWrongThread
+	}
+	ui.drawn++ // unsynchronized on purpose: one thread, one goroutine
+	return nil
+}
+
+func uiThread() {
+	fmt.Println("\n=== 3. A Dedicated UI Thread (lockedthread.Thread) ===")
+
+	// Like a real toolkit initialized from main() while main is locked to the
+	// main thread: Init runs on a thread that no other goroutine will ever
+	// run on.
+	var ui uiToolkit
+	t := lockedthread.Start(ui.Init)
+	defer t.Close()
+
+	const widgets = 50
+	draw := func(call func(func()) error) (ok, wrongThread int) {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for i := range widgets {
+			wg.Go(func() {
+				var err error
+				if callErr := call(func() { err = ui.Draw(fmt.Sprintf("widget-%d", i)) }); callErr != nil {
+					err = callErr
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					wrongThread++
+				} else {
+					ok++
+				}
+			})
+		}
+		wg.Wait()
+		return ok, wrongThread
+	}
+
+	// Calling straight from each goroutine. (The mutex only keeps the
+	// toolkit's counter race-free; it does nothing for thread affinity.)
+	var direct sync.Mutex
+	ok, wrong := draw(func(fn func()) error {
+		direct.Lock()
+		defer direct.Unlock()
+		fn()
+		return nil
+	})
+	fmt.Printf("Direct calls from %d goroutines:   %2d drawn, %2d rejected (wrong thread)\n", widgets, ok, wrong)
+
+	// Forwarding every call to the UI thread.
+	ok, wrong = draw(t.Do)
+	fmt.Printf("Forwarded to UI thread %-8d   %2d drawn, %2d rejected\n", t.ID(), ok, wrong)
+	fmt.Println("→ The UI thread serializes calls too: no mutex needed inside the toolkit")
+}
+
+// LockOSThreadDemo runs every example in this package.
+func LockOSThreadDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║           runtime.LockOSThread - WHEN THREADS MATTER       ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	if lockedthread.ID() == 0 {
+		fmt.Println("(OS thread ids are only available on Linux; results below are trivial)")
+	}
+
+	threadHopping()
+	threadLocalState()
+	uiThread()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Goroutines move between OS threads, esp. after syscalls  ║")
+	fmt.Println("║ • Thread-local state (errno, GL contexts, namespaces):     ║")
+	fmt.Println("║   LockOSThread for the whole sequence, then Unlock         ║")
+	fmt.Println("║ • Thread-affine APIs (GUI main thread): one goroutine      ║")
+	fmt.Println("║   locked for good, work forwarded over a channel           ║")
+	fmt.Println("║ • A locked goroutine that exits takes its thread with it:  ║")
+	fmt.Println("║   tainted thread state never reaches other goroutines      ║")
+	fmt.Println("║ • Locked goroutines cost a whole thread each: use sparingly║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
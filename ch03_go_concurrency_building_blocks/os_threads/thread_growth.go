@@ -0,0 +1,184 @@
+package osthreads
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// THREAD GROWTH: BLOCKING SYSCALLS VS THE NETPOLLER
+// ============================================================================
+// "Goroutines are cheap" has a footnote. A goroutine blocked on a channel,
+// a mutex or a SOCKET costs a couple of KB of stack: the runtime parks it
+// and the thread moves on. A goroutine blocked in a SYSTEM CALL the runtime
+// cannot make asynchronous (regular file I/O, many cgo calls, DNS via libc)
+// keeps its OS thread blocked in the kernel. Everyone else needs a new
+// thread, so 1000 such goroutines means ~1000 threads.
+//
+// Two limits make this dangerous:
+// - Threads are never given back. The runtime parks idle ones for reuse, so
+//   a burst of blocking calls leaves its thread count behind for good.
+// - debug.SetMaxThreads (default 10000). Exceed it and the program dies:
+//   "runtime: program exceeds 10000-thread limit" - a fatal error, not a
+//   panic you could recover from.
+//
+// Sockets avoid all of this: they are non-blocking under the hood, and a
+// goroutine that reads from one is parked on the NETPOLLER (epoll/kqueue)
+// until data arrives.
+//
+// FIX for blocking calls: bound them with a semaphore (a buffered channel)
+// so at most N threads are ever stuck in the kernel.
+// ============================================================================
+
+// threadCount returns the number of OS threads in this process, from
+// /proc/self/status on Linux and otherwise from the threadcreate profile
+// (threads ever created - the same thing, since threads are never freed).
+func threadCount() int {
+	if status, err := os.ReadFile("/proc/self/status"); err == nil {
+		sc := bufio.NewScanner(bytes.NewReader(status))
+		for sc.Scan() {
+			if rest, ok := bytes.CutPrefix(sc.Bytes(), []byte("Threads:")); ok {
+				if n, err := strconv.Atoi(string(bytes.TrimSpace(rest))); err == nil {
+					return n
+				}
+			}
+		}
+	}
+	return pprof.Lookup("threadcreate").Count()
+}
+
+// threadPeak samples threadCount while fn runs and returns the count before
+// and the highest count seen.
+func threadPeak(fn func()) (before, peak int) {
+	before = threadCount()
+	peak = before
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(2 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			peak = max(peak, threadCount())
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	fn()
+	close(done)
+	<-sampled
+	return before, max(peak, threadCount())
+}
+
+// blockedOnSockets parks n goroutines in conn.Read on idle localhost TCP
+// connections for d, then closes them.
+func blockedOnSockets(n int, d time.Duration) error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	// The server side accepts and holds every connection without writing.
+	var server sync.WaitGroup
+	accepted := make(chan net.Conn, n)
+	server.Go(func() {
+		for range n {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	})
+
+	var readers sync.WaitGroup
+	for range n {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return err
+		}
+		readers.Go(func() {
+			defer c.Close()
+			c.SetReadDeadline(time.Now().Add(d))
+			io.ReadAll(c) // parks on the netpoller until the deadline
+		})
+	}
+	readers.Wait()
+	server.Wait()
+	close(accepted)
+	for c := range accepted {
+		c.Close()
+	}
+	return nil
+}
+
+// blockedInSyscalls runs n goroutines that each spend d in a blocking
+// system call. With limit > 0, a semaphore lets at most limit in at once.
+func blockedInSyscalls(n, limit int, d time.Duration) {
+	var sem chan struct{}
+	if limit > 0 {
+		sem = make(chan struct{}, limit)
+	}
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			if sem != nil {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+			blockThread(d)
+		})
+	}
+	wg.Wait()
+}
+
+// ThreadGrowthDemo compares the OS threads needed by goroutines blocked on
+// sockets and goroutines blocked in system calls.
+func ThreadGrowthDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║     THREAD GROWTH: BLOCKING SYSCALLS VS THE NETPOLLER      ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	const goroutines = 300
+	fmt.Printf("GOMAXPROCS=%d, %d goroutines blocked for ~100ms each\n\n", runtime.GOMAXPROCS(0), goroutines)
+	fmt.Printf("%-34s %8s %8s %8s\n", "blocked in", "before", "peak", "added")
+
+	report := func(name string, before, peak int) {
+		fmt.Printf("%-34s %8d %8d %+8d\n", name, before, peak, peak-before)
+	}
+
+	// Sockets first: threads are never freed, so running the syscalls first
+	// would hide the netpoller's effect behind an already-large pool.
+	var netErr error
+	before, peak := threadPeak(func() { netErr = blockedOnSockets(goroutines, 100*time.Millisecond) })
+	if netErr != nil {
+		fmt.Println("socket experiment failed:", netErr)
+	} else {
+		report("conn.Read on idle TCP sockets", before, peak)
+	}
+
+	before, peak = threadPeak(func() { blockedInSyscalls(goroutines, 16, 100*time.Millisecond) })
+	report("syscalls, semaphore of 16", before, peak)
+
+	before, peak = threadPeak(func() { blockedInSyscalls(goroutines, 0, 100*time.Millisecond) })
+	report("syscalls, unbounded", before, peak)
+
+	after := threadCount()
+	fmt.Printf("\nThreads a moment later: %d - idle threads are parked, not freed\n", after)
+	fmt.Println("→ Socket waits park on the netpoller: goroutines, not threads")
+	fmt.Println("→ Each goroutine stuck in a blocking syscall or cgo call holds a thread")
+	fmt.Println("→ Bound blocking calls with a semaphore; 10000 threads is a FATAL error")
+}
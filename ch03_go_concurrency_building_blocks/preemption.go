@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+// ============================================================================
+// 8c. PREEMPTION: CAN A TIGHT LOOP STARVE EVERYONE ELSE?
+// ============================================================================
+// Goroutines used to be preempted only COOPERATIVELY, at function calls
+// (the stack-check in a function's prologue doubles as a "should I yield?"
+// check). A loop without calls, like
+//
+//	for i := 0; i < n; i++ { x += i ^ (x >> 3) }
+//
+// never reaches such a check, so on a busy P nothing else ran until the loop
+// finished - timers fired late, other goroutines starved, and a GC waiting
+// for everyone to stop waited too.
+//
+// Since Go 1.14 the runtime also preempts ASYNCHRONOUSLY: sysmon notices a
+// goroutine running for more than ~10ms and sends its thread a signal; the
+// signal handler parks the goroutine at whatever instruction it was on.
+// GODEBUG=asyncpreemptoff=1 turns that off again, which lets us measure both
+// worlds: a child process with GOMAXPROCS=1 runs the tight loop next to a
+// goroutine that wants to wake up every millisecond, and reports how late
+// those wake-ups were.
+// ============================================================================
+
+func init() { childWorkloads["preemption"] = preemptionWorkload }
+
+// spin is a tight loop with no function calls and therefore no cooperative
+// preemption points.
+//
+//go:noinline
+func spin(n int) int {
+	x := 0
+	for i := 0; i < n; i++ {
+		x += i ^ (x >> 3)
+	}
+	return x
+}
+
+// preemptionWorkload is what the child runs: one spinner and one goroutine
+// that sleeps 1ms at a time, measuring how late each wake-up is, on a single
+// P. It prints one line of results for preemptionDemo to parse.
+func preemptionWorkload() {
+	runtime.GOMAXPROCS(1)
+
+	// Calibrate the loop to run for about 300ms.
+	start := time.Now()
+	spin(10_000_000)
+	n := int(10_000_000 * (300 * time.Millisecond) / max(time.Since(start), time.Microsecond))
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	started := make(chan struct{})
+	var wakeups int
+	var worst, total time.Duration
+	go func() {
+		defer close(done)
+		close(started)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			before := time.Now()
+			time.Sleep(time.Millisecond)
+			late := time.Since(before) - time.Millisecond
+			wakeups++
+			total += late
+			worst = max(worst, late)
+		}
+	}()
+	<-started // the sleeper is running before the spinning starts
+
+	spinStart := time.Now()
+	spinDone := make(chan struct{})
+	go func() {
+		spin(n)
+		close(spinDone)
+	}()
+	<-spinDone
+	spinTime := time.Since(spinStart)
+	close(stop)
+	<-done
+
+	fmt.Printf("wakeups=%d worst=%d mean=%d spin=%d\n",
+		wakeups, worst, total/time.Duration(max(wakeups, 1)), spinTime)
+}
+
+// preemptionResult is what one child reported.
+type preemptionResult struct {
+	wakeups           int
+	worst, mean, spin time.Duration
+}
+
+func runPreemptionChild(ctx context.Context, env ...string) (preemptionResult, error) {
+	cmd, err := childCommand(ctx, "preemption", env...)
+	if err != nil {
+		return preemptionResult{}, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return preemptionResult{}, fmt.Errorf("child: %w: %s", err, stderr.Bytes())
+	}
+	var r preemptionResult
+	var worst, mean, spin int64
+	if _, err := fmt.Sscanf(string(out), "wakeups=%d worst=%d mean=%d spin=%d", &r.wakeups, &worst, &mean, &spin); err != nil {
+		return preemptionResult{}, fmt.Errorf("unexpected child output %q: %w", out, err)
+	}
+	r.worst, r.mean, r.spin = time.Duration(worst), time.Duration(mean), time.Duration(spin)
+	return r, nil
+}
+
+func preemptionDemo() {
+	fmt.Println("\n=== Preemption: a Tight Loop vs. a 1ms Sleeper on One P ===")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// GODEBUG is a comma-separated list; keep whatever the user set.
+	godebug := func(setting string) string {
+		if cur := os.Getenv("GODEBUG"); cur != "" {
+			return "GODEBUG=" + cur + "," + setting
+		}
+		return "GODEBUG=" + setting
+	}
+	modes := []struct {
+		name string
+		env  string
+	}{
+		{"async preemption (Go 1.14+)", godebug("asyncpreemptoff=0")},
+		{"cooperative only (asyncpreemptoff=1)", godebug("asyncpreemptoff=1")},
+	}
+
+	fmt.Printf("%-38s %8s %10s %12s %10s\n", "mode", "spin", "wake-ups", "worst late", "mean late")
+	results := make([]preemptionResult, len(modes))
+	for i, m := range modes {
+		r, err := runPreemptionChild(ctx, m.env)
+		if err != nil {
+			fmt.Println("preemption experiment failed:", err)
+			return
+		}
+		results[i] = r
+		fmt.Printf("%-38s %8v %10d %12v %10v\n", m.name, r.spin.Round(time.Millisecond), r.wakeups,
+			r.worst.Round(100*time.Microsecond), r.mean.Round(100*time.Microsecond))
+	}
+
+	async, coop := results[0], results[1]
+	fmt.Printf("\nWith async preemption the sleeper waited at most %v - a time slice or two\n", async.worst.Round(time.Millisecond))
+	fmt.Printf("(sysmon checks every 10ms). Without it, it waited %v: the whole loop.\n", coop.worst.Round(time.Millisecond))
+	fmt.Println("→ A call-free loop can no longer hog a P, but it still gets ~10ms slices:")
+	fmt.Println("  latency-sensitive goroutines need spare Ps, not just preemption")
+}
@@ -0,0 +1,183 @@
+package producerconsumer
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/stats"
+)
+
+// item is what flows through every buffer in the comparison. The timestamp
+// lets consumers measure how long each item sat in the buffer.
+type item struct {
+	producer, seq int
+	enqueued      time.Time
+}
+
+// runResult is the outcome of pushing a workload through one strategy.
+type runResult struct {
+	received   int
+	duplicates int
+	outOfOrder int // items seen before an earlier item from the same producer
+	elapsed    time.Duration
+	latencies  stats.Snapshot // time each item spent in the buffer
+}
+
+func (r runResult) throughput() float64 {
+	return float64(r.received) / r.elapsed.Seconds()
+}
+
+func (r runResult) percentile(p float64) time.Duration {
+	return r.latencies.QuantileDuration(p).Round(time.Microsecond)
+}
+
+// run pushes producers×perProducer items through buf using the given number
+// of consumers and records what each consumer saw.
+func run(buf Buffer[item], producers, consumers, perProducer int) runResult {
+	total := producers * perProducer
+	if total%consumers != 0 {
+		panic("total items must divide evenly between consumers")
+	}
+	perConsumer := total / consumers
+
+	// Consumers record latencies into one shared histogram: an atomic add
+	// per item instead of a slice per consumer that has to be merged and
+	// sorted afterwards.
+	latencies := stats.NewLatencyHistogram()
+	results := make([][]item, consumers)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for p := range producers {
+		wg.Go(func() {
+			for seq := range perProducer {
+				buf.Put(item{producer: p, seq: seq, enqueued: time.Now()})
+			}
+		})
+	}
+	for c := range consumers {
+		wg.Go(func() {
+			items := make([]item, 0, perConsumer) // each consumer owns its slot: no locking needed
+			for range perConsumer {
+				it := buf.Get()
+				latencies.ObserveDuration(time.Since(it.enqueued))
+				items = append(items, it)
+			}
+			results[c] = items
+		})
+	}
+	wg.Wait()
+
+	res := runResult{elapsed: time.Since(start), latencies: latencies.Snapshot()}
+	seen := make(map[[2]int]bool, total)
+	for _, items := range results {
+		last := make(map[int]int)
+		for _, it := range items {
+			key := [2]int{it.producer, it.seq}
+			if seen[key] {
+				res.duplicates++
+			}
+			seen[key] = true
+			if prev, ok := last[it.producer]; ok && it.seq < prev {
+				res.outOfOrder++
+			}
+			last[it.producer] = it.seq
+		}
+	}
+	res.received = len(seen)
+	return res
+}
+
+// ============================================================================
+// 1. CORRECTNESS: NOTHING LOST, NOTHING DUPLICATED, FIFO PER PRODUCER
+// ============================================================================
+
+func correctness() {
+	fmt.Println("\n=== Correctness Check (8 producers, 8 consumers, capacity 4) ===")
+
+	const producers, consumers, perProducer = 8, 8, 5000
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "Strategy\tSent\tReceived\tDuplicates\tOut of order\n")
+	for _, s := range strategies {
+		r := run(s.newQueue(4), producers, consumers, perProducer)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n",
+			s.name, producers*perProducer, r.received, r.duplicates, r.outOfOrder)
+	}
+	tw.Flush()
+	fmt.Println("→ go test checks this for every strategy and several shapes")
+}
+
+// ============================================================================
+// 2. BENCHMARK: THROUGHPUT AND LATENCY
+// ============================================================================
+
+func benchmark() {
+	fmt.Println("\n=== Throughput & Latency (best of 3 runs) ===")
+
+	configs := []struct {
+		producers, consumers, capacity int
+	}{
+		{1, 1, 1},
+		{1, 1, 64},
+		{4, 4, 1},
+		{4, 4, 64},
+		{16, 4, 16},
+		{4, 16, 16},
+	}
+	const totalItems = 160_000
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "P/C/Cap\tStrategy\tItems/sec\tp50 latency\tp99 latency\n")
+	for _, cfg := range configs {
+		for _, s := range strategies {
+			var best runResult
+			for range 3 {
+				r := run(s.newQueue(cfg.capacity), cfg.producers, cfg.consumers, totalItems/cfg.producers)
+				if best.elapsed == 0 || r.elapsed < best.elapsed {
+					best = r
+				}
+			}
+			fmt.Fprintf(tw, "%d/%d/%d\t%s\t%.0f\t%v\t%v\n",
+				cfg.producers, cfg.consumers, cfg.capacity, s.name,
+				best.throughput(), best.percentile(0.50), best.percentile(0.99))
+		}
+	}
+	tw.Flush()
+	fmt.Println("→ go test -bench . runs the same shapes as benchmarks")
+
+	fmt.Println("\nWhat to look for:")
+	fmt.Println("  • Capacity 1 forces a hand-off per item: every strategy slows down")
+	fmt.Println("  • The channel is usually fastest - the runtime hands items directly")
+	fmt.Println("    to a parked receiver without an extra lock/unlock round trip")
+	fmt.Println("  • The semaphore version pays for a channel op AND a mutex per item")
+	fmt.Println("  • Latency grows with capacity: a bigger buffer means a longer line")
+}
+
+// ============================================================================
+// MAIN FUNCTION - RUN ALL EXAMPLES
+// ============================================================================
+
+func ProducerConsumerDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║        PRODUCER-CONSUMER: Cond vs Channel vs Semaphore     ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	correctness()
+	benchmark()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ All three are correct - pick by clarity first:             ║")
+	fmt.Println("║   • Channel:   simplest, works with select, usually fast   ║")
+	fmt.Println("║   • Cond:      when the wait condition is richer than      ║")
+	fmt.Println("║                \"buffer has room\" (priorities, batches)     ║")
+	fmt.Println("║   • Semaphore: when you already think in slots/permits     ║")
+	fmt.Println("║ Measure before choosing on performance grounds!            ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
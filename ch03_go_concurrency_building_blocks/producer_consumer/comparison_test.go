@@ -0,0 +1,49 @@
+package producerconsumer
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestNoItemLostOrDuplicated pushes items through every strategy with more
+// goroutines than slots: each item must arrive exactly once, and a
+// consumer must see one producer's items in the order they were put.
+func TestNoItemLostOrDuplicated(t *testing.T) {
+	configs := []struct{ producers, consumers, capacity int }{
+		{1, 1, 1}, {8, 8, 4}, {16, 4, 16}, {4, 16, 1},
+	}
+	const perProducer = 2000
+	for _, s := range strategies {
+		for _, cfg := range configs {
+			r := run(s.newQueue(cfg.capacity), cfg.producers, cfg.consumers, perProducer)
+			if want := cfg.producers * perProducer; r.received != want || r.duplicates != 0 || r.outOfOrder != 0 {
+				t.Errorf("%s %d/%d/%d: received %d of %d, %d duplicates, %d out of order",
+					s.name, cfg.producers, cfg.consumers, cfg.capacity, r.received, want, r.duplicates, r.outOfOrder)
+			}
+		}
+	}
+}
+
+func BenchmarkCondBuffer(b *testing.B)      { benchmarkStrategy(b, strategies[0]) }
+func BenchmarkChanBuffer(b *testing.B)      { benchmarkStrategy(b, strategies[1]) }
+func BenchmarkSemaphoreBuffer(b *testing.B) { benchmarkStrategy(b, strategies[2]) }
+
+// benchmarkStrategy moves b.N items (rounded up to split evenly) through
+// s for a few producer/consumer/capacity shapes, so ns/op is the cost of
+// one item. The buffer wait of the median and 99th-percentile item is
+// reported alongside.
+func benchmarkStrategy(b *testing.B, s strategy) {
+	for _, cfg := range []struct{ producers, consumers, capacity int }{
+		{1, 1, 1}, {1, 1, 64}, {4, 4, 1}, {4, 4, 64}, {16, 4, 16}, {4, 16, 16},
+	} {
+		b.Run(fmt.Sprintf("P%d-C%d-cap%d", cfg.producers, cfg.consumers, cfg.capacity), func(b *testing.B) {
+			per := (b.N + cfg.producers*cfg.consumers - 1) / (cfg.producers * cfg.consumers) * cfg.consumers
+			buf := s.newQueue(cfg.capacity)
+			b.ResetTimer()
+			r := run(buf, cfg.producers, cfg.consumers, per)
+			b.StopTimer()
+			b.ReportMetric(float64(r.latencies.QuantileDuration(0.50).Nanoseconds()), "p50-wait-ns")
+			b.ReportMetric(float64(r.latencies.QuantileDuration(0.99).Nanoseconds()), "p99-wait-ns")
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package producerconsumer
+
+import (
+	"sync"
+
+	syncpackage "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/sync_package"
+)
+
+// ============================================================================
+// BOUNDED PRODUCER-CONSUMER: THREE STRATEGIES, ONE INTERFACE
+// ============================================================================
+// The bounded-buffer problem:
+// - Producers must BLOCK when the buffer is full
+// - Consumers must BLOCK when the buffer is empty
+// - No item may be lost or delivered twice
+//
+// We solve it three ways:
+// 1. sync.Cond       - mutex + "notFull"/"notEmpty" condition variables
+// 2. Buffered channel - the runtime does all of the above for us
+// 3. Semaphores       - two counting semaphores ("free slots" / "filled
+//                       slots") around a mutex-protected ring buffer
+//                       (Dijkstra's classic solution)
+// ============================================================================
+
+// Buffer is a bounded FIFO shared between producers and consumers.
+type Buffer[T any] interface {
+	Put(item T) // blocks while the buffer is full
+	Get() T     // blocks while the buffer is empty
+}
+
+// strategy names a Buffer implementation and knows how to build one.
+type strategy struct {
+	name     string
+	newQueue func(capacity int) Buffer[item]
+}
+
+// strategies lists every implementation compared by the demo.
+var strategies = []strategy{
+	{"sync.Cond", func(c int) Buffer[item] { return NewCondBuffer[item](c) }},
+	{"channel", func(c int) Buffer[item] { return NewChanBuffer[item](c) }},
+	{"semaphore", func(c int) Buffer[item] { return NewSemaphoreBuffer[item](c) }},
+}
+
+// ============================================================================
+// 1. sync.Cond
+// ============================================================================
+
+// CondBuffer reuses syncpackage.BoundedQueue, which waits on two Conds that
+// share one mutex.
+type CondBuffer[T any] struct {
+	q *syncpackage.BoundedQueue[T]
+}
+
+func NewCondBuffer[T any](capacity int) *CondBuffer[T] {
+	return &CondBuffer[T]{q: syncpackage.NewBoundedQueue[T](capacity)}
+}
+
+func (b *CondBuffer[T]) Put(item T) {
+	_ = b.q.Enqueue(item) // never closed in this comparison
+}
+
+func (b *CondBuffer[T]) Get() T {
+	item, _ := b.q.Dequeue()
+	return item
+}
+
+// ============================================================================
+// 2. BUFFERED CHANNEL
+// ============================================================================
+
+// ChanBuffer is the idiomatic Go answer: a buffered channel IS a bounded,
+// blocking, concurrency-safe FIFO.
+type ChanBuffer[T any] struct {
+	ch chan T
+}
+
+func NewChanBuffer[T any](capacity int) *ChanBuffer[T] {
+	return &ChanBuffer[T]{ch: make(chan T, capacity)}
+}
+
+func (b *ChanBuffer[T]) Put(item T) { b.ch <- item }
+
+func (b *ChanBuffer[T]) Get() T { return <-b.ch }
+
+// ============================================================================
+// 3. SEMAPHORES
+// ============================================================================
+
+// semaphore is a counting semaphore. Acquire takes a token (blocking when
+// none are left) and Release returns one. A buffered channel of empty
+// structs is the usual way to write one in Go.
+type semaphore chan struct{}
+
+func (s semaphore) Acquire() { <-s }
+func (s semaphore) Release() { s <- struct{}{} }
+
+// SemaphoreBuffer is the textbook solution:
+//
+//	Put: P(free);  lock; write; unlock;  V(filled)
+//	Get: P(filled); lock; read;  unlock;  V(free)
+//
+// The semaphores do the blocking; the mutex only protects the ring indices.
+type SemaphoreBuffer[T any] struct {
+	free   semaphore // tokens = empty slots
+	filled semaphore // tokens = items ready to read
+
+	mu         sync.Mutex
+	ring       []T
+	head, tail int
+}
+
+func NewSemaphoreBuffer[T any](capacity int) *SemaphoreBuffer[T] {
+	b := &SemaphoreBuffer[T]{
+		free:   make(semaphore, capacity),
+		filled: make(semaphore, capacity),
+		ring:   make([]T, capacity),
+	}
+	for range capacity {
+		b.free.Release() // every slot starts out free
+	}
+	return b
+}
+
+func (b *SemaphoreBuffer[T]) Put(item T) {
+	b.free.Acquire() // wait for an empty slot
+
+	b.mu.Lock()
+	b.ring[b.tail] = item
+	b.tail = (b.tail + 1) % len(b.ring)
+	b.mu.Unlock()
+
+	b.filled.Release() // announce a new item
+}
+
+func (b *SemaphoreBuffer[T]) Get() T {
+	b.filled.Acquire() // wait for an item
+
+	b.mu.Lock()
+	item := b.ring[b.head]
+	var zero T
+	b.ring[b.head] = zero
+	b.head = (b.head + 1) % len(b.ring)
+	b.mu.Unlock()
+
+	b.free.Release() // announce a free slot
+	return item
+}
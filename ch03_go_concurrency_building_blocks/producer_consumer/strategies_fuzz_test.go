@@ -0,0 +1,96 @@
+package producerconsumer
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func FuzzCondBuffer(f *testing.F) {
+	fuzzBuffer(f, func(c int) Buffer[fuzzItem] { return NewCondBuffer[fuzzItem](c) })
+}
+
+func FuzzChanBuffer(f *testing.F) {
+	fuzzBuffer(f, func(c int) Buffer[fuzzItem] { return NewChanBuffer[fuzzItem](c) })
+}
+
+func FuzzSemaphoreBuffer(f *testing.F) {
+	fuzzBuffer(f, func(c int) Buffer[fuzzItem] { return NewSemaphoreBuffer[fuzzItem](c) })
+}
+
+// fuzzItem is one queued item: who produced it and its position in that
+// producer's sequence.
+type fuzzItem struct{ producer, seq int }
+
+// fuzzBuffer draws a schedule from the fuzz arguments - producers,
+// consumers, capacity, items per producer, and per operation whether the
+// goroutine yields afterwards - and checks that every item is delivered
+// exactly once and that one producer's items reach any one consumer in the
+// order they were put. A Buffer has no Close, so consumers claim exactly as
+// many Gets as there are items.
+func fuzzBuffer(f *testing.F, newBuffer func(capacity int) Buffer[fuzzItem]) {
+	f.Add(uint8(8), uint8(4), uint8(1), uint8(100), []byte{0})
+	f.Add(uint8(1), uint8(8), uint8(3), uint8(50), []byte{1, 0, 0})
+	f.Add(uint8(4), uint8(1), uint8(2), uint8(60), []byte{1, 1, 0, 1})
+	f.Add(uint8(3), uint8(3), uint8(8), uint8(20), []byte{1})
+	f.Fuzz(func(t *testing.T, producers, consumers, capacity, items uint8, schedule []byte) {
+		var (
+			np, nc = 1 + int(producers%8), 1 + int(consumers%8)
+			per    = 1 + int(items%200)
+			b      = newBuffer(1 + int(capacity%8))
+		)
+		maybeYield := func(stream, s int) {
+			if len(schedule) > 0 && schedule[(stream*per+s)%len(schedule)]&1 != 0 {
+				runtime.Gosched()
+			}
+		}
+
+		var remaining atomic.Int64
+		remaining.Store(int64(np * per))
+		received := make([][]fuzzItem, nc)
+		var wg sync.WaitGroup
+		for c := range nc {
+			wg.Go(func() {
+				for s := 0; remaining.Add(-1) >= 0; s++ {
+					received[c] = append(received[c], b.Get())
+					maybeYield(np+c, s)
+				}
+			})
+		}
+		for p := range np {
+			wg.Go(func() {
+				for s := range per {
+					b.Put(fuzzItem{p, s})
+					maybeYield(p, s)
+				}
+			})
+		}
+		wg.Wait()
+
+		delivered := make([][]int, np)
+		for p := range delivered {
+			delivered[p] = make([]int, per)
+		}
+		for c, got := range received {
+			last := make(map[int]int)
+			for _, it := range got {
+				if it.producer < 0 || it.producer >= np || it.seq < 0 || it.seq >= per {
+					t.Fatalf("consumer %d received %+v, which nobody produced", c, it)
+				}
+				if prev, ok := last[it.producer]; ok && it.seq <= prev {
+					t.Fatalf("consumer %d got producer %d's item %d after item %d", c, it.producer, it.seq, prev)
+				}
+				last[it.producer] = it.seq
+				delivered[it.producer][it.seq]++
+			}
+		}
+		for p := range delivered {
+			for s, n := range delivered[p] {
+				if n != 1 {
+					t.Fatalf("producer %d's item %d delivered %d times, want once", p, s, n)
+				}
+			}
+		}
+	})
+}
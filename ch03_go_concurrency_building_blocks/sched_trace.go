@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/schedtrace"
+)
+
+// ============================================================================
+// 8b. WATCHING THE SCHEDULER WORK (GODEBUG=schedtrace)
+// ============================================================================
+// schedulerDemo() prints GOMAXPROCS and NumGoroutine, which says nothing
+// about what the scheduler is DOING. The runtime will tell us, though: with
+// GODEBUG=schedtrace=50 it prints its state every 50ms. We re-run this very
+// binary as a child process with that setting (see child.go), have it run
+// schedWorkload instead of the demos, and chart what it reports.
+//
+// The workload has three phases:
+// 1. CPU storm  - 200 goroutines spin on the CPU. Only GOMAXPROCS of them can
+//                 run, the rest pile up in the run queues.
+// 2. Pinned     - 20 goroutines call runtime.LockOSThread and sleep. A locked
+//                 goroutine owns its thread, so the thread count climbs.
+// 3. Idle       - nothing to do: run queues empty, Ps idle, the extra threads
+//                 stay around parked for reuse.
+// ============================================================================
+
+func init() { childWorkloads["schedtrace"] = schedWorkload }
+
+// schedWorkload is what the child process runs while being traced.
+func schedWorkload() {
+	phase := func(d time.Duration, n int, body func(stop <-chan struct{})) {
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for range n {
+			wg.Go(func() { body(stop) })
+		}
+		time.Sleep(d)
+		close(stop)
+		wg.Wait()
+	}
+
+	// 1. CPU storm
+	phase(300*time.Millisecond, 200, func(stop <-chan struct{}) {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				for i := 0; i < 10_000; i++ { // burn CPU between checks
+					_ = i * i
+				}
+			}
+		}
+	})
+
+	// 2. Pinned goroutines, each holding an OS thread
+	phase(300*time.Millisecond, 20, func(stop <-chan struct{}) {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		<-stop
+	})
+
+	// 3. Idle
+	time.Sleep(200 * time.Millisecond)
+}
+
+// schedTraceExperiment traces schedWorkload in a child process and charts
+// the scheduler state as the samples arrive.
+func schedTraceExperiment() {
+	fmt.Println("\n--- Live scheduler trace of a child process (GODEBUG=schedtrace=50) ---")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cmd, err := childCommand(ctx, "schedtrace")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("%6s  %5s  %7s  %-25s %s\n", "time", "Ps", "threads", "runnable goroutines", "threads")
+	var samples []schedtrace.Sample
+	err = schedtrace.Run(ctx, cmd, 50*time.Millisecond, func(s schedtrace.Sample) {
+		samples = append(samples, s)
+		fmt.Printf("%6v  %2d/%-2d  %7d  %-25s %s\n",
+			s.At, s.GOMAXPROCS-s.IdleProcs, s.GOMAXPROCS, s.Threads,
+			fmt.Sprintf("%4d %s", s.Runnable(), schedtrace.Bar(s.Runnable(), 200, 20)),
+			schedtrace.Bar(s.Threads, 40, 20))
+	}, os.Stderr)
+	if err != nil {
+		fmt.Println("trace failed:", err)
+		return
+	}
+
+	peakRunnable, peakThreads := 0, 0
+	for _, s := range samples {
+		peakRunnable = max(peakRunnable, s.Runnable())
+		peakThreads = max(peakThreads, s.Threads)
+	}
+	fmt.Printf("\n%d samples: peak %d runnable goroutines waiting for %d P(s), peak %d OS threads\n",
+		len(samples), peakRunnable, samples[0].GOMAXPROCS, peakThreads)
+	fmt.Println("→ Runnable goroutines queue for Ps; blocked/locked goroutines cost THREADS")
+}
@@ -0,0 +1,206 @@
+package spinlocks
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/mcslock"
+	"github.com/mintecr7/concurrency-with-go/pkg/ticketlock"
+)
+
+// ============================================================================
+// QUEUED SPINLOCKS AND CACHE-LINE BOUNCING
+// ============================================================================
+// CPUs don't read single bytes from RAM - they move 64-byte "cache lines"
+// between caches. To write a line, a core must own it EXCLUSIVELY, which
+// invalidates every other core's copy (the MESI coherence protocol).
+//
+// Now picture 16 cores spinning on ONE lock word:
+//
+//   core 1: Load(lock)  ─┐
+//   core 2: Load(lock)   ├─ all share the line, spinning happily
+//   core 3: Load(lock)  ─┘
+//   holder: Store(lock = 0)  → invalidates ALL 15 copies
+//   15 cores miss at once, re-fetch the line, race to CAS it,
+//   14 CASes fail (each failed CAS ALSO takes the line exclusively)...
+//
+// The line "bounces" between cores; a single release costs O(waiters)
+// coherence traffic. This is why naive spinlocks fall off a cliff as core
+// counts grow.
+//
+// THE LOCKS COMPARED:
+// 1. Test-and-set spinlock - everyone CASes the same word (worst case)
+// 2. Ticket lock           - FIFO, but everyone still READS the same
+//                            "now serving" word, so every release
+//                            invalidates every waiter
+// 3. MCS lock              - FIFO, each waiter spins on ITS OWN node;
+//                            a release touches exactly one waiter's line
+// 4. sync.Mutex            - spins briefly, then PARKS waiters in the
+//                            runtime; sleeping goroutines don't bounce
+//                            anything
+// ============================================================================
+
+// tasLock is the simplest possible spinlock: CompareAndSwap until it works.
+type tasLock struct {
+	state atomic.Int32
+}
+
+func (l *tasLock) Lock() {
+	for !l.state.CompareAndSwap(0, 1) {
+		runtime.Gosched()
+	}
+}
+
+func (l *tasLock) Unlock() {
+	l.state.Store(0)
+}
+
+// lockUnderTest pairs a name with a constructor for a fresh lock.
+type lockUnderTest struct {
+	name    string
+	newLock func() sync.Locker
+}
+
+var locksUnderTest = []lockUnderTest{
+	{"sync.Mutex", func() sync.Locker { return &sync.Mutex{} }},
+	{"TAS spinlock", func() sync.Locker { return &tasLock{} }},
+	{"ticket lock", func() sync.Locker { return &ticketlock.Mutex{} }},
+	{"MCS lock", func() sync.Locker { return &mcslock.Mutex{} }},
+}
+
+// hammer has `goroutines` goroutines each take the lock `ops` times to
+// increment a shared counter. It returns the average time per lock/unlock
+// pair and whether the final count was correct.
+func hammer(l sync.Locker, goroutines, ops int) (time.Duration, bool) {
+	var counter int
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for range goroutines {
+		wg.Go(func() {
+			for range ops {
+				l.Lock()
+				counter++ // tiny critical section: the lock IS the bottleneck
+				l.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	return elapsed / time.Duration(goroutines*ops), counter == goroutines*ops
+}
+
+// ============================================================================
+// 1. CORRECTNESS
+// ============================================================================
+
+func correctness() {
+	fmt.Println("\n=== Correctness: 64 goroutines × 2,000 increments ===")
+
+	for _, l := range locksUnderTest {
+		_, ok := hammer(l.newLock(), 64, 2000)
+		verdict := "✓ no lost updates"
+		if !ok {
+			verdict = "✗ LOST UPDATES"
+		}
+		fmt.Printf("  %-13s %s\n", l.name, verdict)
+	}
+}
+
+// ============================================================================
+// 2. SCALING WITH CORE COUNT
+// ============================================================================
+
+func scaling() {
+	fmt.Println("\n=== Cost per Lock/Unlock as Cores Increase ===")
+	fmt.Println("(one goroutine per P, 20,000 lock/unlock pairs each)")
+
+	var procs []int
+	for p := 1; p < runtime.NumCPU(); p *= 2 {
+		procs = append(procs, p)
+	}
+	procs = append(procs, runtime.NumCPU())
+
+	previous := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(previous)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "GOMAXPROCS")
+	for _, l := range locksUnderTest {
+		fmt.Fprintf(tw, "\t%s", l.name)
+	}
+	fmt.Fprintln(tw)
+
+	for _, p := range procs {
+		runtime.GOMAXPROCS(p)
+		fmt.Fprintf(tw, "%d", p)
+		for _, l := range locksUnderTest {
+			perOp, _ := hammer(l.newLock(), p, 20000)
+			fmt.Fprintf(tw, "\t%v", perOp)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	if runtime.NumCPU() < 8 {
+		fmt.Printf("\nOnly %d CPU(s) here - the interesting part starts at 8+ cores.\n", runtime.NumCPU())
+	}
+	fmt.Println("\nWhat to look for on a many-core machine:")
+	fmt.Println("  • TAS spinlock degrades fastest: every waiter CASes the same line")
+	fmt.Println("  • Ticket lock is fair but still degrades: every waiter READS one line")
+	fmt.Println("  • MCS stays flatter: each hand-off touches one waiter's line")
+	fmt.Println("  • sync.Mutex parks waiters, so it rarely loses badly - and it")
+	fmt.Println("    doesn't burn CPU while waiting. It is the right default.")
+}
+
+// ============================================================================
+// 3. OVERSUBSCRIPTION: MORE GOROUTINES THAN CORES
+// ============================================================================
+
+func oversubscription() {
+	fmt.Println("\n=== Oversubscription: 8 goroutines per P ===")
+
+	p := runtime.GOMAXPROCS(0)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "Lock\tper op\n")
+	for _, l := range locksUnderTest {
+		perOp, _ := hammer(l.newLock(), 8*p, 5000)
+		fmt.Fprintf(tw, "%s\t%v\n", l.name, perOp)
+	}
+	tw.Flush()
+
+	fmt.Println("\nFIFO spinlocks suffer most here: the lock must go to ONE specific")
+	fmt.Println("goroutine, which may not even be running. Everyone else spins while")
+	fmt.Println("the scheduler gets around to it (the \"lock-holder preemption\" problem).")
+}
+
+// ============================================================================
+// MAIN FUNCTION - RUN ALL EXAMPLES
+// ============================================================================
+
+func QueuedLocksDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║        QUEUED SPINLOCKS & CACHE-LINE BOUNCING              ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	correctness()
+	scaling()
+	oversubscription()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Shared spin words bounce cache lines between cores       ║")
+	fmt.Println("║ • MCS: each waiter spins on its own padded node            ║")
+	fmt.Println("║ • FIFO fairness costs throughput when oversubscribed       ║")
+	fmt.Println("║ • In Go, sync.Mutex (spin briefly, then park) wins almost  ║")
+	fmt.Println("║   always - queued locks are for learning how locks work    ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
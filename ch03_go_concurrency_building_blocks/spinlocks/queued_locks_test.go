@@ -0,0 +1,33 @@
+package spinlocks
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/mcslock"
+	"github.com/mintecr7/concurrency-with-go/pkg/ticketlock"
+)
+
+// The benchmarks run one goroutine per P, each taking the lock around a
+// one-line critical section, so ns/op is the cost of a lock/unlock pair
+// under full contention. Compare them across core counts with
+//
+//	go test -bench . -cpu 1,2,4,8,16,32 ./ch03_go_concurrency_building_blocks/spinlocks
+func benchmarkLock(b *testing.B, l sync.Locker) {
+	var counter int
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Lock()
+			counter++
+			l.Unlock()
+		}
+	})
+	if counter != b.N {
+		b.Fatalf("counter = %d after %d lock/unlock pairs", counter, b.N)
+	}
+}
+
+func BenchmarkMCSLock(b *testing.B)    { benchmarkLock(b, &mcslock.Mutex{}) }
+func BenchmarkTicketLock(b *testing.B) { benchmarkLock(b, &ticketlock.Mutex{}) }
+func BenchmarkTASLock(b *testing.B)    { benchmarkLock(b, &tasLock{}) }
+func BenchmarkMutex(b *testing.B)      { benchmarkLock(b, &sync.Mutex{}) }
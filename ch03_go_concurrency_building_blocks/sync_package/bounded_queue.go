@@ -0,0 +1,222 @@
+package syncpackage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/chaos"
+)
+
+// ============================================================================
+// BoundedQueue - PRODUCER/CONSUMER QUEUE BUILT ON sync.Cond
+// ============================================================================
+// queueExample() in cond.go shows the idea inline. BoundedQueue packages it
+// as a reusable type:
+// - Enqueue blocks while the queue is FULL
+// - Dequeue blocks while the queue is EMPTY
+// - Close wakes everyone up: producers get ErrQueueClosed, consumers drain
+//   whatever is left and then see ok == false
+//
+// Two Conds share ONE mutex. With a single Cond, Signal() from a consumer
+// might wake another consumer instead of the blocked producer (lost wakeup);
+// separate "notFull" / "notEmpty" conditions make Signal() always wake a
+// goroutine that can actually make progress.
+// ============================================================================
+
+// ErrQueueClosed is returned by Enqueue once the queue has been closed.
+var ErrQueueClosed = errors.New("bounded queue: closed")
+
+// BoundedQueue is a FIFO queue with a fixed capacity that is safe for
+// concurrent use by many producers and consumers.
+type BoundedQueue[T any] struct {
+	mu       sync.Mutex
+	notFull  *sync.Cond // signalled when an item is removed
+	notEmpty *sync.Cond // signalled when an item is added
+	items    []T
+	capacity int
+	closed   bool
+}
+
+// NewBoundedQueue creates a queue that holds at most capacity items.
+func NewBoundedQueue[T any](capacity int) *BoundedQueue[T] {
+	if capacity < 1 {
+		panic("bounded queue: capacity must be at least 1")
+	}
+	q := &BoundedQueue[T]{
+		items:    make([]T, 0, capacity),
+		capacity: capacity,
+	}
+	q.notFull = sync.NewCond(&q.mu)
+	q.notEmpty = sync.NewCond(&q.mu)
+	return q
+}
+
+// Enqueue adds item to the back of the queue, blocking while it is full.
+// It returns ErrQueueClosed if the queue is (or becomes) closed.
+func (q *BoundedQueue[T]) Enqueue(item T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == q.capacity && !q.closed { // ALWAYS wait in a loop
+		q.notFull.Wait()
+	}
+	chaos.Point("boundedqueue.enqueue.woken")
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	q.items = append(q.items, item)
+	chaos.Point("boundedqueue.enqueue.signal")
+	q.notEmpty.Signal() // one new item → wake one consumer
+	return nil
+}
+
+// TryEnqueue adds item only if there is room right now. It never blocks and
+// reports whether the item was added (false if the queue is full or closed).
+func (q *BoundedQueue[T]) TryEnqueue(item T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || len(q.items) == q.capacity {
+		return false
+	}
+	q.items = append(q.items, item)
+	q.notEmpty.Signal()
+	return true
+}
+
+// Dequeue removes and returns the item at the front of the queue, blocking
+// while it is empty. After Close, it keeps returning buffered items until
+// the queue is drained, then returns the zero value and false.
+func (q *BoundedQueue[T]) Dequeue() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == 0 && !q.closed {
+		q.notEmpty.Wait()
+	}
+	chaos.Point("boundedqueue.dequeue.woken")
+
+	var zero T
+	if len(q.items) == 0 { // closed and drained
+		return zero, false
+	}
+
+	item := q.items[0]
+	q.items[0] = zero // don't keep a reference alive in the backing array
+	q.items = q.items[1:]
+	chaos.Point("boundedqueue.dequeue.signal")
+	q.notFull.Signal() // one free slot → wake one producer
+	return item, true
+}
+
+// Close marks the queue as closed and wakes every blocked producer and
+// consumer. Calling Close more than once is a no-op.
+func (q *BoundedQueue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.notFull.Broadcast() // every waiter must re-check the condition
+	chaos.Point("boundedqueue.close.between")
+	q.notEmpty.Broadcast()
+}
+
+// Len returns the number of items currently buffered.
+func (q *BoundedQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Cap returns the maximum number of items the queue can hold.
+func (q *BoundedQueue[T]) Cap() int {
+	return q.capacity
+}
+
+// ============================================================================
+// BOUNDED QUEUE UNDER LOAD: MANY PRODUCERS, MANY CONSUMERS
+// ============================================================================
+
+func boundedQueueStress() {
+	fmt.Println("\n=== BoundedQueue: Many Producers, Many Consumers ===")
+
+	const (
+		producers        = 8
+		consumers        = 4
+		itemsPerProducer = 10000
+		capacity         = 16
+	)
+
+	type item struct{ producer, seq int }
+	q := NewBoundedQueue[item](capacity)
+
+	// Producers
+	var producing sync.WaitGroup
+	for p := range producers {
+		producing.Go(func() {
+			for seq := range itemsPerProducer {
+				if err := q.Enqueue(item{producer: p, seq: seq}); err != nil {
+					fmt.Printf("  producer %d: %v\n", p, err)
+					return
+				}
+			}
+		})
+	}
+
+	// Consumers record what they saw. Each consumer keeps its own slice, so
+	// there is no shared state to lock.
+	seen := make([][]item, consumers)
+	var consuming sync.WaitGroup
+	for c := range consumers {
+		consuming.Go(func() {
+			for {
+				it, ok := q.Dequeue()
+				if !ok {
+					return // closed and drained
+				}
+				seen[c] = append(seen[c], it)
+			}
+		})
+	}
+
+	producing.Wait()
+	q.Close() // no more items: let consumers drain and exit
+	consuming.Wait()
+
+	// Verify: every item delivered exactly once, and each consumer saw every
+	// producer's items in the order they were produced (FIFO).
+	delivered := make(map[item]int)
+	inOrder := true
+	for _, items := range seen {
+		last := make(map[int]int)
+		for _, it := range items {
+			delivered[it]++
+			if prev, ok := last[it.producer]; ok && it.seq <= prev {
+				inOrder = false
+			}
+			last[it.producer] = it.seq
+		}
+	}
+
+	duplicates := 0
+	for _, n := range delivered {
+		if n > 1 {
+			duplicates++
+		}
+	}
+
+	fmt.Printf("Produced:   %d items (%d producers × %d)\n", producers*itemsPerProducer, producers, itemsPerProducer)
+	fmt.Printf("Consumed:   %d unique items by %d consumers\n", len(delivered), consumers)
+	fmt.Printf("Duplicates: %d\n", duplicates)
+	fmt.Printf("Per-producer FIFO order preserved: %v\n", inOrder)
+	fmt.Printf("Queue length after close: %d\n", q.Len())
+
+	if err := q.Enqueue(item{}); errors.Is(err, ErrQueueClosed) {
+		fmt.Println("Enqueue after Close:", err)
+	}
+}
@@ -0,0 +1,135 @@
+package syncpackage
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// FuzzBoundedQueue draws a producer/consumer schedule from its arguments -
+// how many of each, the capacity, when Close lands, and per operation
+// whether a producer uses TryEnqueue and whether anyone yields - and checks
+// what must hold for any schedule:
+//
+//   - conservation: every accepted item is delivered exactly once, and no
+//     rejected one is delivered
+//   - per-producer FIFO: one producer's items reach any one consumer in the
+//     order they were produced
+//   - the queue never holds more than its capacity
+func FuzzBoundedQueue(f *testing.F) {
+	f.Add(uint8(8), uint8(4), uint8(1), uint8(100), uint16(0), []byte{0})
+	f.Add(uint8(1), uint8(8), uint8(3), uint8(50), uint16(0), []byte{2, 0, 0})
+	f.Add(uint8(4), uint8(1), uint8(2), uint8(60), uint16(30), []byte{1, 3, 0, 2})
+	f.Add(uint8(3), uint8(3), uint8(8), uint8(20), uint16(1), []byte{3})
+	f.Fuzz(func(t *testing.T, producers, consumers, capacity, items uint8, closeAfter uint16, schedule []byte) {
+		var (
+			np, nc = 1 + int(producers%8), 1 + int(consumers%8)
+			per    = 1 + int(items%200)
+			q      = NewBoundedQueue[fuzzItem](1 + int(capacity%8))
+		)
+		// op describes producer p's s-th put: bit 0 uses TryEnqueue, bit 1
+		// yields afterwards. Consumers yield on bit 1 of their own stream.
+		op := func(stream, s int) byte {
+			if len(schedule) == 0 {
+				return 0
+			}
+			return schedule[(stream*per+s)%len(schedule)]
+		}
+
+		// closeAfter 0 closes once the producers are done; n > 0 closes
+		// after n-1 accepted items, maybe in the middle of production.
+		var closeOnce sync.Once
+		closeQueue := func() { closeOnce.Do(q.Close) }
+		if closeAfter == 1 {
+			closeQueue()
+		}
+
+		accepted := make([][]bool, np)
+		received := make([][]fuzzItem, nc)
+		var acceptedTotal atomic.Int64
+		var producing, consuming sync.WaitGroup
+		for c := range nc {
+			consuming.Go(func() {
+				for s := 0; ; s++ {
+					it, ok := q.Dequeue()
+					if !ok {
+						return
+					}
+					received[c] = append(received[c], it)
+					if op(np+c, s)&2 != 0 {
+						runtime.Gosched()
+					}
+				}
+			})
+		}
+		for p := range np {
+			accepted[p] = make([]bool, per)
+			producing.Go(func() {
+				for s := range per {
+					var ok bool
+					if op(p, s)&1 != 0 {
+						ok = q.TryEnqueue(fuzzItem{p, s})
+					} else {
+						ok = q.Enqueue(fuzzItem{p, s}) == nil
+					}
+					if n := q.Len(); n > q.Cap() {
+						t.Errorf("Len() = %d, more than the capacity %d", n, q.Cap())
+					}
+					if ok {
+						accepted[p][s] = true
+						if acceptedTotal.Add(1) == int64(closeAfter)-1 {
+							closeQueue()
+						}
+					}
+					if op(p, s)&2 != 0 {
+						runtime.Gosched()
+					}
+				}
+			})
+		}
+		producing.Wait()
+		closeQueue()
+		consuming.Wait()
+		checkDelivery(t, accepted, received)
+	})
+}
+
+// fuzzItem is one queued item: who produced it and its position in that
+// producer's sequence.
+type fuzzItem struct{ producer, seq int }
+
+// checkDelivery checks conservation and per-producer FIFO: accepted is
+// indexed [producer][seq], received [consumer] in arrival order.
+func checkDelivery(t *testing.T, accepted [][]bool, received [][]fuzzItem) {
+	t.Helper()
+	delivered := make([][]int, len(accepted))
+	for p := range delivered {
+		delivered[p] = make([]int, len(accepted[p]))
+	}
+	for c, items := range received {
+		last := make(map[int]int)
+		for _, it := range items {
+			if it.producer < 0 || it.producer >= len(accepted) || it.seq < 0 || it.seq >= len(accepted[it.producer]) {
+				t.Fatalf("consumer %d received %+v, which nobody produced", c, it)
+			}
+			if prev, ok := last[it.producer]; ok && it.seq <= prev {
+				t.Fatalf("consumer %d got producer %d's item %d after item %d", c, it.producer, it.seq, prev)
+			}
+			last[it.producer] = it.seq
+			delivered[it.producer][it.seq]++
+		}
+	}
+	for p := range accepted {
+		for s, n := range delivered[p] {
+			switch {
+			case accepted[p][s] && n == 0:
+				t.Fatalf("producer %d's item %d was accepted but never delivered", p, s)
+			case !accepted[p][s] && n > 0:
+				t.Fatalf("producer %d's item %d was rejected but delivered", p, s)
+			case n > 1:
+				t.Fatalf("producer %d's item %d delivered %d times", p, s, n)
+			}
+		}
+	}
+}
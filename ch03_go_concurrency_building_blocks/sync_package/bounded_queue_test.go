@@ -0,0 +1,149 @@
+package syncpackage
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/chaos"
+)
+
+func TestBoundedQueueManyProducersConsumers(t *testing.T) {
+	const (
+		producers = 8
+		consumers = 4
+		capacity  = 4
+	)
+	itemsPerProducer := 5000
+	if chaos.Enabled {
+		itemsPerProducer = 500 // the chaos points sleep; fewer items, same shapes
+	}
+	type item struct{ producer, seq int }
+	q := NewBoundedQueue[item](capacity)
+
+	var producing sync.WaitGroup
+	for p := range producers {
+		producing.Go(func() {
+			for seq := range itemsPerProducer {
+				if err := q.Enqueue(item{p, seq}); err != nil {
+					t.Errorf("producer %d: Enqueue: %v", p, err)
+					return
+				}
+			}
+		})
+	}
+	seen := make([][]item, consumers)
+	var consuming sync.WaitGroup
+	for c := range consumers {
+		consuming.Go(func() {
+			for {
+				it, ok := q.Dequeue()
+				if !ok {
+					return
+				}
+				if n := q.Len(); n > capacity {
+					t.Errorf("Len() = %d, more than the capacity %d", n, capacity)
+				}
+				seen[c] = append(seen[c], it)
+			}
+		})
+	}
+	producing.Wait()
+	q.Close()
+	consuming.Wait()
+
+	delivered := make(map[item]int)
+	for c, items := range seen {
+		last := make(map[int]int)
+		for _, it := range items {
+			delivered[it]++
+			if prev, ok := last[it.producer]; ok && it.seq <= prev {
+				t.Errorf("consumer %d got producer %d's item %d after item %d", c, it.producer, it.seq, prev)
+			}
+			last[it.producer] = it.seq
+		}
+	}
+	if len(delivered) != producers*itemsPerProducer {
+		t.Errorf("%d distinct items delivered, want %d", len(delivered), producers*itemsPerProducer)
+	}
+	for it, n := range delivered {
+		if n != 1 {
+			t.Errorf("item %+v delivered %d times", it, n)
+		}
+	}
+}
+
+func TestBoundedQueueCloseDrains(t *testing.T) {
+	q := NewBoundedQueue[int](3)
+	for i := range 3 {
+		if err := q.Enqueue(i); err != nil {
+			t.Fatalf("Enqueue(%d): %v", i, err)
+		}
+	}
+	q.Close()
+	q.Close() // a no-op
+
+	if err := q.Enqueue(3); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Enqueue after Close = %v, want ErrQueueClosed", err)
+	}
+	if q.TryEnqueue(3) {
+		t.Error("TryEnqueue after Close added the item")
+	}
+	for want := range 3 {
+		if got, ok := q.Dequeue(); !ok || got != want {
+			t.Fatalf("Dequeue = %d, %v; want %d, true: buffered items outlive Close", got, ok, want)
+		}
+	}
+	if got, ok := q.Dequeue(); ok {
+		t.Errorf("Dequeue on a closed, drained queue = %d, true; want false", got)
+	}
+}
+
+// Close must wake producers waiting for room and consumers waiting for an
+// item, or they block forever. Whether a goroutine is already waiting when
+// Close is called is up to the scheduler; either way it must return.
+func TestBoundedQueueCloseWakesWaiters(t *testing.T) {
+	full := NewBoundedQueue[int](1)
+	full.Enqueue(0)
+	empty := NewBoundedQueue[int](1)
+
+	const waiters = 4
+	errs := make(chan error, waiters)
+	oks := make(chan bool, waiters)
+	for range waiters {
+		go func() { errs <- full.Enqueue(1) }()
+		go func() {
+			_, ok := empty.Dequeue()
+			oks <- ok
+		}()
+	}
+	full.Close()
+	empty.Close()
+	for range waiters {
+		if err := <-errs; !errors.Is(err, ErrQueueClosed) {
+			t.Errorf("blocked Enqueue returned %v, want ErrQueueClosed", err)
+		}
+		if <-oks {
+			t.Error("blocked Dequeue on an empty queue returned ok after Close")
+		}
+	}
+}
+
+func TestBoundedQueueTryEnqueue(t *testing.T) {
+	q := NewBoundedQueue[string](2)
+	if !q.TryEnqueue("a") || !q.TryEnqueue("b") {
+		t.Fatal("TryEnqueue failed with room in the queue")
+	}
+	if q.TryEnqueue("c") {
+		t.Error("TryEnqueue added to a full queue")
+	}
+	if got, _ := q.Dequeue(); got != "a" {
+		t.Errorf("Dequeue = %q, want %q", got, "a")
+	}
+	if !q.TryEnqueue("c") {
+		t.Error("TryEnqueue failed after a Dequeue made room")
+	}
+	if q.Len() != 2 || q.Cap() != 2 {
+		t.Errorf("Len, Cap = %d, %d; want 2, 2", q.Len(), q.Cap())
+	}
+}
@@ -0,0 +1,135 @@
+package syncpackage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+)
+
+// ============================================================================
+// BROADCAST WITHOUT sync.Cond: conc.Broadcast[T]
+// ============================================================================
+// buttonExample() and multipleBroadcasts() need a Cond because a channel
+// can only be closed once. conc.Broadcast re-arms a fresh channel after
+// every Notify, so the same "wake every handler" works with channels - and
+// handlers can now:
+// - receive a VALUE with each broadcast (which click was it?)
+// - give up on a timeout or ctx.Done() inside a select
+// ============================================================================
+
+func channelBroadcast() {
+	fmt.Println("\n=== Broadcast with Channels: conc.Broadcast[T] ===")
+
+	var clicks conc.Broadcast[int]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Every handler takes its Waiter BEFORE announcing it is ready, so no
+	// click can slip through between "ready" and "waiting".
+	var ready, done sync.WaitGroup
+	handler := func(name string, patience time.Duration) {
+		ready.Add(1)
+		done.Go(func() {
+			w := clicks.Wait()
+			ready.Done()
+			for {
+				select {
+				case <-w.Done():
+					fmt.Printf("  %-9s got click #%d\n", name, w.Value())
+					w = clicks.Wait() // re-arm for the next click
+				case <-time.After(patience):
+					fmt.Printf("  %-9s no click for %v, giving up\n", name, patience)
+					return
+				case <-ctx.Done():
+					fmt.Printf("  %-9s shutting down\n", name)
+					return
+				}
+			}
+		})
+	}
+
+	handler("maximize", time.Hour)
+	handler("dialog", time.Hour)
+	handler("impatient", 150*time.Millisecond) // a timeout: impossible with Cond.Wait
+	ready.Wait()
+
+	for click := 1; click <= 3; click++ {
+		fmt.Printf("\nClick #%d:\n", click)
+		clicks.Notify(click)
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	time.Sleep(100 * time.Millisecond) // long enough for "impatient" to give up
+	fmt.Println("\nCancelling the context:")
+	cancel()
+	done.Wait()
+
+	// A late subscriber waits for the NEXT notify, just like Cond.Wait.
+	late, cancelLate := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelLate()
+	_, err := clicks.WaitContext(late)
+	fmt.Println("\nLate subscriber (no more clicks):", err)
+}
+
+// ============================================================================
+// EVERY ITEM TO EVERY SUBSCRIBER: conc.Hub[T] AND SLOW CONSUMERS
+// ============================================================================
+// Broadcast is lossy by design: a handler busy with click #1 misses #2. A
+// chat room can't lose messages, so conc.Hub queues them per member - and
+// then a member who reads slowly falls further and further behind while
+// the others are fine. The Hub reports it before anything is dropped.
+// ============================================================================
+
+func slowSubscribers() {
+	fmt.Println("\n=== Slow Consumers: conc.Hub[T] Lag Tracking ===")
+
+	var reports []conc.Lag
+	var reportsMu sync.Mutex
+	room := conc.NewHub[string](conc.LagLimits{Pending: 10, Age: 50 * time.Millisecond}, func(l conc.Lag) {
+		reportsMu.Lock()
+		reports = append(reports, l)
+		reportsMu.Unlock()
+		fmt.Printf("  ⚠ %s is lagging: %d pending, oldest waited %v\n", l.Subscriber, l.Pending, l.Oldest.Round(time.Millisecond))
+	})
+
+	members := []struct {
+		name    string
+		perRead time.Duration
+	}{{"alice", 0}, {"bob", time.Millisecond}, {"mallory", 8 * time.Millisecond}}
+	received := make([]int, len(members))
+	var done sync.WaitGroup
+	for i, m := range members {
+		sub := room.Subscribe(m.name)
+		done.Go(func() {
+			for range sub.C() {
+				received[i]++
+				time.Sleep(m.perRead) // rendering, a slow network...
+			}
+		})
+	}
+
+	const messages = 60
+	for i := range messages {
+		room.Publish(fmt.Sprintf("message %d", i))
+		time.Sleep(2 * time.Millisecond)
+	}
+	fmt.Println("\nBacklog right after the last message:")
+	for _, l := range room.Lags() {
+		fmt.Printf("  %-8s %3d pending, oldest %5v, %3d delivered\n",
+			l.Subscriber, l.Pending, l.Oldest.Round(time.Millisecond), l.Delivered)
+	}
+
+	room.Close()
+	done.Wait()
+	reportsMu.Lock()
+	defer reportsMu.Unlock()
+	check(len(reports) == 1 && reports[0].Subscriber == "mallory",
+		fmt.Sprintf("only mallory was reported, once (%d report(s))", len(reports)))
+	check(received[0] == messages && received[1] == messages && received[2] == messages,
+		fmt.Sprintf("after Close everyone still got all %d messages: nothing dropped yet", messages))
+	fmt.Println("→ the publisher never waited for mallory; the report says who is")
+	fmt.Println("  behind and by how much, so a drop policy can target just them")
+}
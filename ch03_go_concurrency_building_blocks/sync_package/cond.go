@@ -1,9 +1,14 @@
 package syncpackage
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/chaos"
+	"github.com/mintecr7/concurrency-with-go/pkg/stats"
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
 )
 
 // Cond implements a condition variable, a rendezvous point
@@ -131,44 +136,40 @@ func condWaitBehavior() {
 func queueExample() {
 	fmt.Println("\n=== Producer-Consumer Queue Example ===")
 
-	// Create condition with a Mutex
-	c := sync.NewCond(&sync.Mutex{})
-
-	// Queue with fixed capacity of 2
-	queue := make([]interface{}, 0, 10)
-
-	// Consumer: removes items from queue
-	removeFromQueue := func(delay time.Duration) {
-		time.Sleep(delay)
+	// BoundedQueue (bounded_queue.go) wraps the Lock / for-loop Wait /
+	// Signal dance in Enqueue and Dequeue, so callers never touch the Cond.
+	queue := NewBoundedQueue[int](2) // capacity of 2
 
-		c.L.Lock()        // Enter critical section
-		queue = queue[1:] // Dequeue
-		fmt.Println("  [Consumer] Removed from queue")
-		c.L.Unlock() // Exit critical section
-
-		c.Signal() // Wake up a waiting producer
-	}
+	// Consumer: removes items slowly, so the producer has to wait
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for {
+			item, ok := queue.Dequeue() // blocks while the queue is empty
+			if !ok {
+				fmt.Println("  [Consumer] Queue closed and drained, exiting")
+				return
+			}
+			fmt.Printf("  [Consumer] Removed item %d from queue\n", item)
+			time.Sleep(100 * time.Millisecond) // slow consumer
+		}
+	})
 
 	// Producer: adds 10 items to queue
 	fmt.Println("Adding 10 items to queue (max size: 2)...")
 	for i := range 10 {
-		c.L.Lock() // Enter critical section
-
-		// Wait while queue is full
-		for len(queue) == 2 {
+		if queue.Len() == queue.Cap() {
 			fmt.Println("[Producer] Queue full, waiting...")
-			c.Wait() // Suspend until consumer signals
 		}
-
-		fmt.Printf("[Producer] Adding item %d to queue\n", i+1)
-		queue = append(queue, struct{}{})
-		go removeFromQueue(1 * time.Second) // Start consumer
-
-		c.L.Unlock() // Exit critical section
+		if err := queue.Enqueue(i + 1); err != nil { // blocks while the queue is full
+			fmt.Println("[Producer]", err)
+			break
+		}
+		fmt.Printf("[Producer] Added item %d to queue\n", i+1)
 	}
 
 	fmt.Println("All items added to queue!")
-	time.Sleep(2 * time.Second) // Let consumers finish
+	queue.Close() // no more items; the consumer drains what's left and exits
+	wg.Wait()     // no sleep needed: we know exactly when the consumer is done
 }
 
 // ============================================================================
@@ -394,6 +395,8 @@ func whenToUseCond() {
 	fmt.Println("  - Simple 1-to-1 signaling (use channels)")
 	fmt.Println("  - Transferring data (use channels)")
 	fmt.Println("  - Select statement needed (use channels)")
+	fmt.Println("    ...even for broadcast: conc.Broadcast re-arms a closed channel")
+	fmt.Println("    ...or keep the Cond pattern with chancond.Cond, whose Wait is a channel")
 
 	fmt.Println("\nCond vs Channels:")
 	fmt.Println("  Cond:     Signaling without data, broadcast capability")
@@ -406,23 +409,42 @@ func whenToUseCond() {
 
 type WorkerPool struct {
 	cond     *sync.Cond
-	tasks    []string
+	tasks    []pendingTask
 	mu       sync.Mutex
 	shutdown bool
+
+	// ctx is cancelled by Shutdown, so a task in progress stops instead of
+	// holding up the shutdown.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Latency metrics. Histograms are safe for concurrent use on their own,
+	// so workers record into them outside the lock.
+	queueWait  *stats.Histogram // AddTask → a worker picks it up
+	processing *stats.Histogram // time spent running the task
+}
+
+type pendingTask struct {
+	name  string
+	added time.Time
 }
 
 func NewWorkerPool() *WorkerPool {
 	wp := &WorkerPool{
-		tasks: make([]string, 0),
+		tasks:      make([]pendingTask, 0),
+		queueWait:  stats.NewLatencyHistogram(),
+		processing: stats.NewLatencyHistogram(),
 	}
 	wp.cond = sync.NewCond(&wp.mu)
+	wp.ctx, wp.cancel = context.WithCancel(context.Background())
 	return wp
 }
 
 func (wp *WorkerPool) AddTask(task string) {
 	wp.cond.L.Lock()
-	wp.tasks = append(wp.tasks, task)
+	wp.tasks = append(wp.tasks, pendingTask{name: task, added: time.Now()})
 	wp.cond.L.Unlock()
+	chaos.Point("workerpool.addtask.unlocked")
 	wp.cond.Signal() // Wake up one waiting worker
 }
 
@@ -434,6 +456,7 @@ func (wp *WorkerPool) Worker(id int) {
 		for len(wp.tasks) == 0 && !wp.shutdown {
 			wp.cond.Wait()
 		}
+		chaos.Point("workerpool.worker.woken")
 
 		// Check if shutting down
 		if wp.shutdown {
@@ -445,10 +468,29 @@ func (wp *WorkerPool) Worker(id int) {
 		task := wp.tasks[0]
 		wp.tasks = wp.tasks[1:]
 		wp.cond.L.Unlock()
+		wp.queueWait.ObserveDuration(time.Since(task.added))
 
 		// Process task
-		fmt.Printf("  Worker %d: Processing '%s'\n", id, task)
-		time.Sleep(100 * time.Millisecond)
+		start := time.Now()
+		fmt.Printf("  Worker %d: Processing '%s'\n", id, task.name)
+		if timeutil.Sleep(wp.ctx, 100*time.Millisecond) != nil {
+			fmt.Printf("  Worker %d: '%s' interrupted by shutdown\n", id, task.name)
+			return
+		}
+		wp.processing.ObserveDuration(time.Since(start))
+	}
+}
+
+// PrintLatencies summarises the pool's latency histograms.
+func (wp *WorkerPool) PrintLatencies() {
+	for _, m := range []struct {
+		name string
+		h    *stats.Histogram
+	}{{"queue wait", wp.queueWait}, {"processing", wp.processing}} {
+		s := m.h.Snapshot()
+		fmt.Printf("  %-10s n=%d  mean=%v  p50=%v  p99=%v  max=%v\n", m.name, s.Count,
+			s.MeanDuration().Round(time.Microsecond), s.QuantileDuration(0.50).Round(time.Microsecond),
+			s.QuantileDuration(0.99).Round(time.Microsecond), time.Duration(s.Max).Round(time.Microsecond))
 	}
 }
 
@@ -456,6 +498,7 @@ func (wp *WorkerPool) Shutdown() {
 	wp.cond.L.Lock()
 	wp.shutdown = true
 	wp.cond.L.Unlock()
+	wp.cancel()         // Interrupt tasks in progress
 	wp.cond.Broadcast() // Wake all workers to exit
 }
 
@@ -490,6 +533,9 @@ func workerPoolExample() {
 	pool.Shutdown()
 	wg.Wait()
 	fmt.Println("All workers shut down!")
+
+	fmt.Println("\nLatency metrics:")
+	pool.PrintLatencies()
 }
 
 // ============================================================================
@@ -506,9 +552,16 @@ func CondDemo() {
 	// basicCond()
 	// condWaitBehavior()
 	queueExample()
+	// boundedQueueStress()
+	// sleepingBarber()
 	// signalVsBroadcast()
 	// buttonExample()
 	// multipleBroadcasts()
+	// channelBroadcast()
+	// slowSubscribers()
+	// selectableCond()
+	// readinessGate()
+	// autoResetTurnstile()
 	// whyTheLoop()
 	// whenToUseCond()
 	// workerPoolExample()
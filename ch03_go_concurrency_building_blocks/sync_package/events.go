@@ -0,0 +1,150 @@
+package syncpackage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/event"
+)
+
+// ============================================================================
+// EVENTS: A FLAG YOU CAN WAIT ON
+// ============================================================================
+// Cond.Broadcast wakes the goroutines waiting RIGHT NOW; a goroutine that
+// arrives a moment later waits for the next broadcast. Often that is not
+// what you want: "the cache is warm" stays true, so late arrivals should
+// pass straight through. That is a manual-reset event:
+//
+//	Set()    - open the gate for everyone, now and later
+//	Reset()  - close it again (maintenance, reconnecting, ...)
+//	Wait()   - pass if open, otherwise block (or give up when ctx is done)
+// ============================================================================
+
+func readinessGate() {
+	fmt.Println("\n=== ManualReset Event: Readiness Gate ===")
+
+	var ready event.ManualReset
+	start := time.Now()
+	elapsed := func() time.Duration { return time.Since(start).Round(10 * time.Millisecond) }
+
+	handle := func(id int, timeout time.Duration, wg *sync.WaitGroup) {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := ready.Wait(ctx); err != nil {
+				fmt.Printf("  [%v] request %d: gave up (%v)\n", elapsed(), id, err)
+				return
+			}
+			fmt.Printf("  [%v] request %d: served\n", elapsed(), id)
+		})
+	}
+
+	// Requests arriving during startup queue up at the gate...
+	var wg sync.WaitGroup
+	fmt.Println("Starting up, requests 1-3 arrive early:")
+	for id := 1; id <= 3; id++ {
+		handle(id, time.Second, &wg)
+	}
+	time.Sleep(50 * time.Millisecond)
+	ready.Set()
+	wg.Wait()
+
+	// ...and once set, the gate stays open for late arrivals.
+	fmt.Println("Ready; request 4 arrives later and passes straight through:")
+	handle(4, time.Second, &wg)
+	wg.Wait()
+
+	// Reset closes the gate again.
+	fmt.Println("Maintenance (Reset); requests 5 and 6 arrive, 6 is impatient:")
+	ready.Reset()
+	handle(5, time.Second, &wg)
+	handle(6, 20*time.Millisecond, &wg)
+	time.Sleep(60 * time.Millisecond)
+	fmt.Printf("  [%v] maintenance over (Set)\n", elapsed())
+	ready.Set()
+	wg.Wait()
+}
+
+// ============================================================================
+// AUTO-RESET EVENT: ONE Set, ONE WAITER
+// ============================================================================
+// An AutoReset event is a turnstile: each Set lets exactly one waiter
+// through and the gate closes behind it. Compared with Cond.Signal:
+// - a Set while nobody waits is REMEMBERED (Signal would be lost)
+// - waiters are released in FIFO order, so under a crowd nobody starves
+// ============================================================================
+
+func autoResetTurnstile() {
+	fmt.Println("\n=== AutoReset Event: One Set, One Waiter ===")
+
+	var turnstile event.AutoReset
+
+	// 1. Exactly one waiter per Set.
+	const crowd = 10
+	passed := make(chan int, crowd)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for id := range crowd {
+		wg.Go(func() {
+			if turnstile.Wait(ctx) == nil {
+				passed <- id
+			}
+		})
+	}
+	time.Sleep(50 * time.Millisecond) // let the crowd line up
+	turnstile.Set()
+	time.Sleep(50 * time.Millisecond)
+	fmt.Printf("1 Set with %d waiters: %d released (expected 1)\n", crowd, len(passed))
+	cancel() // send the rest home
+	wg.Wait()
+
+	// 2. A Set with nobody waiting is remembered - once.
+	turnstile.Set()
+	turnstile.Set() // absorbed: an event is a flag, not a counter
+	quick, cancelQuick := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	first := turnstile.Wait(quick)
+	second := turnstile.Wait(quick)
+	cancelQuick()
+	fmt.Printf("2 Sets before anyone waits: first Wait %v, second Wait %v\n", errOrOK(first), errOrOK(second))
+
+	// 3. Fairness: many goroutines compete for a stream of Sets. FIFO
+	// release means each gets (almost exactly) its share.
+	const (
+		waiters = 20
+		rounds  = 100
+	)
+	var counts [waiters]int
+	stop, stopAll := context.WithCancel(context.Background())
+	for w := range waiters {
+		wg.Go(func() {
+			for turnstile.Wait(stop) == nil {
+				counts[w]++ // only goroutine w writes counts[w]
+				time.Sleep(100 * time.Microsecond)
+			}
+		})
+	}
+	time.Sleep(20 * time.Millisecond)
+	for range waiters * rounds {
+		turnstile.Set()
+		time.Sleep(20 * time.Microsecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	stopAll()
+	wg.Wait()
+
+	lo, hi, total := counts[0], counts[0], 0
+	for _, c := range counts {
+		lo, hi, total = min(lo, c), max(hi, c), total+c
+	}
+	fmt.Printf("%d Sets across %d waiters: %d passes, per waiter min %d / max %d (fair share %d)\n",
+		waiters*rounds, waiters, total, lo, hi, rounds)
+}
+
+func errOrOK(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "passed"
+}
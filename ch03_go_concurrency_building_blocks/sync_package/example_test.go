@@ -0,0 +1,84 @@
+package syncpackage_test
+
+import (
+	"fmt"
+	"sync"
+
+	syncpackage "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/sync_package"
+)
+
+func ExampleBufferPool() {
+	bp := syncpackage.NewBufferPool()
+	buf := bp.Get()
+	buf.WriteString("response body")
+	fmt.Println(buf.String())
+	bp.Put(buf) // reset before it goes back
+
+	buf = bp.Get() // maybe the same buffer, maybe a new one: empty either way
+	fmt.Println(buf.Len())
+	// Output:
+	// response body
+	// 0
+}
+
+func ExampleTypedPool() {
+	type request struct{ headers map[string]string }
+	p := syncpackage.NewTypedPool(func() *request {
+		return &request{headers: make(map[string]string)}
+	})
+	r := p.Get() // a *request, no type assertion at the call site
+	r.headers["host"] = "example.com"
+	fmt.Println(r.headers)
+	clear(r.headers)
+	p.Put(r)
+	// Output:
+	// map[host:example.com]
+}
+
+func ExampleBoundedQueue() {
+	q := syncpackage.NewBoundedQueue[int](2)
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for i := range 5 {
+			q.Enqueue(i) // waits on the "not full" Cond while the queue holds 2
+		}
+		q.Close()
+	})
+	var got []int
+	for {
+		v, ok := q.Dequeue() // waits on the "not empty" Cond
+		if !ok {
+			break // closed and drained
+		}
+		got = append(got, v)
+	}
+	wg.Wait()
+	fmt.Println(got)
+	fmt.Println(q.Enqueue(5))
+	// Output:
+	// [0 1 2 3 4]
+	// bounded queue: closed
+}
+
+func ExampleConfig_Load() {
+	var cfg syncpackage.Config
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() { cfg.Load() }) // one loads, the others wait for it
+	}
+	wg.Wait()
+	fmt.Println(cfg.Load()["port"])
+	// Output:
+	// Loading configuration (expensive operation)...
+	//   Configuration loaded!
+	// 8080
+}
+
+func ExampleGetInstance() {
+	a, b := syncpackage.GetInstance(), syncpackage.GetInstance()
+	fmt.Println("same instance:", a == b)
+	// Output:
+	// Creating singleton instance...
+	//   Singleton created!
+	// same instance: true
+}
@@ -0,0 +1,52 @@
+package syncpackage
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// One writer per key stores increasing versions while readers read at
+// random: a reader that has seen version v of a key must never see an older
+// one later, since Set and Get are linearizable under the RWMutex.
+func TestCacheReadsNeverGoBack(t *testing.T) {
+	const keys, readers, versions = 4, 8, 500
+	c := NewCache()
+	var wg sync.WaitGroup
+	for k := range keys {
+		wg.Go(func() {
+			for v := 1; v <= versions; v++ {
+				c.Set(fmt.Sprint("k", k), strconv.Itoa(v))
+			}
+		})
+	}
+	for g := range readers {
+		wg.Go(func() {
+			last := make([]int, keys)
+			for i := range versions * 2 {
+				k := (g + i) % keys
+				s, ok := c.Get(fmt.Sprint("k", k))
+				if !ok {
+					continue
+				}
+				v, err := strconv.Atoi(s)
+				if err != nil {
+					t.Errorf("k%d holds %q, not a version", k, s)
+					return
+				}
+				if v < last[k] {
+					t.Errorf("reader %d saw k%d go from version %d back to %d", g, k, last[k], v)
+					return
+				}
+				last[k] = v
+			}
+		})
+	}
+	wg.Wait()
+	for k := range keys {
+		if s, _ := c.Get(fmt.Sprint("k", k)); s != strconv.Itoa(versions) {
+			t.Errorf("k%d = %q after every Set, want %d", k, s, versions)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package syncpackage
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/refcount"
 )
 
 // ============================================================================
@@ -482,6 +486,83 @@ func comparison() {
 	fmt.Println("  Complex, can be wrong, sync.Once does it internally")
 }
 
+// ============================================================================
+// 13. BEYOND sync.Once: ONCE PER LIFETIME, TORN DOWN WHEN UNUSED
+// ============================================================================
+// A package-level Once builds a connection on first use and keeps it until
+// the program exits. refcount.Resource builds it on the first Acquire (a
+// sync.Once per lifetime), counts the references, and tears it down once
+// the last one is released and a grace period has passed - then builds a
+// new one if it is needed again.
+
+type sharedConn struct {
+	id     int
+	closed atomic.Bool
+}
+
+func refcountedResource() {
+	fmt.Println("\n=== Beyond sync.Once: A Reference-Counted Connection ===")
+
+	var dials atomic.Int64
+	var failNext atomic.Bool
+	res := refcount.New(refcount.Config[*sharedConn]{
+		New: func() (*sharedConn, error) {
+			time.Sleep(20 * time.Millisecond) // the dial
+			if failNext.Swap(false) {
+				return nil, errors.New("dial: connection refused")
+			}
+			return &sharedConn{id: int(dials.Add(1))}, nil
+		},
+		Teardown: func(c *sharedConn) { c.closed.Store(true) },
+		Grace:    30 * time.Millisecond,
+	})
+
+	fmt.Println("\nFive goroutines acquire it at once:")
+	refs := make([]*refcount.Ref[*sharedConn], 5)
+	var wg sync.WaitGroup
+	for i := range refs {
+		wg.Go(func() { refs[i], _ = res.Acquire() })
+	}
+	wg.Wait()
+	first := refs[0].Value()
+	same := true
+	for _, ref := range refs {
+		same = same && ref.Value() == first
+	}
+	st := res.Stats()
+	check(st.Builds == 1 && same, fmt.Sprintf("one dial, all five share conn %d", first.id))
+	check(st.Refs == 5, "5 references held")
+
+	fmt.Println("\nAll released, and acquired again within the grace period:")
+	for _, ref := range refs {
+		ref.Release()
+	}
+	refs[0].Release() // a second Release of the same Ref does nothing
+	check(res.Stats().Refs == 0 && !first.closed.Load(), "0 references, the connection is still open for 30ms")
+	time.Sleep(10 * time.Millisecond)
+	ref, _ := res.Acquire()
+	st = res.Stats()
+	check(ref.Value() == first && st.Reprieves == 1 && st.Builds == 1, "the same connection is taken over: no teardown, no new dial")
+	ref.Release()
+
+	fmt.Println("\nReleased, and the grace period passes:")
+	time.Sleep(50 * time.Millisecond)
+	st = res.Stats()
+	check(first.closed.Load() && st.Teardowns == 1, "torn down once nobody wanted it")
+	ref, _ = res.Acquire()
+	check(ref.Value().id == 2 && res.Stats().Builds == 2, "the next Acquire dials conn 2")
+	ref.Release()
+	time.Sleep(50 * time.Millisecond)
+
+	fmt.Println("\nA failed build is not remembered (unlike a plain sync.Once):")
+	failNext.Store(true)
+	_, err := res.Acquire()
+	ref, err2 := res.Acquire()
+	check(err != nil && err2 == nil && ref.Value().id == 3,
+		fmt.Sprintf("first Acquire: %v; the next one dialled conn 3", err))
+	ref.Release()
+}
+
 // ============================================================================
 // MAIN FUNCTION - RUN ALL EXAMPLES
 // ============================================================================
@@ -505,6 +586,7 @@ func RunOnceExamples() {
 	// performance()
 	// realWorldUseCases()
 	// comparison()
+	refcountedResource()
 
 	// fmt.Println()
 	// fmt.Println("╔════════════════════════════════════════════════════════════╗")
@@ -2,11 +2,20 @@ package syncpackage
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 
 	// "io"
+	"os"
+	"runtime"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+	"github.com/mintecr7/concurrency-with-go/pkg/pool"
 )
 
 // ============================================================================
@@ -57,55 +66,129 @@ func basicPool() {
 // 2. WHY USE sync.Pool? MEMORY OPTIMIZATION
 // ============================================================================
 
-func memoryOptimization() {
-	fmt.Println("\n=== Memory Optimization with sync.Pool ===")
+// gcCost is what one run of a workload cost the garbage collector, read
+// from runtime.MemStats before and after.
+type gcCost struct {
+	elapsed    time.Duration
+	totalAlloc uint64        // bytes allocated on the heap
+	mallocs    uint64        // heap objects allocated
+	numGC      uint32        // completed GC cycles
+	pauseTotal time.Duration // stop-the-world time
+}
 
-	var numCalcsCreated int
+// measureGC runs fn after a forced collection (so earlier garbage is not
+// billed to it) and returns the MemStats deltas.
+func measureGC(fn func()) gcCost {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	fn()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return gcCost{
+		elapsed:    elapsed,
+		totalAlloc: after.TotalAlloc - before.TotalAlloc,
+		mallocs:    after.Mallocs - before.Mallocs,
+		numGC:      after.NumGC - before.NumGC,
+		pauseTotal: time.Duration(after.PauseTotalNs - before.PauseTotalNs),
+	}
+}
 
-	// Pool that creates 1KB byte slices
-	calcPool := &sync.Pool{
+// newBufferPool returns a pool of 1KB buffers and the count of buffers its
+// New has created.
+func newBufferPool() (*sync.Pool, *atomic.Int64) {
+	// New is called concurrently from every goroutine that finds the pool
+	// empty, so the counter must be atomic. A plain created++ here is
+	// a data race (go test -race will report it).
+	created := new(atomic.Int64)
+	return &sync.Pool{
 		New: func() interface{} {
-			numCalcsCreated++
+			created.Add(1)
 			mem := make([]byte, 1024) // 1KB
 			return &mem               // IMPORTANT: Store pointer, not value
 		},
-	}
-
-	// Seed the pool with 4 instances (4KB total)
-	fmt.Println("Seeding pool with 4 instances (4KB)...")
-	calcPool.Put(calcPool.New())
-	calcPool.Put(calcPool.New())
-	calcPool.Put(calcPool.New())
-	calcPool.Put(calcPool.New())
+	}, created
+}
 
-	// Simulate 1 million workers
-	const numWorkers = 1024 * 1024
+// runBufferWorkers starts n goroutines that each get a buffer, touch it and
+// put it back, and waits for all of them.
+func runBufferWorkers(n int, get func() *[]byte, put func(*[]byte)) {
 	var wg sync.WaitGroup
-	wg.Add(numWorkers)
-
-	fmt.Printf("Starting %d workers...\n", numWorkers)
-	start := time.Now()
-
-	for i := numWorkers; i > 0; i-- {
+	wg.Add(n)
+	for i := n; i > 0; i-- {
 		go func() {
 			defer wg.Done()
 
-			// Get from pool (type assertion)
-			mem := calcPool.Get().(*[]byte)
-			defer calcPool.Put(mem) // ALWAYS Put back!
+			mem := get()
+			defer put(mem) // ALWAYS Put back!
 
 			// Simulate quick operation with memory
 			_ = (*mem)[0]
 		}()
 	}
-
 	wg.Wait()
-	elapsed := time.Since(start)
+}
 
-	fmt.Printf("\nCompleted in %v\n", elapsed)
-	fmt.Printf("%d objects created (not %d!)\n", numCalcsCreated, numWorkers)
-	fmt.Printf("Without pool: ~1GB memory\n")
-	fmt.Printf("With pool: ~%dKB memory (reused objects)\n", numCalcsCreated)
+func memoryOptimization() {
+	fmt.Println("\n=== Memory Optimization with sync.Pool ===")
+
+	calcPool, numCalcsCreated := newBufferPool()
+
+	// Seed the pool with 4 instances (4KB total)
+	fmt.Println("Seeding pool with 4 instances (4KB)...")
+	calcPool.Put(calcPool.New())
+	calcPool.Put(calcPool.New())
+	calcPool.Put(calcPool.New())
+	calcPool.Put(calcPool.New())
+
+	// The same workload twice: 1 million workers that each need a 1KB
+	// buffer. Only where the buffer comes from differs.
+	const numWorkers = 1024 * 1024
+	workload := func(get func() *[]byte, put func(*[]byte)) func() {
+		return func() { runBufferWorkers(numWorkers, get, put) }
+	}
+
+	fmt.Printf("Starting %d workers without a pool, then with one...\n", numWorkers)
+	unpooled := measureGC(workload(
+		func() *[]byte { mem := make([]byte, 1024); return &mem }, // fresh 1KB every time
+		func(*[]byte) {}, // dropped: garbage for the GC
+	))
+	pooled := measureGC(workload(
+		func() *[]byte { return calcPool.Get().(*[]byte) }, // Get from pool (type assertion)
+		func(mem *[]byte) { calcPool.Put(mem) },
+	))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "\n\tTime\tHeap allocated\tObjects\tGC cycles\tGC pauses")
+	for _, r := range []struct {
+		name string
+		c    gcCost
+	}{{"Without pool", unpooled}, {"With pool", pooled}} {
+		fmt.Fprintf(tw, "%s\t%v\t%d KB\t%d\t%d\t%v\n", r.name, r.c.elapsed.Round(time.Millisecond),
+			r.c.totalAlloc/1024, r.c.mallocs, r.c.numGC, r.c.pauseTotal.Round(time.Microsecond))
+	}
+	tw.Flush()
+	// Both rows include the goroutines' own bookkeeping (closures, WaitGroup
+	// traffic); the difference between them is the buffers.
+	fmt.Printf("Buffers the GC never had to see: ~%d MB\n", (unpooled.totalAlloc-min(pooled.totalAlloc, unpooled.totalAlloc))>>20)
+
+	created := numCalcsCreated.Load()
+	fmt.Printf("\n%d objects created by the pool (not %d!)\n", created, numWorkers)
+
+	// The exact count depends on scheduling. Normally it stays in single
+	// digits; under -race, Put deliberately drops ~1 in 4 objects, so allow
+	// for that and only require a clear majority of workers to reuse.
+	if created*2 < numWorkers {
+		fmt.Printf("✓ Reuse check passed: %d created < half of %d workers\n", created, numWorkers)
+	} else {
+		fmt.Printf("✗ Reuse check failed: %d created for %d workers\n", created, numWorkers)
+	}
+	if pooled.totalAlloc < unpooled.totalAlloc/4 {
+		fmt.Println("✓ GC check passed: the pooled run allocated less than a quarter as much")
+	} else {
+		fmt.Println("✗ GC check failed: the pooled run allocated about as much as the unpooled one")
+	}
 }
 
 // ============================================================================
@@ -115,7 +198,7 @@ func memoryOptimization() {
 func withoutPool() {
 	fmt.Println("\n=== WITHOUT sync.Pool (Allocates Every Time) ===")
 
-	var created int
+	var created atomic.Int64
 
 	var wg sync.WaitGroup
 	const workers = 10000
@@ -129,7 +212,7 @@ func withoutPool() {
 
 			// Create new buffer every time
 			buffer := make([]byte, 1024)
-			created++
+			created.Add(1)
 
 			// Use buffer
 			_ = buffer[0]
@@ -139,18 +222,18 @@ func withoutPool() {
 	wg.Wait()
 	elapsed := time.Since(start)
 
-	fmt.Printf("Created %d buffers in %v\n", workers, elapsed)
+	fmt.Printf("Created %d buffers in %v\n", created.Load(), elapsed)
 	fmt.Println("→ More GC pressure, more allocations")
 }
 
 func withPool() {
 	fmt.Println("\n=== WITH sync.Pool (Reuses Objects) ===")
 
-	var created int
+	var created atomic.Int64 // New runs concurrently, so count atomically
 
 	pool := &sync.Pool{
 		New: func() interface{} {
-			created++
+			created.Add(1)
 			buffer := make([]byte, 1024)
 			return &buffer
 		},
@@ -183,7 +266,7 @@ func withPool() {
 	wg.Wait()
 	elapsed := time.Since(start)
 
-	fmt.Printf("Created only %d buffers (reused!) in %v\n", created, elapsed)
+	fmt.Printf("Created only %d buffers (reused!) in %v\n", created.Load(), elapsed)
 	fmt.Println("→ Less GC pressure, fewer allocations")
 }
 
@@ -263,25 +346,19 @@ func bufferPoolExample() {
 
 	pool := NewBufferPool()
 
-	var wg sync.WaitGroup
-
 	// Simulate multiple goroutines using buffers
-	for i := 0; i < 5; i++ {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-
-			// Get buffer from pool
-			buf := pool.Get()
-			defer pool.Put(buf) // Return to pool when done
-
-			// Use buffer
-			fmt.Fprintf(buf, "Worker %d: Hello, World!", id)
-			fmt.Printf("%s\n", buf.String())
-		}(i)
-	}
+	workers := []int{0, 1, 2, 3, 4}
+	conc.ForEach(context.Background(), workers, 0, func(_ context.Context, id int) error {
+		// Get buffer from pool
+		buf := pool.Get()
+		defer pool.Put(buf) // Return to pool when done
+
+		// Use buffer
+		fmt.Fprintf(buf, "Worker %d: Hello, World!", id)
+		fmt.Printf("%s\n", buf.String())
+		return nil
+	})
 
-	wg.Wait()
 	fmt.Println("Buffers reused efficiently across goroutines!")
 }
 
@@ -357,6 +434,7 @@ func poolBestPractices() {
 
 	fmt.Println("\n⚠️  Objects can be evicted by GC at any time!")
 	fmt.Println("    Don't rely on Pool for persistent storage")
+	fmt.Println("    (section 14 measures it: an unused object survives one GC, not two)")
 }
 
 // ============================================================================
@@ -500,19 +578,14 @@ func httpServerExample() {
 		fmt.Printf("  %s\n", writer.String())
 	}
 
-	var wg sync.WaitGroup
-
-	// Simulate 10 concurrent requests
+	// Simulate 10 concurrent requests, at most 4 in flight
 	fmt.Println("Handling 10 requests with pooled writers:")
-	for i := 1; i <= 10; i++ {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			handleRequest(id)
-		}(i)
-	}
+	requests := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	conc.ForEach(context.Background(), requests, 4, func(_ context.Context, id int) error {
+		handleRequest(id)
+		return nil
+	})
 
-	wg.Wait()
 	fmt.Println("All requests handled with object reuse!")
 }
 
@@ -593,6 +666,261 @@ func poolVsOthers() {
 	fmt.Println("  ✓ Multiple objects available")
 }
 
+// ============================================================================
+// 13. BEYOND sync.Pool: A CONNECTION POOL (pkg/pool)
+// ============================================================================
+// sync.Pool is for memory. A connection is a resource: it must be closed,
+// not forgotten; it can die while idle; and how many are kept matters to
+// the server on the other end. pool.Pool keeps exactly MaxIdle, validates
+// on Get, resets on Put and finalizes everything it lets go of.
+
+// fakeConn stands in for a network connection; open counts the ones not
+// yet closed, as the server would see them.
+type fakeConn struct {
+	id       int
+	lastUsed time.Time
+	buf      bytes.Buffer
+	open     *atomic.Int64
+}
+
+func (c *fakeConn) Close() { c.open.Add(-1) }
+
+func connPool(maxIdle int, idleTimeout time.Duration, open *atomic.Int64) *pool.Pool[*fakeConn] {
+	var ids atomic.Int64
+	return pool.New(pool.Config[*fakeConn]{
+		New: func(ctx context.Context) (*fakeConn, error) {
+			time.Sleep(time.Millisecond) // the dial
+			open.Add(1)
+			return &fakeConn{id: int(ids.Add(1)), lastUsed: time.Now(), open: open}, nil
+		},
+		Reset: func(c *fakeConn) {
+			c.buf.Reset()
+			c.lastUsed = time.Now()
+		},
+		Validate: func(c *fakeConn) error {
+			if idle := time.Since(c.lastUsed); idle > idleTimeout {
+				return fmt.Errorf("conn %d idle for %v: the server has closed it", c.id, idle.Round(time.Millisecond))
+			}
+			return nil
+		},
+		Finalize: (*fakeConn).Close,
+		MaxIdle:  maxIdle,
+	})
+}
+
+func check(ok bool, what string) {
+	mark := "✓"
+	if !ok {
+		mark = "✗"
+	}
+	fmt.Printf("  %s %s\n", mark, what)
+}
+
+func connectionPoolExample() {
+	fmt.Println()
+	fmt.Println("=== Beyond sync.Pool: A Connection Pool (pkg/pool) ===")
+	ctx := context.Background()
+
+	fmt.Println("\nBursts of 8 concurrent requests, 10 bursts:")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "  MaxIdle\tdials\treused\tclosed\tidle\topen after")
+	for _, maxIdle := range []int{-1, 2, 8} {
+		var open atomic.Int64
+		p := connPool(maxIdle, time.Minute, &open)
+		for range 10 {
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Go(func() {
+					c, _ := p.Get(ctx)
+					fmt.Fprintf(&c.buf, "GET /%d", c.id)
+					time.Sleep(2 * time.Millisecond)
+					p.Put(c)
+				})
+			}
+			wg.Wait()
+		}
+		st := p.Stats()
+		fmt.Fprintf(tw, "  %d\t%d\t%d\t%d\t%d\t%d\n", maxIdle, st.Created, st.Reused, st.Finalized, st.Idle, open.Load())
+		p.Close()
+	}
+	tw.Flush()
+	fmt.Println("→ every connection beyond MaxIdle is closed on Put, never silently dropped:")
+	fmt.Println("  open connections = idle ones, exactly the number you asked for")
+
+	fmt.Println("\nStale connections are caught on Get:")
+	var open atomic.Int64
+	p := connPool(4, 20*time.Millisecond, &open)
+	var held []*fakeConn
+	for range 4 {
+		c, _ := p.Get(ctx)
+		held = append(held, c)
+	}
+	for _, c := range held {
+		p.Put(c)
+	}
+	time.Sleep(30 * time.Millisecond) // longer than the server's idle timeout
+	c, err := p.Get(ctx)
+	if err != nil {
+		fmt.Println("  Get:", err)
+		return
+	}
+	st := p.Stats()
+	fmt.Printf("  %d idle conns failed Validate and were closed; Get dialled conn %d\n", st.Invalid, c.id)
+	fmt.Fprint(&c.buf, "GET /half-written")
+	p.Put(c)
+	again, _ := p.Get(ctx)
+	fmt.Printf("  the next Get reuses conn %d; after Reset on Put its buffer holds %q\n", again.id, again.buf.String())
+
+	fmt.Println("\nClose with a connection still in use:")
+	p.Close()
+	fmt.Printf("  after Close: %d open - the idle ones are closed at once, the one in use isn't\n", open.Load())
+	p.Put(c)
+	fmt.Printf("  after Put: %d open - Put after Close closes it\n", open.Load())
+	_, err = p.Get(ctx)
+	fmt.Printf("  Get after Close: %v\n", err)
+
+	fmt.Println("\nThe same with sync.Pool:")
+	open.Store(0)
+	var sp sync.Pool
+	for i := range 4 {
+		open.Add(1)
+		sp.Put(&fakeConn{id: i, open: &open})
+	}
+	runtime.GC()
+	runtime.GC() // the first moves the pool to its victim cache, the second frees it
+	fmt.Printf("  after two GCs Get returns %v, and %d connections are still open: nobody closed them\n", sp.Get(), open.Load())
+}
+
+// ============================================================================
+// 14. THE VICTIM CACHE: WHAT A GC REALLY EVICTS
+// ============================================================================
+// "GC can evict objects anytime" is true but vague. What happens: at the
+// start of every GC cycle, each pool's contents move to a victim cache,
+// and the previous victim cache is dropped. Get looks in the pool first,
+// then in the victim cache. So an object Put back survives one GC and is
+// collected at the second, unless a Get takes it in between.
+//
+// The counters below prove it. Every New is a miss; a cleanup attached to
+// every object counts the ones the GC actually collected. Automatic GC is
+// switched off while they run, so the only cycles are the runtime.GC calls.
+
+// trackedPool is a sync.Pool of 4 KB buffers that counts its misses and
+// the buffers the GC has freed.
+type trackedPool struct {
+	pool  sync.Pool
+	news  atomic.Int64
+	freed atomic.Int64
+}
+
+func newTrackedPool() *trackedPool {
+	tp := &trackedPool{}
+	tp.pool.New = func() any {
+		tp.news.Add(1)
+		b := new([4096]byte)
+		runtime.AddCleanup(b, func(freed *atomic.Int64) { freed.Add(1) }, &tp.freed)
+		return b
+	}
+	return tp
+}
+
+// burst takes n buffers at once, as n requests in flight would, then puts
+// them all back. It returns how many came from the pool.
+func (tp *trackedPool) burst(n int) int {
+	before := tp.news.Load()
+	held := make([]*[4096]byte, n)
+	for i := range held {
+		held[i] = tp.pool.Get().(*[4096]byte)
+	}
+	for _, b := range held {
+		tp.pool.Put(b)
+	}
+	return n - int(tp.news.Load()-before)
+}
+
+// gc runs n collections and gives the cleanups of what they freed time to
+// run (they run on their own goroutine, after the cycle).
+func gc(n int) {
+	for range n {
+		runtime.GC()
+	}
+	time.Sleep(20 * time.Millisecond)
+}
+
+func victimCache() {
+	fmt.Println()
+	fmt.Println("=== The Victim Cache: What a GC Really Evicts ===")
+	defer debug.SetGCPercent(debug.SetGCPercent(-1)) // only our runtime.GC calls
+	// Each P (GOMAXPROCS slot) also keeps one object in a private slot that
+	// other Ps don't steal from. A goroutine that resumes on another P after
+	// runtime.GC would miss that one, and the counts would be off by one.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	fmt.Println("\n100 buffers Put back, then N GCs, then 100 Gets:")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "  GCs\tfrom pool\tNew calls\tfreed by GC")
+	survived := make([]int, 4)
+	for n := range survived {
+		tp := newTrackedPool()
+		tp.burst(100)
+		gc(n)
+		freed := tp.freed.Load()
+		survived[n] = tp.burst(100)
+		fmt.Fprintf(tw, "  %d\t%d\t%d\t%d\n", n, survived[n], 100-survived[n], freed)
+	}
+	tw.Flush()
+	check(survived[0] == 100 && survived[1] == 100, "after one GC every buffer is still there: served from the victim cache")
+	check(survived[2] == 0 && survived[3] == 0, "after two GCs none is: the victim cache was dropped and collected")
+
+	fmt.Println("\nBursts of different sizes, one GC between bursts:")
+	tp := newTrackedPool()
+	tw = tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "  burst\tin flight\tfrom pool\tNew calls\tfreed by the GC before")
+	hits := make([]int, 0, 6)
+	for i, size := range []int{64, 8, 8, 8, 64, 64} {
+		freedBefore := tp.freed.Load()
+		if i > 0 {
+			gc(1)
+		}
+		hits = append(hits, tp.burst(size))
+		fmt.Fprintf(tw, "  %d\t%d\t%d\t%d\t%d\n", i+1, size, hits[i], size-hits[i], tp.freed.Load()-freedBefore)
+	}
+	tw.Flush()
+	check(hits[1] == 8 && tp.freed.Load() == 56, "after a quiet cycle the 56 buffers nobody asked for were freed")
+	check(hits[4] == 8, "the next burst of 64 found only 8: the pool had shrunk to the recent peak")
+	check(hits[5] == 64, "and one burst later it had grown back")
+
+	fmt.Println("\n20 bursts of 16, by GCs between bursts:")
+	tw = tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "  GCs between\tGets\tNew calls\thit rate")
+	rates := make([]float64, 3)
+	for n := range rates {
+		tp := newTrackedPool()
+		for i := range 20 {
+			if i > 0 {
+				gc(n)
+			}
+			tp.burst(16)
+		}
+		rates[n] = 1 - float64(tp.news.Load())/320
+		fmt.Fprintf(tw, "  %d\t%d\t%d\t%.0f%%\n", n, 320, tp.news.Load(), 100*rates[n])
+	}
+	tw.Flush()
+	check(rates[1] == rates[0] && rates[2] == 0,
+		"one GC between bursts costs nothing; two make the pool useless")
+
+	fmt.Println("\nSizing a sync.Pool for steady state:")
+	fmt.Println("  • It has no size. It holds what was Put since the last GC plus what")
+	fmt.Println("    survived the cycle before: roughly the peak number in flight over")
+	fmt.Println("    the last one or two GC cycles. It grows and shrinks on its own.")
+	fmt.Println("  • Count New calls: they are the misses. Hit rate = 1 - New/Get.")
+	fmt.Println("  • The GC sets the pace (GOGC, GOMEMLIMIT), not you: work that comes")
+	fmt.Println("    less often than every other cycle finds the pool empty.")
+	fmt.Println("  • Need a floor of warm objects for rare bursts? Keep it explicitly:")
+	fmt.Println("    a buffered channel as a free list, or pkg/pool with MaxIdle.")
+	fmt.Println("  • Don't Put back outliers: a 64 MB buffer kept for two cycles costs")
+	fmt.Println("    more than allocating it the one time it is needed.")
+}
+
 // ============================================================================
 // MAIN FUNCTION - RUN ALL EXAMPLES
 // ============================================================================
@@ -616,6 +944,8 @@ func PoolDemo() {
 	httpServerExample()
 	typedPoolExample()
 	poolVsOthers()
+	connectionPoolExample()
+	victimCache()
 
 	fmt.Println()
 	fmt.Println("╔════════════════════════════════════════════════════════════╗")
@@ -653,6 +983,8 @@ func PoolDemo() {
 	fmt.Println("║                                                            ║")
 	fmt.Println("║   IMPORTANT: GC can evict objects anytime!                 ║")
 	fmt.Println("║     Don't rely on Pool for persistent storage              ║")
+	fmt.Println("║     Connections need pkg/pool: MaxIdle, Validate, Close    ║")
+	fmt.Println("║     Unused objects survive one GC (victim cache), not two  ║")
 	fmt.Println("║                                                            ║")
 	fmt.Println("║ Pattern:                                                   ║")
 	fmt.Println("║   pool := &sync.Pool{                                      ║")
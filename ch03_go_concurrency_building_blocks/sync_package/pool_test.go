@@ -0,0 +1,26 @@
+package syncpackage
+
+import "testing"
+
+// TestBufferPoolReuse is memoryOptimization's workload with a check: a
+// pool shared by many short-lived workers creates far fewer buffers than
+// there are workers. Run it with -race too - New's counter is shared by
+// every goroutine that finds the pool empty.
+func TestBufferPoolReuse(t *testing.T) {
+	const numWorkers = 1 << 16
+	pool, created := newBufferPool()
+	for range 4 {
+		pool.Put(pool.New())
+	}
+	runBufferWorkers(numWorkers,
+		func() *[]byte { return pool.Get().(*[]byte) },
+		func(mem *[]byte) { pool.Put(mem) },
+	)
+	// Under -race, Put drops about 1 in 4 objects on purpose, so only ask
+	// for a clear majority of workers to have reused a buffer.
+	n := created.Load()
+	t.Logf("%d buffers created for %d workers", n, numWorkers)
+	if n*2 >= numWorkers {
+		t.Fatalf("the pool created %d buffers for %d workers, want fewer than %d", n, numWorkers, numWorkers/2)
+	}
+}
@@ -0,0 +1,148 @@
+package syncpackage
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/profiles"
+)
+
+// ============================================================================
+// PROFILING CONTENTION: goroutine, block and mutex profiles
+// ============================================================================
+// performanceComparison() tells you THAT RWMutex beats Mutex with many
+// readers. The runtime can tell you WHERE the time went - but only if you
+// ask before the workload runs:
+//
+//	runtime.SetBlockProfileRate(1)     // record every blocking event
+//	runtime.SetMutexProfileFraction(1) // record every contended Unlock
+//
+// Both are off by default because recording costs something on every
+// blocking operation. The goroutine profile is always available.
+//
+// Reading them:
+// - BLOCK profile: charged to the WAITER, at the line that blocked
+//   (Lock, RLock, <-ch, wg.Wait). "Who waited, and for how long?"
+// - MUTEX profile: charged to the HOLDER, at the line that unlocked while
+//   others were queued. "Whose critical section made everyone else wait?"
+// - GOROUTINE profile: a snapshot. "Where is everybody right now?"
+//
+// The package github.com/mintecr7/concurrency-with-go/pkg/profiles reads all three and prints the
+// top sites in YOUR code, skipping runtime and sync frames.
+// ============================================================================
+
+// contendedReadersAndWriter is the performanceComparison workload with a
+// writer whose critical section takes real time (think: an update that does
+// I/O while holding the lock), so there is contention worth profiling.
+func contendedReadersAndWriter(readers int, writer, reader sync.Locker) time.Duration {
+	shared := make(map[int]int)
+
+	write := func() {
+		for i := range 20 {
+			writer.Lock()
+			time.Sleep(200 * time.Microsecond) // slow update under the lock
+			shared[i] = i
+			writer.Unlock()
+			time.Sleep(100 * time.Microsecond)
+		}
+	}
+	read := func() {
+		for range 20 {
+			reader.Lock()
+			_ = shared[len(shared)/2]
+			reader.Unlock()
+			time.Sleep(50 * time.Microsecond)
+		}
+	}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	wg.Go(write)
+	for range readers {
+		wg.Go(read)
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+// parkedGoroutines starts n goroutines that block on a channel until the
+// returned release function is called - something to find in the goroutine
+// profile.
+func parkedGoroutines(n int) (release func()) {
+	gate := make(chan struct{})
+	var started, wg sync.WaitGroup
+	started.Add(n)
+	for range n {
+		wg.Go(func() {
+			started.Done()
+			<-gate // all n goroutines are parked on this line
+		})
+	}
+	started.Wait()
+	time.Sleep(time.Millisecond) // let the last ones reach <-gate
+	return func() {
+		close(gate)
+		wg.Wait()
+	}
+}
+
+func ProfilingDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║       PROFILING: GOROUTINE, BLOCK AND MUTEX PROFILES       ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	// Events before this point are not recorded, so the profiles below only
+	// describe this demo.
+	restore := profiles.Enable()
+	defer restore()
+
+	const readers = 64
+	var m sync.RWMutex
+	rwTime := contendedReadersAndWriter(readers, &m, m.RLocker())
+	mutexTime := contendedReadersAndWriter(readers, &m, &m)
+	fmt.Printf("\n1 writer + %d readers: RWMutex %v, Mutex %v\n",
+		readers, rwTime.Round(time.Millisecond), mutexTime.Round(time.Millisecond))
+
+	fmt.Println("\n=== Block profile: where goroutines waited ===")
+	if sites, err := profiles.Block(5); err != nil {
+		fmt.Println("  error:", err)
+	} else {
+		profiles.Print(os.Stdout, "Top 5 blocking sites:", sites)
+	}
+
+	fmt.Println("\n=== Mutex profile: whose Unlock kept others waiting ===")
+	if sites, err := profiles.Mutex(5); err != nil {
+		fmt.Println("  error:", err)
+	} else {
+		profiles.Print(os.Stdout, "Top 5 contended critical sections:", sites)
+	}
+
+	fmt.Println("\n=== Goroutine profile: where everybody is right now ===")
+	release := parkedGoroutines(25)
+	if sites, err := profiles.Goroutines(3); err != nil {
+		fmt.Println("  error:", err)
+	} else {
+		profiles.Print(os.Stdout, "Top 3 goroutine locations:", sites)
+	}
+	release()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ Turn profiling ON before the workload:                     ║")
+	fmt.Println("║   • runtime.SetBlockProfileRate(rate)                      ║")
+	fmt.Println("║   • runtime.SetMutexProfileFraction(fraction)              ║")
+	fmt.Println("║                                                            ║")
+	fmt.Println("║ Block profile  → the WAITER's line (Lock, <-ch, Wait)      ║")
+	fmt.Println("║ Mutex profile  → the HOLDER's Unlock: shrink that section  ║")
+	fmt.Println("║ Goroutine      → snapshot: leaks and pile-ups              ║")
+	fmt.Println("║                                                            ║")
+	fmt.Println("║ The slow writer shows up in BOTH: readers block on RLock,  ║")
+	fmt.Println("║ and the writer's Unlock is charged with their wait.        ║")
+	fmt.Println("║ In production use rate/fraction > 1: sampling is cheap,    ║")
+	fmt.Println("║ recording every event is not.                              ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
@@ -0,0 +1,98 @@
+package syncpackage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/chancond"
+)
+
+// ============================================================================
+// A Cond YOU CAN select ON: chancond.Cond
+// ============================================================================
+// whenToUseCond() says "select statement needed → use channels". The reason:
+// sync.Cond.Wait() can't be interrupted, so a consumer waiting for work
+// can't also watch ctx.Done() or a deadline. chancond.Cond keeps the exact
+// Cond pattern (lock, for-loop, wait) but Wait returns a channel.
+//
+// This demo checks three things:
+// 1. Signal wakes exactly ONE waiter, Broadcast wakes ALL (same as sync.Cond)
+// 2. A waiter can give up on a timeout while the condition stays false
+// 3. A waiter that gives up after being signalled passes the wake-up on,
+//    so the signal is not lost
+// ============================================================================
+
+func selectableCond() {
+	fmt.Println("\n=== Selectable Cond: chancond.Cond ===")
+
+	var mu sync.Mutex
+	cond := chancond.New(&mu)
+
+	// waitOnce parks one waiter; woken reports whether Signal/Broadcast (not
+	// the timeout) ended the wait.
+	waitOnce := func(timeout time.Duration, woken chan<- bool) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		mu.Lock()
+		err := cond.WaitContext(ctx)
+		mu.Unlock()
+		woken <- err == nil
+	}
+	count := func(woken <-chan bool, n int) (wokenUp, timedOut int) {
+		for range n {
+			if <-woken {
+				wokenUp++
+			} else {
+				timedOut++
+			}
+		}
+		return
+	}
+
+	// 1. Signal vs Broadcast parity
+	const waiters = 5
+	woken := make(chan bool, waiters)
+	for range waiters {
+		go waitOnce(200*time.Millisecond, woken)
+	}
+	time.Sleep(50 * time.Millisecond) // let everyone register
+	cond.Signal()
+	up, out := count(woken, waiters)
+	fmt.Printf("Signal():    %d woken, %d timed out (expected 1 and %d)\n", up, out, waiters-1)
+
+	for range waiters {
+		go waitOnce(200*time.Millisecond, woken)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cond.Broadcast()
+	up, out = count(woken, waiters)
+	fmt.Printf("Broadcast(): %d woken, %d timed out (expected %d and 0)\n", up, out, waiters)
+
+	// 2. Waiting for a condition OR a deadline
+	ready := false
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	mu.Lock()
+	var err error
+	for !ready && err == nil {
+		err = cond.WaitContext(ctx) // with sync.Cond this would block forever
+	}
+	mu.Unlock()
+	fmt.Printf("Condition never became true: gave up after %v with %q\n", time.Since(start).Round(10*time.Millisecond), err)
+
+	// 3. A signalled waiter that abandons the wait hands the signal on.
+	first := cond.Wait()
+	second := cond.Wait()
+	cond.Signal() // goes to `first`, the oldest waiter...
+	<-first
+	passedOn := !cond.Abandon(first) // ...which decides it doesn't want it
+	select {
+	case <-second:
+		fmt.Printf("Abandoned wake-up passed on to the next waiter: %v\n", passedOn)
+	case <-time.After(100 * time.Millisecond):
+		fmt.Println("Abandoned wake-up was LOST")
+	}
+}
@@ -0,0 +1,140 @@
+package syncpackage
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// THE SLEEPING BARBER (Dijkstra, 1965)
+// ============================================================================
+// A barbershop has ONE barber, ONE barber chair and N waiting-room chairs.
+// - No customers?          The barber falls asleep in his chair.
+// - Customer arrives:      Wakes the barber if asleep, otherwise sits down
+//                          in the waiting room...
+// - ...waiting room full?  The customer LEAVES (no blocking, no queueing).
+// - Barber finishes a cut: Calls the next customer, or goes back to sleep.
+//
+// Mapping to Go:
+// - Waiting room = BoundedQueue with TryEnqueue (full → walk away)
+// - Barber sleeping = Dequeue() → notEmpty.Wait() inside the queue
+// - Customer waiting for their haircut to finish = a Cond shared by ALL
+//   seated customers, each waiting for THEIR OWN condition
+//
+// That last point is the lesson: many goroutines wait on ONE Cond for
+// DIFFERENT conditions. Signal() would wake one arbitrary waiter - probably
+// not the customer who was just served - and that wakeup would be wasted
+// while the right customer sleeps on. Broadcast() + a per-waiter condition
+// in a for-loop is the correct pattern.
+// ============================================================================
+
+type barberCustomer struct {
+	id      int
+	arrived time.Time
+	done    bool // guarded by barberShop.mu
+}
+
+type barberShop struct {
+	waitingRoom *BoundedQueue[*barberCustomer]
+
+	mu       sync.Mutex
+	haircuts *sync.Cond // broadcast whenever a haircut finishes
+
+	// stats, guarded by mu
+	served, turnedAway, naps int
+	totalWait                time.Duration
+}
+
+func newBarberShop(chairs int) *barberShop {
+	shop := &barberShop{waitingRoom: NewBoundedQueue[*barberCustomer](chairs)}
+	shop.haircuts = sync.NewCond(&shop.mu)
+	return shop
+}
+
+// barber cuts hair until the shop closes (the waiting room is closed and
+// empty).
+func (s *barberShop) barber(cutTime time.Duration) {
+	for {
+		if s.waitingRoom.Len() == 0 {
+			fmt.Println("  💤 Barber: nobody waiting, going to sleep")
+			s.mu.Lock()
+			s.naps++
+			s.mu.Unlock()
+		}
+
+		c, ok := s.waitingRoom.Dequeue() // sleeps (Cond.Wait) while the room is empty
+		if !ok {
+			fmt.Println("  Barber: shop closed, going home")
+			return
+		}
+
+		fmt.Printf("  ✂️  Barber: cutting customer %d's hair\n", c.id)
+		time.Sleep(cutTime)
+
+		s.mu.Lock()
+		c.done = true
+		s.served++
+		s.totalWait += time.Since(c.arrived)
+		s.mu.Unlock()
+
+		// Everyone seated is waiting on the same Cond; only customer c's
+		// condition just became true. Broadcast so c is guaranteed to wake.
+		s.haircuts.Broadcast()
+	}
+}
+
+// customer tries to get a haircut and leaves if the waiting room is full.
+func (s *barberShop) customer(id int) {
+	c := &barberCustomer{id: id, arrived: time.Now()}
+
+	if !s.waitingRoom.TryEnqueue(c) {
+		fmt.Printf("  Customer %d: waiting room full, leaving 😞\n", id)
+		s.mu.Lock()
+		s.turnedAway++
+		s.mu.Unlock()
+		return
+	}
+
+	s.mu.Lock()
+	for !c.done { // wake-ups for OTHER customers' haircuts land here too
+		s.haircuts.Wait()
+	}
+	s.mu.Unlock()
+	fmt.Printf("  Customer %d: leaving with a fresh haircut 💈\n", id)
+}
+
+func sleepingBarber() {
+	fmt.Println("\n=== Sleeping Barber (Cond + Bounded Waiting Room) ===")
+
+	const (
+		customers = 20
+		chairs    = 3
+		cutTime   = 20 * time.Millisecond
+	)
+	shop := newBarberShop(chairs)
+
+	var barberDone sync.WaitGroup
+	barberDone.Go(func() { shop.barber(cutTime) })
+
+	// Customers arrive in random bursts: sometimes faster than the barber can
+	// cut (the room fills up), sometimes slower (the barber naps).
+	var customersDone sync.WaitGroup
+	for id := 1; id <= customers; id++ {
+		customersDone.Go(func() { shop.customer(id) })
+		time.Sleep(rand.N(2 * cutTime))
+	}
+
+	customersDone.Wait()
+	shop.waitingRoom.Close() // closing time
+	barberDone.Wait()
+
+	shop.mu.Lock()
+	defer shop.mu.Unlock()
+	fmt.Printf("\nServed: %d, turned away: %d, barber naps: %d\n", shop.served, shop.turnedAway, shop.naps)
+	if shop.served > 0 {
+		fmt.Printf("Average time in shop for served customers: %v\n", (shop.totalWait / time.Duration(shop.served)).Round(time.Millisecond))
+	}
+	fmt.Printf("Every customer accounted for: %v\n", shop.served+shop.turnedAway == customers)
+}
@@ -0,0 +1,95 @@
+package syncpackage
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/counter"
+)
+
+// ============================================================================
+// 2b. FIXING THE RACE WITHOUT A BOTTLENECK: counter.Adder
+// ============================================================================
+// withMutex() fixes the lost increments, and atomic.Int64 fixes them without
+// a lock - but both funnel every increment through ONE memory location. On
+// many cores that location's cache line ping-pongs between them and the
+// counter becomes the bottleneck.
+//
+// counter.Adder stripes the count over one padded cell per P and only sums
+// the cells on Load. Writes mostly stay on their own core's cache line.
+// ============================================================================
+
+func withStripedCounter() {
+	fmt.Println("\n=== WITH counter.Adder (Safe, Striped) ===")
+
+	var count counter.Adder
+	var wg sync.WaitGroup
+	for range 1000 {
+		wg.Go(func() {
+			count.Inc() // SAFE: each P mostly increments its own cell
+		})
+	}
+	wg.Wait()
+	fmt.Printf("Expected: 1000, Got: %d (correct!)\n", count.Load())
+
+	// How do the three correct counters scale as contention grows?
+	const opsPerGoroutine = 200_000
+	procs := runtime.GOMAXPROCS(0)
+	fmt.Printf("\nns per increment, %d increments per goroutine (GOMAXPROCS=%d):\n", opsPerGoroutine, procs)
+
+	counters := []struct {
+		name string
+		inc  func() func() // returns a fresh counter's increment function
+	}{
+		{"sync.Mutex", func() func() {
+			var mu sync.Mutex
+			var n int64
+			return func() { mu.Lock(); n++; mu.Unlock() }
+		}},
+		{"atomic.Int64", func() func() {
+			var n atomic.Int64
+			return func() { n.Add(1) }
+		}},
+		{"counter.Adder", func() func() {
+			var n counter.Adder
+			return n.Inc
+		}},
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "Goroutines\t")
+	for _, c := range counters {
+		fmt.Fprintf(tw, "%s\t", c.name)
+	}
+	fmt.Fprintln(tw)
+	for _, goroutines := range slices.Compact([]int{1, procs, 4 * procs, 16 * procs}) {
+		fmt.Fprintf(tw, "%d\t", goroutines)
+		for _, c := range counters {
+			inc := c.inc()
+			start := time.Now()
+			var wg sync.WaitGroup
+			for range goroutines {
+				wg.Go(func() {
+					for range opsPerGoroutine {
+						inc()
+					}
+				})
+			}
+			wg.Wait()
+			perOp := float64(time.Since(start).Nanoseconds()) / float64(goroutines*opsPerGoroutine)
+			fmt.Fprintf(tw, "%.1f\t", perOp)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	fmt.Println("On one core nothing is contended: atomic.Int64 wins and Adder only")
+	fmt.Println("pays for picking a cell. Run with many cores (GOMAXPROCS ≥ 8) and")
+	fmt.Println("watch atomic.Int64 slow down as goroutines grow while Adder stays flat.")
+}
@@ -1,9 +1,15 @@
 package syncpackage
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
+	"os"
 	"sync"
 	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/event"
+	"github.com/mintecr7/concurrency-with-go/pkg/progress"
 )
 
 // ============================================================================
@@ -209,9 +215,12 @@ func waitGroupWithResults() {
 // 6. WAITGROUP AS STRUCT FIELD
 // ============================================================================
 
-// Service demonstrates WaitGroup as a struct field
+// Service demonstrates WaitGroup as a struct field. Workers start right
+// away but don't touch any work until the service is ready: the ready event
+// is the "system is ready" gate.
 type Service struct {
 	wg     sync.WaitGroup
+	ready  event.ManualReset
 	active bool
 }
 
@@ -225,11 +234,15 @@ func (s *Service) Start() {
 		go s.worker(i)
 	}
 
-	fmt.Println("Service started with 3 workers")
+	fmt.Println("Service started with 3 workers, warming up...")
+	time.Sleep(30 * time.Millisecond) // load config, open connections, ...
+	fmt.Println("Service ready")
+	s.ready.Set() // releases every worker at once
 }
 
 func (s *Service) worker(id int) {
 	defer s.wg.Done()
+	s.ready.Wait(context.Background())
 	fmt.Printf("Worker %d started\n", id)
 	time.Sleep(100 * time.Millisecond)
 	fmt.Printf("Worker %d finished\n", id)
@@ -238,7 +251,8 @@ func (s *Service) worker(id int) {
 func (s *Service) Stop() {
 	fmt.Println("Stopping service...")
 	s.active = false
-	s.wg.Wait() // Wait for all workers to finish
+	s.ready.Reset() // no longer ready: anything new would wait again
+	s.wg.Wait()     // Wait for all workers to finish
 	fmt.Println("Service stopped gracefully")
 }
 
@@ -383,35 +397,40 @@ func bestPractices() {
 func realWorldExample() {
 	fmt.Println("\n=== Real-World: Parallel File Processing ===")
 
-	// Simulate processing multiple files in parallel
-	files := []string{
-		"document1.txt", "document2.txt", "document3.txt",
-		"document4.txt", "document5.txt",
-	}
-
-	processFile := func(wg *sync.WaitGroup, filename string) {
-		defer wg.Done()
-
-		fmt.Printf("Processing %s...\n", filename)
-		time.Sleep(50 * time.Millisecond) // Simulate work
-		fmt.Printf("✓ Completed %s\n", filename)
+	// Simulate processing 40 files of different sizes in parallel
+	files := make([]time.Duration, 40)
+	var sequential time.Duration
+	for i := range files {
+		files[i] = time.Duration(20+rand.IntN(180)) * time.Millisecond
+		sequential += files[i]
 	}
 
-	var wg sync.WaitGroup
-
+	// One goroutine per file, but no Printf per file: with 40 (or 40,000)
+	// files that is a storm of interleaved lines. Each goroutine bumps an
+	// atomic counter; one reporter goroutine prints a line now and then.
 	fmt.Println("Starting parallel file processing...")
 	start := time.Now()
+	bar := progress.Start(context.Background(), progress.Config{
+		Total: int64(len(files)),
+		Unit:  "files",
+		Every: 50 * time.Millisecond,
+		Out:   os.Stdout,
+	})
 
-	wg.Add(len(files))
-	for _, file := range files {
-		go processFile(&wg, file)
+	var wg sync.WaitGroup
+	for _, cost := range files {
+		wg.Go(func() {
+			time.Sleep(cost) // Simulate work
+			bar.Add(1)
+		})
 	}
 
 	wg.Wait()
+	bar.Stop() // waits for the final line
 	elapsed := time.Since(start)
 
-	fmt.Printf("\nAll files processed in %v\n", elapsed)
-	fmt.Println("(Sequential would take ~250ms, parallel took ~50ms)")
+	fmt.Printf("\nAll files processed in %v\n", elapsed.Round(time.Millisecond))
+	fmt.Printf("(Sequential would take ~%v: the slowest file sets the pace)\n", sequential.Round(time.Millisecond))
 }
 
 // ============================================================================
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/yield"
+)
+
+// ============================================================================
+// 8d. COOPERATIVE YIELDING: HOW OFTEN SHOULD A HOT LOOP STEP ASIDE?
+// ============================================================================
+// Preemption (8c) bounds how long a loop can hog a P: ~10ms, or forever
+// with asyncpreemptoff=1. For a goroutine that wants to run every
+// millisecond, 10ms is still a stall. The loop can do better by itself:
+// runtime.Gosched every so often hands the P to whoever is waiting. How
+// often is the trade-off - every call costs a trip through the scheduler.
+//
+// The experiment, in a child process with GOMAXPROCS=1: two goroutines
+// crunch 100,000 steps of ~1µs each, calling yield.Every(n).Tick() after
+// every step, while a third sleeps 1ms at a time and records how late each
+// wake-up is. n goes from "never" to "every step", once with async
+// preemption and once without.
+//
+// Tick inlines to a decrement and a compare, and spin is a leaf the
+// compiler gives no stack check, so the hog loop contains no function
+// call: without async preemption and without yields, nothing can stop it.
+// ============================================================================
+
+func init() { childWorkloads["yielding"] = yieldingWorkload }
+
+// yieldEvery are the yield intervals compared, in ~1µs steps. 0 is never.
+var yieldEvery = []int{0, 10_000, 1_000, 100, 10, 1}
+
+const (
+	yieldHogs  = 2
+	yieldSteps = 100_000 // per hog
+)
+
+// yieldRun is one configuration's outcome.
+type yieldRun struct {
+	every              int
+	elapsed            time.Duration // until both hogs finished
+	yields             int64
+	wakeups            int
+	p50, p99, worstLat time.Duration // how late the sleeper woke up
+}
+
+// runYieldHogs runs the hogs next to the sleeper, yielding every n steps of
+// step spin iterations each.
+func runYieldHogs(step, every int) yieldRun {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	started := make(chan struct{})
+	var lates []time.Duration
+	go func() {
+		defer close(done)
+		close(started)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			before := time.Now()
+			time.Sleep(time.Millisecond)
+			lates = append(lates, time.Since(before)-time.Millisecond)
+		}
+	}()
+	<-started // the sleeper is running before the spinning starts
+
+	var yields atomic.Int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range yieldHogs {
+		wg.Go(func() {
+			y := yield.Every(every)
+			for range yieldSteps {
+				spin(step)
+				y.Tick()
+			}
+			yields.Add(y.Yields())
+		})
+	}
+	wg.Wait()
+	r := yieldRun{every: every, elapsed: time.Since(start), yields: yields.Load()}
+	close(stop)
+	<-done
+
+	slices.Sort(lates)
+	if n := len(lates); n > 0 {
+		r.wakeups = n
+		r.p50, r.p99, r.worstLat = lates[n/2], lates[n*99/100], lates[n-1]
+	}
+	return r
+}
+
+// yieldingWorkload is what the child runs: every interval in turn, one
+// result line each.
+func yieldingWorkload() {
+	runtime.GOMAXPROCS(1)
+
+	// Calibrate one step to about 1µs.
+	start := time.Now()
+	spin(10_000_000)
+	step := max(1, int(10_000_000*time.Microsecond/max(time.Since(start), time.Microsecond)))
+
+	for _, every := range yieldEvery {
+		r := runYieldHogs(step, every)
+		fmt.Printf("every=%d elapsed=%d yields=%d wakeups=%d p50=%d p99=%d worst=%d\n",
+			r.every, r.elapsed, r.yields, r.wakeups, r.p50, r.p99, r.worstLat)
+	}
+}
+
+func runYieldingChild(ctx context.Context, env ...string) ([]yieldRun, error) {
+	cmd, err := childCommand(ctx, "yielding", env...)
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("child: %w: %s", err, stderr.Bytes())
+	}
+	var runs []yieldRun
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		var r yieldRun
+		var elapsed, p50, p99, worst int64
+		if _, err := fmt.Sscanf(sc.Text(), "every=%d elapsed=%d yields=%d wakeups=%d p50=%d p99=%d worst=%d",
+			&r.every, &elapsed, &r.yields, &r.wakeups, &p50, &p99, &worst); err != nil {
+			return nil, fmt.Errorf("unexpected child output %q: %w", sc.Text(), err)
+		}
+		r.elapsed, r.p50, r.p99, r.worstLat = time.Duration(elapsed), time.Duration(p50), time.Duration(p99), time.Duration(worst)
+		runs = append(runs, r)
+	}
+	if len(runs) != len(yieldEvery) {
+		return nil, fmt.Errorf("child reported %d runs, want %d", len(runs), len(yieldEvery))
+	}
+	return runs, nil
+}
+
+// costPct is how much longer r's hogs took than base's, in whole percent.
+func costPct(r, base yieldRun) float64 {
+	return math.Round(100*(float64(r.elapsed)/float64(base.elapsed)-1)) + 0 // + 0 turns -0 into 0
+}
+
+func yieldingDemo() {
+	fmt.Println("\n=== Cooperative Yielding: yield.Every(n) in a Hot Loop ===")
+	fmt.Printf("%d hogs × %d steps of ~1µs, and a 1ms sleeper, on one P\n", yieldHogs, yieldSteps)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	godebug := func(setting string) string {
+		if cur := os.Getenv("GODEBUG"); cur != "" {
+			return "GODEBUG=" + cur + "," + setting
+		}
+		return "GODEBUG=" + setting
+	}
+	modes := []struct {
+		name string
+		env  string
+	}{
+		{"async preemption (Go 1.14+)", godebug("asyncpreemptoff=0")},
+		{"cooperative only (asyncpreemptoff=1)", godebug("asyncpreemptoff=1")},
+	}
+
+	results := make([][]yieldRun, len(modes))
+	for i, m := range modes {
+		runs, err := runYieldingChild(ctx, m.env)
+		if err != nil {
+			fmt.Println("yielding experiment failed:", err)
+			return
+		}
+		results[i] = runs
+
+		fmt.Printf("\n%s:\n", m.name)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+		fmt.Fprintln(tw, "  yield every\thogs took\tcost\tyields\twake-ups\tp50 late\tp99 late\tworst late")
+		for _, r := range runs {
+			every := "never"
+			if r.every > 0 {
+				every = fmt.Sprintf("%d steps (~%v)", r.every, time.Duration(r.every)*time.Microsecond)
+			}
+			fmt.Fprintf(tw, "  %s\t%v\t%+.0f%%\t%d\t%d\t%v\t%v\t%v\n", every,
+				r.elapsed.Round(time.Millisecond), costPct(r, runs[0]), r.yields, r.wakeups,
+				r.p50.Round(10*time.Microsecond), r.p99.Round(10*time.Microsecond), r.worstLat.Round(10*time.Microsecond))
+		}
+		tw.Flush()
+	}
+
+	async, coop := results[0], results[1]
+	fmt.Printf("\nNever yielding, the sleeper waited up to %v with async preemption and\n", async[0].worstLat.Round(time.Millisecond))
+	fmt.Printf("up to %v without it - until a hog finished. Yielding every ~100µs\n", coop[0].worstLat.Round(time.Millisecond))
+	fmt.Printf("brought the p99 to %v, for %+.0f%% on the hogs; yielding every step cost %+.0f%%.\n",
+		async[3].p99.Round(10*time.Microsecond), costPct(async[3], async[0]), costPct(async[5], async[0]))
+	fmt.Println("→ Yield about as often as the others can afford to wait, no more: the")
+	fmt.Println("  latency gain flattens out while the cost of Gosched keeps growing")
+	fmt.Println("→ Yielding only helps goroutines on the same P. With spare Ps - and")
+	fmt.Println("  Go's default is one per core - they run elsewhere and never wait")
+}
@@ -0,0 +1,231 @@
+// Package boundedparallelism shows conc.ForEach, conc.MapSlice and
+// conc.Walk: parallel loops and recursion with a concurrency limit, ordered
+// results and early exit on error.
+package boundedparallelism
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// ============================================================================
+// 1. ORDER IS PRESERVED, NO MATTER WHO FINISHES FIRST
+// ============================================================================
+
+func orderedResults() {
+	fmt.Println("\n=== 1. MapSlice: Results in Input Order ===")
+
+	words := []string{"fan", "out", "and", "fan", "in", "without", "losing", "order"}
+	var finished []string
+	finishedOrder := make(chan string, len(words))
+
+	lengths, err := conc.MapSlice(context.Background(), words, 3, func(_ context.Context, w string) (int, error) {
+		time.Sleep(rand.N(20 * time.Millisecond)) // finish in random order
+		finishedOrder <- w
+		return len(w), nil
+	})
+	close(finishedOrder)
+	for w := range finishedOrder {
+		finished = append(finished, w)
+	}
+
+	fmt.Println("Finished in:", finished)
+	fmt.Println("Input:      ", words)
+	fmt.Println("Lengths:    ", lengths, "err:", err)
+	fmt.Println("→ out[i] always belongs to items[i]")
+}
+
+// ============================================================================
+// 2. THE LIMIT IS A HARD CAP
+// ============================================================================
+
+func limitEnforced() {
+	fmt.Println("\n=== 2. ForEach: Concurrency Limit ===")
+
+	items := make([]int, 50)
+	for _, limit := range []int{1, 4, 16, 0} {
+		var inFlight, peak atomic.Int64
+		start := time.Now()
+		err := conc.ForEach(context.Background(), items, limit, func(context.Context, int) error {
+			storeMax(&peak, inFlight.Add(1))
+			time.Sleep(5 * time.Millisecond)
+			inFlight.Add(-1)
+			return nil
+		})
+		label := fmt.Sprint(limit)
+		if limit == 0 {
+			label = "none"
+		}
+		fmt.Printf("limit %-4s → peak in flight %2d, took %v (err: %v)\n",
+			label, peak.Load(), time.Since(start).Round(time.Millisecond), err)
+	}
+	fmt.Println("→ 50 tasks × 5ms: time ≈ 50/limit × 5ms, and the peak never exceeds the limit")
+}
+
+// storeMax raises v to n if n is larger.
+func storeMax(v *atomic.Int64, n int64) {
+	for old := v.Load(); n > old; old = v.Load() {
+		if v.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+// ============================================================================
+// 3. THE FIRST ERROR STOPS THE LOOP
+// ============================================================================
+
+func errorShortCircuit() {
+	fmt.Println("\n=== 3. ForEach: First Error Short-Circuits ===")
+
+	errBadItem := errors.New("item 10 is bad")
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+
+	var started, cancelled atomic.Int64
+	err := conc.ForEach(context.Background(), items, 4, func(ctx context.Context, i int) error {
+		started.Add(1)
+		if i == 10 {
+			return errBadItem
+		}
+		if err := timeutil.Sleep(ctx, 20*time.Millisecond); err != nil { // "work"
+			cancelled.Add(1) // a sibling failed: stop early
+			return err
+		}
+		return nil
+	})
+
+	fmt.Printf("Returned:  %v (is errBadItem: %v)\n", err, errors.Is(err, errBadItem))
+	fmt.Printf("Started:   %d of %d items\n", started.Load(), len(items))
+	fmt.Printf("Cancelled: %d in-flight calls saw ctx.Done() and stopped early\n", cancelled.Load())
+	fmt.Println("→ the error that caused the cancellation is returned, not context.Canceled")
+}
+
+// ============================================================================
+// 4. CANCELLING THE PARENT CONTEXT
+// ============================================================================
+
+func parentCancellation() {
+	fmt.Println("\n=== 4. ForEach: Parent Context Deadline ===")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var done atomic.Int64
+	err := conc.ForEach(ctx, make([]int, 100), 2, func(ctx context.Context, _ int) error {
+		if timeutil.Sleep(ctx, 10*time.Millisecond) != nil {
+			return nil // not a failure of this item: ForEach reports ctx's error
+		}
+		done.Add(1)
+		return nil
+	})
+	fmt.Printf("Completed %d of 100 items before the deadline, err: %v\n", done.Load(), err)
+}
+
+// ============================================================================
+// 5. RECURSION UNDER A LIMIT: THE DEADLOCK AND conc.Walk
+// ============================================================================
+// A tree of 364 nodes (depth 5, 3 children each); visiting a node takes a
+// millisecond. The naive version holds its semaphore slot while it waits
+// for its children.
+
+type treeNode struct{ id, depth int }
+
+func (n treeNode) children() []treeNode {
+	if n.depth == 5 {
+		return nil
+	}
+	kids := make([]treeNode, 3)
+	for i := range kids {
+		kids[i] = treeNode{id: n.id*3 + i + 1, depth: n.depth + 1}
+	}
+	return kids
+}
+
+// naiveVisit is the textbook recursive walk with a semaphore. It gives up
+// when ctx is done, so the demo can unwind it.
+func naiveVisit(ctx context.Context, n treeNode, sem chan struct{}, visited *atomic.Int64) {
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { <-sem }()
+	time.Sleep(time.Millisecond)
+	visited.Add(1)
+	var wg sync.WaitGroup
+	for _, c := range n.children() {
+		wg.Go(func() { naiveVisit(ctx, c, sem, visited) })
+	}
+	wg.Wait() // holding our slot
+}
+
+func recursiveWalk() {
+	fmt.Println("\n=== 5. Recursive Work: Holding a Slot While Waiting Deadlocks ===")
+	root := treeNode{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	var visited atomic.Int64
+	sem := make(chan struct{}, 4)
+	start := time.Now()
+	naiveVisit(ctx, root, sem, &visited)
+	cancel()
+	fmt.Printf("naive, limit 4: visited %d of 364, gave up after %v (%v)\n",
+		visited.Load(), time.Since(start).Round(time.Millisecond), ctx.Err())
+
+	var inFlight, peak atomic.Int64
+	start = time.Now()
+	ids, err := conc.Walk(context.Background(), []treeNode{root}, 4, func(_ context.Context, n treeNode) (int, []treeNode, error) {
+		storeMax(&peak, inFlight.Add(1))
+		defer inFlight.Add(-1)
+		time.Sleep(time.Millisecond)
+		return n.id, n.children(), nil
+	})
+	fmt.Printf("conc.Walk, limit 4: visited %d of 364 in %v, peak in flight %d (err: %v)\n",
+		len(ids), time.Since(start).Round(time.Millisecond), peak.Load(), err)
+
+	ids, err = conc.Walk(context.Background(), []treeNode{root}, 4, func(_ context.Context, n treeNode) (int, []treeNode, error) {
+		if n.depth == 2 && n.id%2 == 0 {
+			return 0, nil, fmt.Errorf("node %d: unreadable", n.id)
+		}
+		return n.id, n.children(), nil
+	})
+	joined := err.(interface{ Unwrap() []error }).Unwrap()
+	fmt.Printf("even nodes at depth 2 unreadable: %d results, %d errors joined (first: %v)\n",
+		len(ids), len(joined), joined[0])
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	ids, err = conc.Walk(ctx, []treeNode{root}, 4, func(ctx context.Context, n treeNode) (int, []treeNode, error) {
+		if err := timeutil.Sleep(ctx, time.Millisecond); err != nil {
+			return 0, nil, err
+		}
+		return n.id, n.children(), nil
+	})
+	fmt.Printf("20ms deadline: %d results, err: %v\n", len(ids), err)
+	fmt.Println("→ the naive walk fills all 4 slots with parents waiting for children;")
+	fmt.Println("  Walk never waits while holding a slot: visits return their children")
+}
+
+// BoundedParallelismDemo runs the ForEach / MapSlice / Walk examples.
+func BoundedParallelismDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║    BOUNDED PARALLELISM: conc.ForEach / MapSlice / Walk     ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	orderedResults()
+	limitEnforced()
+	errorShortCircuit()
+	parentCancellation()
+	recursiveWalk()
+}
@@ -0,0 +1,218 @@
+package contextpackage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/ctxtree"
+)
+
+// ============================================================================
+// CONTEXT CANCELLATION TREES - COMPLETE GUIDE
+// ============================================================================
+// Every context derived with WithCancel/WithTimeout/WithDeadline becomes a
+// CHILD of the context it was derived from. Together they form a tree:
+//
+//                      root
+//                    /      \
+//            service-A      service-B
+//             /    \          /    \
+//      worker-A1 worker-A2 worker-B1 worker-B2
+//
+// RULES:
+// - Cancelling a node cancels EVERY descendant (the whole subtree)
+// - Cancelling a node NEVER affects its parent or its siblings
+// - A goroutine only stops if it actually watches ctx.Done()
+//
+// The tree is invisible in the standard library (a context only knows its
+// parent), so this demo uses the ctxtree helper to print it.
+// ============================================================================
+
+// treeGoroutine is one goroutine in the demo tree, bound to its own context.
+type treeGoroutine struct {
+	name   string
+	cancel context.CancelCauseFunc
+	done   chan struct{} // closed when the goroutine has returned
+}
+
+// cancellationTree is the three-level tree of goroutines used by the demo.
+type cancellationTree struct {
+	root  context.Context
+	nodes map[string]*treeGoroutine
+	order []string // names in creation order, for stable output
+}
+
+// spawnTree builds root -> 2 services -> 2 workers each, starting one
+// goroutine per node. Each goroutine blocks until its context is cancelled.
+func spawnTree() *cancellationTree {
+	t := &cancellationTree{nodes: make(map[string]*treeGoroutine)}
+
+	var spawn func(parent context.Context, name string) context.Context
+	spawn = func(parent context.Context, name string) context.Context {
+		ctx, cancel := ctxtree.WithCancelCause(parent, name)
+		g := &treeGoroutine{name: name, cancel: cancel, done: make(chan struct{})}
+		t.nodes[name] = g
+		t.order = append(t.order, name)
+
+		go func() {
+			defer close(g.done)
+			<-ctx.Done() // a real worker would select on this alongside its work
+		}()
+		return ctx
+	}
+
+	t.root = spawn(context.Background(), "root")
+	for _, svc := range []string{"A", "B"} {
+		svcCtx := spawn(t.root, "service-"+svc)
+		for i := 1; i <= 2; i++ {
+			spawn(svcCtx, fmt.Sprintf("worker-%s%d", svc, i))
+		}
+	}
+	return t
+}
+
+// cancelAndWait cancels the named node and waits for every goroutine in its
+// subtree to exit. Names in the subtree all start with the node's suffix,
+// e.g. "service-B" owns "worker-B1" and "worker-B2".
+func (t *cancellationTree) cancelAndWait(name string) {
+	g := t.nodes[name]
+	g.cancel(errors.New(name + " was cancelled"))
+
+	for _, other := range t.order {
+		if t.inSubtree(name, other) {
+			<-t.nodes[other].done
+		}
+	}
+}
+
+// inSubtree reports whether node belongs to the subtree rooted at top.
+func (t *cancellationTree) inSubtree(top, node string) bool {
+	switch {
+	case top == "root", top == node:
+		return true
+	case strings.HasPrefix(top, "service-"):
+		return strings.HasPrefix(node, "worker-"+strings.TrimPrefix(top, "service-"))
+	}
+	return false
+}
+
+// stopped returns the names of goroutines that have exited.
+func (t *cancellationTree) stopped() []string {
+	var names []string
+	for _, name := range t.order {
+		select {
+		case <-t.nodes[name].done:
+			names = append(names, name)
+		default:
+		}
+	}
+	return names
+}
+
+func (t *cancellationTree) show() {
+	fmt.Print(indent(ctxtree.Dump(t.root), "  "))
+	stopped := t.stopped()
+	fmt.Printf("  Goroutines stopped: %d/%d %v\n", len(stopped), len(t.order), stopped)
+}
+
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(strings.TrimSuffix(s, "\n"), "\n")
+	return prefix + strings.Join(lines, prefix) + "\n"
+}
+
+// ============================================================================
+// 1. CANCELLING A LEAF
+// ============================================================================
+
+func cancelLeaf() {
+	fmt.Println("\n=== 1. Cancel a Leaf (worker-A1) ===")
+
+	tree := spawnTree()
+	defer tree.cancelAndWait("root")
+
+	tree.cancelAndWait("worker-A1")
+	tree.show()
+
+	fmt.Println("→ Only the leaf stops. Its parent and siblings keep running.")
+}
+
+// ============================================================================
+// 2. CANCELLING A MIDDLE NODE
+// ============================================================================
+
+func cancelSubtree() {
+	fmt.Println("\n=== 2. Cancel a Middle Node (service-B) ===")
+
+	tree := spawnTree()
+	defer tree.cancelAndWait("root")
+
+	tree.cancelAndWait("service-B")
+	tree.show()
+
+	fmt.Println("→ service-B AND both of its workers stop.")
+	fmt.Println("→ The workers report service-B's cause: cancellation flows DOWN.")
+}
+
+// ============================================================================
+// 3. CANCELLING THE ROOT
+// ============================================================================
+
+func cancelRoot() {
+	fmt.Println("\n=== 3. Cancel the Root ===")
+
+	tree := spawnTree()
+
+	start := time.Now()
+	tree.cancelAndWait("root")
+	fmt.Printf("  All goroutines exited %v after cancel\n", time.Since(start).Round(time.Microsecond))
+	tree.show()
+
+	fmt.Println("→ One cancel call tears down the entire tree.")
+}
+
+// ============================================================================
+// 4. CANCELLATION NEVER FLOWS UP
+// ============================================================================
+
+func cancellationDirection() {
+	fmt.Println("\n=== 4. Cancellation Never Flows Up ===")
+
+	tree := spawnTree()
+	defer tree.cancelAndWait("root")
+
+	tree.cancelAndWait("worker-A1")
+	tree.cancelAndWait("worker-A2")
+	tree.show()
+
+	fmt.Println("→ Every child of service-A is gone, but service-A itself is still running.")
+	fmt.Println("→ A parent must decide for itself when to stop; children can't cancel it.")
+}
+
+// ============================================================================
+// MAIN FUNCTION - RUN ALL EXAMPLES
+// ============================================================================
+
+func CancellationTreeDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║           CONTEXT CANCELLATION TREE GUIDE                  ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	cancelLeaf()
+	cancelSubtree()
+	cancelRoot()
+	cancellationDirection()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Derived contexts form a tree                             ║")
+	fmt.Println("║ • Cancel flows DOWN to every descendant                    ║")
+	fmt.Println("║ • Cancel never flows UP or SIDEWAYS                        ║")
+	fmt.Println("║ • context.Cause tells children WHY they were stopped       ║")
+	fmt.Println("║ • Goroutines must watch ctx.Done() to actually stop        ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
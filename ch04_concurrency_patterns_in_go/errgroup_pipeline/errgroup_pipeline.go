@@ -0,0 +1,292 @@
+// Package errgrouppipeline composes conc.Group with pipeline stages: every
+// goroutine of every stage belongs to one group, so the first error anywhere
+// cancels the whole pipeline, every channel still closes, and Wait says
+// what went wrong.
+package errgrouppipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+)
+
+// ============================================================================
+// STAGES AS MEMBERS OF A GROUP
+// ============================================================================
+// The pipeline chapter wires stages with channels and closes each output
+// when its input is done. The error-handling chapter returns errors from
+// goroutines. Put together naively, a stage that fails simply returns - and
+// the stage upstream blocks forever on a send nobody will receive.
+//
+// With a Group the rules are:
+//
+//   - every goroutine (source, workers, sink) is started with g.Go and
+//     returns an error, or nil when its input is done
+//   - every send selects on the group's ctx, so a failure anywhere unblocks
+//     everyone upstream
+//   - a stage's output is closed by one more group goroutine, after all of
+//     the stage's workers have returned - on success and on failure alike,
+//     so the close still travels down and every range loop ends
+//   - g.Wait returns once all of them have, with the first error: the cause,
+//     not the context.Canceled the others returned because of it
+// ============================================================================
+
+// source sends items, one at a time, on the channel it returns, and closes
+// it when done or cancelled. sent counts the items it got out.
+func source[T any](ctx context.Context, g *conc.Group, items []T, sent *atomic.Int64) <-chan T {
+	out := make(chan T)
+	g.Go(func() error {
+		defer close(out)
+		for _, item := range items {
+			select {
+			case out <- item:
+				sent.Add(1)
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// stage starts workers goroutines that apply fn to the items from in and
+// send the results on the channel it returns. That channel is closed once
+// every worker has returned. A worker returns fn's first error, or ctx's
+// error once the pipeline is cancelled.
+func stage[In, Out any](ctx context.Context, g *conc.Group, workers int, in <-chan In, fn func(ctx context.Context, item In) (Out, error)) <-chan Out {
+	out := make(chan Out)
+	var running sync.WaitGroup
+	for range workers {
+		running.Add(1)
+		g.Go(func() error {
+			defer running.Done()
+			for item := range in { // ends when the stage upstream closes in
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				v, err := fn(ctx, item)
+				if err != nil {
+					return err
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		running.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// ============================================================================
+// THE PIPELINE: source → parse ×3 → enrich ×2 → sink
+// ============================================================================
+
+var errCorrupt = errors.New("corrupt record")
+
+func parse(_ context.Context, line string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(line, "rec-"))
+	if err != nil {
+		return 0, fmt.Errorf("parse %q: %w", line, errCorrupt)
+	}
+	return n, nil
+}
+
+func enrich(ctx context.Context, n int) (int, error) {
+	select {
+	case <-time.After(100 * time.Microsecond): // a lookup
+		return 2 * n, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// run is the outcome of one run of the pipeline.
+type run struct {
+	err    error
+	cause  error // context.Cause of the group's ctx after Wait
+	sent   int64 // records the source got out
+	sunk   int   // results that reached the sink
+	sum    int
+	closed bool // every channel between stages is closed
+	leaked int  // goroutines still alive after Wait
+}
+
+// runPipeline runs lines through the pipeline under ctx.
+func runPipeline(ctx context.Context, lines []string) run {
+	before := runtime.NumGoroutine()
+	var r run
+	var sent atomic.Int64
+
+	g, ctx := conc.NewGroup(ctx)
+	records := source(ctx, g, lines, &sent)
+	parsed := stage(ctx, g, 3, records, parse)
+	enriched := stage(ctx, g, 2, parsed, enrich)
+	g.Go(func() error { // the sink
+		for v := range enriched {
+			r.sum += v
+			r.sunk++
+		}
+		return nil
+	})
+
+	r.err = g.Wait()
+	r.cause = context.Cause(ctx)
+	r.sent = sent.Load()
+	r.closed = isClosed(records) && isClosed(parsed) && isClosed(enriched)
+	r.leaked = settleGoroutines(before) - before
+	return r
+}
+
+// isClosed reports whether ch is closed, discarding anything still in it.
+// It never blocks.
+func isClosed[T any](ch <-chan T) bool {
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return true
+			}
+		default:
+			return false
+		}
+	}
+}
+
+// settleGoroutines waits up to 100ms for the goroutine count to fall back
+// to want - a goroutine that has returned may not have been removed yet -
+// and returns the count.
+func settleGoroutines(want int) int {
+	n := runtime.NumGoroutine()
+	for deadline := time.Now().Add(100 * time.Millisecond); n > want && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		n = runtime.NumGoroutine()
+	}
+	return n
+}
+
+func lines(n, corruptAt int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = "rec-" + strconv.Itoa(i+1)
+	}
+	if corruptAt > 0 {
+		out[corruptAt-1] = "rec-??"
+	}
+	return out
+}
+
+func report(r run) {
+	fmt.Printf("sent %d, sunk %d, Wait: %v\n", r.sent, r.sunk, r.err)
+	fmt.Printf("every channel closed: %v, goroutines left: %d\n", r.closed, r.leaked)
+}
+
+// ============================================================================
+// WITHOUT THE GROUP: THE FAILING STAGE JUST RETURNS
+// ============================================================================
+
+// naive is the same pipeline with one worker per stage, a WaitGroup for the
+// sink and no shared context: parse returns on error and closes its output.
+// The sink finishes, Wait returns - and the source is left blocked on a
+// send. It returns the goroutines left behind, a func that releases them
+// and the error.
+func naive(in []string) (leaked int, release func(), err error) {
+	before := runtime.NumGoroutine()
+	records := make(chan string)
+	go func() {
+		defer close(records)
+		for _, line := range in {
+			records <- line // blocks forever once parse has gone
+		}
+	}()
+	parsed := make(chan int)
+	go func() {
+		defer close(parsed)
+		for line := range records {
+			n, perr := parse(context.Background(), line)
+			if perr != nil {
+				err = perr // read after the sink is done: parse closed parsed after it
+				return
+			}
+			parsed <- n
+		}
+	}()
+	var sink sync.WaitGroup
+	sink.Go(func() {
+		for range parsed {
+		}
+	})
+	sink.Wait()
+	// parse has closed parsed; only the source can still be running.
+	leaked = settleGoroutines(before) - before
+	return leaked, func() {
+		for range records { // receive the rest, so the source can finish
+		}
+	}, err
+}
+
+// ErrgroupPipelineDemo runs the pipeline to completion, with a corrupt record
+// in the middle and under a deadline, and compares it with the naive wiring.
+func ErrgroupPipelineDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║        PIPELINE STAGES IN AN ERROR GROUP                   ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	fmt.Println("source → parse ×3 → enrich ×2 → sink, 1000 records, one conc.Group")
+
+	fmt.Println("\n=== 1. Every record is good ===")
+	r := runPipeline(context.Background(), lines(1000, 0))
+	report(r)
+	fmt.Printf("sum %d (2 × 1 + ... + 2 × 1000 = %d)\n", r.sum, 1000*1001)
+
+	fmt.Println("\n=== 2. Record 100 is corrupt: parse fails mid-pipeline ===")
+	r = runPipeline(context.Background(), lines(1000, 100))
+	report(r)
+	fmt.Printf("the group's ctx was cancelled with cause: %v\n", r.cause)
+	fmt.Println("→ Wait returns the parse error, not the context.Canceled of the")
+	fmt.Println("  workers it stopped; the source gave up early, and every close")
+	fmt.Println("  still travelled down")
+
+	fmt.Println("\n=== 3. The caller's deadline expires mid-stream ===")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	r = runPipeline(ctx, lines(1000, 0))
+	cancel()
+	report(r)
+	fmt.Println("→ nothing failed, the caller gave up: what reached the sink by the")
+	fmt.Println("  deadline is kept, the rest is abandoned")
+
+	fmt.Println("\n=== 4. Without the group: parse just returns ===")
+	leaked, release, err := naive(lines(1000, 100))
+	fmt.Printf("err: %v\n", err)
+	fmt.Printf("goroutines left behind: %d\n", leaked)
+	fmt.Println("→ the sink finished and the error was seen, but the source is")
+	fmt.Println("  blocked on a send forever. Nothing told it to stop.")
+	release()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • One group for every goroutine of every stage             ║")
+	fmt.Println("║ • Every send selects on the group's ctx                    ║")
+	fmt.Println("║ • Close a stage's output after all its workers returned,   ║")
+	fmt.Println("║   on failure too: the close still travels down             ║")
+	fmt.Println("║ • Wait returns the first error - the cause, not the        ║")
+	fmt.Println("║   context.Canceled of everyone it stopped                  ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
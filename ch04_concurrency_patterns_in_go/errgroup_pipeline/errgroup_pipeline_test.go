@@ -0,0 +1,82 @@
+package errgrouppipeline
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+)
+
+func TestRunPipeline(t *testing.T) {
+	r := runPipeline(context.Background(), lines(1000, 0))
+	if r.err != nil || r.sent != 1000 || r.sunk != 1000 || r.sum != 1000*1001 {
+		t.Errorf("err %v, sent %d, sunk %d, sum %d; want nil, 1000, 1000, %d", r.err, r.sent, r.sunk, r.sum, 1000*1001)
+	}
+	if !r.closed || r.leaked != 0 {
+		t.Errorf("closed %v, leaked %d; want every channel closed, nothing left", r.closed, r.leaked)
+	}
+}
+
+// TestRunPipelineParseFails corrupts record 100: parse, the middle of the
+// pipeline, fails and the run must end with its error, not the Canceled of
+// the stages it stopped.
+func TestRunPipelineParseFails(t *testing.T) {
+	r := runPipeline(context.Background(), lines(1000, 100))
+	if !errors.Is(r.err, errCorrupt) || !errors.Is(r.cause, errCorrupt) {
+		t.Errorf("Wait = %v, cause %v; want both to be the parse error", r.err, r.cause)
+	}
+	if r.sent == 1000 {
+		t.Error("the source sent every record: the failure did not stop it")
+	}
+	if !r.closed || r.leaked != 0 {
+		t.Errorf("closed %v, leaked %d; want every channel closed, nothing left", r.closed, r.leaked)
+	}
+}
+
+// TestMiddleStageFails wires source → stage → failing stage → stage → sink
+// by hand. Whichever worker fails, every stage's output must be closed
+// once Wait returns, no goroutine may be left and Wait must return that
+// failure.
+func TestMiddleStageFails(t *testing.T) {
+	errStage := errors.New("stage failed")
+	before := runtime.NumGoroutine()
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	var sent atomic.Int64
+
+	g, ctx := conc.NewGroup(context.Background())
+	src := source(ctx, g, items, &sent)
+	first := stage(ctx, g, 2, src, func(_ context.Context, n int) (int, error) { return n + 1, nil })
+	middle := stage(ctx, g, 3, first, func(_ context.Context, n int) (int, error) {
+		if n == 50 {
+			return 0, errStage
+		}
+		return n, nil
+	})
+	last := stage(ctx, g, 2, middle, func(_ context.Context, n int) (int, error) { return 2 * n, nil })
+	g.Go(func() error {
+		for range last {
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != errStage {
+		t.Errorf("Wait = %v, want %v", err, errStage)
+	}
+	for name, ch := range map[string]<-chan int{"source": src, "first": first, "middle": middle, "last": last} {
+		if !isClosed(ch) {
+			t.Errorf("the %s stage's output is still open after Wait", name)
+		}
+	}
+	if n := settleGoroutines(before) - before; n != 0 {
+		t.Errorf("%d goroutines left after Wait", n)
+	}
+	if n := sent.Load(); n == int64(len(items)) {
+		t.Errorf("the source sent all %d items: the failure did not stop it", n)
+	}
+}
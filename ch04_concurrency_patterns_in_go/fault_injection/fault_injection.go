@@ -0,0 +1,244 @@
+// Package faultinjection runs retries and hedged requests against a backend
+// made unreliable on purpose with pkg/inject.
+package faultinjection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/inject"
+	"github.com/mintecr7/concurrency-with-go/pkg/stats"
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// ============================================================================
+// FAULT INJECTION: RESILIENCE PATTERNS AGAINST A MISBEHAVING BACKEND
+// ============================================================================
+// Retries and hedged requests only make sense against failures and slow
+// tails - which a simulated backend never has unless we add them. The
+// backend here is a trivial lookup behind an inject.Injector:
+//
+//	latency  5ms + [0, 5ms) jitter
+//	tail     1 call in 20 takes 100ms longer
+//	failures 1 call in 5 returns an error
+//
+// 1. RETRY turns a 20% failure rate into ~0.2% - at the price of latency.
+// 2. HEDGING sends a second request when the first is slow, and takes
+//    whichever answers first: the 100ms tail all but disappears.
+// ============================================================================
+
+// backendConfig is the misbehaviour every section runs against.
+var backendConfig = inject.Config{
+	Latency:   5 * time.Millisecond,
+	Jitter:    5 * time.Millisecond,
+	SlowRate:  0.05,
+	Slow:      100 * time.Millisecond,
+	ErrorRate: 0.2,
+}
+
+// lookupFunc is the backend's API: one call per key.
+type lookupFunc func(ctx context.Context, key string) (string, error)
+
+// newBackend returns the lookup behind a fresh injector.
+func newBackend(cfg inject.Config) (lookupFunc, *inject.Injector) {
+	in := inject.New(cfg)
+	lookup := inject.Func(in, func(_ context.Context, key string) (string, error) {
+		return "value-of-" + key, nil
+	})
+	return lookup, in
+}
+
+// result is the outcome of calling one client n times.
+type result struct {
+	name     string
+	ok       int
+	calls    int
+	backend  inject.Stats
+	latency  stats.Snapshot
+	attempts int // backend calls made by the client, including hedges
+}
+
+// measure calls client n times, sequentially, and records its latency.
+func measure(name string, n int, client func(ctx context.Context, key string, attempts *int) error, in *inject.Injector) result {
+	h := stats.NewLatencyHistogram()
+	r := result{name: name, calls: n}
+	for i := range n {
+		start := time.Now()
+		if client(context.Background(), fmt.Sprint("key-", i), &r.attempts) == nil {
+			r.ok++
+		}
+		h.ObserveDuration(time.Since(start))
+	}
+	r.latency = h.Snapshot()
+	r.backend = in.Stats()
+	return r
+}
+
+func printResults(results ...result) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "client\tsucceeded\tbackend calls\tp50\tp99\tmax\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d/%d\t%d\t%v\t%v\t%v\t\n", r.name, r.ok, r.calls, r.attempts,
+			r.latency.QuantileDuration(0.5).Round(100*time.Microsecond),
+			r.latency.QuantileDuration(0.99).Round(time.Millisecond),
+			time.Duration(r.latency.Max).Round(time.Millisecond))
+	}
+	tw.Flush()
+}
+
+// ============================================================================
+// 1. RETRY WITH EXPONENTIAL BACKOFF AND JITTER
+// ============================================================================
+// Retry only what can succeed next time (here: everything the injector
+// fails), wait a little longer after each failure, and randomize the wait
+// so a thousand clients that failed together don't retry together.
+// ============================================================================
+
+// retry calls fn up to attempts times, sleeping base, 2·base, 4·base... with
+// "full jitter" (a random fraction of that) between tries.
+func retry(ctx context.Context, attempts int, base time.Duration, fn func(context.Context) error) error {
+	var err error
+	for i := range attempts {
+		if err = fn(ctx); err == nil || !errors.Is(err, inject.ErrInjected) {
+			return err
+		}
+		if i < attempts-1 {
+			if err := timeutil.Sleep(ctx, rand.N(base<<i)); err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+func retries() {
+	fmt.Println("\n=== 1. Retry with Backoff ===")
+
+	const n = 200
+	lookup, in := newBackend(backendConfig)
+	once := measure("no retry", n, func(ctx context.Context, key string, attempts *int) error {
+		*attempts++
+		_, err := lookup(ctx, key)
+		return err
+	}, in)
+
+	lookup, in = newBackend(backendConfig)
+	retried := measure("up to 4 tries", n, func(ctx context.Context, key string, attempts *int) error {
+		return retry(ctx, 4, 2*time.Millisecond, func(ctx context.Context) error {
+			*attempts++
+			_, err := lookup(ctx, key)
+			return err
+		})
+	}, in)
+
+	printResults(once, retried)
+	fmt.Println("→ 0.2⁴ = 0.16% of keys fail four times in a row; every retry adds")
+	fmt.Println("  a backend round trip plus backoff to that request's latency")
+}
+
+// ============================================================================
+// 2. HEDGED REQUESTS
+// ============================================================================
+// If the first request has not answered by the time most requests have
+// (around p95), send a second one and take whichever comes back first. The
+// loser is cancelled. Cost: a few percent more backend calls. Benefit: a
+// request is only slow if BOTH copies hit the tail.
+// ============================================================================
+
+// hedged calls fn, and again after delay if the first call hasn't returned,
+// and returns the first success. Every copy is cancelled once one wins.
+func hedged(ctx context.Context, delay time.Duration, fn func(context.Context) error) error {
+	// Deferred calls run last-in first-out: cancel the loser, THEN wait for it.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan error, 2) // buffered: the loser never blocks
+	launch := func() { wg.Go(func() { results <- fn(ctx) }) }
+
+	launch()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	inFlight := 1
+	var err error
+	for inFlight > 0 {
+		select {
+		case <-timer.C:
+			launch()
+			inFlight++
+		case err = <-results:
+			if err == nil {
+				return nil // deferred cancel stops the other copy
+			}
+			inFlight--
+		}
+	}
+	return err
+}
+
+func hedging() {
+	fmt.Println("\n=== 2. Hedged Requests ===")
+
+	// Failures off: a fast error ends a request before any hedge is sent,
+	// which would only blur the picture. Errors are retry's job.
+	cfg := backendConfig
+	cfg.ErrorRate = 0
+
+	const n = 200
+	lookup, in := newBackend(cfg)
+	plain := measure("single request", n, func(ctx context.Context, key string, attempts *int) error {
+		*attempts++
+		_, err := lookup(ctx, key)
+		return err
+	}, in)
+
+	lookup, in = newBackend(cfg)
+	var mu sync.Mutex
+	hedge := measure("hedge after 15ms", n, func(ctx context.Context, key string, attempts *int) error {
+		return hedged(ctx, 15*time.Millisecond, func(ctx context.Context) error {
+			mu.Lock()
+			*attempts++
+			mu.Unlock()
+			_, err := lookup(ctx, key)
+			return err
+		})
+	}, in)
+
+	printResults(plain, hedge)
+	fmt.Printf("  backend cancelled %d hedge losers mid-delay (the injector honours ctx)\n", hedge.backend.Canceled)
+	fmt.Println("→ The p99 drops from the slow tail to about the hedge delay plus one")
+	fmt.Println("  normal call: a request is slow only if both copies are (0.25%)")
+}
+
+// FaultInjectionDemo runs both sections.
+func FaultInjectionDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║      FAULT INJECTION: RETRIES AND HEDGED REQUESTS          ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	fmt.Printf("Backend: %v + [0, %v) jitter, %.0f%% slow by %v, %.0f%% failures\n",
+		backendConfig.Latency, backendConfig.Jitter, backendConfig.SlowRate*100,
+		backendConfig.Slow, backendConfig.ErrorRate*100)
+
+	retries()
+	hedging()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Test resilience code against injected faults, not a      ║")
+	fmt.Println("║   backend that always answers in 5ms                       ║")
+	fmt.Println("║ • Retry: exponential backoff with jitter, retry only       ║")
+	fmt.Println("║   errors that can succeed next time                        ║")
+	fmt.Println("║ • Hedge at ~p95: a few % more calls, tail latency gone     ║")
+	fmt.Println("║ • Both need ctx everywhere, so losers and retries stop     ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
@@ -0,0 +1,277 @@
+// Package loadingcache shows pkg/loadingcache: deduplicated loads, TTL,
+// stale-while-revalidate and bounded refreshes, step by step against a
+// fake clock.
+package loadingcache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/loadingcache"
+)
+
+// ============================================================================
+// A FAKE CLOCK AND A BACKEND THE DEMO CONTROLS
+// ============================================================================
+// Expiry is timed with the fake clock, so "61 seconds later" is one call to
+// Advance and every step below comes out the same on every run. The
+// backend counts its calls, can be held until the demo releases it, and
+// can be told to fail. Background refreshes finish on their own time, so
+// the demo waits for Stats.Loading to drop to zero before looking.
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+type backend struct {
+	calls   atomic.Int64
+	version atomic.Int64 // bumped by the demo: "the data changed"
+	fail    atomic.Bool
+	gate    chan struct{} // if not nil, every load waits for it to be closed
+}
+
+func (b *backend) load(ctx context.Context, key string) (string, error) {
+	b.calls.Add(1)
+	if b.gate != nil {
+		select {
+		case <-b.gate:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if b.fail.Load() {
+		return "", fmt.Errorf("backend: %s unavailable", key)
+	}
+	return fmt.Sprintf("%s@v%d", key, b.version.Load()), nil
+}
+
+// hold makes loads wait until the returned func is called.
+func (b *backend) hold() (release func()) {
+	b.gate = make(chan struct{})
+	return func() { close(b.gate) }
+}
+
+func newCache(b *backend, clock *fakeClock, swr time.Duration, maxRefreshes int) *loadingcache.Cache[string, string] {
+	return loadingcache.New(loadingcache.Config[string, string]{
+		Load:                 b.load,
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: swr,
+		MaxRefreshes:         maxRefreshes,
+		Now:                  clock.Now,
+	})
+}
+
+// waitUntil polls cond for up to a second: for the goroutines the demo
+// starts to reach the point it wants.
+func waitUntil(cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Microsecond)
+	}
+}
+
+// settle waits for the loads and refreshes in flight to finish.
+func settle(c *loadingcache.Cache[string, string]) {
+	waitUntil(func() bool { return c.Stats().Loading == 0 })
+}
+
+// ============================================================================
+// 1. A STAMPEDE ON A COLD KEY
+// ============================================================================
+
+func stampede() {
+	fmt.Println("\n=== 1. Ten Gets for a key nobody has loaded yet ===")
+	b, clock := &backend{}, &fakeClock{}
+	c := newCache(b, clock, 0, 0)
+	defer c.Close()
+
+	release := b.hold()
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Go(func() { results[i], _ = c.Get(context.Background(), "home") })
+	}
+	waitUntil(func() bool { s := c.Stats(); return s.Misses+s.Shared == 10 })
+	release()
+	wg.Wait()
+	s := c.Stats()
+	fmt.Printf("  backend calls: %d; %d caller missed, %d waited for its load\n", b.calls.Load(), s.Misses, s.Shared)
+	fmt.Printf("  results: %q\n", results)
+}
+
+// ============================================================================
+// 2. TTL
+// ============================================================================
+
+func ttl() {
+	fmt.Println("\n=== 2. TTL 1m, no stale-while-revalidate ===")
+	b, clock := &backend{}, &fakeClock{}
+	c := newCache(b, clock, 0, 0)
+	defer c.Close()
+	ctx := context.Background()
+
+	v, _ := c.Get(ctx, "k")
+	b.version.Add(1)
+	clock.Advance(59 * time.Second)
+	v59, _ := c.Get(ctx, "k")
+	fmt.Printf("  at  0s: %q\n", v)
+	fmt.Printf("  at 59s: %q from the cache, though the backend moved on (backend calls: %d)\n", v59, b.calls.Load())
+	clock.Advance(2 * time.Second)
+	v61, _ := c.Get(ctx, "k")
+	fmt.Printf("  at 61s: expired, Get waited for a load: %q (backend calls: %d)\n", v61, b.calls.Load())
+}
+
+// ============================================================================
+// 3. STALE-WHILE-REVALIDATE
+// ============================================================================
+
+func staleWhileRevalidate() {
+	fmt.Println("\n=== 3. TTL 1m, stale-while-revalidate 30s ===")
+	b, clock := &backend{}, &fakeClock{}
+	c := newCache(b, clock, 30*time.Second, 0)
+	defer c.Close()
+	ctx := context.Background()
+
+	c.Get(ctx, "k")
+	b.version.Add(1)
+	clock.Advance(70 * time.Second)
+	release := b.hold()
+	v1, _ := c.Get(ctx, "k")
+	v2, _ := c.Get(ctx, "k")
+	s := c.Stats()
+	fmt.Printf("  at 70s, refresh held: two Gets return %q and %q at once\n", v1, v2)
+	fmt.Printf("  %d stale hits, %d background refresh for the two of them\n", s.StaleHits, s.Refreshes)
+	release()
+	settle(c)
+	v3, _ := c.Get(ctx, "k")
+	fmt.Printf("  after the refresh: %q, a fresh hit (%d hits)\n", v3, c.Stats().Hits)
+
+	b.gate = nil
+	clock.Advance(100 * time.Second)
+	b.version.Add(1)
+	v4, _ := c.Get(ctx, "k")
+	fmt.Printf("  past TTL+30s: too stale to serve, Get waits for a load: %q (%d misses)\n", v4, c.Stats().Misses)
+}
+
+// ============================================================================
+// 4. BOUNDED REFRESHES
+// ============================================================================
+
+func boundedRefreshes() {
+	fmt.Println("\n=== 4. 6 keys expire together, MaxRefreshes 2 ===")
+	b, clock := &backend{}, &fakeClock{}
+	c := newCache(b, clock, 30*time.Second, 2)
+	defer c.Close()
+	ctx := context.Background()
+
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	for _, k := range keys {
+		c.Get(ctx, k)
+	}
+	clock.Advance(70 * time.Second)
+	release := b.hold()
+	stale := 0
+	for _, k := range keys {
+		if v, _ := c.Get(ctx, k); v == k+"@v0" {
+			stale++
+		}
+	}
+	s := c.Stats()
+	fmt.Printf("  %d of 6 Gets answered at once with their stale value\n", stale)
+	fmt.Printf("  %d refreshes started, %d left for later: the backend sees 2 at a time\n", s.Refreshes, s.Skipped)
+	release()
+	for range 2 {
+		settle(c)
+		for _, k := range keys {
+			c.Get(ctx, k)
+		}
+	}
+	settle(c)
+	s = c.Stats()
+	fmt.Printf("  two more rounds of Gets: %d refreshes in all, %d backend calls\n", s.Refreshes, b.calls.Load())
+}
+
+// ============================================================================
+// 5. ERRORS AND CANCELLATION
+// ============================================================================
+
+func errorsAndCancellation() {
+	fmt.Println("\n=== 5. Errors are not cached; a caller that gives up ===")
+	b, clock := &backend{}, &fakeClock{}
+	c := newCache(b, clock, 30*time.Second, 0)
+	ctx := context.Background()
+
+	b.fail.Store(true)
+	_, err := c.Get(ctx, "k")
+	b.fail.Store(false)
+	v, err2 := c.Get(ctx, "k")
+	fmt.Printf("  first Get: %v\n", err)
+	fmt.Printf("  next Get: %q, %v - the error was not cached\n", v, err2)
+
+	clock.Advance(70 * time.Second)
+	b.fail.Store(true)
+	stale, _ := c.Get(ctx, "k")
+	settle(c)
+	again, _ := c.Get(ctx, "k")
+	settle(c)
+	fmt.Printf("  refresh failing: Gets still return %q and %q (%d errors so far)\n", stale, again, c.Stats().Errors)
+	b.fail.Store(false)
+
+	release := b.hold()
+	impatient, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	patient := make(chan string)
+	go func() {
+		v, _ := c.Get(ctx, "other")
+		patient <- v
+	}()
+	_, err = c.Get(impatient, "other")
+	release()
+	fmt.Printf("  the impatient caller: %v\n", err)
+	fmt.Printf("  the load still finishes, and answers the other caller: %q\n", <-patient)
+
+	c.Close()
+	_, err = c.Get(ctx, "k")
+	fmt.Printf("  after Close: %v\n", err)
+}
+
+// LoadingCacheDemo runs the loading cache through its cases.
+func LoadingCacheDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║      LOADING CACHE: DEDUP, TTL, STALE-WHILE-REVALIDATE     ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	stampede()
+	ttl()
+	staleWhileRevalidate()
+	boundedRefreshes()
+	errorsAndCancellation()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • One load per key in flight: the rest wait for its result ║")
+	fmt.Println("║ • Serve a recently expired value while one refresh runs:   ║")
+	fmt.Println("║   nobody waits at the TTL boundary                         ║")
+	fmt.Println("║ • Cap background refreshes, or expiry becomes a storm      ║")
+	fmt.Println("║ • Load with the cache's context, wait with the caller's    ║")
+	fmt.Println("║ • Inject the clock: TTL logic is then testable to the tick ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
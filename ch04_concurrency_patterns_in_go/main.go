@@ -0,0 +1,27 @@
+package main
+
+import (
+	// boundedparallelism "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/bounded_parallelism"
+	contextpackage "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/context_package"
+	// errgrouppipeline "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/errgroup_pipeline"
+	// faultinjection "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/fault_injection"
+	// gracefuldrain "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/graceful_drain"
+	// loadingcache "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/loading_cache"
+	// orderedresults "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/ordered_results"
+	// shardedworkers "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/sharded_workers"
+	// timebudget "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/time_budget"
+	// "github.com/mintecr7/concurrency-with-go/ch04_concurrency_patterns_in_go/timers"
+)
+
+func main() {
+	contextpackage.CancellationTreeDemo()
+	// boundedparallelism.BoundedParallelismDemo()
+	// timers.TimersDemo()
+	// faultinjection.FaultInjectionDemo()
+	// gracefuldrain.GracefulDrainDemo()
+	// shardedworkers.ShardedPoolDemo()
+	// orderedresults.OrderedResultsDemo()
+	// timebudget.TimeBudgetDemo()
+	// loadingcache.LoadingCacheDemo()
+	// errgrouppipeline.ErrgroupPipelineDemo()
+}
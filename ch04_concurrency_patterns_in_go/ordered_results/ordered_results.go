@@ -0,0 +1,180 @@
+// Package orderedresults shows aggregator: fanning numbered jobs out to
+// workers and getting the results back in order, with bounded memory, gaps
+// and cancellation.
+package orderedresults
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/aggregator"
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// ============================================================================
+// WORKERS THAT FINISH OUT OF ORDER
+// ============================================================================
+// Jobs 0..n-1 go out in order to a few workers; each takes a random time,
+// job `slow` takes much longer, and job `fail` fails. Results go to the
+// aggregator, failures are skipped unless skipFailures is false.
+
+type setup struct {
+	jobs, workers, window int
+	slow, fail            int64 // -1 = none
+	skipFailures          bool
+}
+
+// start runs the workers and returns the aggregator plus the order in which
+// results arrived. The aggregator is closed when the workers are done.
+func start(ctx context.Context, s setup) (*aggregator.Aggregator[int64], func() []int64) {
+	agg := aggregator.New[int64](s.window)
+	jobs := make(chan int64)
+	go func() {
+		defer close(jobs)
+		for seq := range int64(s.jobs) {
+			select {
+			case jobs <- seq:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var arrived []int64
+	var workers sync.WaitGroup
+	for range s.workers {
+		workers.Go(func() {
+			for seq := range jobs {
+				d := time.Duration(rand.IntN(2000)) * time.Microsecond
+				if seq == s.slow {
+					d = 30 * time.Millisecond
+				}
+				if timeutil.Sleep(ctx, d) != nil {
+					return // cancelled
+				}
+				var err error
+				if seq == s.fail {
+					if s.skipFailures {
+						err = agg.Skip(ctx, seq)
+					}
+				} else {
+					mu.Lock()
+					arrived = append(arrived, seq)
+					mu.Unlock()
+					err = agg.Put(ctx, seq, seq*seq)
+				}
+				if err != nil {
+					return // cancelled
+				}
+			}
+		})
+	}
+	go func() {
+		workers.Wait()
+		agg.Close()
+	}()
+	return agg, func() []int64 {
+		workers.Wait()
+		mu.Lock()
+		defer mu.Unlock()
+		return arrived
+	}
+}
+
+// collect reads results until ErrDone or another error, checking that
+// they come in order.
+func collect(ctx context.Context, agg *aggregator.Aggregator[int64], stopAfter int) (got []int64, err error) {
+	for stopAfter < 0 || len(got) < stopAfter {
+		v, err := agg.Next(ctx)
+		if err != nil {
+			return got, err
+		}
+		got = append(got, v)
+	}
+	return got, nil
+}
+
+// squares reports whether got is the squares of 0, 1, ... in order, leaving
+// out missing.
+func squares(got []int64, missing int64) bool {
+	want := int64(0)
+	for _, v := range got {
+		if want == missing {
+			want++
+		}
+		if v != want*want {
+			return false
+		}
+		want++
+	}
+	return true
+}
+
+// OrderedResultsDemo runs the aggregator through ordering, back-pressure,
+// gaps and cancellation.
+func OrderedResultsDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║      OUT-OF-ORDER RESULTS, EMITTED IN ORDER                ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	ctx := context.Background()
+
+	fmt.Println("\n=== 1. 8 workers, 40 jobs, window 16 ===")
+	agg, arrived := start(ctx, setup{jobs: 40, workers: 8, window: 16, slow: -1, fail: -1})
+	got, err := collect(ctx, agg, -1)
+	fmt.Printf("  arrived: %v ...\n", arrived()[:12])
+	fmt.Printf("  emitted: %v ...\n", got[:12])
+	fmt.Printf("  %d results, in sequence order: %v, then %v\n", len(got), squares(got, -1), err)
+
+	fmt.Println("\n=== 2. Job 5 is slow: the window bounds what piles up ===")
+	for _, window := range []int{8, 1000} {
+		agg, _ := start(ctx, setup{jobs: 200, workers: 8, window: window, slow: 5, fail: -1})
+		got, _ := collect(ctx, agg, -1)
+		st := agg.Stats()
+		fmt.Printf("  window %4d: at most %3d results held at once, in order: %v\n", window, st.MaxPending, squares(got, -1))
+	}
+	fmt.Println("→ with a small window the fast workers wait in Put instead of")
+	fmt.Println("  filling memory with results that can't be emitted yet")
+
+	fmt.Println("\n=== 3. Job 13 fails ===")
+	agg, _ = start(ctx, setup{jobs: 30, workers: 4, window: 8, slow: -1, fail: 13, skipFailures: true})
+	got, err = collect(ctx, agg, -1)
+	fmt.Printf("  worker calls Skip(13): %d results, in order with 13 passed over: %v, then %v\n",
+		len(got), squares(got, 13), err)
+	agg, _ = start(ctx, setup{jobs: 30, workers: 4, window: 32, slow: -1, fail: 13})
+	got, err = collect(ctx, agg, -1)
+	fmt.Printf("  no Skip: %d results, then %v\n", len(got), err)
+	fmt.Println("→ without Skip a failed job is a hole the consumer can't see past;")
+	fmt.Println("  Close turns the silent stall into an error")
+
+	fmt.Println("\n=== 4. The consumer stops after 10 results ===")
+	before := runtime.NumGoroutine()
+	cctx, cancel := context.WithCancel(ctx)
+	agg, arrived = start(cctx, setup{jobs: 1000, workers: 8, window: 8, slow: -1, fail: -1})
+	got, _ = collect(cctx, agg, 10)
+	cancel()
+	_, err = agg.Next(cctx)
+	done := len(arrived()) // waits for the workers
+	fmt.Printf("  %d results, then Next returns %v\n", len(got), err)
+	fmt.Printf("  the workers stopped after %d of 1000 jobs\n", done)
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	fmt.Printf("  goroutines: %d before, %d after\n", before, runtime.NumGoroutine())
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Tag results with their sequence number, re-order at the  ║")
+	fmt.Println("║   end: workers stay independent, output stays ordered      ║")
+	fmt.Println("║ • Bound the re-order buffer with a window: producers block ║")
+	fmt.Println("║ • A result that never comes must be skipped or reported    ║")
+	fmt.Println("║ • Every wait takes a context, so cancelling frees all      ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
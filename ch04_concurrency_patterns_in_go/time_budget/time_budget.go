@@ -0,0 +1,129 @@
+// Package timebudget shows conc.RunWithin: a batch of best-effort tasks
+// that gets a fixed amount of time, keeps what finished and cancels the rest.
+package timebudget
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// ============================================================================
+// A SEARCH THAT ASKS EIGHT BACKENDS
+// ============================================================================
+// Each backend answers after a fixed latency; "ads" fails, "archive" is far
+// too slow. The page is rendered with whatever answered within the budget.
+
+type backend struct {
+	name    string
+	latency time.Duration
+	fail    bool
+}
+
+var backends = []backend{
+	{"web", 8 * time.Millisecond, false},
+	{"images", 15 * time.Millisecond, false},
+	{"news", 25 * time.Millisecond, false},
+	{"ads", 5 * time.Millisecond, true},
+	{"maps", 35 * time.Millisecond, false},
+	{"videos", 60 * time.Millisecond, false},
+	{"shopping", 90 * time.Millisecond, false},
+	{"archive", 2 * time.Second, false},
+}
+
+// queries returns one task per backend. running counts the tasks that have
+// not returned yet.
+func queries(running *atomic.Int64) []func(context.Context) (string, error) {
+	tasks := make([]func(context.Context) (string, error), len(backends))
+	for i, b := range backends {
+		tasks[i] = func(ctx context.Context) (string, error) {
+			running.Add(1)
+			defer running.Add(-1)
+			if err := timeutil.Sleep(ctx, b.latency); err != nil {
+				return "", err
+			}
+			if b.fail {
+				return "", fmt.Errorf("%s: backend unavailable", b.name)
+			}
+			return b.name + " results", nil
+		}
+	}
+	return tasks
+}
+
+// waitFor polls cond for up to a second.
+func waitFor(cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TimeBudgetDemo runs the same batch under several budgets.
+func TimeBudgetDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║      TIME-BOXED BATCHES: conc.RunWithin                    ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	ctx := context.Background()
+
+	fmt.Println("\n=== 1. Eight backends, a 45ms budget ===")
+	var running atomic.Int64
+	start := time.Now()
+	report := conc.RunWithin(ctx, 45*time.Millisecond, queries(&running)...)
+	took := time.Since(start)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "  backend\tlatency\tfinished\tresult")
+	for i, o := range report.Outcomes {
+		result := fmt.Sprintf("%q", o.Value)
+		if o.Err != nil {
+			result = o.Err.Error()
+		}
+		fmt.Fprintf(tw, "  %s\t%v\t%v\t%s\n", backends[i].name, backends[i].latency, o.Finished, result)
+	}
+	tw.Flush()
+	fmt.Printf("  returned after %v: %d completed, %d failed, %d cancelled\n",
+		took.Round(time.Millisecond), report.Completed, report.Failed, report.Cancelled)
+	waitFor(func() bool { return running.Load() == 0 })
+	fmt.Printf("  tasks still running a moment later: %d (the slowest backend takes %v)\n",
+		running.Load(), backends[7].latency)
+	fmt.Printf("  page shows: %q\n", report.Values())
+
+	fmt.Println("\n=== 2. The budget decides how much of the page you get ===")
+	for _, budget := range []time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 100 * time.Millisecond} {
+		r := conc.RunWithin(ctx, budget, queries(&running)...)
+		fmt.Printf("  budget %-5v → %d completed, %d failed, %d cancelled\n", budget, r.Completed, r.Failed, r.Cancelled)
+	}
+	fmt.Println("→ latency is fixed by the budget; completeness is what varies")
+
+	fmt.Println("\n=== 3. The caller gives up first ===")
+	before := runtime.NumGoroutine()
+	cctx, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	r := conc.RunWithin(cctx, time.Second, queries(&running)...)
+	fmt.Printf("  parent cancelled after 20ms: %d completed, %d cancelled, archive's error: %v\n",
+		r.Completed, r.Cancelled, r.Outcomes[7].Err)
+	waitFor(func() bool { return runtime.NumGoroutine() <= before })
+	fmt.Printf("  goroutines: %d before, %d after\n", before, runtime.NumGoroutine())
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Waiting for all makes the slowest task the latency; a    ║")
+	fmt.Println("║   budget caps it and keeps whatever finished in time       ║")
+	fmt.Println("║ • Cancel the rest through their context, don't abandon it  ║")
+	fmt.Println("║ • A buffered result channel lets stragglers exit alone     ║")
+	fmt.Println("║ • Report completed / failed / cancelled separately: a      ║")
+	fmt.Println("║   timeout is not the task's fault                          ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
@@ -0,0 +1,330 @@
+// Package timers covers the ways time.Timer, time.Ticker and time.After go
+// wrong in concurrent code, and the patterns that avoid them.
+package timers
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// ============================================================================
+// TIMERS AND TICKERS - MANAGEMENT PATTERNS
+// ============================================================================
+// A timer is a runtime object plus a channel. Most bugs come from one of the
+// two outliving its usefulness:
+// - the TIMER: armed but nobody will ever read it (time.After in a loop)
+// - the GOROUTINE: blocked on t.C of a timer that will never fire (Stop)
+//
+// Go 1.23 changed timers in two ways this chapter relies on (the old
+// behaviour, GODEBUG=asynctimerchan=1, was removed in Go 1.27):
+// 1. Unreferenced timers and tickers are garbage collected even if they were
+//    never stopped or never fired.
+// 2. t.C is synchronous: after Stop or Reset returns, a receive can never
+//    yield a stale value from the previous arming.
+// ============================================================================
+
+// heapAfterGC returns the live heap after a full collection.
+func heapAfterGC() uint64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+// mallocs returns the number of heap allocations so far.
+func mallocs() uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.Mallocs
+}
+
+// ============================================================================
+// 1. time.After IN A LOOP
+// ============================================================================
+// The classic "per-message timeout":
+//
+//	for {
+//		select {
+//		case msg := <-in:      // usually ready immediately
+//		case <-time.After(time.Minute):
+//		}
+//	}
+//
+// Every iteration creates a one-minute timer that nobody stops. Before Go
+// 1.23 each one stayed alive until it fired: 100k messages a second meant
+// six million live timers. Today they are garbage as soon as the select
+// returns - but each iteration still ALLOCATES one. A single timer, Reset
+// per iteration, allocates nothing.
+// ============================================================================
+
+func afterInLoop() {
+	fmt.Println("\n=== 1. time.After in a Loop ===")
+
+	const iterations = 100_000
+	in := make(chan int, 1)
+
+	run := func(name string, loop func()) {
+		heapBefore := heapAfterGC()
+		m := mallocs()
+		start := time.Now()
+		loop()
+		elapsed := time.Since(start)
+		allocs := mallocs() - m
+		heapAfter := heapAfterGC()
+		live := heapAfter - min(heapBefore, heapAfter)
+		fmt.Printf("  %-28s %8v  %6.1f allocs/iter  %6d KB still live after GC\n",
+			name, elapsed.Round(time.Millisecond), float64(allocs)/iterations, live>>10)
+	}
+
+	run("time.After per iteration", func() {
+		for i := range iterations {
+			in <- i
+			select {
+			case <-in:
+			case <-time.After(time.Minute):
+			}
+		}
+	})
+
+	run("one timer, Reset per iter", func() {
+		t := time.NewTimer(time.Minute)
+		defer t.Stop()
+		for i := range iterations {
+			in <- i
+			t.Reset(time.Minute) // safe on an active timer since Go 1.23
+			select {
+			case <-in:
+			case <-t.C:
+			}
+		}
+	})
+	fmt.Println("→ No longer a leak (Go 1.23+), still an allocation per iteration")
+}
+
+// ============================================================================
+// 2. Stop DOES NOT CLOSE t.C
+// ============================================================================
+// Stop prevents the timer from firing. It does not wake anyone already
+// waiting on t.C - so a goroutine doing only `<-t.C` is now stuck forever.
+// This is how a timer leak became a GOROUTINE leak.
+// ============================================================================
+
+func stopDoesNotWake() {
+	fmt.Println("\n=== 2. Stop Does Not Wake Waiters ===")
+
+	baseline := runtime.NumGoroutine()
+	const waiters = 100
+
+	// WRONG: wait on the channel alone.
+	timers := make([]*time.Timer, waiters)
+	var woke atomic.Int64
+	var wg sync.WaitGroup
+	for i := range timers {
+		timers[i] = time.NewTimer(time.Hour)
+		t := timers[i]
+		wg.Go(func() {
+			<-t.C // only a fire can end this wait
+			woke.Add(1)
+		})
+	}
+	time.Sleep(10 * time.Millisecond)
+	for _, t := range timers {
+		t.Stop() // "cancel" the timeouts
+	}
+	time.Sleep(10 * time.Millisecond)
+	fmt.Printf("  <-t.C alone:               %d goroutines still blocked after Stop\n", runtime.NumGoroutine()-baseline)
+
+	// Clean up the demo's leak: firing the timers is the only way out.
+	for _, t := range timers {
+		t.Reset(0)
+	}
+	wg.Wait()
+
+	// RIGHT: wait on the timer AND a cancellation signal.
+	ctx, cancel := context.WithCancel(context.Background())
+	for range waiters {
+		wg.Go(func() {
+			timeutil.Sleep(ctx, time.Hour) // returns on cancel, stops its timer
+		})
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	wg.Wait()
+	fmt.Printf("  timeutil.Sleep + cancel(): %d goroutines still blocked\n", runtime.NumGoroutine()-baseline)
+	fmt.Println("→ Never wait on a timer alone; select on ctx.Done() (or a done channel) too")
+}
+
+// ============================================================================
+// 3. Stop AND Reset RETURN VALUES
+// ============================================================================
+// Before Go 1.23 a fired-but-unread timer kept its value buffered in t.C,
+// so resetting needed a drain:
+//
+//	if !t.Stop() { <-t.C }  // discard the stale value
+//	t.Reset(d)
+//
+// With synchronous channels there is nothing to drain, and the idiom turns
+// into a trap: Stop returns false only if the value was already RECEIVED,
+// and then `<-t.C` blocks forever.
+// ============================================================================
+
+func stopAndReset() {
+	fmt.Println("\n=== 3. Stop and Reset (Go 1.23+ semantics) ===")
+
+	// Fired, nobody received: Stop reports it stopped the delivery.
+	t := time.NewTimer(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	fmt.Printf("  fired but not received → Stop() = %v (nothing to drain)\n", t.Stop())
+
+	// No stale value after Reset.
+	t = time.NewTimer(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	t.Reset(30 * time.Millisecond)
+	start := time.Now()
+	<-t.C
+	fmt.Printf("  Reset(30ms) on a fired timer → next receive after %v (not a stale tick)\n",
+		time.Since(start).Round(time.Millisecond))
+
+	// Received: Stop returns false, and the old drain idiom would hang.
+	t = time.NewTimer(time.Millisecond)
+	<-t.C
+	stopped := t.Stop()
+	drained := false
+	select {
+	case <-t.C:
+		drained = true
+	case <-time.After(20 * time.Millisecond):
+	}
+	fmt.Printf("  already received → Stop() = %v; `<-t.C` got a value: %v (it would block forever)\n", stopped, drained)
+	fmt.Println("→ Just call Reset; drop the `if !t.Stop() { <-t.C }` dance in new code")
+}
+
+// ============================================================================
+// 4. TICKERS DROP TICKS FOR SLOW RECEIVERS
+// ============================================================================
+// A ticker's channel holds at most one tick. If the receiver is slower than
+// the period, ticks are DROPPED, not queued: a ticker paces work, it does not
+// count time.
+// ============================================================================
+
+func slowTicker() {
+	fmt.Println("\n=== 4. Tickers Drop Ticks ===")
+
+	const period, work, run = 2 * time.Millisecond, 10 * time.Millisecond, 200 * time.Millisecond
+	ticker := time.NewTicker(period)
+	defer ticker.Stop() // not required for GC since Go 1.23, still the clear way to say "done"
+
+	received := 0
+	deadline := time.After(run)
+loop:
+	for {
+		select {
+		case <-ticker.C:
+			received++
+			time.Sleep(work) // slower than the period
+		case <-deadline:
+			break loop
+		}
+	}
+	fmt.Printf("  %v ticker, %v of work per tick, %v: %d ticks received of %d elapsed\n",
+		period, work, run, received, run/period)
+	fmt.Println("→ Use the tick's time (or time.Since) for rates, never the tick count")
+}
+
+// ============================================================================
+// 5. CONTEXT-AWARE SLEEPING
+// ============================================================================
+// time.Sleep cannot be interrupted. A worker that sleeps between polls
+// notices shutdown only after its current sleep; with timeutil.Sleep it
+// notices at once.
+// ============================================================================
+
+func contextAwareSleep() {
+	fmt.Println("\n=== 5. Context-Aware Sleeping ===")
+
+	const pollEvery = 200 * time.Millisecond
+	shutdown := func(sleep func(ctx context.Context)) time.Duration {
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				sleep(ctx) // ...then poll something
+			}
+		})
+		time.Sleep(20 * time.Millisecond)
+		start := time.Now()
+		cancel()
+		wg.Wait()
+		return time.Since(start)
+	}
+
+	plain := shutdown(func(context.Context) { time.Sleep(pollEvery) })
+	aware := shutdown(func(ctx context.Context) { timeutil.Sleep(ctx, pollEvery) })
+	fmt.Printf("  time.Sleep:         worker stopped %v after cancel\n", plain.Round(time.Millisecond))
+	fmt.Printf("  timeutil.Sleep:  worker stopped %v after cancel\n", aware.Round(time.Millisecond))
+}
+
+// ============================================================================
+// 6. time.AfterFunc
+// ============================================================================
+// AfterFunc runs f in its OWN goroutine when the timer fires. Stop returns
+// false if f has already been started - it does not wait for f to finish.
+// ============================================================================
+
+func afterFunc() {
+	fmt.Println("\n=== 6. time.AfterFunc ===")
+
+	var ran atomic.Bool
+	early := time.AfterFunc(50*time.Millisecond, func() { ran.Store(true) })
+	fmt.Printf("  Stop before firing: Stop() = %v, f ran: %v\n", early.Stop(), ran.Load())
+
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	late := time.AfterFunc(time.Millisecond, func() {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		close(finished)
+	})
+	<-started
+	stopped := late.Stop()
+	select {
+	case <-finished:
+		fmt.Printf("  Stop while f runs:  Stop() = %v, and f had already finished\n", stopped)
+	default:
+		fmt.Printf("  Stop while f runs:  Stop() = %v, f is STILL running\n", stopped)
+	}
+	<-finished
+	fmt.Println("→ Stop()=false means \"too late\": wait for f yourself if you must")
+}
+
+func TimersDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║          TIMERS AND TICKERS - MANAGEMENT PATTERNS          ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	afterInLoop()
+	stopDoesNotWake()
+	stopAndReset()
+	slowTicker()
+	contextAwareSleep()
+	afterFunc()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Hot loop with a timeout: one Timer + Reset, not After    ║")
+	fmt.Println("║ • Stop never wakes a waiter: select on ctx.Done() too      ║")
+	fmt.Println("║ • Go 1.23+: Reset is safe, no drain; the old drain idiom   ║")
+	fmt.Println("║   can block forever                                        ║")
+	fmt.Println("║ • Tickers drop ticks for slow receivers                    ║")
+	fmt.Println("║ • Sleep with timeutil.Sleep so shutdown is immediate    ║")
+	fmt.Println("║ • AfterFunc.Stop doesn't wait for a running f              ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
@@ -0,0 +1,176 @@
+package classics
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// THE UNISEX BATHROOM (Little Book of Semaphores, 6.2)
+// ============================================================================
+// One bathroom, used by women and men:
+// - women and men are never inside at the same time
+// - at most `capacity` people are inside
+// - nobody starves: a steady stream of one group must not lock out the other
+//
+// The naive rule "enter if it's empty or full of your own kind" satisfies
+// the first two and fails the third: as long as men keep arriving before the
+// last man leaves, the women queue forever. The fix is to take TURNS: while
+// the other group is waiting, the group inside may admit at most one
+// roomful (a batch of `capacity`) before the turn passes to the others when
+// the room empties.
+//
+// PRIMITIVE FIT: sync.Cond. Whether you may enter depends on the gender
+// inside, how many are inside, and who is queued - and it changes on every
+// exit. A Cond lets each waiter re-check that whole predicate under one
+// mutex. The semaphore solution ("lightswitch" + turnstile) exists, but it
+// takes three semaphores to say what one for-loop says here.
+// ============================================================================
+
+// Gender selects which group a bathroom visitor belongs to.
+type Gender int
+
+const (
+	Women Gender = iota
+	Men
+)
+
+func (g Gender) String() string {
+	if g == Women {
+		return "women"
+	}
+	return "men"
+}
+
+func (g Gender) other() Gender { return 1 - g }
+
+// Bathroom admits one group at a time, up to a fixed capacity, and switches
+// groups fairly when both are waiting.
+type Bathroom struct {
+	mu        sync.Mutex
+	changed   *sync.Cond // broadcast on every exit
+	capacity  int
+	inside    int
+	occupants Gender // meaningful only while inside > 0
+	turn      Gender // whose batch is current; the other group goes next
+	batch     int    // admitted in the current turn
+	waiting   [2]int // queued visitors per group
+	violation bool   // set if the invariants are ever broken (self-check)
+}
+
+// NewBathroom creates a bathroom that holds at most capacity people.
+func NewBathroom(capacity int) *Bathroom {
+	if capacity < 1 {
+		panic("bathroom: capacity must be at least 1")
+	}
+	b := &Bathroom{capacity: capacity}
+	b.changed = sync.NewCond(&b.mu)
+	return b
+}
+
+// canEnter is the whole synchronization policy. Called with mu held.
+func (b *Bathroom) canEnter(g Gender) bool {
+	if b.inside == 0 {
+		// Empty room: it's our turn, or nobody from the other group wants in.
+		return b.turn == g || b.waiting[g.other()] == 0
+	}
+	if b.occupants != g || b.inside == b.capacity {
+		return false
+	}
+	// Our group is inside and there is room. If the other group is waiting,
+	// only finish the current batch - this is what prevents starvation.
+	return b.waiting[g.other()] == 0 || (b.turn == g && b.batch < b.capacity)
+}
+
+// Enter blocks until a member of group g may use the bathroom.
+func (b *Bathroom) Enter(g Gender) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.waiting[g]++
+	for !b.canEnter(g) {
+		b.changed.Wait()
+	}
+	b.waiting[g]--
+
+	if b.inside > 0 && b.occupants != g || b.inside == b.capacity {
+		b.violation = true
+	}
+	if b.inside == 0 || b.turn != g {
+		b.turn, b.batch = g, 0 // a new turn starts
+	}
+	b.occupants = g
+	b.inside++
+	b.batch++
+}
+
+// Exit leaves the bathroom. The last one out hands the turn to the other
+// group.
+func (b *Bathroom) Exit(g Gender) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inside--
+	if b.inside == 0 {
+		b.turn = g.other()
+	}
+	// Different waiters wait for different things (their own group, free
+	// space, an empty room) - Signal could wake the wrong one.
+	b.changed.Broadcast()
+}
+
+func bathroomDemo() {
+	fmt.Println("\n=== Unisex Bathroom (Cond with a fairness rule) ===")
+
+	const (
+		capacity   = 3
+		visitors   = 60 // per group
+		visitTime  = 2 * time.Millisecond
+		arrivalGap = 300 * time.Microsecond
+	)
+	b := NewBathroom(capacity)
+
+	var mu sync.Mutex
+	var longest [2]time.Duration
+	var served [2]int
+	maxInside := 0
+
+	visit := func(g Gender) {
+		start := time.Now()
+		b.Enter(g)
+		waited := time.Since(start)
+
+		b.mu.Lock()
+		maxInside = max(maxInside, b.inside)
+		b.mu.Unlock()
+
+		time.Sleep(visitTime)
+		b.Exit(g)
+
+		mu.Lock()
+		served[g]++
+		longest[g] = max(longest[g], waited)
+		mu.Unlock()
+	}
+
+	// Both groups arrive as steady streams, faster than the bathroom can
+	// serve them - exactly the load that starves one side under the naive
+	// rule.
+	var wg sync.WaitGroup
+	for range visitors {
+		for _, g := range []Gender{Men, Women} {
+			wg.Go(func() { visit(g) })
+		}
+		time.Sleep(rand.N(2 * arrivalGap))
+	}
+	wg.Wait()
+
+	for _, g := range []Gender{Women, Men} {
+		fmt.Printf("  %-5v served: %d, longest wait: %v\n", g, served[g], longest[g].Round(time.Millisecond))
+	}
+	fmt.Println("  " + verdict(!b.violation, "never mixed groups, never over capacity"))
+	fmt.Println("  " + verdict(maxInside <= capacity, fmt.Sprintf("peak occupancy %d of %d", maxInside, capacity)))
+	fmt.Println("  " + verdict(served[Women] == visitors && served[Men] == visitors, "both groups fully served - nobody starved"))
+}
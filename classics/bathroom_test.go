@@ -0,0 +1,82 @@
+package classics
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestBathroomInvariants(t *testing.T) {
+	const capacity, visitors = 3, 200
+	b := NewBathroom(capacity)
+	var wg sync.WaitGroup
+	for i := range visitors {
+		g := Gender(i % 2)
+		wg.Go(func() {
+			b.Enter(g)
+			runtime.Gosched() // stay inside long enough for others to try
+			b.mu.Lock()
+			if b.inside > capacity {
+				t.Errorf("%d inside, capacity %d", b.inside, capacity)
+			}
+			if b.occupants != g {
+				t.Errorf("%v inside with %v", g, b.occupants)
+			}
+			b.mu.Unlock()
+			b.Exit(g)
+		})
+	}
+	wg.Wait()
+	if b.violation {
+		t.Error("Enter admitted a visitor against the rules")
+	}
+	if b.inside != 0 || b.waiting != [2]int{} {
+		t.Errorf("inside %d, waiting %v after everyone left", b.inside, b.waiting)
+	}
+}
+
+// waitQueued returns once n visitors of group g are waiting to enter.
+func waitQueued(b *Bathroom, g Gender, n int) {
+	for {
+		b.mu.Lock()
+		w := b.waiting[g]
+		b.mu.Unlock()
+		if w == n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+// A man arriving while a woman waits may only finish the men's current
+// batch; after that the woman goes first, however many men keep coming.
+func TestBathroomNoStarvation(t *testing.T) {
+	b := NewBathroom(2)
+	b.Enter(Men) // batch 1 of 2
+
+	woman := make(chan struct{})
+	go func() {
+		b.Enter(Women)
+		close(woman)
+	}()
+	waitQueued(b, Women, 1)
+
+	b.Enter(Men) // batch 2 of 2: the current batch may still be finished
+	man := make(chan struct{})
+	go func() {
+		b.Enter(Men)
+		close(man)
+	}()
+	waitQueued(b, Men, 1)
+
+	b.Exit(Men)
+	b.Exit(Men) // the room is empty: the women's turn
+	select {
+	case <-woman:
+	case <-man:
+		t.Fatal("the man went ahead of the woman who was waiting before him")
+	}
+	b.Exit(Women)
+	<-man
+	b.Exit(Men)
+}
@@ -0,0 +1,50 @@
+// Package classics implements textbook synchronization problems with Go
+// primitives: the cigarette smokers, building H2O, and the unisex bathroom.
+// (The dining philosophers live in the classics/philosophers command.)
+//
+// Each problem comes with a correct solution, a self-checking demo and a
+// note on WHICH primitive fits it best and why:
+//
+//	Problem             Best fit                 Why
+//	cigarette smokers   channels (message routing)  "who may proceed" is a decision
+//	                                                 made by looking at a COMBINATION
+//	                                                 of events - route it as a message
+//	H2O                 semaphores + barrier     admission is pure counting (2 H, 1 O),
+//	                                             then the group must move together
+//	unisex bathroom     sync.Cond                the entry predicate is rich (gender,
+//	                                             capacity, who is queued) and changes
+//	                                             with every exit
+package classics
+
+import "fmt"
+
+// ClassicProblemsDemo runs every problem in this package and checks its
+// invariants.
+func ClassicProblemsDemo() {
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║        CLASSIC SYNCHRONIZATION PROBLEMS                    ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+
+	smokersDemo()
+	h2oDemo()
+	bathroomDemo()
+
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                    KEY TAKEAWAYS                           ║")
+	fmt.Println("╠════════════════════════════════════════════════════════════╣")
+	fmt.Println("║ • Routing a decision to ONE goroutine  → channels          ║")
+	fmt.Println("║ • Counting permits / admitting N       → semaphores        ║")
+	fmt.Println("║ • Moving a group in lockstep           → barrier (Cond)    ║")
+	fmt.Println("║ • Waiting on a rich, changing predicate → Cond + for-loop  ║")
+	fmt.Println("║ • Always state the invariant - then CHECK it               ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+}
+
+// verdict formats a pass/fail line for the self-checks.
+func verdict(ok bool, what string) string {
+	if ok {
+		return "✓ " + what
+	}
+	return "✗ VIOLATED: " + what
+}
@@ -0,0 +1,155 @@
+package classics
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+)
+
+// ============================================================================
+// BUILDING H2O (Little Book of Semaphores, 5.6)
+// ============================================================================
+// Hydrogen and oxygen atoms arrive as goroutines in any order. They must
+// bond in groups of exactly TWO hydrogens and ONE oxygen: the three atoms of
+// one molecule all call bond() before any atom of the next molecule does.
+//
+// Two separate problems hide in there:
+// 1. ADMISSION - let in at most 2 H and 1 O at a time. That is counting, and
+//    counting is what semaphores are for: a buffered channel of capacity 2
+//    for hydrogen, capacity 1 for oxygen.
+// 2. LOCKSTEP - the three admitted atoms must meet, bond, and only then make
+//    room for the next molecule. That is a barrier.
+//
+// PRIMITIVE FIT: semaphores (buffered channels) for admission, a cyclic
+// barrier (Mutex + Cond) for the group. Trying to do both with one Cond
+// gives a predicate like "am I one of the first two hydrogens since the last
+// molecule" - correct is possible, readable is not.
+// ============================================================================
+
+// barrier is a reusable (cyclic) barrier for a fixed number of parties.
+type barrier struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	parties    int
+	arrived    int
+	generation int // bumped every time the barrier trips
+}
+
+func newBarrier(parties int) *barrier {
+	b := &barrier{parties: parties}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Await blocks until all parties have called Await for this generation.
+func (b *barrier) Await() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	gen := b.generation
+	b.arrived++
+	if b.arrived == b.parties {
+		b.arrived = 0
+		b.generation++
+		b.cond.Broadcast()
+		return
+	}
+	// Waiting on the generation, not on arrived, keeps a fast goroutine that
+	// re-enters the NEXT round from confusing the waiters of this one.
+	for gen == b.generation {
+		b.cond.Wait()
+	}
+}
+
+// H2O admits atoms and makes them bond one molecule at a time.
+type H2O struct {
+	hydrogen chan struct{} // semaphore: 2 hydrogen slots per molecule
+	oxygen   chan struct{} // semaphore: 1 oxygen slot per molecule
+	meet     *barrier
+}
+
+// NewH2O returns an empty reaction chamber.
+func NewH2O() *H2O {
+	return &H2O{
+		hydrogen: make(chan struct{}, 2),
+		oxygen:   make(chan struct{}, 1),
+		meet:     newBarrier(3),
+	}
+}
+
+// Hydrogen blocks until this atom is part of a complete molecule, calls
+// bond, and returns once the whole molecule has bonded.
+func (h *H2O) Hydrogen(bond func()) { h.atom(h.hydrogen, bond) }
+
+// Oxygen is the oxygen counterpart of Hydrogen.
+func (h *H2O) Oxygen(bond func()) { h.atom(h.oxygen, bond) }
+
+func (h *H2O) atom(slots chan struct{}, bond func()) {
+	slots <- struct{}{} // acquire a slot in the current molecule
+	h.meet.Await()      // wait for the other two atoms
+	bond()
+	h.meet.Await() // wait until all three have bonded...
+	<-slots        // ...before making room for the next molecule
+}
+
+// react starts one goroutine per atom ('H' or 'O') and returns the order in
+// which the atoms called bond.
+func react(atoms []byte) []byte {
+	h := NewH2O()
+	var mu sync.Mutex
+	var bonded []byte
+	record := func(a byte) func() {
+		return func() {
+			mu.Lock()
+			bonded = append(bonded, a)
+			mu.Unlock()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, a := range atoms {
+		if a == 'H' {
+			wg.Go(func() { h.Hydrogen(record('H')) })
+		} else {
+			wg.Go(func() { h.Oxygen(record('O')) })
+		}
+	}
+	wg.Wait()
+	return bonded
+}
+
+// wellFormed reports whether every consecutive group of three bonds is two
+// H and one O.
+func wellFormed(bonded []byte) bool {
+	if len(bonded)%3 != 0 {
+		return false
+	}
+	for i := 0; i < len(bonded); i += 3 {
+		hs := 0
+		for _, a := range bonded[i : i+3] {
+			if a == 'H' {
+				hs++
+			}
+		}
+		if hs != 2 {
+			return false
+		}
+	}
+	return true
+}
+
+func h2oDemo() {
+	fmt.Println("\n=== Building H2O (semaphores + barrier) ===")
+
+	const molecules = 500
+	atoms := make([]byte, 0, 3*molecules)
+	for range molecules {
+		atoms = append(atoms, 'H', 'H', 'O')
+	}
+	rand.Shuffle(len(atoms), func(i, j int) { atoms[i], atoms[j] = atoms[j], atoms[i] })
+	bonded := react(atoms)
+
+	fmt.Printf("  %d atoms arrived in random order, first bonds: %s...\n", len(atoms), bonded[:min(18, len(bonded))])
+	fmt.Println("  " + verdict(len(bonded) == len(atoms), fmt.Sprintf("all %d molecules formed", molecules)))
+	fmt.Println("  " + verdict(wellFormed(bonded), "every group of three bonds is exactly H, H, O in some order"))
+}
@@ -0,0 +1,41 @@
+package classics
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestH2OBondsWholeMolecules(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		atoms string
+	}{
+		{"in molecule order", "HHOHHOHHO"},
+		{"oxygen first", "OOOHHHHHH"},
+		{"hydrogen first", "HHHHHHOOO"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bonded := react([]byte(tc.atoms))
+			if len(bonded) != len(tc.atoms) || !wellFormed(bonded) {
+				t.Errorf("bonds in order %s, want groups of H, H, O", bonded)
+			}
+		})
+	}
+}
+
+func TestH2OShuffled(t *testing.T) {
+	const molecules = 300
+	atoms := make([]byte, 0, 3*molecules)
+	for range molecules {
+		atoms = append(atoms, 'H', 'H', 'O')
+	}
+	r := rand.New(rand.NewPCG(1, 2))
+	r.Shuffle(len(atoms), func(i, j int) { atoms[i], atoms[j] = atoms[j], atoms[i] })
+	bonded := react(atoms)
+	if len(bonded) != len(atoms) {
+		t.Fatalf("%d atoms bonded, want %d", len(bonded), len(atoms))
+	}
+	if !wellFormed(bonded) {
+		t.Errorf("a group of three bonds is not H, H, O: %s", bonded)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// d) CHANDY–MISRA: FORKS ARE MESSAGES, NOT LOCKS
+// ============================================================================
+// No mutex anywhere. Every fork is owned by exactly one philosopher at a
+// time and travels between neighbours as a message. Each fork is either
+// DIRTY (its owner has eaten with it) or CLEAN (just received).
+//
+// RULES (Chandy & Misra, 1984):
+// 1. Initially every fork is dirty and belongs to the LOWER-numbered of the
+//    two philosophers sharing it. (This makes the "who yields to whom" graph
+//    acyclic, so there is no circular wait to begin with.)
+// 2. A hungry philosopher REQUESTS every fork it is missing.
+// 3. On a request, the owner hands the fork over (cleaning it) if it is
+//    DIRTY and the owner is not eating. A CLEAN fork is kept until the owner
+//    has eaten with it; the request is remembered and honoured afterwards.
+// 4. Eating makes both forks dirty.
+//
+// Why it works: a philosopher who just ate holds dirty forks and must give
+// them up on request, so a hungry neighbour always gets a turn. Priority
+// rotates around the table - no deadlock AND no starvation.
+//
+// Each philosopher's fork state lives inside its own goroutine; the only
+// shared things are the inbox channels. "Share memory by communicating."
+// ============================================================================
+
+type msgKind int
+
+const (
+	requestFork msgKind = iota // "please send me fork f"
+	sendFork                   // "here is fork f (clean)"
+)
+
+type cmMessage struct {
+	kind msgKind
+	fork int
+}
+
+// cmFork is one philosopher's view of one of its two forks.
+type cmFork struct {
+	held      bool // we own it right now
+	dirty     bool // we have eaten with it since receiving it
+	asked     bool // we sent a request and are waiting for it
+	requested bool // the neighbour asked for it and we are keeping it for now
+}
+
+type cmPhilosopher struct {
+	inbox  chan cmMessage
+	forks  map[int]*cmFork        // fork id -> our state for it
+	shares map[int]*cmPhilosopher // fork id -> neighbour we share it with
+	eating bool
+}
+
+func (p *cmPhilosopher) send(to *cmPhilosopher, kind msgKind, fork int) {
+	to.inbox <- cmMessage{kind: kind, fork: fork} // inbox is sized so this never blocks
+}
+
+// giveAway hands fork f to the neighbour sharing it, cleaning it first.
+func (p *cmPhilosopher) giveAway(f int) {
+	fork := p.forks[f]
+	fork.held, fork.dirty, fork.requested = false, false, false
+	p.send(p.shares[f], sendFork, f)
+}
+
+// handle applies rules 3 (requests) and receives forks.
+func (p *cmPhilosopher) handle(msg cmMessage, hungry bool) {
+	fork := p.forks[msg.fork]
+	switch msg.kind {
+	case sendFork:
+		fork.held, fork.dirty, fork.asked = true, false, false
+	case requestFork:
+		if fork.held && fork.dirty && !p.eating {
+			p.giveAway(msg.fork)
+			if hungry { // we still need it: ask for it straight back
+				fork.asked = true
+				p.send(p.shares[msg.fork], requestFork, msg.fork)
+			}
+			return
+		}
+		fork.requested = true // clean (or in flight to us): honour after eating
+	}
+}
+
+func (p *cmPhilosopher) hasBoth() bool {
+	for _, f := range p.forks {
+		if !f.held {
+			return false
+		}
+	}
+	return true
+}
+
+// dine is the philosopher's whole life: think, get hungry, collect forks,
+// eat, pass on any forks neighbours asked for.
+func (p *cmPhilosopher) dine(ctx context.Context, cfg config, d *diner) {
+	for {
+		// THINKING: answer requests while we think.
+		timer := time.NewTimer(thinkTime(cfg))
+	thinking:
+		for {
+			select {
+			case msg := <-p.inbox:
+				p.handle(msg, false)
+			case <-timer.C:
+				break thinking
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+
+		// HUNGRY: request missing forks, then wait for them.
+		d.startWaiting()
+		for f, fork := range p.forks {
+			if !fork.held && !fork.asked {
+				fork.asked = true
+				p.send(p.shares[f], requestFork, f)
+			}
+		}
+		for !p.hasBoth() {
+			select {
+			case msg := <-p.inbox:
+				p.handle(msg, true)
+			case <-ctx.Done():
+				return
+			}
+		}
+		d.stopWaiting()
+
+		// EATING: both forks become dirty.
+		p.eating = true
+		time.Sleep(cfg.eat)
+		for _, fork := range p.forks {
+			fork.dirty = true
+		}
+		p.eating = false
+		d.meals.Add(1)
+
+		// Honour requests we deferred because our forks were clean.
+		for f, fork := range p.forks {
+			if fork.requested {
+				p.giveAway(f)
+			}
+		}
+	}
+}
+
+func runChandyMisra(ctx context.Context, cfg config) ([]stats, bool) {
+	n := cfg.philosophers
+	ps := make([]*cmPhilosopher, n)
+	for i := range ps {
+		ps[i] = &cmPhilosopher{
+			// At most a request and a fork can be in flight per shared fork
+			// per direction, so 4 slots are enough; 8 leaves headroom.
+			inbox:  make(chan cmMessage, 8),
+			forks:  make(map[int]*cmFork),
+			shares: make(map[int]*cmPhilosopher),
+		}
+	}
+
+	// Fork f lies between philosopher f (its left fork) and philosopher
+	// f-1 (its right fork). Rule 1: the lower-numbered one starts with it.
+	for f := range n {
+		a, b := f, (f-1+n)%n
+		ps[a].shares[f], ps[b].shares[f] = ps[b], ps[a]
+		owner := min(a, b)
+		ps[a].forks[f] = &cmFork{held: owner == a, dirty: true}
+		ps[b].forks[f] = &cmFork{held: owner == b, dirty: true}
+	}
+
+	diners := make([]diner, n)
+	var wg sync.WaitGroup
+	for i, p := range ps {
+		wg.Go(func() { p.dine(ctx, cfg, &diners[i]) })
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	deadlocked := watchForDeadlock(ctx, cfg, diners, done) // should never fire
+	return snapshotAll(diners), deadlocked
+}
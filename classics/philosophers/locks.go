@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// diner holds one philosopher's counters. They are atomics because the
+// reporter may read them while the philosopher is still running (or, after
+// a deadlock, stuck forever inside Lock).
+type diner struct {
+	meals       atomic.Int64
+	blockedNs   atomic.Int64
+	hungrySince atomic.Int64 // UnixNano when it started waiting, 0 if not waiting
+}
+
+func (d *diner) startWaiting() { d.hungrySince.Store(time.Now().UnixNano()) }
+
+func (d *diner) stopWaiting() {
+	since := d.hungrySince.Swap(0)
+	d.blockedNs.Add(time.Now().UnixNano() - since)
+}
+
+// snapshot includes the wait that is still in progress, so a philosopher
+// stuck in a deadlock shows how long it has been stuck.
+func (d *diner) snapshot(now time.Time) stats {
+	blocked := d.blockedNs.Load()
+	if since := d.hungrySince.Load(); since != 0 {
+		blocked += now.UnixNano() - since
+	}
+	return stats{meals: int(d.meals.Load()), blocked: time.Duration(blocked)}
+}
+
+func snapshotAll(diners []diner) []stats {
+	now := time.Now()
+	results := make([]stats, len(diners))
+	for i := range diners {
+		results[i] = diners[i].snapshot(now)
+	}
+	return results
+}
+
+// thinkTime picks a random thinking time so philosophers drift out of step.
+func thinkTime(cfg config) time.Duration {
+	if cfg.think <= 0 {
+		return 0
+	}
+	return rand.N(cfg.think)
+}
+
+// ============================================================================
+// LOCK-BASED SOLUTIONS: FORKS ARE MUTEXES
+// ============================================================================
+
+// table decides how a philosopher acquires and releases its two forks.
+type table interface {
+	pickUp(id int)
+	putDown(id int)
+}
+
+// forks is the shared array of fork mutexes. Philosopher i sits between
+// fork i (left) and fork i+1 (right).
+type forks []sync.Mutex
+
+func (f forks) left(id int) int  { return id }
+func (f forks) right(id int) int { return (id + 1) % len(f) }
+
+// runLocked seats cfg.philosophers goroutines at t and lets them think, pick
+// up forks, eat and put them down until ctx is done. If meals stop being
+// served while dinner is still on, it reports a deadlock and returns without
+// waiting for the stuck goroutines.
+func runLocked(ctx context.Context, cfg config, t table) ([]stats, bool) {
+	diners := make([]diner, cfg.philosophers)
+
+	var wg sync.WaitGroup
+	for id := range diners {
+		wg.Go(func() {
+			d := &diners[id]
+			for {
+				if timeutil.Sleep(ctx, thinkTime(cfg)) != nil {
+					return // dinner is over
+				}
+				d.startWaiting()
+				t.pickUp(id) // blocking; no way to cancel a sync.Mutex Lock
+				d.stopWaiting()
+
+				time.Sleep(cfg.eat)
+				d.meals.Add(1)
+				t.putDown(id)
+			}
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	deadlocked := watchForDeadlock(ctx, cfg, diners, done)
+	return snapshotAll(diners), deadlocked
+}
+
+// watchForDeadlock returns true if no meal is eaten for a long stretch while
+// dinner is still on. It returns false once every philosopher has left.
+func watchForDeadlock(ctx context.Context, cfg config, diners []diner, done <-chan struct{}) bool {
+	stall := max(100*(cfg.think+cfg.eat+cfg.reach), 250*time.Millisecond)
+	ticker := time.NewTicker(stall / 10)
+	defer ticker.Stop()
+
+	total := func() (n int64) {
+		for i := range diners {
+			n += diners[i].meals.Load()
+		}
+		return n
+	}
+
+	lastMeals, lastProgress := total(), time.Now()
+	for {
+		select {
+		case <-done:
+			return false
+		case <-ticker.C:
+			if ctx.Err() != nil {
+				continue // winding down: philosophers finish their current meal
+			}
+			if n := total(); n != lastMeals {
+				lastMeals, lastProgress = n, time.Now()
+			} else if time.Since(lastProgress) > stall {
+				return true
+			}
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// a) NAIVE: LEFT FORK, THEN RIGHT FORK
+// ----------------------------------------------------------------------------
+// If all philosophers grab their left fork at about the same time, each
+// waits forever for the right one, which its neighbour holds as a LEFT fork.
+// That is the circular wait of ch01's deadlock demo, with N goroutines.
+
+type naiveTable struct {
+	forks
+	reach time.Duration
+}
+
+func (t naiveTable) pickUp(id int) {
+	t.forks[t.left(id)].Lock()
+	time.Sleep(t.reach) // widen the window in which everyone holds one fork
+	t.forks[t.right(id)].Lock()
+}
+
+func (t naiveTable) putDown(id int) {
+	t.forks[t.right(id)].Unlock()
+	t.forks[t.left(id)].Unlock()
+}
+
+func runNaive(ctx context.Context, cfg config) ([]stats, bool) {
+	return runLocked(ctx, cfg, naiveTable{make(forks, cfg.philosophers), cfg.reach})
+}
+
+// ----------------------------------------------------------------------------
+// b) RESOURCE ORDERING: LOWER-NUMBERED FORK FIRST
+// ----------------------------------------------------------------------------
+// Philosopher N-1 sits between fork N-1 and fork 0. Taking the lower number
+// first makes it reach for fork 0 BEFORE fork N-1 - the opposite direction
+// to everyone else - so the wait can never close into a cycle. This is the
+// "lock hierarchy" fix from ch01's deadlock notes.
+
+type orderedTable struct {
+	forks
+	reach time.Duration
+}
+
+func (t orderedTable) pickUp(id int) {
+	first, second := min(t.left(id), t.right(id)), max(t.left(id), t.right(id))
+	t.forks[first].Lock()
+	time.Sleep(t.reach)
+	t.forks[second].Lock()
+}
+
+func (t orderedTable) putDown(id int) {
+	t.forks[t.right(id)].Unlock()
+	t.forks[t.left(id)].Unlock()
+}
+
+func runOrdered(ctx context.Context, cfg config) ([]stats, bool) {
+	return runLocked(ctx, cfg, orderedTable{make(forks, cfg.philosophers), cfg.reach})
+}
+
+// ----------------------------------------------------------------------------
+// c) WAITER: A SEMAPHORE WITH N-1 SEATS
+// ----------------------------------------------------------------------------
+// Keep the naive left-then-right order, but a waiter only lets N-1
+// philosophers try at once. With one seat always empty, at least one seated
+// philosopher has both neighbours' forks within reach, so somebody always
+// eats. The semaphore is a buffered channel: send = take a seat.
+
+type waiterTable struct {
+	naiveTable
+	seats chan struct{}
+}
+
+func (t waiterTable) pickUp(id int) {
+	t.seats <- struct{}{} // blocks while N-1 philosophers are already trying
+	t.naiveTable.pickUp(id)
+}
+
+func (t waiterTable) putDown(id int) {
+	t.naiveTable.putDown(id)
+	<-t.seats // give the seat back
+}
+
+func runWaiter(ctx context.Context, cfg config) ([]stats, bool) {
+	t := waiterTable{
+		naiveTable: naiveTable{make(forks, cfg.philosophers), cfg.reach},
+		seats:      make(chan struct{}, cfg.philosophers-1),
+	}
+	return runLocked(ctx, cfg, t)
+}
@@ -0,0 +1,146 @@
+// Command philosophers simulates Dijkstra's dining philosophers problem with
+// several solutions and reports how many meals each philosopher ate and how
+// long each spent blocked waiting for forks.
+//
+//	go run ./classics/philosophers                       # run every solution
+//	go run ./classics/philosophers -solution naive       # watch it deadlock
+//	go run ./classics/philosophers -solution chandy-misra -n 7 -duration 5s
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ============================================================================
+// THE DINING PHILOSOPHERS
+// ============================================================================
+// N philosophers sit around a round table with ONE fork between each pair.
+// To eat, a philosopher needs BOTH neighbouring forks.
+//
+//          P0
+//      f0      f1
+//    P4          P1
+//     f4        f2
+//       P3    P2
+//           f3
+//
+// Each fork is a shared resource; each philosopher is a goroutine. The
+// problem packs every classic hazard into one table:
+// - DEADLOCK:   everyone picks up their left fork and waits for the right
+// - STARVATION: a philosopher whose neighbours keep eating never gets both
+// - LIVELOCK:   everyone puts forks down and retries in lockstep
+//
+// SOLUTIONS IMPLEMENTED:
+// a) naive         - left fork, then right fork           → deadlocks
+// b) ordered       - lower-numbered fork first            → no cycle, no deadlock
+// c) waiter        - a semaphore seats at most N-1 diners → no cycle, no deadlock
+// d) chandy-misra  - no locks at all: forks are messages  → no deadlock, fair
+// ============================================================================
+
+// config holds the knobs shared by every solution.
+type config struct {
+	philosophers int
+	duration     time.Duration
+	think, eat   time.Duration
+	reach        time.Duration // pause between picking up the first and second fork
+}
+
+// stats is what one philosopher records about its dinner.
+type stats struct {
+	meals   int
+	blocked time.Duration // total time spent hungry, waiting for forks
+}
+
+// solution runs a dinner until ctx is done and returns each philosopher's
+// stats. deadlocked reports that the run had to be abandoned.
+type solution struct {
+	name string
+	run  func(ctx context.Context, cfg config) (results []stats, deadlocked bool)
+}
+
+var solutions = []solution{
+	{"naive", runNaive},
+	{"ordered", runOrdered},
+	{"waiter", runWaiter},
+	{"chandy-misra", runChandyMisra},
+}
+
+func main() {
+	var cfg config
+	var which string
+	flag.StringVar(&which, "solution", "all", "naive, ordered, waiter, chandy-misra or all")
+	flag.IntVar(&cfg.philosophers, "n", 5, "number of philosophers (and forks)")
+	flag.DurationVar(&cfg.duration, "duration", 2*time.Second, "how long dinner lasts")
+	flag.DurationVar(&cfg.think, "think", 2*time.Millisecond, "maximum time spent thinking between meals")
+	flag.DurationVar(&cfg.eat, "eat", 2*time.Millisecond, "time spent eating a meal")
+	flag.DurationVar(&cfg.reach, "reach", time.Millisecond, "pause between picking up the first and second fork")
+	flag.Parse()
+
+	if cfg.philosophers < 2 {
+		fmt.Fprintln(os.Stderr, "philosophers: need at least 2 philosophers")
+		os.Exit(2)
+	}
+
+	ran := false
+	for _, s := range solutions {
+		if which != "all" && which != s.name {
+			continue
+		}
+		ran = true
+		dine(s, cfg)
+	}
+	if !ran {
+		fmt.Fprintf(os.Stderr, "philosophers: unknown solution %q\n", which)
+		os.Exit(2)
+	}
+}
+
+// dine runs one solution and prints its report.
+func dine(s solution, cfg config) {
+	fmt.Printf("\n=== %s: %d philosophers for %v ===\n", s.name, cfg.philosophers, cfg.duration)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.duration)
+	defer cancel()
+
+	results, deadlocked := s.run(ctx, cfg)
+	if deadlocked {
+		fmt.Println("✗ DEADLOCK: every philosopher holds one fork and waits for another.")
+		fmt.Println("  Nobody has eaten since; the goroutines are stuck in Lock() for good.")
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintf(tw, "Philosopher\tMeals\tBlocked\tAvg wait/meal\t\n")
+	var total, fewest, most int
+	for i, r := range results {
+		avg := time.Duration(0)
+		if r.meals > 0 {
+			avg = r.blocked / time.Duration(r.meals)
+		}
+		fmt.Fprintf(tw, "P%d\t%d\t%v\t%v\t%s\n", i, r.meals, r.blocked.Round(time.Millisecond), avg.Round(time.Microsecond), bar(r.meals, results))
+		total += r.meals
+		if i == 0 || r.meals < fewest {
+			fewest = r.meals
+		}
+		most = max(most, r.meals)
+	}
+	tw.Flush()
+	fmt.Printf("Total meals: %d, fewest: %d, most: %d\n", total, fewest, most)
+}
+
+// bar draws a meal count relative to the hungriest diner's count.
+func bar(meals int, all []stats) string {
+	most := 0
+	for _, r := range all {
+		most = max(most, r.meals)
+	}
+	if most == 0 {
+		return ""
+	}
+	return strings.Repeat("█", meals*30/most)
+}
@@ -0,0 +1,158 @@
+package classics
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+)
+
+// ============================================================================
+// THE CIGARETTE SMOKERS (Patil, 1971)
+// ============================================================================
+// Three smokers each have an infinite supply of ONE ingredient: tobacco,
+// paper or matches. An agent repeatedly puts TWO different ingredients on
+// the table. The smoker holding the third one must pick them up, roll,
+// smoke, and tell the agent to go again.
+//
+// THE TRAP: give each ingredient its own semaphore and let each smoker wait
+// on the two it needs. The agent puts down tobacco + paper. The smoker who
+// holds paper (needs tobacco + matches) grabs the tobacco; the smoker who
+// holds tobacco (needs paper + matches) grabs the paper. Both now wait
+// forever for matches, and the matches smoker - the only one who could have
+// smoked - gets nothing. Deadlock.
+//
+// The rules forbid changing the agent, so Parnas' fix adds PUSHERS: one
+// goroutine per ingredient that records what is on the table and, once it
+// sees a complete pair, signals the ONE smoker who can use it. The decision
+// is made by whoever sees the second ingredient, under one small mutex.
+//
+// PRIMITIVE FIT: channels. Every signal here is "wake exactly this
+// goroutine" - a message with one recipient.
+// ============================================================================
+
+type ingredient int
+
+const (
+	tobacco ingredient = iota
+	paper
+	matches
+)
+
+func (i ingredient) String() string {
+	return [...]string{"tobacco", "paper", "matches"}[i]
+}
+
+// smokersTable is the shared state the pushers maintain.
+type smokersTable struct {
+	mu     sync.Mutex
+	onDesk [3]bool // which ingredients the pushers have seen but not yet matched
+
+	placed [3]chan struct{} // agent → pusher: "ingredient i was placed"
+	smoker [3]chan struct{} // pusher → smoker holding ingredient i: "your pair is ready"
+	agent  chan struct{}    // smoker → agent: "done smoking, go again"
+}
+
+func newSmokersTable() *smokersTable {
+	t := &smokersTable{agent: make(chan struct{})}
+	for i := range 3 {
+		t.placed[i] = make(chan struct{})
+		t.smoker[i] = make(chan struct{})
+	}
+	return t
+}
+
+// pusher waits for ingredient `mine`. If a partner ingredient is already on
+// the desk, the smoker holding the THIRD ingredient is woken; otherwise the
+// pusher just records that `mine` is there.
+func (t *smokersTable) pusher(mine ingredient, done <-chan struct{}) {
+	for {
+		select {
+		case <-t.placed[mine]:
+		case <-done:
+			return
+		}
+
+		t.mu.Lock()
+		wake := ingredient(-1)
+		for other := range ingredient(3) {
+			if other != mine && t.onDesk[other] {
+				t.onDesk[other] = false
+				wake = 3 - mine - other // the one ingredient not on the table
+				break
+			}
+		}
+		if wake < 0 {
+			t.onDesk[mine] = true
+		}
+		t.mu.Unlock()
+
+		if wake >= 0 {
+			t.smoker[wake] <- struct{}{} // never block while holding mu
+		}
+	}
+}
+
+// runSmokers plays one round per entry of offers: the agent places the two
+// ingredients other than offers[i] and waits for the smoke. It returns how
+// often each smoker smoked.
+func runSmokers(offers []ingredient) [3]int {
+	t := newSmokersTable()
+
+	var smoked [3]int // smoker i's count; only smoker i writes it
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	// Three pushers. How often each ingredient gets placed is random, so
+	// pushers (and smokers) run until the agent is finished.
+	for i := range ingredient(3) {
+		wg.Go(func() { t.pusher(i, done) })
+	}
+
+	// Three smokers.
+	for i := range ingredient(3) {
+		wg.Go(func() {
+			for {
+				select {
+				case <-t.smoker[i]:
+					smoked[i]++ // roll and smoke
+					t.agent <- struct{}{}
+				case <-done:
+					return
+				}
+			}
+		})
+	}
+
+	// The agent: place both ingredients of the pair, wait for the smoke.
+	for _, missing := range offers {
+		for i := range ingredient(3) {
+			if i != missing {
+				t.placed[i] <- struct{}{}
+			}
+		}
+		<-t.agent
+	}
+	close(done)
+	wg.Wait()
+	return smoked
+}
+
+func smokersDemo() {
+	fmt.Println("\n=== Cigarette Smokers (Parnas' pushers) ===")
+
+	const rounds = 300
+	var expected [3]int // how often the agent offered the pair smoker i needs
+	offers := make([]ingredient, rounds)
+	for i := range offers {
+		offers[i] = ingredient(rand.N(3)) // the agent picks a random pair
+		expected[offers[i]]++
+	}
+	smoked := runSmokers(offers)
+
+	for i := range ingredient(3) {
+		fmt.Printf("  Smoker with %-8v smoked %3d times (agent offered its pair %3d times)\n", i, smoked[i], expected[i])
+	}
+	fmt.Println("  " + verdict(smoked == expected, "every offered pair went to the one smoker who could use it"))
+	fmt.Println("  " + verdict(smoked[0]+smoked[1]+smoked[2] == rounds, fmt.Sprintf("all %d rounds completed without deadlock", rounds)))
+}
@@ -0,0 +1,36 @@
+package classics
+
+import "testing"
+
+func TestSmokersEachPairToItsSmoker(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		offers []ingredient
+	}{
+		{"one of each", []ingredient{tobacco, paper, matches}},
+		{"same pair again and again", []ingredient{paper, paper, paper, paper, paper}},
+		{"mixed", []ingredient{matches, tobacco, tobacco, paper, matches, tobacco, paper, paper, matches}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var want [3]int
+			for _, missing := range tc.offers {
+				want[missing]++
+			}
+			if got := runSmokers(tc.offers); got != want {
+				t.Errorf("smoked %v, want %v (indexed by the ingredient each smoker holds)", got, want)
+			}
+		})
+	}
+}
+
+func TestSmokersManyRounds(t *testing.T) {
+	offers := make([]ingredient, 3000)
+	var want [3]int
+	for i := range offers {
+		offers[i] = ingredient(i * 7 % 3)
+		want[offers[i]]++
+	}
+	if got := runSmokers(offers); got != want {
+		t.Errorf("smoked %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,675 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	stateownership "github.com/mintecr7/concurrency-with-go/ch02_code_modeling/state_ownership"
+	producerconsumer "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/producer_consumer"
+	"github.com/mintecr7/concurrency-with-go/pkg/counter"
+	"github.com/mintecr7/concurrency-with-go/pkg/fswalk"
+	"github.com/mintecr7/concurrency-with-go/pkg/mcslock"
+	"github.com/mintecr7/concurrency-with-go/pkg/ticketlock"
+)
+
+// ============================================================================
+// bench - DID MY TUNING HELP? A BENCHSTAT-STYLE A/B COMPARISON
+// ============================================================================
+// "I made the channel buffered and it got faster" is usually one run each
+// way - and one run proves little: the second one ran with a warm cache, the
+// laptop throttled, a browser tab woke up. bench compare runs a suite
+// several times with each configuration, ALTERNATING between them so drift
+// hits both equally, and reports:
+//
+//	median ± spread   per configuration (spread: half the min-max range)
+//	delta             change of the median, B relative to A
+//	p                 Mann-Whitney U test: how likely a difference at least
+//	                  this big is if A and B are really the same
+//
+// Like benchstat, a delta with p >= 0.05 is printed as "~": not enough
+// evidence that anything changed. More runs (-count) give the test more
+// power; with only 3 runs each no difference can ever be significant.
+// ============================================================================
+
+func init() {
+	commands["bench"] = command{"run a benchmark suite with two configurations and compare (bench list | bench compare)", runBench}
+}
+
+// benchParam is one knob of a suite.
+type benchParam struct {
+	name, def, usage string
+}
+
+// benchSuite is a named benchmark whose behaviour depends on parameters.
+type benchSuite struct {
+	summary string
+	params  []benchParam
+	// build validates the parameters and returns the benchmark to time.
+	// Each b.N is one unit of the suite's work (an item, an increment...).
+	build func(p benchParams) (func(b *testing.B), error)
+}
+
+// benchParams holds one configuration: every parameter of the suite, with
+// defaults filled in.
+type benchParams map[string]string
+
+func (p benchParams) int(name string) (int, error) {
+	n, err := strconv.Atoi(p[name])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s=%q: want a non-negative integer", name, p[name])
+	}
+	return n, nil
+}
+
+// ints parses several integer parameters, requiring each to be at least
+// 1 unless it is listed in zeroOK.
+func (p benchParams) ints(zeroOK []string, names ...string) ([]int, error) {
+	out := make([]int, len(names))
+	for i, name := range names {
+		n, err := p.int(name)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 && !slices.Contains(zeroOK, name) {
+			return nil, fmt.Errorf("%s must be at least 1", name)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// String lists the parameters in name order, for the table header.
+func (p benchParams) String() string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + p[k]
+	}
+	return strings.Join(parts, ",")
+}
+
+// shares splits n units of work over parts goroutines.
+func shares(n, parts int) []int {
+	s := make([]int, parts)
+	for i := range s {
+		s[i] = n / parts
+		if i < n%parts {
+			s[i]++
+		}
+	}
+	return s
+}
+
+// spinWork burns roughly n iterations of CPU, standing in for real work.
+//
+//go:noinline
+func spinWork(n int) int {
+	x := 0
+	for i := range n {
+		x += i ^ (x >> 3)
+	}
+	return x
+}
+
+var benchSuites = map[string]benchSuite{
+	"handoff": {
+		summary: "items through one channel, producers → consumers",
+		params: []benchParam{
+			{"buffer", "0", "channel capacity (0: unbuffered)"},
+			{"producers", "1", "sending goroutines"},
+			{"consumers", "1", "receiving goroutines"},
+		},
+		build: func(p benchParams) (func(*testing.B), error) {
+			v, err := p.ints([]string{"buffer"}, "buffer", "producers", "consumers")
+			if err != nil {
+				return nil, err
+			}
+			buffer, producers, consumers := v[0], v[1], v[2]
+			return func(b *testing.B) {
+				ch := make(chan int, buffer)
+				var senders, receivers sync.WaitGroup
+				for range consumers {
+					receivers.Go(func() {
+						for range ch {
+						}
+					})
+				}
+				for _, n := range shares(b.N, producers) {
+					senders.Go(func() {
+						for i := range n {
+							ch <- i
+						}
+					})
+				}
+				senders.Wait()
+				close(ch)
+				receivers.Wait()
+			}, nil
+		},
+	},
+	"buffer": {
+		summary: "the ch03 bounded-buffer strategies under producer/consumer load",
+		params: []benchParam{
+			{"impl", "channel", "channel | cond | semaphore"},
+			{"capacity", "16", "buffer capacity"},
+			{"producers", "4", "producing goroutines"},
+			{"consumers", "4", "consuming goroutines"},
+		},
+		build: func(p benchParams) (func(*testing.B), error) {
+			v, err := p.ints(nil, "capacity", "producers", "consumers")
+			if err != nil {
+				return nil, err
+			}
+			capacity, producers, consumers := v[0], v[1], v[2]
+			var newBuffer func(int) producerconsumer.Buffer[int]
+			switch p["impl"] {
+			case "channel":
+				newBuffer = func(c int) producerconsumer.Buffer[int] { return producerconsumer.NewChanBuffer[int](c) }
+			case "cond":
+				newBuffer = func(c int) producerconsumer.Buffer[int] { return producerconsumer.NewCondBuffer[int](c) }
+			case "semaphore":
+				newBuffer = func(c int) producerconsumer.Buffer[int] { return producerconsumer.NewSemaphoreBuffer[int](c) }
+			default:
+				return nil, fmt.Errorf("impl=%q: want channel, cond or semaphore", p["impl"])
+			}
+			return func(b *testing.B) {
+				buf := newBuffer(capacity)
+				var wg sync.WaitGroup
+				for _, n := range shares(b.N, consumers) {
+					wg.Go(func() {
+						for range n {
+							buf.Get()
+						}
+					})
+				}
+				for _, n := range shares(b.N, producers) {
+					wg.Go(func() {
+						for i := range n {
+							buf.Put(i)
+						}
+					})
+				}
+				wg.Wait()
+			}, nil
+		},
+	},
+	"counter": {
+		summary: "goroutines incrementing one shared counter",
+		params: []benchParam{
+			{"impl", "atomic", "atomic | mutex | adder (pkg/counter)"},
+			{"goroutines", "8", "incrementing goroutines"},
+		},
+		build: func(p benchParams) (func(*testing.B), error) {
+			v, err := p.ints(nil, "goroutines")
+			if err != nil {
+				return nil, err
+			}
+			goroutines := v[0]
+			var newInc func() func()
+			switch p["impl"] {
+			case "atomic":
+				newInc = func() func() {
+					var n atomic.Int64
+					return func() { n.Add(1) }
+				}
+			case "mutex":
+				newInc = func() func() {
+					var mu sync.Mutex
+					var n int64
+					return func() { mu.Lock(); n++; mu.Unlock() }
+				}
+			case "adder":
+				newInc = func() func() {
+					var n counter.Adder
+					return n.Inc
+				}
+			default:
+				return nil, fmt.Errorf("impl=%q: want atomic, mutex or adder", p["impl"])
+			}
+			return func(b *testing.B) {
+				inc := newInc()
+				var wg sync.WaitGroup
+				for _, n := range shares(b.N, goroutines) {
+					wg.Go(func() {
+						for range n {
+							inc()
+						}
+					})
+				}
+				wg.Wait()
+			}, nil
+		},
+	},
+	"lock": {
+		summary: "goroutines taking turns on one lock with work inside",
+		params: []benchParam{
+			{"impl", "mutex", "mutex | ticket (pkg/ticketlock) | mcs (pkg/mcslock)"},
+			{"goroutines", "4", "contending goroutines"},
+			{"work", "100", "spin iterations inside the critical section"},
+		},
+		build: func(p benchParams) (func(*testing.B), error) {
+			v, err := p.ints([]string{"work"}, "goroutines", "work")
+			if err != nil {
+				return nil, err
+			}
+			goroutines, work := v[0], v[1]
+			var newLock func() sync.Locker
+			switch p["impl"] {
+			case "mutex":
+				newLock = func() sync.Locker { return new(sync.Mutex) }
+			case "ticket":
+				newLock = func() sync.Locker { return new(ticketlock.Mutex) }
+			case "mcs":
+				newLock = func() sync.Locker { return new(mcslock.Mutex) }
+			default:
+				return nil, fmt.Errorf("impl=%q: want mutex, ticket or mcs", p["impl"])
+			}
+			return func(b *testing.B) {
+				mu := newLock()
+				var sink int
+				var wg sync.WaitGroup
+				for _, n := range shares(b.N, goroutines) {
+					wg.Go(func() {
+						for range n {
+							mu.Lock()
+							sink += spinWork(work)
+							mu.Unlock()
+						}
+					})
+				}
+				wg.Wait()
+			}, nil
+		},
+	},
+	"state": {
+		summary: "the ch02 key-value store: mutex-guarded map vs. state-owning goroutine",
+		params: []benchParam{
+			{"impl", "mutex", "mutex | monitor"},
+			{"op", "add", "add | inc (no reply) | kv (90% Get) | transfer"},
+			{"goroutines", "4", "calling goroutines"},
+			{"work", "0", "spin iterations per operation, while the state is held"},
+		},
+		build: func(p benchParams) (func(*testing.B), error) {
+			v, err := p.ints([]string{"work"}, "goroutines", "work")
+			if err != nil {
+				return nil, err
+			}
+			goroutines, work := v[0], v[1]
+			var newStore func() stateownership.Store
+			switch p["impl"] {
+			case "mutex":
+				newStore = func() stateownership.Store { return stateownership.NewMutexStore(work) }
+			case "monitor":
+				newStore = func() stateownership.Store { return stateownership.NewMonitorStore(work, 64) }
+			default:
+				return nil, fmt.Errorf("impl=%q: want mutex or monitor", p["impl"])
+			}
+			i := slices.IndexFunc(stateownership.Workloads, func(w stateownership.Workload) bool { return w.Name == p["op"] })
+			if i < 0 {
+				return nil, fmt.Errorf("op=%q: want add, inc, kv or transfer", p["op"])
+			}
+			w := stateownership.Workloads[i]
+			return func(b *testing.B) {
+				stateownership.Drive(newStore(), w, goroutines, b.N)
+			}, nil
+		},
+	},
+	"walk": {
+		summary: "one walk of a directory tree: filepath.WalkDir vs. pkg/fswalk",
+		params: []benchParam{
+			{"impl", "walkdir", "walkdir | fswalk"},
+			{"parallelism", "8", "fswalk's listings and visits at once"},
+			{"work", "read", "per file: stat | read (the whole file)"},
+			{"root", "", "tree to walk (default: $GOROOT/src)"},
+		},
+		build: func(p benchParams) (func(*testing.B), error) {
+			v, err := p.ints(nil, "parallelism")
+			if err != nil {
+				return nil, err
+			}
+			parallelism := v[0]
+			var work func(ctx context.Context, path string, d fs.DirEntry) (int64, error)
+			switch p["work"] {
+			case "stat":
+				work = func(_ context.Context, _ string, d fs.DirEntry) (int64, error) {
+					info, err := d.Info()
+					if err != nil {
+						return 0, err
+					}
+					return info.Size(), nil
+				}
+			case "read":
+				work = func(_ context.Context, path string, _ fs.DirEntry) (int64, error) {
+					data, err := os.ReadFile(path)
+					return int64(len(data)), err
+				}
+			default:
+				return nil, fmt.Errorf("work=%q: want stat or read", p["work"])
+			}
+			root := p["root"]
+			if root == "" {
+				out, err := exec.Command("go", "env", "GOROOT").Output()
+				if err != nil {
+					return nil, fmt.Errorf("go env GOROOT: %w", err)
+				}
+				root = filepath.Join(strings.TrimSpace(string(out)), "src")
+			}
+			sequential := func() ([]fswalk.File[int64], error) {
+				var files []fswalk.File[int64]
+				err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+					if err != nil || d.IsDir() {
+						return err
+					}
+					n, err := work(context.Background(), path, d)
+					files = append(files, fswalk.File[int64]{Path: path, Value: n})
+					return err
+				})
+				return files, err
+			}
+			parallel := func() ([]fswalk.File[int64], error) {
+				return fswalk.Walk(context.Background(), root, parallelism, work)
+			}
+
+			// Both must produce the same list, in the same order, or the
+			// comparison means nothing.
+			want, err := sequential()
+			if err != nil {
+				return nil, err
+			}
+			got, err := parallel()
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Equal(got, want) {
+				return nil, fmt.Errorf("fswalk and WalkDir disagree on %s", root)
+			}
+
+			walk := sequential
+			switch p["impl"] {
+			case "walkdir":
+			case "fswalk":
+				walk = parallel
+			default:
+				return nil, fmt.Errorf("impl=%q: want walkdir or fswalk", p["impl"])
+			}
+			return func(b *testing.B) {
+				for range b.N {
+					if _, err := walk(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}, nil
+		},
+	},
+}
+
+// parseBenchParams applies "k=v,k=v" on top of the suite's defaults.
+func parseBenchParams(s benchSuite, spec string) (benchParams, error) {
+	p := benchParams{}
+	for _, bp := range s.params {
+		p[bp.name] = bp.def
+	}
+	for kv := range strings.SplitSeq(spec, ",") {
+		if kv == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		if _, known := p[k]; !ok || !known {
+			return nil, fmt.Errorf("bad parameter %q (want name=value, see: lab bench list)", kv)
+		}
+		p[k] = v
+	}
+	return p, nil
+}
+
+// ============================================================================
+// STATISTICS
+// ============================================================================
+
+// sampleSummary is the median and spread of one configuration's runs.
+type sampleSummary struct {
+	median, spread float64 // spread is relative: ±(max-min)/2/median
+}
+
+func summarize(xs []float64) sampleSummary {
+	s := slices.Sorted(slices.Values(xs))
+	n := len(s)
+	median := s[n/2]
+	if n%2 == 0 {
+		median = (s[n/2-1] + s[n/2]) / 2
+	}
+	spread := 0.0
+	if median != 0 {
+		spread = (s[n-1] - s[0]) / 2 / median
+	}
+	return sampleSummary{median, spread}
+}
+
+// mannWhitneyP returns the two-sided p-value of the Mann-Whitney U test for
+// "a and b come from the same distribution". Without ties it is exact;
+// with ties (allocation counts are often all equal) it uses the normal
+// approximation with a tie correction.
+func mannWhitneyP(a, b []float64) float64 {
+	n, m := len(a), len(b)
+	// U counts the pairs (x in a, y in b) with x > y, ties counting half.
+	u := 0.0
+	ties := false
+	for _, x := range a {
+		for _, y := range b {
+			switch {
+			case x > y:
+				u++
+			case x == y:
+				u += 0.5
+				ties = true
+			}
+		}
+	}
+	if !ties {
+		// counts[i][j][k]: orderings of i values from a and j from b with U = k.
+		// Built up one value at a time: the largest remaining value is
+		// either from a (beating all j values of b) or from b.
+		counts := make([][][]float64, n+1)
+		for i := range counts {
+			counts[i] = make([][]float64, m+1)
+			for j := range counts[i] {
+				counts[i][j] = make([]float64, n*m+1)
+				if i == 0 || j == 0 {
+					counts[i][j][0] = 1
+					continue
+				}
+				for k := range counts[i][j] {
+					if k >= j {
+						counts[i][j][k] += counts[i-1][j][k-j]
+					}
+					counts[i][j][k] += counts[i][j-1][k]
+				}
+			}
+		}
+		total, below, above := 0.0, 0.0, 0.0
+		for k, c := range counts[n][m] {
+			total += c
+			if float64(k) <= u {
+				below += c
+			}
+			if float64(k) >= u {
+				above += c
+			}
+		}
+		return min(1, 2*min(below, above)/total)
+	}
+
+	// Normal approximation with tie correction.
+	all := append(slices.Clone(a), b...)
+	slices.Sort(all)
+	tieTerm := 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j] == all[i] {
+			j++
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+	N := float64(n + m)
+	variance := float64(n*m) / 12 * ((N + 1) - tieTerm/(N*(N-1)))
+	if variance == 0 {
+		return 1 // every value identical
+	}
+	z := (math.Abs(u-float64(n*m)/2) - 0.5) / math.Sqrt(variance)
+	return math.Erfc(max(z, 0) / math.Sqrt2)
+}
+
+// ============================================================================
+// THE COMMAND
+// ============================================================================
+
+func runBench(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		return benchList()
+	}
+	if len(args) == 0 || args[0] != "compare" {
+		fmt.Fprintln(os.Stderr, "usage: lab bench list")
+		fmt.Fprintln(os.Stderr, "       lab bench compare [-count N] [-benchtime D] suite paramsA paramsB")
+		return flag.ErrHelp
+	}
+
+	flags := flag.NewFlagSet("bench compare", flag.ContinueOnError)
+	count := flags.Int("count", 8, "runs per configuration")
+	benchtime := flags.Duration("benchtime", 200*time.Millisecond, "target duration of each run")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lab bench compare [-count N] [-benchtime D] suite paramsA paramsB")
+		fmt.Fprintln(flags.Output(), "\nparams are name=value[,name=value...]; omitted ones keep their defaults.")
+		fmt.Fprintln(flags.Output(), "example: lab bench compare handoff buffer=0 buffer=64")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 3 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	if *count < 2 {
+		return fmt.Errorf("-count must be at least 2")
+	}
+	name := flags.Arg(0)
+	suite, ok := benchSuites[name]
+	if !ok {
+		return fmt.Errorf("unknown suite %q (see: lab bench list)", name)
+	}
+
+	// testing.Benchmark reads its target duration from the -test.benchtime
+	// flag, which only exists once testing.Init has registered it.
+	testing.Init()
+	if err := flag.Set("test.benchtime", benchtime.String()); err != nil {
+		return err
+	}
+
+	configs := make([]benchParams, 2)
+	benches := make([]func(*testing.B), 2)
+	for i, spec := range flags.Args()[1:] {
+		p, err := parseBenchParams(suite, spec)
+		if err != nil {
+			return err
+		}
+		if benches[i], err = suite.build(p); err != nil {
+			return err
+		}
+		configs[i] = p
+	}
+
+	fmt.Printf("suite %s: %d runs of ~%v per configuration, alternating A and B\n", name, *count, *benchtime)
+	fmt.Printf("  A: %s\n  B: %s\n\n", configs[0], configs[1])
+
+	// Alternate A and B so slow drift (thermal throttling, background load)
+	// lands on both configurations equally.
+	var nsPerOp, allocsPerOp [2][]float64
+	for range *count {
+		for i, bench := range benches {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			r := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				bench(b)
+			})
+			if r.N == 0 {
+				return fmt.Errorf("configuration %c failed to run", 'A'+i)
+			}
+			nsPerOp[i] = append(nsPerOp[i], float64(r.T.Nanoseconds())/float64(r.N))
+			allocsPerOp[i] = append(allocsPerOp[i], float64(r.AllocsPerOp()))
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tA\tB\tdelta\t")
+	row := func(metric string, samples [2][]float64, format func(float64) string) {
+		a, b := summarize(samples[0]), summarize(samples[1])
+		p := mannWhitneyP(samples[0], samples[1])
+		delta := "~"
+		if p < 0.05 && a.median != 0 {
+			delta = fmt.Sprintf("%+.1f%%", (b.median-a.median)/a.median*100)
+		}
+		fmt.Fprintf(tw, "%s\t%s ± %.0f%%\t%s ± %.0f%%\t%s\t(p=%.3f n=%d+%d)\n",
+			metric, format(a.median), a.spread*100, format(b.median), b.spread*100, delta, p, len(samples[0]), len(samples[1]))
+	}
+	row("time/op", nsPerOp, formatNs)
+	row("allocs/op", allocsPerOp, func(n float64) string { return strconv.FormatFloat(n, 'f', -1, 64) })
+	tw.Flush()
+
+	fmt.Println("\n~ means no significant difference (p >= 0.05): rerun with a larger -count")
+	fmt.Println("before concluding anything, or accept that the change doesn't matter.")
+	return nil
+}
+
+// formatNs prints a per-op time with three significant digits; time.Duration
+// would round 12.4ns to 12ns.
+func formatNs(ns float64) string {
+	switch {
+	case ns < 1e3:
+		return fmt.Sprintf("%.3gns", ns)
+	case ns < 1e6:
+		return fmt.Sprintf("%.3gµs", ns/1e3)
+	case ns < 1e9:
+		return fmt.Sprintf("%.3gms", ns/1e6)
+	}
+	return fmt.Sprintf("%.3gs", ns/1e9)
+}
+
+func benchList() error {
+	names := make([]string, 0, len(benchSuites))
+	for name := range benchSuites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	for _, name := range names {
+		s := benchSuites[name]
+		fmt.Fprintf(tw, "%s\t%s\t\n", name, s.summary)
+		for _, p := range s.params {
+			fmt.Fprintf(tw, "  %s=%s\t%s\t\n", p.name, p.def, p.usage)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/chaos"
+)
+
+// ============================================================================
+// chaos - THE TESTS, LOOPED WITH SCHEDULING NOISE AT THE CRITICAL POINTS
+// ============================================================================
+// Built with -tags chaos, the chaos.Point markers inside BoundedQueue,
+// conc.ForEach, the ch03 Cache and the ch03 WorkerPool yield or sleep at
+// random. This command builds the test binaries of the packages that have
+// such markers with that tag, and runs them over and over, each round with
+// a fresh seed in CHAOS_SEED, so every round tries a different set of
+// interleavings:
+//
+//	go run ./cmd/lab chaos -runs 100
+//	go run ./cmd/lab chaos -run BoundedQueue -race
+//
+// A failing round prints the test output and the command that reruns it
+// with the same seed.
+// ============================================================================
+
+func init() {
+	commands["chaos"] = command{"loop the tests with random scheduling noise (-tags chaos, a new seed each round)", runChaos}
+}
+
+// chaosPackages are the packages whose code, or whose tests, reach
+// chaos.Point.
+var chaosPackages = []string{
+	"./ch03_go_concurrency_building_blocks/sync_package",
+	"./ch03_go_concurrency_building_blocks/producer_consumer",
+	"./pkg/conc",
+	"./pkg/chaos",
+}
+
+// testBinary is one package's test binary, built with -tags chaos.
+type testBinary struct {
+	pkg, dir, path string
+}
+
+// buildChaosTests compiles a test binary per package into tmp. Each is run
+// in its package's directory, as go test would.
+func buildChaosTests(ctx context.Context, root, tmp string, pkgs []string, race bool) ([]testBinary, error) {
+	var bins []testBinary
+	for i, pkg := range pkgs {
+		out, err := exec.CommandContext(ctx, "go", "list", "-f", "{{.Dir}}", pkg).Output()
+		if err != nil {
+			return nil, fmt.Errorf("go list %s: %w", pkg, err)
+		}
+		b := testBinary{pkg: pkg, dir: strings.TrimSpace(string(out)), path: filepath.Join(tmp, strconv.Itoa(i)+".test")}
+		args := []string{"test", "-c", "-tags", "chaos", "-o", b.path}
+		if race {
+			args = append(args, "-race")
+		}
+		build := exec.CommandContext(ctx, "go", append(args, pkg)...)
+		build.Dir = root
+		build.Stderr = os.Stderr
+		if err := build.Run(); err != nil {
+			return nil, fmt.Errorf("go test -c -tags chaos %s: %w", pkg, err)
+		}
+		bins = append(bins, b)
+	}
+	return bins, nil
+}
+
+// runChaosRound runs one test binary with seed. A failure returns the
+// binary's output with the error.
+func runChaosRound(ctx context.Context, b testBinary, seed uint64, run string, timeout time.Duration) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, b.path, "-test.count=1", "-test.timeout="+timeout.String(), "-test.run="+run)
+	cmd.Dir = b.dir
+	cmd.Env = append(os.Environ(), chaos.SeedEnv+"="+strconv.FormatUint(seed, 10))
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return out.Bytes(), err
+}
+
+// lastLines returns at most n lines from the end of out.
+func lastLines(out []byte, n int) string {
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	return strings.Join(lines[max(0, len(lines)-n):], "\n")
+}
+
+func runChaos(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("chaos", flag.ContinueOnError)
+	runs := flags.Int("runs", 50, "rounds, each with a fresh seed")
+	seed := flags.Uint64("seed", 0, "run a single round with this seed (0: random seeds)")
+	run := flags.String("run", ".", "run only the tests matching this regexp (as go test -run)")
+	pkgList := flags.String("pkgs", strings.Join(chaosPackages, ","), "comma-separated packages whose tests are looped")
+	race := flags.Bool("race", false, "build the tests with the race detector")
+	timeout := flags.Duration("timeout", time.Minute, "fail a round whose tests take longer than this")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go run ./cmd/lab chaos [-runs N] [-seed S] [-run REGEXP] [-pkgs P,...] [-race] [-timeout D]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *runs < 1 {
+		return errors.New("-runs must be at least 1")
+	}
+
+	out, err := exec.CommandContext(ctx, "go", "env", "GOMOD").Output()
+	if err != nil {
+		return fmt.Errorf("go env GOMOD: %w", err)
+	}
+	gomod := strings.TrimSpace(string(out))
+	if gomod == "" || gomod == os.DevNull {
+		return errors.New("run lab chaos inside the module")
+	}
+	root := filepath.Dir(gomod)
+
+	tmp, err := os.MkdirTemp("", "lab-chaos")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	fmt.Printf("building the tests with -tags chaos...\n")
+	bins, err := buildChaosTests(ctx, root, tmp, strings.Split(*pkgList, ","), *race)
+	if err != nil {
+		return err
+	}
+
+	seeds := []uint64{*seed}
+	if *seed == 0 {
+		seeds = make([]uint64, *runs)
+		for i := range seeds {
+			seeds[i] = rand.Uint64() | 1
+		}
+	}
+
+	failed := 0
+	for _, b := range bins {
+		start := time.Now()
+		bad := 0
+		for _, s := range seeds {
+			out, err := runChaosRound(ctx, b, s, *run, *timeout)
+			if err == nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if bad == 0 {
+				raceFlag := ""
+				if *race {
+					raceFlag = " -race"
+				}
+				fmt.Printf("FAIL  %s\n%s\n      rerun: %s=%d go test -tags chaos%s -count=1 -run '%s' %s\n",
+					b.pkg, lastLines(out, 20), chaos.SeedEnv, s, raceFlag, *run, b.pkg)
+			}
+			bad++
+		}
+		if bad == 0 {
+			fmt.Printf("ok    %-58s %d rounds in %v\n", b.pkg, len(seeds), time.Since(start).Round(time.Millisecond))
+		} else {
+			failed++
+			fmt.Printf("      %d of %d rounds failed\n", bad, len(seeds))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d package(s) failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/profiles"
+)
+
+// ============================================================================
+// contention - A BLOCK-PROFILE GUIDED FIX, BEFORE AND AFTER
+// ============================================================================
+// criticalSectionOptimization() in chapter 3 says "keep critical sections
+// small" and shows badAppend / goodAppend side by side. This runs both and
+// lets the block profile make the case:
+//
+//	before: Lock → expensive work → append → Unlock   (work is serialized)
+//	after:  expensive work → Lock → append → Unlock   (only append is)
+//
+// 1. Enable block profiling and snapshot it.
+// 2. Run the BEFORE workload, snapshot again. The difference is the
+//    contention it caused, attributed to the exact Lock line.
+// 3. Run the AFTER workload the same way and compare.
+//
+// With -o DIR the raw profiles are written too, for the full pprof UI:
+//
+//	go tool pprof -diff_base DIR/before.pb.gz DIR/after.pb.gz
+// ============================================================================
+
+func init() {
+	commands["contention"] = command{"find an over-broad critical section with the block profile, then fix it", runContention}
+}
+
+// appender is a workload: workers goroutines each append ops values to one
+// shared slice.
+type appender func(ctx context.Context, workers, ops int, work time.Duration) []int
+
+// broadAppend is badAppend: the expensive work is done while holding the
+// lock, so workers take turns doing it.
+func broadAppend(ctx context.Context, workers, ops int, work time.Duration) []int {
+	var mu sync.Mutex
+	var data []int
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Go(func() {
+			for i := range ops {
+				if ctx.Err() != nil {
+					return
+				}
+				mu.Lock() // BEFORE: the block profile points here
+				time.Sleep(work)
+				data = append(data, (w*ops+i)*2)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return data
+}
+
+// narrowAppend is goodAppend: the work happens outside the lock and only the
+// append is serialized.
+func narrowAppend(ctx context.Context, workers, ops int, work time.Duration) []int {
+	var mu sync.Mutex
+	var data []int
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Go(func() {
+			for i := range ops {
+				if ctx.Err() != nil {
+					return
+				}
+				time.Sleep(work)
+				result := (w*ops + i) * 2
+				mu.Lock() // AFTER: held only for the append
+				data = append(data, result)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return data
+}
+
+// phase is the measured outcome of one workload.
+type phase struct {
+	name    string
+	elapsed time.Duration
+	sites   []profiles.Site // block profile sites new during this phase, minus the harness
+	items   int
+}
+
+func runContention(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("contention", flag.ContinueOnError)
+	workers := flags.Int("workers", 8, "goroutines appending concurrently")
+	ops := flags.Int("ops", 25, "appends per goroutine")
+	work := flags.Duration("work", time.Millisecond, "expensive work per append")
+	out := flags.String("o", "", "also write before.pb.gz and after.pb.gz block profiles to this directory")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lab contention [-workers N] [-ops N] [-work D] [-o dir]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *workers < 1 || *ops < 1 {
+		return fmt.Errorf("-workers and -ops must be at least 1")
+	}
+	if *out != "" {
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			return err
+		}
+	}
+
+	restore := profiles.Enable()
+	defer restore()
+
+	run := func(name string, fn appender) (phase, error) {
+		before, err := profiles.Block(0)
+		if err != nil {
+			return phase{}, err
+		}
+		start := time.Now()
+		data := fn(ctx, *workers, *ops, *work)
+		elapsed := time.Since(start)
+		if err := ctx.Err(); err != nil {
+			return phase{}, err
+		}
+		now, err := profiles.Block(0)
+		if err != nil {
+			return phase{}, err
+		}
+		if *out != "" {
+			if err := writeBlockProfile(filepath.Join(*out, name+".pb.gz")); err != nil {
+				return phase{}, err
+			}
+		}
+		// The workload function itself blocks in wg.Wait for the whole run.
+		// That is the harness waiting, not contention: leave it out.
+		harness := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+		sites := slices.DeleteFunc(profiles.Since(before, now), func(s profiles.Site) bool {
+			return s.Function == harness
+		})
+		return phase{name, elapsed, sites, len(data)}, nil
+	}
+
+	fmt.Printf("%d workers × %d appends, %v of work each\n", *workers, *ops, *work)
+	before, err := run("before", broadAppend)
+	if err != nil {
+		return err
+	}
+	after, err := run("after", narrowAppend)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []phase{before, after} {
+		fmt.Println()
+		profiles.Print(os.Stdout, fmt.Sprintf("%s - top blocking sites:", p.name), p.sites[:min(3, len(p.sites))])
+	}
+
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tWall time\tBlocked events\tTime blocked\tItems")
+	for _, p := range []phase{before, after} {
+		count, delay := profiles.Total(p.sites)
+		fmt.Fprintf(tw, "%s\t%v\t%d\t%v\t%d\n", p.name, p.elapsed.Round(time.Millisecond), count, delay.Round(time.Millisecond), p.items)
+	}
+	tw.Flush()
+
+	_, beforeDelay := profiles.Total(before.sites)
+	_, afterDelay := profiles.Total(after.sites)
+	if afterDelay > 0 {
+		fmt.Printf("\nShrinking the critical section cut time spent blocked %.0fx and wall time %.1fx.\n",
+			float64(beforeDelay)/float64(afterDelay), float64(before.elapsed)/float64(after.elapsed))
+	} else {
+		fmt.Printf("\nShrinking the critical section removed blocking entirely and cut wall time %.1fx.\n",
+			float64(before.elapsed)/float64(after.elapsed))
+	}
+	if *out != "" {
+		fmt.Printf("Profiles written: go tool pprof -diff_base %s %s\n",
+			filepath.Join(*out, "before.pb.gz"), filepath.Join(*out, "after.pb.gz"))
+	}
+	return nil
+}
+
+// writeBlockProfile saves the cumulative block profile in pprof's binary
+// format.
+func writeBlockProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup("block").WriteTo(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+	"github.com/mintecr7/concurrency-with-go/pkg/inject"
+	"github.com/mintecr7/concurrency-with-go/pkg/progress"
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// ============================================================================
+// download - A DOWNLOAD MANAGER: LIMIT, RETRY, LIVE PROGRESS
+// ============================================================================
+// Most of the repo's pieces in one program:
+//
+//	resources ──► conc.MapSlice (limit -parallel) ──► fetch, retry with jitter
+//	                     │ atomic counters
+//	                     ▼
+//	              progress.Reporter ──► one status line, redrawn every -every
+//
+// - workers:   conc.MapSlice runs at most -parallel downloads; Ctrl-C
+//              cancels its context and every request with it.
+// - retries:   a failed attempt (connection error, 5xx, a body cut short)
+//              is retried up to -retries times after a backoff with full
+//              jitter, so failed downloads don't all come back at once.
+//              A 4xx or a cancellation is final.
+// - progress:  workers only ever Add to atomic counters. The reporter
+//              goroutine of pkg/progress reads them and redraws the status
+//              line; the workers never print, block or take a lock for it.
+//
+// With URLs as arguments it downloads them. Without, it starts a local
+// server whose resources stream at a limited rate, sit behind an
+// inject.Injector (latency, failed connections) and sometimes drop the
+// connection mid-body - something to retry against.
+// ============================================================================
+
+func init() {
+	commands["download"] = command{"download resources with bounded concurrency, retries and a live progress line", runDownload}
+}
+
+// resource is one thing to download; name is the file it is saved as.
+type resource struct {
+	url, name string
+}
+
+// fetched is the outcome of one resource.
+type fetched struct {
+	resource
+	bytes    int64
+	attempts int
+	took     time.Duration
+	err      error
+}
+
+// transferStats are the counters the progress line shows besides the
+// number of resources done. Workers only ever Add to them.
+type transferStats struct {
+	bytes, retries, busy atomic.Int64
+}
+
+func runDownload(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("download", flag.ContinueOnError)
+	parallel := flags.Int("parallel", 4, "downloads at once")
+	retries := flags.Int("retries", 3, "retries per resource after the first attempt")
+	dir := flags.String("dir", "", "save files here (default: discard the data)")
+	every := flags.Duration("every", 200*time.Millisecond, "progress line refresh interval")
+	n := flags.Int("n", 24, "simulated resources (without URL arguments)")
+	failRate := flags.Float64("fail", 0.2, "simulated: fraction of connections that fail")
+	cutRate := flags.Float64("cut", 0.1, "simulated: fraction of bodies cut short")
+	rate := flags.Int("rate", 2<<20, "simulated: bytes/second per connection")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lab download [-parallel N] [-retries N] [-dir D] [URL...]")
+		fmt.Fprintln(flags.Output(), "\nWithout URLs, downloads -n resources from a simulated, flaky local server.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *parallel < 1 || *n < 1 || *rate < 1 {
+		return fmt.Errorf("-parallel, -n and -rate must be at least 1")
+	}
+
+	client := http.DefaultClient
+	var resources []resource
+	if flags.NArg() > 0 {
+		for i, u := range flags.Args() {
+			resources = append(resources, resource{u, fmt.Sprintf("%02d-%s", i, path.Base(u))})
+		}
+	} else {
+		srv := newFlakyServer(*n, *rate, *cutRate)
+		defer srv.Close()
+		in := inject.New(inject.Config{Latency: 20 * time.Millisecond, Jitter: 30 * time.Millisecond, ErrorRate: *failRate})
+		client = &http.Client{Transport: in.RoundTripper(srv.Client().Transport)}
+		for i := range *n {
+			resources = append(resources, resource{fmt.Sprintf("%s/r/%d", srv.URL, i), fmt.Sprintf("resource-%02d.bin", i)})
+		}
+		fmt.Fprintf(os.Stderr, "simulated server %s: %d resources, %.1f MB/s per connection, %.0f%% failed connections, %.0f%% cut bodies\n",
+			srv.URL, *n, float64(*rate)/(1<<20), *failRate*100, *cutRate*100)
+	}
+	if *dir != "" {
+		if err := os.MkdirAll(*dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	st := &transferStats{}
+	start := time.Now()
+	bar := progress.Start(ctx, progress.Config{
+		Total: int64(len(resources)),
+		Unit:  "resources",
+		Every: *every,
+		Extra: func() string {
+			mb := float64(st.bytes.Load()) / (1 << 20)
+			return fmt.Sprintf("%d active  %.1f MB  %.1f MB/s  %d retries",
+				st.busy.Load(), mb, mb/time.Since(start).Seconds(), st.retries.Load())
+		},
+	})
+	results, err := conc.MapSlice(ctx, resources, *parallel, func(ctx context.Context, r resource) (fetched, error) {
+		st.busy.Add(1)
+		defer st.busy.Add(-1)
+		f := fetchWithRetry(ctx, client, r, *dir, *retries, st)
+		switch {
+		case f.err == nil:
+			bar.Add(1)
+		case ctx.Err() == nil: // cancelled downloads didn't fail
+			bar.Fail(1)
+		}
+		return f, nil // one failed resource doesn't stop the others
+	})
+	bar.Stop()
+	if err != nil {
+		return err // cancelled: results is nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nresource\tbytes\tattempts\ttime\tresult")
+	var failed int
+	var total int64
+	for _, f := range results {
+		result := "ok"
+		if f.err != nil {
+			result = f.err.Error()
+			failed++
+		}
+		total += f.bytes
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%s\n", f.name, f.bytes, f.attempts, f.took.Round(time.Millisecond), result)
+	}
+	tw.Flush()
+	elapsed := time.Since(start)
+	fmt.Printf("\n%d of %d downloaded, %.1f MB in %v (%.1f MB/s), %d retries\n",
+		len(results)-failed, len(results), float64(total)/(1<<20), elapsed.Round(time.Millisecond),
+		float64(total)/(1<<20)/elapsed.Seconds(), st.retries.Load())
+	if failed > 0 {
+		return fmt.Errorf("%d downloads failed", failed)
+	}
+	return nil
+}
+
+// fetchWithRetry downloads r, retrying retryable failures with jittered
+// exponential backoff.
+func fetchWithRetry(ctx context.Context, client *http.Client, r resource, dir string, retries int, st *transferStats) fetched {
+	f := fetched{resource: r}
+	start := time.Now()
+	backoff := 100 * time.Millisecond
+	for {
+		f.attempts++
+		f.bytes, f.err = fetch(ctx, client, r, dir, st)
+		if f.err == nil || !retryable(ctx, f.err) || f.attempts > retries {
+			break
+		}
+		st.retries.Add(1)
+		if timeutil.Sleep(ctx, rand.N(backoff)) != nil { // full jitter: [0, backoff)
+			f.err = ctx.Err()
+			break
+		}
+		backoff *= 2
+	}
+	f.took = time.Since(start)
+	return f
+}
+
+// httpError is a response with a non-2xx status.
+type httpError struct{ code int }
+
+func (e httpError) Error() string { return "HTTP " + strconv.Itoa(e.code) }
+
+// retryable reports whether another attempt could succeed: not after a
+// cancellation, and not for a 4xx, which will only be repeated.
+func retryable(ctx context.Context, err error) bool {
+	var he httpError
+	switch {
+	case ctx.Err() != nil:
+		return false
+	case errors.As(err, &he):
+		return he.code >= 500
+	}
+	return true
+}
+
+// fetch makes one attempt. Received bytes are added to st as they arrive,
+// so the progress line moves during a long download.
+func fetch(ctx context.Context, client *http.Client, r resource, dir string, st *transferStats) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return 0, httpError{resp.StatusCode}
+	}
+	body := countingReader{resp.Body, &st.bytes}
+	if dir == "" {
+		return io.Copy(io.Discard, body)
+	}
+
+	// Write to a temporary file and rename it on success, so a failed
+	// attempt never leaves a truncated file under the real name.
+	tmp, err := os.CreateTemp(dir, r.name+".part*")
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(tmp, body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(dir, r.name))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return n, err
+}
+
+// countingReader adds every byte read to n.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// newFlakyServer serves n resources of 256KB-2MB at /r/{i}, streamed at
+// rate bytes/second. A fraction cut of the responses stop halfway and drop
+// the connection.
+func newFlakyServer(n, rate int, cut float64) *httptest.Server {
+	sizes := make([]int, n)
+	rng := rand.New(rand.NewPCG(1, 2)) // the same sizes every run
+	for i := range sizes {
+		sizes[i] = 256<<10 + rng.IntN(7*256<<10)
+	}
+	chunk := make([]byte, 32<<10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /r/{i}", func(w http.ResponseWriter, req *http.Request) {
+		i, err := strconv.Atoi(req.PathValue("i"))
+		if err != nil || i < 0 || i >= n {
+			http.NotFound(w, req)
+			return
+		}
+		size := sizes[i]
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		cutAt := size + 1
+		if rand.Float64() < cut {
+			cutAt = size / 2
+		}
+		for sent := 0; sent < size; sent += len(chunk) {
+			if sent >= cutAt {
+				panic(http.ErrAbortHandler) // drop the connection mid-body
+			}
+			part := chunk[:min(len(chunk), size-sent)]
+			if _, err := w.Write(part); err != nil {
+				return
+			}
+			if timeutil.Sleep(req.Context(), time.Duration(len(part))*time.Second/time.Duration(rate)) != nil {
+				return
+			}
+		}
+	})
+	return httptest.NewServer(mux)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/litmus"
+)
+
+// ============================================================================
+// litmus - DOES THE HARDWARE REALLY REORDER MY RACY CODE?
+// ============================================================================
+// The memory model says a racy program may observe outcomes no interleaving
+// explains. This runs the classic litmus tests (see pkg/litmus) millions of
+// times, with plain variables and with sync/atomic, and counts how often the
+// forbidden outcome happens:
+//
+//	go run ./cmd/lab litmus -n 2000000
+//	go run ./cmd/lab litmus -test SB
+// ============================================================================
+
+func init() {
+	commands["litmus"] = command{"run SB/MP/LB memory-model litmus tests, plain vs. atomic", runLitmus}
+}
+
+func runLitmus(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("litmus", flag.ContinueOnError)
+	n := flags.Int("n", 1_000_000, "iterations per test")
+	only := flags.String("test", "", "run only this test (SB, MP or LB)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lab litmus [-n N] [-test SB|MP|LB]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *n < 1 {
+		return fmt.Errorf("-n must be at least 1")
+	}
+
+	fmt.Printf("%s/%s, %d CPU(s), GOMAXPROCS=%d, %d iterations per test\n",
+		runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), runtime.GOMAXPROCS(0), *n)
+	if runtime.GOMAXPROCS(0) < 2 {
+		fmt.Println("note: the two threads cannot run at the same time here, so they take")
+		fmt.Println("      turns and only sequentially consistent outcomes can appear")
+	}
+	fmt.Println()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "test\tvariant\tforbidden\tseen\ttime\toutcomes")
+	ran := 0
+	var racyForbidden, atomicForbidden int
+	for _, t := range litmus.Tests() {
+		if *only != "" && !strings.EqualFold(*only, t.Name) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := time.Now()
+		r := litmus.Run(t, *n)
+		ran++
+
+		// Most frequent outcome first.
+		outcomes := slices.SortedFunc(maps.Keys(r.Counts), func(a, b litmus.Outcome) int {
+			return cmp.Compare(r.Counts[b], r.Counts[a])
+		})
+		parts := make([]string, len(outcomes))
+		for i, o := range outcomes {
+			parts[i] = fmt.Sprintf("%s:%d", o, r.Counts[o])
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%v\t%s\n", t.Name, t.Variant, t.Forbidden, r.ForbiddenSeen(),
+			time.Since(start).Round(time.Millisecond), strings.Join(parts, "  "))
+		if t.Variant == "atomic" {
+			atomicForbidden += r.ForbiddenSeen()
+		} else {
+			racyForbidden += r.ForbiddenSeen()
+		}
+	}
+	tw.Flush()
+	if ran == 0 {
+		return fmt.Errorf("no test named %q", *only)
+	}
+
+	fmt.Println()
+	switch {
+	case atomicForbidden > 0:
+		// sync/atomic is sequentially consistent: this would be a runtime bug.
+		return fmt.Errorf("a forbidden outcome appeared with sync/atomic (%d times)", atomicForbidden)
+	case racyForbidden > 0:
+		fmt.Println("→ Plain variables produced outcomes NO interleaving can explain:")
+		fmt.Println("  the CPU (or compiler) reordered memory operations. sync/atomic never did.")
+	default:
+		fmt.Println("→ No forbidden outcome this time. That is luck or hardware, not a")
+		fmt.Println("  guarantee: the memory model allows them for the plain variants.")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Command lab bundles the repo's hands-on experiments that work on real
+// input (files, subprocesses, profiles) rather than canned demo data.
+//
+//	go run ./cmd/lab                       # list subcommands
+//	go run ./cmd/lab md5sum -parallel 8 .  # checksum a directory tree
+//	go run ./cmd/lab md5sum -checkpoint c.json .  # ...resumable after Ctrl-C
+//	go run ./cmd/lab download              # bounded downloads with retries and live progress
+//	go run ./cmd/lab contention            # block profile, before/after a fix
+//	go run ./cmd/lab queuecheck            # random schedules vs. the bounded queues
+//	go run ./cmd/lab chaos                 # the tests, looped with scheduling noise and random seeds
+//	go run ./cmd/lab bench list            # A/B benchmark suites and their knobs
+//	go run ./cmd/lab bench compare walk impl=walkdir impl=fswalk  # parallel walk vs. WalkDir
+//	go run ./cmd/lab litmus                # memory-model litmus tests
+//	go run ./cmd/lab races                 # ch01's data races, summarized
+//	go run ./cmd/lab vet                   # static checks for the classic mistakes
+//	go run ./cmd/lab replay -record r.jsonl  # capture a racy interleaving
+//
+// Every subcommand parses its own flags: go run ./cmd/lab <name> -h.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"text/tabwriter"
+)
+
+// command is one subcommand of lab.
+type command struct {
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+// commands is filled in by the init functions of the subcommand files.
+var commands = map[string]command{}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "lab: unknown subcommand %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	// Ctrl-C cancels the context; every subcommand is expected to stop
+	// promptly and clean up when it does.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, args); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "lab %s: %v\n", name, err)
+		}
+		stop()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: lab <subcommand> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "\nsubcommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(os.Stderr, 0, 1, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, commands[name].summary)
+	}
+	tw.Flush()
+}
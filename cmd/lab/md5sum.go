@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/pipeline"
+)
+
+// ============================================================================
+// md5sum - THE PARALLEL DIGEST EXAMPLE, ON REAL FILES
+// ============================================================================
+// The "Go Concurrency Patterns: Pipelines and cancellation" article builds
+// MD5 of a directory tree three ways. This is its final, BOUNDED version:
+//
+//	walk ──paths──► digester ×N ──results──► collect ──► sorted report
+//
+// - walk:      one goroutine runs filepath.WalkDir and sends every regular
+//              file's path. It stops as soon as ctx is cancelled.
+// - digester:  a FIXED number of goroutines read paths and hash files. That
+//              bounds open files and memory no matter how big the tree is
+//              (the unbounded version starts one goroutine per file).
+// - collect:   the calling goroutine gathers results into a slice and sorts
+//              it by path, so the report is deterministic even though files
+//              finish in any order.
+//
+// Errors: the first error (unreadable file, walk failure, Ctrl-C) cancels
+// ctx. walk stops sending, digesters stop hashing, every goroutine exits,
+// and the error is returned. Nothing leaks, even on failure.
+//
+// Resume (-checkpoint FILE): WalkDir visits entries in lexical order, so a
+// file's position in the walk is a stable sequence number while the tree
+// doesn't change. collect prints lines in that order as soon as they are
+// contiguous and marks them in a pipeline.Checkpoint, which saves the
+// watermark to FILE every -every. After Ctrl-C or -timeout, rerunning with
+// the same FILE skips every file below the watermark:
+//
+//	lab md5sum -checkpoint sums.ckpt -timeout 2s ~/src > sums.txt
+//	lab md5sum -checkpoint sums.ckpt ~/src >> sums.txt   # picks up there
+//
+// A few lines printed after the last save may repeat (at-least-once). FILE
+// is removed once the whole tree is done.
+// ============================================================================
+
+func init() {
+	commands["md5sum"] = command{"checksum every file under a directory with bounded parallelism", runMD5Sum}
+}
+
+// digest is one line of the report.
+type digest struct {
+	seq  int64 // position in the walk
+	path string
+	sum  [md5.Size]byte
+	size int64
+}
+
+func runMD5Sum(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("md5sum", flag.ContinueOnError)
+	parallel := flags.Int("parallel", runtime.GOMAXPROCS(0), "number of files hashed at once")
+	timeout := flags.Duration("timeout", 0, "give up after this long (0 = no limit)")
+	checkpoint := flags.String("checkpoint", "", "save progress to this file and resume from it")
+	every := flags.Duration("every", time.Second, "how often the checkpoint is saved")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lab md5sum [-parallel N] [-timeout D] [-checkpoint FILE [-every D]] [dir]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *parallel < 1 {
+		return fmt.Errorf("-parallel must be at least 1")
+	}
+	root := "."
+	if flags.NArg() > 0 {
+		root = flags.Arg(0)
+	}
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	if *checkpoint != "" {
+		return md5Resumable(ctx, root, *parallel, *checkpoint, *every, start)
+	}
+	digests, err := md5All(ctx, root, *parallel)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, d := range digests {
+		fmt.Printf("%x  %s\n", d.sum, d.path)
+		total += d.size
+	}
+	fmt.Fprintf(os.Stderr, "\n%d files, %.1f MB in %v with %d digesters\n",
+		len(digests), float64(total)/(1<<20), time.Since(start).Round(time.Millisecond), *parallel)
+	return nil
+}
+
+// md5Resumable is runMD5Sum with a checkpoint: it prints digests in walk
+// order as they become contiguous and records them in the checkpoint file.
+func md5Resumable(ctx context.Context, root string, parallel int, file string, every time.Duration, start time.Time) error {
+	cp, err := pipeline.OpenCheckpoint(pipeline.FileStore(file), every)
+	if err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	from := cp.Resume()
+	if from > 0 {
+		fmt.Fprintf(os.Stderr, "resuming after %d files (%s)\n", from, file)
+	}
+
+	// Digesters finish out of order; hold results until the ones before
+	// them are printed, so that the watermark matches the output.
+	pending := map[int64]digest{}
+	next := from
+	var printed, total int64
+	var saveErr error
+	err = md5Walk(ctx, root, parallel, from, func(d digest) {
+		pending[d.seq] = d
+		for d, ok := pending[next]; ok; d, ok = pending[next] {
+			delete(pending, next)
+			fmt.Printf("%x  %s\n", d.sum, d.path)
+			printed++
+			total += d.size
+			if err := cp.Done(next); err != nil && saveErr == nil {
+				saveErr = err
+			}
+			next++
+		}
+	})
+	if ferr := cp.Flush(); ferr != nil && saveErr == nil {
+		saveErr = ferr
+	}
+	fmt.Fprintf(os.Stderr, "\n%d files, %.1f MB in %v with %d digesters\n",
+		printed, float64(total)/(1<<20), time.Since(start).Round(time.Millisecond), parallel)
+	if saveErr != nil {
+		return fmt.Errorf("checkpoint: %w", saveErr)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "stopped after %d files; rerun with -checkpoint %s to resume\n", cp.Resume(), file)
+		return err
+	}
+	return os.Remove(file) // finished: the next run starts from scratch
+}
+
+// md5All hashes every regular file under root using `parallel` digesters and
+// returns the digests sorted by path. On the first error every stage stops
+// and that error is returned.
+func md5All(ctx context.Context, root string, parallel int) ([]digest, error) {
+	var digests []digest
+	if err := md5Walk(ctx, root, parallel, 0, func(d digest) { digests = append(digests, d) }); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(digests, func(a, b digest) int { return strings.Compare(a.path, b.path) })
+	return digests, nil
+}
+
+// md5Walk runs the walk and digest stages, skipping the first `from` files
+// of the walk, and calls collect on the calling goroutine for every digest,
+// in the order they finish.
+func md5Walk(ctx context.Context, root string, parallel int, from int64, collect func(digest)) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// Stage 1: walk the tree.
+	type item struct {
+		seq  int64
+		path string
+	}
+	paths := make(chan item)
+	walkErr := make(chan error, 1)
+	go func() {
+		defer close(paths)
+		var seq int64
+		walkErr <- filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			seq++
+			if seq <= from {
+				return nil // done by an earlier run
+			}
+			select {
+			case paths <- item{seq - 1, path}:
+				return nil
+			case <-ctx.Done():
+				return context.Cause(ctx) // abort the walk
+			}
+		})
+	}()
+
+	// Stage 2: a bounded number of digesters.
+	results := make(chan digest)
+	var wg sync.WaitGroup
+	for range parallel {
+		wg.Go(func() {
+			for it := range paths {
+				d, err := hashFile(ctx, it.path)
+				if err != nil {
+					cancel(err) // first error wins; later ones are ignored
+					return
+				}
+				d.seq = it.seq
+				select {
+				case results <- d:
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Stage 3: collect.
+	for d := range results {
+		collect(d)
+	}
+
+	// Every digester has exited; now the walker's outcome is final too.
+	if err := <-walkErr; err != nil {
+		cancel(err)
+	}
+	return context.Cause(ctx)
+}
+
+// hashFile hashes one file, checking ctx between chunks so a huge file
+// doesn't delay cancellation.
+func hashFile(ctx context.Context, path string) (digest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return digest{}, err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	buf := make([]byte, 64<<10)
+	var size int64
+	for {
+		if err := context.Cause(ctx); err != nil {
+			return digest{}, err
+		}
+		n, err := f.Read(buf)
+		h.Write(buf[:n])
+		size += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return digest{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	d := digest{path: path, size: size}
+	h.Sum(d.sum[:0])
+	return d, nil
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	producerconsumer "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/producer_consumer"
+	syncpackage "github.com/mintecr7/concurrency-with-go/ch03_go_concurrency_building_blocks/sync_package"
+)
+
+// ============================================================================
+// queuecheck - RANDOMIZED INVARIANT CHECKS FOR THE BOUNDED QUEUES
+// ============================================================================
+// A stress test runs one fixed shape: N producers, M consumers, a fixed
+// capacity. Bugs in concurrent queues hide in the shapes nobody wrote down -
+// capacity 1 with eight producers, a Close that lands while producers are
+// blocked, a consumer that yields between every Get. queuecheck draws the
+// shape from a seed, runs it, and checks what must hold for ANY shape:
+//
+//  1. Conservation: every item a producer got accepted is delivered exactly
+//     once - nothing lost, nothing duplicated, nothing invented.
+//  2. Per-producer FIFO: one producer's items reach any single consumer in
+//     the order that producer put them in.
+//  3. Bounded: the queue never reports more items than its capacity.
+//
+// Every run prints its seed; -seed reruns one exactly (as far as the
+// scheduler allows), which is the whole point of a fuzzer's corpus entry.
+//
+// The same invariants are go test fuzz targets next to the queues, which
+// let the fuzzer search for schedules instead of drawing them blindly:
+//
+//	go test ./ch03_go_concurrency_building_blocks/sync_package -fuzz FuzzBoundedQueue
+//	go test ./ch03_go_concurrency_building_blocks/producer_consumer -fuzz FuzzSemaphoreBuffer
+// ============================================================================
+
+func init() {
+	commands["queuecheck"] = command{"random producer/consumer schedules against the bounded queues, checking invariants", runQueueCheck}
+}
+
+// msg is one queued item: who produced it and its position in that
+// producer's sequence.
+type msg struct {
+	producer, seq int
+}
+
+// plan is one randomly drawn schedule.
+type plan struct {
+	producers, consumers int
+	capacity             int
+	perProducer          int     // items each producer tries to put
+	tryRatio             float64 // fraction of puts that use TryEnqueue
+	yieldRatio           float64 // fraction of operations followed by runtime.Gosched
+	closeAfter           int     // BoundedQueue: close after this many accepted items (-1: after the producers)
+	seed                 uint64
+}
+
+func drawPlan(seed uint64, maxGoroutines, maxItems int) plan {
+	r := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	p := plan{
+		producers:   1 + r.IntN(maxGoroutines),
+		consumers:   1 + r.IntN(maxGoroutines),
+		capacity:    1 + r.IntN(8),
+		perProducer: 1 + r.IntN(maxItems),
+		tryRatio:    []float64{0, 0, 0.3, 0.9}[r.IntN(4)],
+		yieldRatio:  []float64{0, 0.1, 0.5}[r.IntN(3)],
+		closeAfter:  -1,
+		seed:        seed,
+	}
+	if r.IntN(2) == 0 {
+		p.closeAfter = r.IntN(p.producers*p.perProducer + 1)
+	}
+	return p
+}
+
+func (p plan) String() string {
+	closing := "after producers"
+	if p.closeAfter >= 0 {
+		closing = fmt.Sprintf("after %d accepted", p.closeAfter)
+	}
+	return fmt.Sprintf("seed=%d producers=%d consumers=%d cap=%d items/producer=%d try=%.0f%% yield=%.0f%% close %s",
+		p.seed, p.producers, p.consumers, p.capacity, p.perProducer, p.tryRatio*100, p.yieldRatio*100, closing)
+}
+
+// ledger collects what producers got accepted and what consumers received,
+// and checks the invariants at the end.
+type ledger struct {
+	accepted [][]bool // [producer][seq]
+	received [][]msg  // [consumer] in arrival order
+	overCap  atomic.Int64
+}
+
+func newLedger(p plan) *ledger {
+	l := &ledger{accepted: make([][]bool, p.producers), received: make([][]msg, p.consumers)}
+	for i := range l.accepted {
+		l.accepted[i] = make([]bool, p.perProducer)
+	}
+	return l
+}
+
+func (l *ledger) check() error {
+	seen := make([][]int, len(l.accepted))
+	for i := range seen {
+		seen[i] = make([]int, len(l.accepted[i]))
+	}
+	for c, got := range l.received {
+		last := make(map[int]int) // producer → last seq this consumer saw
+		for _, m := range got {
+			if m.producer < 0 || m.producer >= len(seen) || m.seq < 0 || m.seq >= len(seen[m.producer]) {
+				return fmt.Errorf("consumer %d received an item nobody produced: %+v", c, m)
+			}
+			if prev, ok := last[m.producer]; ok && m.seq <= prev {
+				return fmt.Errorf("FIFO: consumer %d got producer %d's item %d after item %d", c, m.producer, m.seq, prev)
+			}
+			last[m.producer] = m.seq
+			seen[m.producer][m.seq]++
+		}
+	}
+	for p := range seen {
+		for s, n := range seen[p] {
+			switch {
+			case l.accepted[p][s] && n == 0:
+				return fmt.Errorf("lost: producer %d's item %d was accepted but never delivered", p, s)
+			case !l.accepted[p][s] && n > 0:
+				return fmt.Errorf("producer %d's item %d was rejected but delivered", p, s)
+			case n > 1:
+				return fmt.Errorf("duplicated: producer %d's item %d delivered %d times", p, s, n)
+			}
+		}
+	}
+	if n := l.overCap.Load(); n > 0 {
+		return fmt.Errorf("queue reported more items than its capacity %d times", n)
+	}
+	return nil
+}
+
+// yielder returns a per-goroutine "maybe yield" function with its own
+// random stream, so goroutines never share a *rand.Rand.
+func yielder(p plan, stream uint64) func() {
+	r := rand.New(rand.NewPCG(p.seed, stream))
+	return func() {
+		if r.Float64() < p.yieldRatio {
+			runtime.Gosched()
+		}
+	}
+}
+
+// checkBoundedQueue runs p against syncpackage.BoundedQueue, including
+// TryEnqueue and a Close that may land in the middle of production.
+func checkBoundedQueue(p plan) error {
+	q := syncpackage.NewBoundedQueue[msg](p.capacity)
+	l := newLedger(p)
+
+	var acceptedTotal atomic.Int64
+	var closeOnce sync.Once
+	closeQueue := func() { closeOnce.Do(q.Close) }
+	if p.closeAfter == 0 {
+		closeQueue()
+	}
+
+	var producers, consumers sync.WaitGroup
+	for c := range p.consumers {
+		consumers.Go(func() {
+			maybeYield := yielder(p, uint64(1000+c))
+			for {
+				m, ok := q.Dequeue()
+				if !ok {
+					return
+				}
+				l.received[c] = append(l.received[c], m)
+				maybeYield()
+			}
+		})
+	}
+	for pr := range p.producers {
+		producers.Go(func() {
+			r := rand.New(rand.NewPCG(p.seed, uint64(pr)))
+			for s := range p.perProducer {
+				var ok bool
+				if r.Float64() < p.tryRatio {
+					ok = q.TryEnqueue(msg{pr, s})
+				} else {
+					ok = q.Enqueue(msg{pr, s}) == nil
+				}
+				if n := q.Len(); n > q.Cap() {
+					l.overCap.Add(1)
+				}
+				if ok {
+					l.accepted[pr][s] = true
+					if int(acceptedTotal.Add(1)) == p.closeAfter {
+						closeQueue()
+					}
+				}
+				if r.Float64() < p.yieldRatio {
+					runtime.Gosched()
+				}
+			}
+		})
+	}
+	producers.Wait()
+	closeQueue()
+	consumers.Wait()
+	return l.check()
+}
+
+// checkBuffer runs p against a producerconsumer.Buffer, which has no Close
+// and no non-blocking put: consumers claim exactly as many Gets as there
+// are items.
+func checkBuffer(p plan, b producerconsumer.Buffer[msg]) error {
+	l := newLedger(p)
+	remaining := atomic.Int64{}
+	remaining.Store(int64(p.producers * p.perProducer))
+
+	var wg sync.WaitGroup
+	for c := range p.consumers {
+		wg.Go(func() {
+			maybeYield := yielder(p, uint64(1000+c))
+			for remaining.Add(-1) >= 0 {
+				l.received[c] = append(l.received[c], b.Get())
+				maybeYield()
+			}
+		})
+	}
+	for pr := range p.producers {
+		wg.Go(func() {
+			maybeYield := yielder(p, uint64(pr))
+			for s := range p.perProducer {
+				b.Put(msg{pr, s})
+				l.accepted[pr][s] = true
+				maybeYield()
+			}
+		})
+	}
+	wg.Wait()
+	return l.check()
+}
+
+// queueTargets are the implementations queuecheck exercises.
+var queueTargets = []struct {
+	name  string
+	check func(plan) error
+}{
+	{"syncpackage.BoundedQueue", checkBoundedQueue},
+	{"producerconsumer.CondBuffer", func(p plan) error {
+		return checkBuffer(p, producerconsumer.NewCondBuffer[msg](p.capacity))
+	}},
+	{"producerconsumer.ChanBuffer", func(p plan) error {
+		return checkBuffer(p, producerconsumer.NewChanBuffer[msg](p.capacity))
+	}},
+	{"producerconsumer.SemaphoreBuffer", func(p plan) error {
+		return checkBuffer(p, producerconsumer.NewSemaphoreBuffer[msg](p.capacity))
+	}},
+}
+
+// runWithin runs check and reports a hang as an error: a schedule that
+// deadlocks is a failure too, and it should print its seed like any other.
+func runWithin(ctx context.Context, d time.Duration, check func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- check() }()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("no progress after %v (deadlock or lost wake-up?)", d)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func runQueueCheck(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("queuecheck", flag.ContinueOnError)
+	runs := flags.Int("runs", 500, "random schedules per implementation")
+	seed := flags.Uint64("seed", 0, "run only the schedule with this seed (0: random seeds)")
+	maxGoroutines := flags.Int("goroutines", 8, "maximum producers, and maximum consumers, per schedule")
+	maxItems := flags.Int("items", 200, "maximum items per producer")
+	timeout := flags.Duration("timeout", 10*time.Second, "fail a schedule that takes longer than this")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lab queuecheck [-runs N] [-seed S] [-goroutines N] [-items N] [-timeout D]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *runs < 1 || *maxGoroutines < 1 || *maxItems < 1 {
+		return fmt.Errorf("-runs, -goroutines and -items must be at least 1")
+	}
+
+	seeds := []uint64{*seed}
+	if *seed == 0 {
+		seeds = make([]uint64, *runs)
+		for i := range seeds {
+			seeds[i] = rand.Uint64() | 1 // never 0, so every seed can be passed back to -seed
+		}
+	}
+
+	failed := 0
+	for _, target := range queueTargets {
+		start := time.Now()
+		var firstErr error
+		var firstPlan plan
+		bad := 0
+		for _, s := range seeds {
+			p := drawPlan(s, *maxGoroutines, *maxItems)
+			if err := runWithin(ctx, *timeout, func() error { return target.check(p) }); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if bad == 0 {
+					firstErr, firstPlan = err, p
+				}
+				bad++
+			}
+		}
+		if bad == 0 {
+			fmt.Printf("ok    %-34s %d schedules in %v\n", target.name, len(seeds), time.Since(start).Round(time.Millisecond))
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL  %-34s %d of %d schedules\n", target.name, bad, len(seeds))
+		fmt.Printf("      %v\n      %s\n", firstErr, firstPlan)
+		fmt.Printf("      rerun: go run ./cmd/lab queuecheck -seed %d\n", firstPlan.seed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d implementation(s) broke an invariant", failed)
+	}
+	return nil
+}
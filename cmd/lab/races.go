@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/racereport"
+)
+
+// ============================================================================
+// races - THE RACE DETECTOR'S REPORTS, AS A TABLE
+// ============================================================================
+// ch01 races on purpose. Run under -race, each demo prints pages of stack
+// traces; this builds ch01 once with -race, runs the chosen demos as
+// subprocesses, parses their reports (see pkg/racereport) and prints one
+// row per distinct race: the two conflicting accesses and where the
+// goroutines involved were started.
+//
+//	go run ./cmd/lab races
+//	go run ./cmd/lab races -demos atomicity -raw
+//
+// Run it from the repository root: it builds the ch01 package with go build.
+// ============================================================================
+
+func init() {
+	commands["races"] = command{"run the ch01 demos under -race and summarize the races found", runRaces}
+}
+
+// raceRow is one distinct race within a demo, and how often it was reported.
+type raceRow struct {
+	race  racereport.Race
+	count int
+}
+
+func runRaces(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("races", flag.ContinueOnError)
+	demoList := flags.String("demos", "race,atomicity,memsync", "comma-separated ch01 demos to run")
+	pkg := flags.String("pkg", "./ch01_introduction", "package with the demos, built with -race")
+	raw := flags.Bool("raw", false, "also print each demo's stderr unparsed")
+	timeout := flags.Duration("timeout", time.Minute, "kill a demo that runs longer than this")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lab races [-demos a,b] [-pkg dir] [-raw] [-timeout D]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "lab-races")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	bin := filepath.Join(dir, "demos")
+	fmt.Printf("building %s with -race...\n", *pkg)
+	build := exec.CommandContext(ctx, "go", "build", "-race", "-o", bin, *pkg)
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		return fmt.Errorf("go build -race %s: %w", *pkg, err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "demo\taccess\tconflicts with\tgoroutines (created at)\treports")
+	var results []string
+	total := 0
+	for _, demo := range strings.Split(*demoList, ",") {
+		demo = strings.TrimSpace(demo)
+		if demo == "" {
+			continue
+		}
+		stderr, exit, err := runRaceDemo(ctx, bin, demo, *timeout)
+		if err != nil {
+			return fmt.Errorf("demo %s: %w", demo, err)
+		}
+		if *raw {
+			fmt.Printf("\n--- %s: raw stderr ---\n%s", demo, stderr)
+		}
+		races, err := racereport.Parse(bytes.NewReader(stderr))
+		if err != nil {
+			return fmt.Errorf("demo %s: parsing race reports: %w", demo, err)
+		}
+		if len(races) == 0 && exit != 0 && exit != 66 {
+			return fmt.Errorf("demo %s exited with status %d and reported no races:\n%s", demo, exit, stderr)
+		}
+
+		// The same pair of lines racing again is one row with a count.
+		var rows []*raceRow
+		byKey := map[string]*raceRow{}
+		for _, r := range races {
+			if row, ok := byKey[r.Key()]; ok {
+				row.count++
+				continue
+			}
+			row := &raceRow{race: r, count: 1}
+			byKey[r.Key()] = row
+			rows = append(rows, row)
+		}
+		for _, row := range rows {
+			a := row.race.Accesses
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", demo,
+				describeAccess(a, 0), describeAccess(a, 1), describeGoroutines(row.race), row.count)
+		}
+		total += len(rows)
+		results = append(results, fmt.Sprintf("%s: %d race(s)", demo, len(rows)))
+	}
+
+	fmt.Println()
+	if total == 0 {
+		fmt.Println("no data races reported")
+	} else {
+		tw.Flush()
+	}
+	fmt.Printf("\n%s\n", strings.Join(results, ", "))
+	return nil
+}
+
+// runRaceDemo runs one demo and returns its stderr and exit status. A -race
+// binary exits with status 66 when it reported a race; halt_on_error=0 lets
+// it keep running after the first one so every race in the demo shows up.
+func runRaceDemo(ctx context.Context, bin, demo string, timeout time.Duration) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, demo)
+	cmd.Env = append(os.Environ(), "GORACE=halt_on_error=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, 0, fmt.Errorf("still running after %v (a deadlock demo?)", timeout)
+	} else if ctx.Err() != nil {
+		return nil, 0, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stderr.Bytes(), exitErr.ExitCode(), nil
+	}
+	return stderr.Bytes(), 0, err
+}
+
+func describeAccess(a []racereport.Access, i int) string {
+	if i >= len(a) {
+		return "-"
+	}
+	return fmt.Sprintf("%s %s", strings.ToLower(a[i].Op), a[i].Frame.Location())
+}
+
+// describeGoroutines lists the goroutines of a race with the line that
+// started each, e.g. "main, #9 race.go:39".
+func describeGoroutines(r racereport.Race) string {
+	var parts []string
+	for _, a := range r.Accesses {
+		g := a.Goroutine
+		var s string
+		if g == "main goroutine" {
+			s = "main"
+		} else {
+			s = "#" + strings.TrimPrefix(g, "goroutine ")
+			if f, ok := r.Created[g]; ok {
+				s += " " + strings.Fields(f.Location())[0]
+			}
+		}
+		if !slices.Contains(parts, s) {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/replay"
+)
+
+// ============================================================================
+// replay - CAPTURE AN INTERLEAVING, THEN RUN IT AGAIN
+// ============================================================================
+// Workers send their results to one collector after a random delay, so
+// every run prints a different arrival order - the kind of output that
+// makes a race demo hard to discuss ("it did something else for me").
+//
+//	go run ./cmd/lab replay -record run.jsonl    # some order; logged
+//	go run ./cmd/lab replay -replay run.jsonl    # the SAME order, every time
+//
+// The replay run keeps the random delays. They just no longer decide
+// anything: each channel operation waits for its turn in the log.
+// ============================================================================
+
+func init() {
+	commands["replay"] = command{"record a racy channel interleaving to a file and replay it exactly", runReplay}
+}
+
+// arrivals runs the racy workload on s and returns the order in which
+// results reached the collector.
+func arrivals(ctx context.Context, s *replay.Session, workers, items int, jitter time.Duration) []string {
+	results := replay.NewChan[string](s, "results", 0)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		name := fmt.Sprintf("worker-%d", w)
+		s.Go(name, func() {
+			defer wg.Done()
+			for i := range items {
+				if ctx.Err() != nil {
+					return
+				}
+				time.Sleep(rand.N(jitter)) // "work" - different every run
+				results.Send(fmt.Sprintf("w%d.%d", w, i))
+			}
+		})
+	}
+
+	var order []string
+	done := make(chan struct{})
+	s.Go("collector", func() {
+		defer close(done)
+		for {
+			v, ok := results.Recv()
+			if !ok {
+				return
+			}
+			order = append(order, v)
+		}
+	})
+
+	s.Label("main")
+	wg.Wait()
+	results.Close()
+	<-done
+	return order
+}
+
+func runReplay(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	record := flags.String("record", "", "run freely and log the interleaving to this file")
+	replayFrom := flags.String("replay", "", "enforce the interleaving logged in this file")
+	workers := flags.Int("workers", 3, "racing workers (must match the recording when replaying)")
+	items := flags.Int("items", 4, "results per worker (must match the recording when replaying)")
+	jitter := flags.Duration("jitter", 5*time.Millisecond, "maximum random delay before each send")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lab replay -record FILE | -replay FILE [-workers N] [-items N] [-jitter D]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if (*record == "") == (*replayFrom == "") {
+		flags.Usage()
+		return errors.New("exactly one of -record and -replay is required")
+	}
+	if *workers < 1 || *items < 1 || *jitter <= 0 {
+		return errors.New("-workers, -items and -jitter must be positive")
+	}
+
+	var s *replay.Session
+	var out *os.File
+	if *record != "" {
+		f, err := os.Create(*record)
+		if err != nil {
+			return err
+		}
+		out = f
+		s = replay.NewRecorder(f)
+	} else {
+		f, err := os.Open(*replayFrom)
+		if err != nil {
+			return err
+		}
+		s, err = replay.NewReplayer(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	// A replay that diverges panics with replay.ErrDiverged in whichever
+	// goroutine noticed, which ends the program with the mismatch spelled out.
+	order := arrivals(ctx, s, *workers, *items, *jitter)
+	elapsed := time.Since(start)
+
+	if out != nil {
+		err := errors.Join(s.Flush(), out.Close())
+		if err != nil {
+			return err
+		}
+	}
+
+	mode := "recorded to " + *record
+	if s.Replaying() {
+		mode = "replayed from " + *replayFrom
+	}
+	fmt.Printf("arrival order (%s, %v):\n  %s\n", mode, elapsed.Round(time.Millisecond), strings.Join(order, " "))
+	if !s.Replaying() {
+		fmt.Printf("→ run again with -record: a different order. With -replay %s: this one.\n", *record)
+	}
+	return ctx.Err()
+}
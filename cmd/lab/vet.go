@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/concvet"
+	"golang.org/x/tools/go/analysis"
+)
+
+// ============================================================================
+// vet - THE REPO'S OWN PITFALLS, FOUND STATICALLY
+// ============================================================================
+// Runs the pkg/concvet checks (copied locks, wg.Add inside the goroutine,
+// returns with a mutex held, time.Sleep as synchronization) over packages of
+// this module. Most chapters contain some of these on purpose, to show what
+// goes wrong; the report lists them so they can be compared with the text:
+//
+//	go run ./cmd/lab vet
+//	go run ./cmd/lab vet -checks unlock,wgadd ./ch03_go_concurrency_building_blocks/...
+// ============================================================================
+
+func init() {
+	commands["vet"] = command{"statically flag copied locks, misplaced wg.Add, missing Unlocks, sleep-as-sync", runVet}
+}
+
+func runVet(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("vet", flag.ContinueOnError)
+	checks := flags.String("checks", "", "comma-separated checks to run (default: all)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: lab vet [-checks a,b] [packages]   (default packages: ./...)")
+		fmt.Fprintln(flags.Output(), "\nchecks:")
+		for _, a := range concvet.Analyzers() {
+			fmt.Fprintf(flags.Output(), "  %-10s %s\n", a.Name, a.Doc)
+		}
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	analyzers := concvet.Analyzers()
+	if *checks != "" {
+		want := strings.Split(*checks, ",")
+		for _, name := range want {
+			if !slices.ContainsFunc(analyzers, func(a *analysis.Analyzer) bool { return a.Name == name }) {
+				return fmt.Errorf("unknown check %q (see lab vet -h)", name)
+			}
+		}
+		analyzers = slices.DeleteFunc(analyzers, func(a *analysis.Analyzer) bool { return !slices.Contains(want, a.Name) })
+	}
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	diags, err := concvet.Check(".", patterns, analyzers)
+	if err != nil {
+		return err
+	}
+	wd, _ := os.Getwd()
+	counts := map[string]int{}
+	for _, d := range diags {
+		if rel, err := filepath.Rel(wd, d.Pos.Filename); err == nil {
+			d.Pos.Filename = rel
+		}
+		fmt.Println(d)
+		counts[d.Analyzer]++
+	}
+
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 2, ' ', 0)
+	fmt.Fprintln(tw, "check\tfindings\twhat it looks for")
+	for _, a := range analyzers {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", a.Name, counts[a.Name], a.Doc)
+	}
+	return tw.Flush()
+}
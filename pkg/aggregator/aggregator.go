@@ -0,0 +1,178 @@
+// Package aggregator puts results from concurrent workers back in order.
+//
+// Fan out N workers over a numbered input and the results come back in
+// whatever order the workers finish. An Aggregator takes them tagged with
+// their sequence number (Put) and hands them out strictly as 0, 1, 2, ...
+// (Next), holding early arrivals until the gap before them is filled.
+//
+// Memory is bounded by a window: Put blocks while its item is window or
+// more ahead of the next one to emit. A slow item therefore throttles the
+// fast workers instead of letting early results pile up without limit.
+//
+// An item that will never come (its worker failed) is a gap that would stall
+// the consumer for good. Skip fills it with nothing; Close says no more
+// items will come, after which Next reports a remaining gap as a *GapError.
+//
+// In use:
+//
+//	agg := aggregator.New[Result](64)
+//	// workers:  agg.Put(ctx, job.Seq, result)  or  agg.Skip(ctx, job.Seq)
+//	// when all workers are done: agg.Close()
+//	for {
+//		r, err := agg.Next(ctx)
+//		if errors.Is(err, aggregator.ErrDone) { break }
+//		...
+//	}
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/chancond"
+)
+
+var (
+	// ErrDone is returned by Next once every item up to Close has been
+	// emitted.
+	ErrDone = errors.New("aggregator: done")
+	// ErrClosed is returned by Put and Skip after Close.
+	ErrClosed = errors.New("aggregator: closed")
+)
+
+// GapError is returned by Next after Close when an item below the highest
+// one received never arrived and was not skipped.
+type GapError struct {
+	Seq int64 // the first missing item
+}
+
+func (e *GapError) Error() string {
+	return fmt.Sprintf("aggregator: item %d never arrived", e.Seq)
+}
+
+// Stats describes what an Aggregator has done so far.
+type Stats struct {
+	Emitted    int64 // items returned by Next
+	Skipped    int64 // gaps filled with Skip
+	MaxPending int   // most items held at once waiting for a gap
+}
+
+// slot is one pending entry; skip marks a gap filled by Skip.
+type slot[T any] struct {
+	v    T
+	skip bool
+}
+
+// Aggregator re-orders results. Create it with New.
+type Aggregator[T any] struct {
+	window int64
+
+	mu      sync.Mutex
+	cond    *chancond.Cond // broadcast whenever next, pending or closed change
+	next    int64          // the item Next returns next
+	high    int64          // one past the highest item received
+	pending map[int64]slot[T]
+	closed  bool
+	stats   Stats
+}
+
+// New returns an Aggregator that holds at most window items ahead of the
+// next one to emit.
+func New[T any](window int) *Aggregator[T] {
+	if window < 1 {
+		panic("aggregator: window must be at least 1")
+	}
+	a := &Aggregator[T]{window: int64(window), pending: map[int64]slot[T]{}}
+	a.cond = chancond.New(&a.mu)
+	return a
+}
+
+// Put hands over item seq, waiting while it is window or more ahead of the
+// next item to emit. It returns ctx's error if ctx is done first, ErrClosed
+// after Close, and an error if seq was already put, skipped or emitted.
+func (a *Aggregator[T]) Put(ctx context.Context, seq int64, v T) error {
+	return a.put(ctx, seq, slot[T]{v: v})
+}
+
+// Skip marks item seq as never coming, so that Next goes past it. It waits
+// for room like Put.
+func (a *Aggregator[T]) Skip(ctx context.Context, seq int64) error {
+	return a.put(ctx, seq, slot[T]{skip: true})
+}
+
+func (a *Aggregator[T]) put(ctx context.Context, seq int64, s slot[T]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for !a.closed && seq >= a.next+a.window {
+		if err := a.cond.WaitContext(ctx); err != nil {
+			return err
+		}
+	}
+	if a.closed {
+		return ErrClosed
+	}
+	if _, dup := a.pending[seq]; dup || seq < a.next {
+		return fmt.Errorf("aggregator: item %d put twice", seq)
+	}
+	a.pending[seq] = s
+	a.high = max(a.high, seq+1)
+	a.stats.MaxPending = max(a.stats.MaxPending, len(a.pending))
+	a.cond.Broadcast()
+	return nil
+}
+
+// Next returns the next item in sequence, waiting until it has arrived, or
+// ctx's error once ctx is done. Skipped items are passed over. After Close
+// it returns ErrDone once every item received has been emitted, or a
+// *GapError if one is missing.
+func (a *Aggregator[T]) Next(ctx context.Context) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for {
+		if s, ok := a.pending[a.next]; ok {
+			delete(a.pending, a.next)
+			a.next++
+			a.cond.Broadcast() // room for one more Put
+			if s.skip {
+				a.stats.Skipped++
+				continue
+			}
+			a.stats.Emitted++
+			return s.v, nil
+		}
+		if a.closed {
+			if a.next < a.high {
+				return zero, &GapError{Seq: a.next}
+			}
+			return zero, ErrDone
+		}
+		if err := a.cond.WaitContext(ctx); err != nil {
+			return zero, err
+		}
+	}
+}
+
+// Close says that no more items will be put. Waiting Puts return
+// ErrClosed; Next emits what it can and then reports ErrDone or the gap.
+func (a *Aggregator[T]) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	a.cond.Broadcast()
+}
+
+// Stats returns the counts so far.
+func (a *Aggregator[T]) Stats() Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stats
+}
@@ -0,0 +1,189 @@
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestNextEmitsInOrder has workers put items in random order and checks
+// that Next returns them in sequence, with the window respected.
+func TestNextEmitsInOrder(t *testing.T) {
+	const n, window = 500, 16
+	ctx := context.Background()
+	agg := New[int64](window)
+	jobs := make(chan int64)
+	go func() {
+		defer close(jobs)
+		for seq := range int64(n) {
+			jobs <- seq
+		}
+	}()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for seq := range jobs {
+				if rand.IntN(4) == 0 {
+					runtime.Gosched()
+				}
+				if err := agg.Put(ctx, seq, seq*seq); err != nil {
+					t.Errorf("Put(%d): %v", seq, err)
+				}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		agg.Close()
+	}()
+
+	for want := int64(0); ; want++ {
+		v, err := agg.Next(ctx)
+		if errors.Is(err, ErrDone) {
+			if want != n {
+				t.Errorf("ErrDone after %d items, want %d", want, n)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if v != want*want {
+			t.Fatalf("item %d = %d, want %d", want, v, want*want)
+		}
+	}
+	st := agg.Stats()
+	if st.Emitted != n || st.Skipped != 0 || st.MaxPending > window {
+		t.Errorf("Stats = %+v, want Emitted %d, Skipped 0, MaxPending <= %d", st, n, window)
+	}
+}
+
+// TestPutWaitsForWindow checks that Put blocks an item window or more ahead
+// of the next one, and lets it in once Next makes room.
+func TestPutWaitsForWindow(t *testing.T) {
+	ctx := context.Background()
+	agg := New[int](2)
+	for seq := range int64(2) {
+		if err := agg.Put(ctx, seq, int(seq)); err != nil {
+			t.Fatalf("Put(%d): %v", seq, err)
+		}
+	}
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := agg.Put(short, 2, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Put outside the window = %v, want DeadlineExceeded", err)
+	}
+
+	put := make(chan error, 1)
+	go func() { put <- agg.Put(ctx, 2, 2) }()
+	select {
+	case err := <-put:
+		t.Fatalf("Put outside the window returned %v before Next", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	if v, err := agg.Next(ctx); v != 0 || err != nil {
+		t.Fatalf("Next = %d, %v, want 0, nil", v, err)
+	}
+	select {
+	case err := <-put:
+		if err != nil {
+			t.Fatalf("Put after Next: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Put still waiting after Next made room")
+	}
+}
+
+// TestSkipAndGap checks that Next passes over a skipped item, and after
+// Close reports an item that was neither put nor skipped.
+func TestSkipAndGap(t *testing.T) {
+	ctx := context.Background()
+	agg := New[string](8)
+	agg.Put(ctx, 0, "a")
+	agg.Skip(ctx, 1)
+	agg.Put(ctx, 2, "c")
+	agg.Put(ctx, 4, "e") // 3 never comes
+	agg.Close()
+
+	var got []string
+	for {
+		v, err := agg.Next(ctx)
+		if err != nil {
+			var gap *GapError
+			if !errors.As(err, &gap) || gap.Seq != 3 {
+				t.Fatalf("Next = %v, want a GapError at 3", err)
+			}
+			break
+		}
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("emitted %q, want [a c]", got)
+	}
+	if st := agg.Stats(); st.Emitted != 2 || st.Skipped != 1 {
+		t.Errorf("Stats = %+v, want Emitted 2, Skipped 1", st)
+	}
+}
+
+func TestPutErrors(t *testing.T) {
+	ctx := context.Background()
+	agg := New[int](2)
+	agg.Put(ctx, 0, 0)
+	if err := agg.Put(ctx, 0, 0); err == nil {
+		t.Error("second Put of the same item succeeded")
+	}
+	agg.Next(ctx)
+	if err := agg.Skip(ctx, 0); err == nil {
+		t.Error("Skip of an emitted item succeeded")
+	}
+
+	agg.Put(ctx, 1, 1)
+	waiting := make(chan error, 1)
+	go func() { waiting <- agg.Put(ctx, 3, 3) }() // outside the window
+	time.Sleep(10 * time.Millisecond)
+	agg.Close()
+	select {
+	case err := <-waiting:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("waiting Put after Close = %v, want ErrClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not release a waiting Put")
+	}
+	if err := agg.Put(ctx, 2, 2); !errors.Is(err, ErrClosed) {
+		t.Errorf("Put after Close = %v, want ErrClosed", err)
+	}
+	if v, err := agg.Next(ctx); v != 1 || err != nil {
+		t.Errorf("Next after Close = %d, %v, want 1, nil", v, err)
+	}
+	if _, err := agg.Next(ctx); !errors.Is(err, ErrDone) {
+		t.Errorf("Next at the end = %v, want ErrDone", err)
+	}
+}
+
+func TestNextCancel(t *testing.T) {
+	agg := New[int](4)
+	ctx, cancel := context.WithCancel(context.Background())
+	next := make(chan error, 1)
+	go func() {
+		_, err := agg.Next(ctx)
+		next <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-next:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Next = %v, want Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Next still waiting after cancel")
+	}
+	if _, err := agg.Next(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Next with a done context = %v, want Canceled", err)
+	}
+}
@@ -0,0 +1,70 @@
+package aggregator_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/aggregator"
+)
+
+func Example() {
+	ctx := context.Background()
+	words := []string{"zero", "one", "two", "three", "four", "five"}
+	agg := aggregator.New[string](4)
+
+	// Workers finish in any order; item 3's worker fails.
+	var wg sync.WaitGroup
+	for seq, w := range words {
+		wg.Go(func() {
+			if seq == 3 {
+				agg.Skip(ctx, int64(seq))
+				return
+			}
+			agg.Put(ctx, int64(seq), strings.ToUpper(w))
+		})
+	}
+	go func() {
+		wg.Wait()
+		agg.Close()
+	}()
+
+	for {
+		w, err := agg.Next(ctx)
+		if errors.Is(err, aggregator.ErrDone) {
+			break
+		}
+		fmt.Println(w)
+	}
+	fmt.Printf("%+v\n", agg.Stats().Skipped)
+	// Output:
+	// ZERO
+	// ONE
+	// TWO
+	// FOUR
+	// FIVE
+	// 1
+}
+
+func ExampleGapError() {
+	ctx := context.Background()
+	agg := aggregator.New[int](4)
+	agg.Put(ctx, 0, 10)
+	agg.Put(ctx, 2, 30) // item 1 never comes and is not skipped
+	agg.Close()
+
+	for {
+		v, err := agg.Next(ctx)
+		var gap *aggregator.GapError
+		if errors.As(err, &gap) {
+			fmt.Println("missing item", gap.Seq)
+			return
+		}
+		fmt.Println(v)
+	}
+	// Output:
+	// 10
+	// missing item 1
+}
@@ -0,0 +1,120 @@
+// Package chancond provides a condition variable whose Wait returns a
+// channel, so waiting can be combined with ctx.Done(), timeouts or any other
+// case in a select statement.
+//
+// sync.Cond's Wait blocks inside the runtime: once a goroutine is in
+// c.Wait() nothing but Signal or Broadcast can get it out. whenToUseCond()
+// in ch03 lists "select statement needed" as the reason to avoid Cond.
+// chancond.Cond keeps the Cond usage pattern - lock, check the condition in
+// a loop, wait, re-check - and makes the wait selectable:
+//
+//	c.L.Lock()
+//	for !condition() {
+//		if err := c.WaitContext(ctx); err != nil {
+//			c.L.Unlock()
+//			return err
+//		}
+//	}
+//	// ... use the state ...
+//	c.L.Unlock()
+//
+// Each waiter gets its own channel. Signal closes the oldest one (FIFO),
+// Broadcast closes them all. A waiter that stops waiting before it is woken
+// must say so with Abandon, otherwise a Signal addressed to it would be lost;
+// WaitContext does this automatically.
+package chancond
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Cond is a condition variable with selectable waits.
+type Cond struct {
+	// L is held while observing or changing the condition.
+	L sync.Locker
+
+	mu      sync.Mutex      // guards waiters; separate from L so Signal may be called without L
+	waiters []chan struct{} // oldest first
+}
+
+// New returns a Cond that uses l as its lock.
+func New(l sync.Locker) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait registers the caller as a waiter and returns a channel that Signal or
+// Broadcast will close. Call it with c.L held, then unlock c.L, receive from
+// the channel (in a select if you like), and lock c.L again before
+// re-checking the condition. Registering BEFORE unlocking is what makes the
+// wake-up impossible to miss.
+//
+// If you stop waiting without the channel being closed, call Abandon.
+func (c *Cond) Wait() <-chan struct{} {
+	ch := make(chan struct{})
+	c.mu.Lock()
+	c.waiters = append(c.waiters, ch)
+	c.mu.Unlock()
+	return ch
+}
+
+// Abandon deregisters a waiter returned by Wait that no longer wants to be
+// woken. If a Signal has already closed ch, that wake-up is passed on to the
+// next waiter so it is not lost. It reports whether ch was still waiting.
+func (c *Cond) Abandon(ch <-chan struct{}) bool {
+	c.mu.Lock()
+	i := slices.IndexFunc(c.waiters, func(w chan struct{}) bool { return w == ch })
+	if i >= 0 {
+		c.waiters = slices.Delete(c.waiters, i, i+1)
+	}
+	c.mu.Unlock()
+
+	if i < 0 {
+		// Already woken. We can't tell Signal from Broadcast here, and an
+		// extra wake-up is harmless (waiters re-check in a loop) while a
+		// lost one is a hang - so pass it on.
+		c.Signal()
+		return false
+	}
+	return true
+}
+
+// WaitContext is Wait for callers that only need ctx: it must be called with
+// c.L held, unlocks c.L while waiting, and re-locks it before returning. It
+// returns ctx.Err() if ctx is done before the Cond is signalled.
+func (c *Cond) WaitContext(ctx context.Context) error {
+	ch := c.Wait()
+	c.L.Unlock()
+	defer c.L.Lock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		c.Abandon(ch)
+		return ctx.Err()
+	}
+}
+
+// Signal wakes the goroutine that has been waiting the longest, if any.
+func (c *Cond) Signal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.waiters) == 0 {
+		return
+	}
+	close(c.waiters[0])
+	c.waiters[0] = nil
+	c.waiters = c.waiters[1:]
+}
+
+// Broadcast wakes all waiting goroutines.
+func (c *Cond) Broadcast() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range c.waiters {
+		close(ch)
+	}
+	c.waiters = nil
+}
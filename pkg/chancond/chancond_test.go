@@ -0,0 +1,158 @@
+package chancond
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func closed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestSignalWakesOldestWaiter(t *testing.T) {
+	c := New(new(sync.Mutex))
+	c.Signal() // nobody waiting: like sync.Cond, not remembered
+	a, b := c.Wait(), c.Wait()
+	if closed(a) || closed(b) {
+		t.Fatal("a Signal with no waiters woke a later waiter")
+	}
+	c.Signal()
+	if !closed(a) || closed(b) {
+		t.Fatalf("after one Signal: first woken %v, second woken %v; want true, false", closed(a), closed(b))
+	}
+	c.Signal()
+	if !closed(b) {
+		t.Fatal("second Signal did not wake the second waiter")
+	}
+}
+
+func TestBroadcastWakesAll(t *testing.T) {
+	c := New(new(sync.Mutex))
+	chs := []<-chan struct{}{c.Wait(), c.Wait(), c.Wait()}
+	c.Broadcast()
+	for i, ch := range chs {
+		if !closed(ch) {
+			t.Errorf("waiter %d not woken by Broadcast", i)
+		}
+	}
+	later := c.Wait()
+	c.Broadcast()
+	c.Signal() // must not panic on a waiter Broadcast already closed
+	if !closed(later) {
+		t.Error("a waiter registered after a Broadcast missed the next one")
+	}
+}
+
+func TestAbandon(t *testing.T) {
+	c := New(new(sync.Mutex))
+
+	// Abandoned before any Signal: it is simply dropped.
+	a, b := c.Wait(), c.Wait()
+	if !c.Abandon(a) {
+		t.Fatal("Abandon of a waiting channel reported false")
+	}
+	c.Signal()
+	if closed(a) || !closed(b) {
+		t.Fatal("Signal went to the abandoned waiter instead of the next one")
+	}
+
+	// Abandoned after its Signal: the wake-up moves on to the next waiter.
+	a, b = c.Wait(), c.Wait()
+	c.Signal()
+	if c.Abandon(a) {
+		t.Fatal("Abandon of an already woken channel reported true")
+	}
+	if !closed(b) {
+		t.Fatal("the Signal the abandoned waiter got was lost")
+	}
+}
+
+func TestWaitContext(t *testing.T) {
+	var mu sync.Mutex
+	c := New(&mu)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mu.Lock()
+	err := c.WaitContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitContext = %v, want context.Canceled", err)
+	}
+	if mu.TryLock() {
+		t.Error("WaitContext returned without re-locking L")
+	}
+	mu.Unlock()
+
+	c.mu.Lock()
+	n := len(c.waiters)
+	c.mu.Unlock()
+	if n != 0 {
+		t.Errorf("%d waiters left registered after WaitContext gave up", n)
+	}
+}
+
+// The usual Cond pattern, many consumers and producers: every item is taken
+// exactly once, and no consumer hangs.
+func TestProducerConsumer(t *testing.T) {
+	const producers, consumers, perProducer = 4, 4, 500
+	var (
+		mu    sync.Mutex
+		c     = New(&mu)
+		queue []int
+		taken = make([]int, producers*perProducer)
+		wg    sync.WaitGroup
+	)
+	ctx := context.Background()
+	for range consumers {
+		wg.Go(func() {
+			for {
+				mu.Lock()
+				for len(queue) == 0 {
+					if err := c.WaitContext(ctx); err != nil {
+						mu.Unlock()
+						t.Error(err)
+						return
+					}
+				}
+				item := queue[0]
+				queue = queue[1:]
+				mu.Unlock()
+				if item < 0 {
+					return // one stop marker per consumer
+				}
+				taken[item]++
+			}
+		})
+	}
+	var producing sync.WaitGroup
+	for p := range producers {
+		producing.Go(func() {
+			for i := range perProducer {
+				mu.Lock()
+				queue = append(queue, p*perProducer+i)
+				mu.Unlock()
+				c.Signal()
+			}
+		})
+	}
+	producing.Wait()
+	mu.Lock()
+	for range consumers {
+		queue = append(queue, -1)
+	}
+	mu.Unlock()
+	c.Broadcast()
+	wg.Wait()
+	for item, n := range taken {
+		if n != 1 {
+			t.Fatalf("item %d taken %d times", item, n)
+		}
+	}
+}
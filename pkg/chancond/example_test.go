@@ -0,0 +1,64 @@
+package chancond_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/chancond"
+)
+
+func ExampleCond_WaitContext() {
+	var mu sync.Mutex
+	c := chancond.New(&mu)
+	var queue []string
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	got := make(chan string)
+	go func() {
+		c.L.Lock()
+		for len(queue) == 0 {
+			if err := c.WaitContext(ctx); err != nil {
+				c.L.Unlock()
+				got <- err.Error()
+				return
+			}
+		}
+		item := queue[0]
+		queue = queue[1:]
+		c.L.Unlock()
+		got <- item
+	}()
+
+	c.L.Lock()
+	queue = append(queue, "job-1")
+	c.L.Unlock()
+	c.Signal()
+	fmt.Println(<-got)
+
+	// The same wait gives up when its context ends - which sync.Cond can't.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	c.L.Lock()
+	fmt.Println(c.WaitContext(ctx))
+	c.L.Unlock()
+	// Output:
+	// job-1
+	// context canceled
+}
+
+func ExampleCond_Wait() {
+	c := chancond.New(new(sync.Mutex))
+	woken := c.Wait()
+	c.Broadcast()
+	select {
+	case <-woken:
+		fmt.Println("woken")
+	case <-time.After(time.Second):
+		fmt.Println("timed out")
+	}
+	// Output:
+	// woken
+}
@@ -0,0 +1,29 @@
+// Package chaos perturbs goroutine scheduling at marked points, to shake
+// out ordering bugs that a quiet machine never hits.
+//
+// A concurrency bug usually needs one goroutine to be descheduled at one
+// specific instruction - between a Wait returning and the re-check, between
+// an Unlock and a Signal. On an idle machine that almost never happens.
+// Implementations mark such transitions with
+//
+//	chaos.Point("boundedqueue.dequeue.woken")
+//
+// In a normal build Point is an empty function the compiler inlines away.
+// Built with -tags chaos, each Point may yield the processor or sleep for a
+// few microseconds, as a hash of a seed decides, so a stress run tries
+// many more interleavings than the scheduler would on its own:
+//
+//	go run ./cmd/lab chaos
+//
+// which runs the tests of the packages with chaos points over and over,
+// each time with a new seed in SeedEnv.
+package chaos
+
+// SeedEnv is the environment variable a chaos build reads its seed from at
+// start-up, so that a test binary run by `lab chaos` (or by hand) draws the
+// same noise again:
+//
+//	CHAOS_SEED=12345 go test -tags chaos ./pkg/conc
+//
+// Without it the seed comes from the clock.
+const SeedEnv = "CHAOS_SEED"
@@ -0,0 +1,35 @@
+package chaos_test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/chaos"
+)
+
+// account marks the window between reading and writing its balance: the
+// spot where, without the lock, a second deposit would get lost.
+type account struct {
+	mu      sync.Mutex
+	balance int
+}
+
+func (a *account) deposit(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b := a.balance
+	chaos.Point("account.deposit.read")
+	a.balance = b + n
+}
+
+func ExamplePoint() {
+	chaos.Seed(1)
+	var a account
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Go(func() { a.deposit(1) })
+	}
+	wg.Wait()
+	fmt.Println(a.balance)
+	// Output: 100
+}
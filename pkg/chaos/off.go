@@ -0,0 +1,18 @@
+//go:build !chaos
+
+package chaos
+
+// Enabled reports whether this build was made with -tags chaos.
+const Enabled = false
+
+// Point marks a scheduling-sensitive transition. Without -tags chaos it
+// does nothing.
+func Point(name string) {}
+
+// Seed sets the seed Point draws from. Without -tags chaos it does
+// nothing.
+func Seed(seed uint64) {}
+
+// Hits returns how often each Point was reached since the last Seed. Without
+// -tags chaos it returns nil.
+func Hits() map[string]int64 { return nil }
@@ -0,0 +1,104 @@
+//go:build chaos
+
+package chaos
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Enabled reports whether this build was made with -tags chaos.
+const Enabled = true
+
+// round is the state one Seed starts: the seed and a hit counter per point.
+// Seed swaps in a new round instead of resetting the old one, so Point never
+// takes a lock.
+type round struct {
+	seed   uint64
+	points sync.Map // name → *point
+}
+
+// point is one marked transition. key is a hash of its name, so that two
+// points draw different streams from the same seed.
+type point struct {
+	key  uint64
+	hits atomic.Int64
+}
+
+var current atomic.Pointer[round]
+
+func init() {
+	seed, err := strconv.ParseUint(os.Getenv(SeedEnv), 10, 64)
+	if err != nil {
+		seed = uint64(time.Now().UnixNano())
+	}
+	Seed(seed)
+}
+
+// Point marks a scheduling-sensitive transition. Half the time it returns
+// at once; otherwise it yields the processor, and now and then sleeps for up
+// to 100µs so that other goroutines - on other Ps too - run in between.
+//
+// The choice is a hash of the seed, the point and how many times it was
+// reached before, so no lock is taken and no random source is shared: the
+// only synchronization Point adds is one atomic add on its own counter,
+// instead of an edge between every pair of goroutines passing any point.
+func Point(name string) {
+	r := current.Load()
+	p, ok := r.points.Load(name)
+	if !ok {
+		p, _ = r.points.LoadOrStore(name, &point{key: fnv(name)})
+	}
+	pt := p.(*point)
+	n := pt.hits.Add(1)
+	x := mix((r.seed ^ pt.key) + uint64(n)*0x9e3779b97f4a7c15)
+
+	switch v := x % 100; {
+	case v < 50:
+	case v < 90:
+		runtime.Gosched()
+	default:
+		time.Sleep(time.Duration((x >> 32) % uint64(100*time.Microsecond)))
+	}
+}
+
+// Seed sets the seed Point draws from and clears the hit counts.
+// The goroutine interleaving is still up to the scheduler, so a seed makes a
+// failure likely to recur, not certain.
+func Seed(seed uint64) {
+	current.Store(&round{seed: seed})
+}
+
+// Hits returns how often each Point was reached since the last Seed.
+func Hits() map[string]int64 {
+	hits := map[string]int64{}
+	current.Load().points.Range(func(name, p any) bool {
+		hits[name.(string)] = p.(*point).hits.Load()
+		return true
+	})
+	return hits
+}
+
+// mix is splitmix64's finalizer: every input bit affects every output bit.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// fnv is FNV-1a, fixed across runs (unlike hash/maphash) so that a seed
+// means the same thing in every process.
+func fnv(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := range len(s) {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	return h
+}
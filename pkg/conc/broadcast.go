@@ -0,0 +1,97 @@
+package conc
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ============================================================================
+// Broadcast[T] - A RE-ARMABLE, SELECTABLE sync.Cond.Broadcast
+// ============================================================================
+// Closing a channel wakes EVERY goroutine receiving from it - that is the
+// channel-native way to broadcast. The catch is that a channel can only be
+// closed once. Broadcast re-arms itself by keeping the "current" channel
+// behind an atomic.Pointer:
+//
+//	Notify(v):  swap in a fresh channel, store v next to the old one, close
+//	            the old one. Everyone waiting on it wakes up and reads v.
+//	Wait():     load the current channel. The NEXT Notify will close it.
+//
+// Unlike sync.Cond there is no mutex to hold and the wait is a plain
+// channel, so it composes with select, ctx.Done() and timeouts.
+//
+// Like sync.Cond, a Notify only reaches goroutines that were already
+// waiting: call Wait() BEFORE checking whatever condition you are waiting
+// for, or a Notify in between is missed.
+// ============================================================================
+
+// notice is one arming of a Broadcast: closed exactly once, by the Notify
+// that swapped it out.
+type notice[T any] struct {
+	done  chan struct{}
+	value T // written before done is closed, read only after
+}
+
+func newNotice[T any]() *notice[T] {
+	return &notice[T]{done: make(chan struct{})}
+}
+
+// Broadcast wakes all current waiters with a value each time Notify is
+// called. The zero value is ready to use. A Broadcast must not be copied
+// after first use.
+type Broadcast[T any] struct {
+	current atomic.Pointer[notice[T]]
+}
+
+// Waiter is a ticket for the next Notify on a Broadcast.
+type Waiter[T any] struct {
+	n *notice[T]
+}
+
+// Done returns a channel that is closed by the next Notify.
+func (w Waiter[T]) Done() <-chan struct{} { return w.n.done }
+
+// Value returns the value passed to the Notify that closed Done. It must only
+// be called after Done is closed.
+func (w Waiter[T]) Value() T { return w.n.value }
+
+// load returns the current notice, creating the first one on demand.
+func (b *Broadcast[T]) load() *notice[T] {
+	if n := b.current.Load(); n != nil {
+		return n
+	}
+	b.current.CompareAndSwap(nil, newNotice[T]())
+	return b.current.Load() // ours, or the one that beat us
+}
+
+// Wait returns a Waiter for the next Notify. It never blocks; receive from
+// the Waiter's Done channel to wait.
+func (b *Broadcast[T]) Wait() Waiter[T] {
+	return Waiter[T]{n: b.load()}
+}
+
+// WaitContext blocks until the next Notify and returns its value, or returns
+// ctx's error if ctx is done first.
+func (b *Broadcast[T]) WaitContext(ctx context.Context) (T, error) {
+	w := b.Wait()
+	select {
+	case <-w.Done():
+		return w.Value(), nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Notify wakes every goroutine currently waiting and hands each of them v.
+// Goroutines that call Wait afterwards wait for the following Notify.
+// Notify is safe to call concurrently: Swap hands every call a different
+// channel to close.
+func (b *Broadcast[T]) Notify(v T) {
+	old := b.current.Swap(newNotice[T]())
+	if old == nil {
+		return // nobody has ever waited
+	}
+	old.value = v
+	close(old.done)
+}
@@ -0,0 +1,106 @@
+package conc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ============================================================================
+// RunWithin - BEST-EFFORT WORK UNDER A TIME BUDGET
+// ============================================================================
+// Some batches don't need every answer, they need an answer on time: ask ten
+// replicas and use whoever replies within 50ms, render a page with the
+// widgets that were ready, fill a cache with what could be fetched before
+// the next request. Waiting for everyone (errgroup, ForEach) makes the
+// slowest task the latency of the batch; giving up on everyone at the first
+// timeout (WithTimeout) throws away the ten that did finish.
+//
+// RunWithin keeps what finished in time and cancels the rest:
+//
+//   - every task starts at once, with a context that expires with the budget
+//   - results are collected until all tasks are in or the budget is spent
+//   - then the context is cancelled and RunWithin returns immediately; the
+//     result channel has room for every task, so a straggler's send never
+//     blocks and it exits as soon as it notices the cancellation
+// ============================================================================
+
+// Outcome is what one task of RunWithin produced.
+type Outcome[T any] struct {
+	Value    T
+	Err      error // the task's error; the budget's error if it didn't finish in time
+	Finished bool  // the task returned within the budget
+}
+
+// BudgetReport is the result of RunWithin.
+type BudgetReport[T any] struct {
+	Outcomes []Outcome[T] // one per task, in task order
+
+	Completed int // finished in time without an error
+	Failed    int // finished in time with an error of their own
+	Cancelled int // still running when the budget ran out, or stopped by it
+}
+
+// Values returns the values of the completed tasks, in task order.
+func (r BudgetReport[T]) Values() []T {
+	var vs []T
+	for _, o := range r.Outcomes {
+		if o.Finished && o.Err == nil {
+			vs = append(vs, o.Value)
+		}
+	}
+	return vs
+}
+
+// RunWithin starts every task at once and waits at most budget (or until
+// ctx is done) for them. It returns what finished in time and cancels the
+// context of the tasks that didn't. A task that gives up with the context's
+// error counts as cancelled, not failed. RunWithin does not wait for
+// cancelled tasks to return: they exit on their own, promptly if they watch
+// their context.
+func RunWithin[T any](ctx context.Context, budget time.Duration, tasks ...func(ctx context.Context) (T, error)) BudgetReport[T] {
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	type indexed struct {
+		i int
+		result[T]
+	}
+	done := make(chan indexed, len(tasks)) // buffered: stragglers never block
+	for i, task := range tasks {
+		go func() {
+			v, err := task(ctx)
+			done <- indexed{i, result[T]{v, err}}
+		}()
+	}
+
+	report := BudgetReport[T]{Outcomes: make([]Outcome[T], len(tasks))}
+	for range tasks {
+		select {
+		case r := <-done:
+			report.Outcomes[r.i] = Outcome[T]{Value: r.v, Err: r.err, Finished: true}
+			switch {
+			case r.err == nil:
+				report.Completed++
+			case ctx.Err() != nil && errors.Is(r.err, ctx.Err()):
+				report.Outcomes[r.i].Finished = false // stopped by the budget
+			default:
+				report.Failed++
+			}
+		case <-ctx.Done():
+			return report.cancelRest(ctx.Err())
+		}
+	}
+	return report.cancelRest(ctx.Err())
+}
+
+// cancelRest marks every unfinished task as stopped by err and counts it.
+func (r BudgetReport[T]) cancelRest(err error) BudgetReport[T] {
+	for i, o := range r.Outcomes {
+		if !o.Finished {
+			r.Outcomes[i] = Outcome[T]{Err: err}
+			r.Cancelled++
+		}
+	}
+	return r
+}
@@ -0,0 +1,97 @@
+package conc
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestRunWithinKeepsWhatFinished runs a batch with every kind of task: one
+// that succeeds, one that fails, one that watches its context and one that
+// ignores it. RunWithin must return at the budget with each outcome
+// classified, and the stragglers must still exit.
+func TestRunWithinKeepsWhatFinished(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	errBackend := errors.New("backend down")
+	release := make(chan struct{})
+	start := time.Now()
+	report := RunWithin(context.Background(), 20*time.Millisecond,
+		func(context.Context) (string, error) { return "fast", nil },
+		func(context.Context) (string, error) { return "", errBackend },
+		func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+		func(context.Context) (string, error) {
+			<-release
+			return "late", nil
+		},
+	)
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("RunWithin returned after %v, want about the 20ms budget", took)
+	}
+
+	if report.Completed != 1 || report.Failed != 1 || report.Cancelled != 2 {
+		t.Errorf("Completed %d, Failed %d, Cancelled %d, want 1, 1, 2",
+			report.Completed, report.Failed, report.Cancelled)
+	}
+	want := []Outcome[string]{
+		{Value: "fast", Finished: true},
+		{Err: errBackend, Finished: true},
+		{Err: context.DeadlineExceeded},
+		{Err: context.DeadlineExceeded},
+	}
+	for i, o := range report.Outcomes {
+		if o.Value != want[i].Value || o.Finished != want[i].Finished || !errors.Is(o.Err, want[i].Err) {
+			t.Errorf("Outcomes[%d] = %+v, want %+v", i, o, want[i])
+		}
+	}
+	if vs := report.Values(); !slices.Equal(vs, []string{"fast"}) {
+		t.Errorf("Values = %q, want [fast]", vs)
+	}
+
+	close(release) // the task that ignored its context sends into the buffer and exits
+	settles(t, baseline)
+}
+
+func TestRunWithinReturnsWhenAllFinish(t *testing.T) {
+	tasks := make([]func(context.Context) (int, error), 10)
+	for i := range tasks {
+		tasks[i] = func(context.Context) (int, error) { return i * i, nil }
+	}
+	start := time.Now()
+	report := RunWithin(context.Background(), time.Hour, tasks...)
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("RunWithin took %v with every task done at once", took)
+	}
+	if report.Completed != 10 || report.Cancelled != 0 {
+		t.Errorf("Completed %d, Cancelled %d, want 10, 0", report.Completed, report.Cancelled)
+	}
+	if vs := report.Values(); !slices.Equal(vs, []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}) {
+		t.Errorf("Values = %v, want the squares in task order", vs)
+	}
+}
+
+func TestRunWithinParentCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	report := RunWithin(ctx, time.Hour,
+		func(context.Context) (int, error) { return 1, nil },
+		func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		},
+	)
+	if report.Completed != 1 || report.Cancelled != 1 {
+		t.Errorf("Completed %d, Cancelled %d, want 1, 1", report.Completed, report.Cancelled)
+	}
+	if err := report.Outcomes[1].Err; !errors.Is(err, context.Canceled) {
+		t.Errorf("the cancelled task's error = %v, want Canceled", err)
+	}
+}
@@ -0,0 +1,89 @@
+package conc
+
+import "context"
+
+// ============================================================================
+// Zip / Concat - COMBINING CHANNELS
+// ============================================================================
+// Two ways to compose streams besides fan-in (which interleaves in arrival
+// order):
+//
+//   - Zip walks two channels in lockstep, pairing their i-th items
+//   - Concat drains channels one after another, preserving their order
+//
+// Both run one goroutine that owns the output channel and closes it when
+// the inputs are done or ctx is cancelled - so a consumer that stops early
+// cancels ctx instead of leaving the goroutine blocked on a send.
+// ============================================================================
+
+// Pair is the i-th item of each of Zip's inputs.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Zip sends Pair{a_i, b_i} for i = 0, 1, ... until either input is closed
+// or ctx is done, then closes the output. When one input ends first, the
+// item already read from the other (if any) is dropped.
+func Zip[A, B any](ctx context.Context, a <-chan A, b <-chan B) <-chan Pair[A, B] {
+	out := make(chan Pair[A, B])
+	go func() {
+		defer close(out)
+		for {
+			var p Pair[A, B]
+			var ok bool
+			select {
+			case p.First, ok = <-a:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+			select {
+			case p.Second, ok = <-b:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+			select {
+			case out <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Concat sends every item of chs[0] until it is closed, then every item of
+// chs[1], and so on, and closes the output after the last one - or as soon
+// as ctx is done.
+func Concat[T any](ctx context.Context, chs ...<-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for _, ch := range chs {
+			for {
+				var v T
+				var ok bool
+				select {
+				case v, ok = <-ch:
+				case <-ctx.Done():
+					return
+				}
+				if !ok {
+					break // next channel
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,94 @@
+package conc
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// send returns a channel that yields items and is then closed.
+func send[T any](items ...T) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for _, v := range items {
+			ch <- v
+		}
+	}()
+	return ch
+}
+
+// endless returns a channel that yields v until ctx is done.
+func endless[T any](ctx context.Context, v T) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func TestZipPairsInLockstep(t *testing.T) {
+	ctx := context.Background()
+	var got []Pair[int, string]
+	for p := range Zip(ctx, send(1, 2, 3, 4, 5), send("a", "b", "c")) {
+		got = append(got, p)
+	}
+	want := []Pair[int, string]{{1, "a"}, {2, "b"}, {3, "c"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Zip = %v, want %v: pairs up to the shorter input", got, want)
+	}
+
+	if _, ok := <-Zip(ctx, send[int](), send("x")); ok {
+		t.Error("Zip with an empty input sent a pair")
+	}
+}
+
+func TestConcatKeepsOrder(t *testing.T) {
+	ctx := context.Background()
+	// The inputs are all ready at once; the output must still be the first
+	// one's items, then the second's, then the third's.
+	var got []int
+	for v := range Concat(ctx, send(1, 2, 3), send[int](), send(4), send(5, 6)) {
+		got = append(got, v)
+	}
+	if want := []int{1, 2, 3, 4, 5, 6}; !slices.Equal(got, want) {
+		t.Errorf("Concat = %v, want %v", got, want)
+	}
+
+	if _, ok := <-Concat[int](ctx); ok {
+		t.Error("Concat of no channels sent a value")
+	}
+}
+
+// A consumer that stops early cancels ctx; the output must close and every
+// goroutine - Zip's and Concat's own, and the endless inputs - exit.
+func TestZipConcatStopOnCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	never := make(chan int) // Concat never gets to it
+	zipped := Zip(ctx, endless(ctx, 1), endless(ctx, "x"))
+	joined := Concat(ctx, endless(ctx, 7), never)
+	for range 3 {
+		if p := <-zipped; p != (Pair[int, string]{1, "x"}) {
+			t.Fatalf("Zip sent %v, want {1 x}", p)
+		}
+		if v := <-joined; v != 7 {
+			t.Fatalf("Concat sent %d, want 7 until the first input ends", v)
+		}
+	}
+	cancel()
+	for range zipped {
+	}
+	for range joined {
+	}
+	settles(t, baseline)
+}
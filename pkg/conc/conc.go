@@ -0,0 +1,8 @@
+// Package conc collects small, reusable concurrency helpers that the
+// chapter demos kept re-implementing inline.
+//
+// Everything here is built from the standard library only (channels,
+// sync and sync/atomic) and is written to be read: each helper's source
+// explains the technique it uses, so the package doubles as a catalogue of
+// patterns.
+package conc
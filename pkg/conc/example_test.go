@@ -0,0 +1,178 @@
+package conc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+)
+
+func ExampleGroup() {
+	g, ctx := conc.NewGroup(context.Background())
+	g.Go(func() error {
+		return errors.New("parse: bad record")
+	})
+	g.Go(func() error {
+		<-ctx.Done() // the failure above stops this one
+		return ctx.Err()
+	})
+	fmt.Println(g.Wait())
+	// Output: parse: bad record
+}
+
+func ExampleForEach() {
+	urls := []string{"a", "b", "c", "d"}
+	err := conc.ForEach(context.Background(), urls, 2, func(ctx context.Context, u string) error {
+		if u == "c" {
+			return fmt.Errorf("fetch %s: 404", u)
+		}
+		return nil
+	})
+	fmt.Println(err)
+	// Output: fetch c: 404
+}
+
+func ExampleMapSlice() {
+	words := []string{"go", "chan", "select"}
+	lengths, err := conc.MapSlice(context.Background(), words, 2, func(_ context.Context, w string) (int, error) {
+		return len(w), nil
+	})
+	fmt.Println(lengths, err)
+	// Output: [2 4 6] <nil>
+}
+
+func ExampleWalk() {
+	tree := map[string][]string{
+		"/":    {"/a", "/b"},
+		"/a":   {"/a/x", "/a/y"},
+		"/b":   {"/b/z"},
+		"/a/x": nil,
+		"/a/y": nil,
+		"/b/z": nil,
+	}
+	names, err := conc.Walk(context.Background(), []string{"/"}, 2,
+		func(_ context.Context, dir string) (string, []string, error) {
+			return dir, tree[dir], nil
+		})
+	slices.Sort(names) // Walk returns results in no particular order
+	fmt.Println(names, err)
+	// Output: [/ /a /a/x /a/y /b /b/z] <nil>
+}
+
+func ExampleWithTimeout() {
+	slow := func(ctx context.Context) (string, error) {
+		select {
+		case <-time.After(time.Second):
+			return "done", nil
+		case <-ctx.Done():
+			return "", ctx.Err() // watching ctx lets the goroutine exit at once
+		}
+	}
+	_, err := conc.WithTimeout(context.Background(), 10*time.Millisecond, slow)
+	fmt.Println(err)
+	// Output: context deadline exceeded
+}
+
+func ExampleRecvTimeout() {
+	ch := make(chan int, 1)
+	ch <- 42
+	v, ok, err := conc.RecvTimeout(ch, time.Second)
+	fmt.Println(v, ok, err)
+
+	_, _, err = conc.RecvTimeout(ch, 10*time.Millisecond) // nothing more comes
+	fmt.Println(err)
+
+	close(ch)
+	_, ok, err = conc.RecvTimeout(ch, time.Second)
+	fmt.Println(ok, err)
+	// Output:
+	// 42 true <nil>
+	// context deadline exceeded
+	// false <nil>
+}
+
+// gen sends vs on a new channel and closes it.
+func gen[T any](vs ...T) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for _, v := range vs {
+			ch <- v
+		}
+	}()
+	return ch
+}
+
+func ExampleZip() {
+	ctx := context.Background()
+	for p := range conc.Zip(ctx, gen("a", "b", "c"), gen(1, 2)) {
+		fmt.Println(p.First, p.Second)
+	}
+	// Output:
+	// a 1
+	// b 2
+}
+
+func ExampleConcat() {
+	ctx := context.Background()
+	var got []int
+	for v := range conc.Concat(ctx, gen(1, 2), gen(3), gen(4, 5)) {
+		got = append(got, v)
+	}
+	fmt.Println(got)
+	// Output: [1 2 3 4 5]
+}
+
+func ExampleBroadcast() {
+	var config conc.Broadcast[string]
+	w1, w2 := config.Wait(), config.Wait()
+	config.Notify("v2")
+	<-w1.Done()
+	<-w2.Done()
+	fmt.Println(w1.Value(), w2.Value())
+	// Output: v2 v2
+}
+
+func ExampleHub() {
+	hub := conc.NewHub[string](conc.LagLimits{}, nil)
+	fast, slow := hub.Subscribe("fast"), hub.Subscribe("slow")
+	for _, ev := range []string{"start", "tick", "stop"} {
+		hub.Publish(ev) // never blocks, whoever is behind
+	}
+	hub.Close()
+
+	for _, s := range []*conc.Subscription[string]{fast, slow} {
+		var got []string
+		for ev := range s.C() {
+			got = append(got, ev)
+		}
+		fmt.Println(strings.Join(got, " "))
+	}
+	// Output:
+	// start tick stop
+	// start tick stop
+}
+
+func ExampleRunWithin() {
+	task := func(d time.Duration, v string) func(context.Context) (string, error) {
+		return func(ctx context.Context) (string, error) {
+			select {
+			case <-time.After(d):
+				return v, nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+	}
+	r := conc.RunWithin(context.Background(), 100*time.Millisecond,
+		task(time.Millisecond, "cache"),
+		task(time.Millisecond, "db"),
+		task(time.Minute, "search"),
+	)
+	fmt.Println(r.Values(), r.Completed, r.Cancelled)
+	// Output: [cache db] 2 1
+}
@@ -0,0 +1,108 @@
+package conc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/chaos"
+)
+
+// ============================================================================
+// ForEach / MapSlice - BOUNDED PARALLEL LOOPS
+// ============================================================================
+// The demos kept writing the same loop:
+//
+//	var wg sync.WaitGroup
+//	for _, item := range items {
+//		wg.Add(1)
+//		go func(item T) { defer wg.Done(); ... }(item)
+//	}
+//	wg.Wait()
+//
+// which has no limit on concurrency, no error handling and no way to stop
+// early. ForEach adds all three:
+//
+//   - a semaphore (buffered channel) caps the number of calls in flight
+//   - the first error cancels the context passed to every call, and no new
+//     calls are started after it
+//   - cancelling the parent context stops the loop the same way
+// ============================================================================
+
+// ForEach calls fn for every item with at most limit calls running at once
+// (limit <= 0 means no limit). It returns the first error returned by fn; as
+// soon as one call fails, ctx passed to the others is cancelled and no
+// further calls are started. If the parent ctx is cancelled first, ForEach
+// stops starting calls and returns ctx's error. ForEach always waits for the
+// calls it started before returning.
+func ForEach[T any](ctx context.Context, items []T, limit int, fn func(ctx context.Context, item T) error) error {
+	return forEachIndex(ctx, len(items), limit, func(ctx context.Context, i int) error {
+		return fn(ctx, items[i])
+	})
+}
+
+// MapSlice is ForEach that collects fn's results: out[i] is the result for
+// items[i], whatever order the calls finish in. On error it returns nil and
+// the first error.
+func MapSlice[T, Out any](ctx context.Context, items []T, limit int, fn func(ctx context.Context, item T) (Out, error)) ([]Out, error) {
+	out := make([]Out, len(items))
+	err := forEachIndex(ctx, len(items), limit, func(ctx context.Context, i int) error {
+		v, err := fn(ctx, items[i])
+		out[i] = v // each call owns its index: no lock needed
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func forEachIndex(parent context.Context, n, limit int, fn func(ctx context.Context, i int) error) error {
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		sem      = make(chan struct{}, max(limit, 1))
+	)
+	fail := func(err error) {
+		chaos.Point("foreach.fail")
+		errOnce.Do(func() {
+			firstErr = err
+			cancel(err)
+		})
+	}
+
+loop:
+	for i := range n {
+		select {
+		case sem <- struct{}{}: // acquire a slot
+		case <-ctx.Done():
+			break loop
+		}
+		chaos.Point("foreach.acquired")
+		if ctx.Err() != nil { // both cases were ready: don't start more work
+			<-sem
+			break
+		}
+		wg.Go(func() {
+			defer func() {
+				chaos.Point("foreach.release")
+				<-sem
+			}()
+			if err := fn(ctx, i); err != nil {
+				fail(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return parent.Err()
+}
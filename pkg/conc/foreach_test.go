@@ -0,0 +1,129 @@
+package conc
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+// peakTracker counts calls in flight and remembers the most at once.
+type peakTracker struct {
+	inFlight, peak atomic.Int64
+}
+
+func (p *peakTracker) enter() {
+	now := p.inFlight.Add(1)
+	for old := p.peak.Load(); now > old && !p.peak.CompareAndSwap(old, now); old = p.peak.Load() {
+	}
+}
+
+func (p *peakTracker) exit() { p.inFlight.Add(-1) }
+
+func TestForEachLimit(t *testing.T) {
+	for _, limit := range []int{1, 3, 8} {
+		var p peakTracker
+		ran := make([]atomic.Int32, 200)
+		items := make([]int, len(ran))
+		for i := range items {
+			items[i] = i
+		}
+		err := ForEach(context.Background(), items, limit, func(ctx context.Context, i int) error {
+			p.enter()
+			defer p.exit()
+			ran[i].Add(1)
+			for range 3 {
+				runtime.Gosched() // let the others pile up
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("limit %d: ForEach = %v", limit, err)
+		}
+		if peak := p.peak.Load(); peak > int64(limit) {
+			t.Errorf("limit %d: %d calls in flight at once", limit, peak)
+		} else if limit > 1 && peak < 2 {
+			t.Errorf("limit %d: never more than %d call in flight", limit, peak)
+		}
+		if p.inFlight.Load() != 0 {
+			t.Errorf("limit %d: ForEach returned with %d calls still running", limit, p.inFlight.Load())
+		}
+		for i := range ran {
+			if n := ran[i].Load(); n != 1 {
+				t.Fatalf("limit %d: item %d ran %d times", limit, i, n)
+			}
+		}
+	}
+}
+
+// The failing call returns at once while every other call waits for the
+// cancellation: so the only calls ever started are the ones that fit in the
+// limit before the failure.
+func TestForEachErrorShortCircuits(t *testing.T) {
+	const limit, failAt = 4, 2
+	errItem := errors.New("item failed")
+	var started atomic.Int32
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	err := ForEach(context.Background(), items, limit, func(ctx context.Context, i int) error {
+		started.Add(1)
+		if i == failAt {
+			return errItem
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, errItem) {
+		t.Fatalf("ForEach = %v, want the item's error, not the cancellation it caused", err)
+	}
+	if n := started.Load(); n > limit {
+		t.Errorf("%d calls started, want at most %d: no new call after the first error", n, limit)
+	}
+}
+
+func TestForEachParentCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var started atomic.Int32
+	err := ForEach(ctx, []int{1, 2, 3}, 2, func(context.Context, int) error {
+		started.Add(1)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ForEach = %v, want context.Canceled", err)
+	}
+	if started.Load() != 0 {
+		t.Errorf("%d calls started on a cancelled context", started.Load())
+	}
+}
+
+func TestMapSliceKeepsOrder(t *testing.T) {
+	items := make([]int, 100)
+	want := make([]int, len(items))
+	for i := range items {
+		items[i], want[i] = i, i*i
+	}
+	got, err := MapSlice(context.Background(), items, 5, func(_ context.Context, i int) (int, error) {
+		for range i % 7 { // finish out of order
+			runtime.Gosched()
+		}
+		return i * i, nil
+	})
+	if err != nil || !slices.Equal(got, want) {
+		t.Fatalf("MapSlice = %v, %v; want squares in item order", got, err)
+	}
+
+	got, err = MapSlice(context.Background(), items, 5, func(_ context.Context, i int) (int, error) {
+		if i == 50 {
+			return 0, errors.New("item 50")
+		}
+		return i, nil
+	})
+	if err == nil || got != nil {
+		t.Errorf("MapSlice with a failing item = %v, %v; want nil and the error", got, err)
+	}
+}
@@ -0,0 +1,60 @@
+package conc
+
+import (
+	"context"
+	"sync"
+)
+
+// ============================================================================
+// Group - GOROUTINES THAT SUCCEED OR FAIL TOGETHER
+// ============================================================================
+// A WaitGroup waits; it doesn't know about errors. Every demo that needed
+// "run these, stop them all at the first failure, tell me what failed"
+// grew the same three extra pieces next to it:
+//
+//   - a sync.Once guarding the first error (later ones are consequences)
+//   - a context cancelled with that error, watched by every goroutine
+//   - Wait returning the error after the last goroutine has exited
+//
+// Group is those pieces, in the shape of golang.org/x/sync/errgroup. The
+// goroutines are still responsible for watching ctx: cancelling it only
+// asks them to stop.
+// ============================================================================
+
+// Group runs goroutines and collects the first error. Create it with
+// NewGroup.
+type Group struct {
+	cancel   context.CancelCauseFunc
+	wg       sync.WaitGroup
+	errOnce  sync.Once
+	firstErr error
+}
+
+// NewGroup returns a Group and a context derived from ctx that is cancelled,
+// with the error as its cause, when a goroutine of the group first returns
+// an error - or when Wait returns, whichever comes first.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Go runs fn in a new goroutine. If fn is the first in the group to return
+// an error, the group's context is cancelled and Wait will return it.
+func (g *Group) Go(fn func() error) {
+	g.wg.Go(func() {
+		if err := fn(); err != nil {
+			g.errOnce.Do(func() {
+				g.firstErr = err
+				g.cancel(err)
+			})
+		}
+	})
+}
+
+// Wait waits for every goroutine started with Go and returns the first
+// error, if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(g.firstErr)
+	return g.firstErr
+}
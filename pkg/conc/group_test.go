@@ -0,0 +1,106 @@
+package conc
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+// TestGroupReturnsFirstError fails one goroutine while the others wait for
+// the group's context: Wait must return the failure, not the Canceled the
+// others returned because of it, and the context's cause must be it too.
+func TestGroupReturnsFirstError(t *testing.T) {
+	errFirst := errors.New("first")
+	g, ctx := NewGroup(context.Background())
+	var stopped atomic.Int64
+	for range 5 {
+		g.Go(func() error {
+			<-ctx.Done()
+			stopped.Add(1)
+			return ctx.Err()
+		})
+	}
+	g.Go(func() error { return errFirst })
+
+	if err := g.Wait(); err != errFirst {
+		t.Errorf("Wait = %v, want %v", err, errFirst)
+	}
+	if n := stopped.Load(); n != 5 {
+		t.Errorf("Wait returned with %d of 5 goroutines stopped", n)
+	}
+	if cause := context.Cause(ctx); cause != errFirst {
+		t.Errorf("context.Cause = %v, want %v", cause, errFirst)
+	}
+}
+
+func TestGroupWaitCancelsContext(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	var ran atomic.Int64
+	for range 10 {
+		g.Go(func() error {
+			runtime.Gosched()
+			ran.Add(1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait = %v, want nil", err)
+	}
+	if n := ran.Load(); n != 10 {
+		t.Errorf("Wait returned after %d of 10 goroutines", n)
+	}
+	if ctx.Err() == nil {
+		t.Error("the group's context is still live after Wait")
+	}
+	if cause := context.Cause(ctx); cause != context.Canceled {
+		t.Errorf("context.Cause = %v, want Canceled when nothing failed", cause)
+	}
+}
+
+func TestGroupParentCancelled(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	g, ctx := NewGroup(parent)
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	cancel()
+	if err := g.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait = %v, want Canceled", err)
+	}
+}
+
+// TestGroupFailureReleasesSenders is the pipeline case: a producer blocked
+// on a send must be released by a failure downstream, so that Wait returns
+// and nothing is left running.
+func TestGroupFailureReleasesSenders(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	errStage := errors.New("stage failed")
+	g, ctx := NewGroup(context.Background())
+	ch := make(chan int)
+	g.Go(func() error {
+		defer close(ch)
+		for i := 0; ; i++ {
+			select {
+			case ch <- i:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+	g.Go(func() error {
+		for v := range ch {
+			if v == 3 {
+				return errStage
+			}
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != errStage {
+		t.Errorf("Wait = %v, want %v", err, errStage)
+	}
+	settles(t, baseline)
+}
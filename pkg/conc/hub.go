@@ -0,0 +1,243 @@
+package conc
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// Hub[T] - FAN-OUT WITH SLOW-CONSUMER DETECTION
+// ============================================================================
+// Broadcast wakes whoever is waiting and forgets the rest; a Hub delivers
+// every published item to every subscriber, in order. That moves the
+// problem: the publisher must not block on the slowest subscriber, so each
+// subscription has its own queue and its own goroutine feeding its
+// channel - and a subscriber that can't keep up grows its queue without
+// anybody noticing, until memory runs out or its data is minutes old.
+//
+// So the Hub measures lag per subscriber:
+//
+//   - Pending: items queued and not yet received
+//   - Oldest:  how long the oldest of them has waited
+//
+// and calls onLag once when a Publish finds a subscriber over either limit
+// (the limits are checked there, where the backlog grows). The episode
+// ends when the subscriber catches up (its queue empties); the next
+// crossing is reported again. Detection comes first: what to do about a
+// slow consumer - drop its oldest items, disconnect it, slow the publisher -
+// is a policy, and it can be chosen by looking at these numbers.
+// ============================================================================
+
+// LagLimits says when a subscriber counts as slow. A zero field is no
+// limit.
+type LagLimits struct {
+	Pending int           // more items queued than this
+	Age     time.Duration // the oldest queued item has waited longer than this
+}
+
+// Lag is one subscriber's backlog.
+type Lag struct {
+	Subscriber string
+	Pending    int           // items queued, not yet received
+	Oldest     time.Duration // age of the oldest of them; 0 if none
+	Delivered  int64         // items received so far
+}
+
+// stamped is a queued item and when it was published.
+type stamped[T any] struct {
+	v  T
+	at time.Time
+}
+
+// Hub delivers every published item to every subscriber. Create it with
+// NewHub; it is safe for concurrent use.
+type Hub[T any] struct {
+	limits LagLimits
+	onLag  func(Lag)
+
+	mu     sync.Mutex
+	subs   map[*Subscription[T]]struct{}
+	closed bool
+}
+
+// NewHub returns a Hub that reports subscribers over limits to onLag, if
+// not nil. onLag is called on the publishing goroutine, so it must not
+// block or publish.
+func NewHub[T any](limits LagLimits, onLag func(Lag)) *Hub[T] {
+	return &Hub[T]{limits: limits, onLag: onLag, subs: make(map[*Subscription[T]]struct{})}
+}
+
+// Subscription is one subscriber's queue and channel.
+type Subscription[T any] struct {
+	hub  *Hub[T]
+	name string
+	c    chan T
+
+	mu        sync.Mutex
+	queue     []stamped[T]
+	lagging   bool          // in a reported lag episode
+	closing   bool          // deliver what is queued, then close c
+	wake      chan struct{} // 1-buffered: "the queue changed"
+	stop      chan struct{} // closed by Unsubscribe
+	stopOnce  sync.Once
+	delivered atomic.Int64
+}
+
+// Subscribe adds a subscriber that receives every item published from now
+// on, from C. If the Hub is closed, C is closed at once.
+func (h *Hub[T]) Subscribe(name string) *Subscription[T] {
+	s := &Subscription[T]{
+		hub:  h,
+		name: name,
+		c:    make(chan T),
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+	}
+	h.mu.Lock()
+	if h.closed {
+		s.closing = true
+	} else {
+		h.subs[s] = struct{}{}
+	}
+	h.mu.Unlock()
+	go s.forward()
+	return s
+}
+
+// C returns the channel items arrive on. It is closed after Hub.Close once
+// the queue is delivered, or at Unsubscribe.
+func (s *Subscription[T]) C() <-chan T { return s.c }
+
+// Unsubscribe stops delivery: queued items are dropped and C is closed.
+func (s *Subscription[T]) Unsubscribe() {
+	s.hub.mu.Lock()
+	delete(s.hub.subs, s)
+	s.hub.mu.Unlock()
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Lag returns the subscriber's current backlog.
+func (s *Subscription[T]) Lag() Lag {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lagLocked(time.Now())
+}
+
+func (s *Subscription[T]) lagLocked(now time.Time) Lag {
+	l := Lag{Subscriber: s.name, Pending: len(s.queue), Delivered: s.delivered.Load()}
+	if len(s.queue) > 0 {
+		l.Oldest = now.Sub(s.queue[0].at)
+	}
+	return l
+}
+
+// forward moves items from the queue to c, one at a time, so that only
+// this goroutine ever waits for the subscriber.
+func (s *Subscription[T]) forward() {
+	defer close(s.c)
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.lagging = false // caught up: the episode is over
+			closing := s.closing
+			s.mu.Unlock()
+			if closing {
+				return
+			}
+			select {
+			case <-s.wake:
+				continue
+			case <-s.stop:
+				return
+			}
+		}
+		head := s.queue[0].v
+		s.mu.Unlock()
+
+		select {
+		case s.c <- head:
+			s.mu.Lock()
+			var zero stamped[T]
+			s.queue[0] = zero // don't keep the item reachable
+			s.queue = s.queue[1:]
+			s.delivered.Add(1)
+			s.mu.Unlock()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Publish queues v for every subscriber and never blocks. Subscribers over
+// the Hub's limits are reported to onLag. Publish after Close is ignored.
+func (h *Hub[T]) Publish(v T) {
+	now := time.Now()
+	var slow []Lag
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	for s := range h.subs {
+		s.mu.Lock()
+		s.queue = append(s.queue, stamped[T]{v, now})
+		if l := s.lagLocked(now); !s.lagging && h.over(l) {
+			s.lagging = true
+			slow = append(slow, l)
+		}
+		s.mu.Unlock()
+		select {
+		case s.wake <- struct{}{}:
+		default: // already woken
+		}
+	}
+	h.mu.Unlock()
+	if h.onLag != nil {
+		for _, l := range slow {
+			h.onLag(l)
+		}
+	}
+}
+
+func (h *Hub[T]) over(l Lag) bool {
+	return h.limits.Pending > 0 && l.Pending > h.limits.Pending ||
+		h.limits.Age > 0 && l.Oldest > h.limits.Age
+}
+
+// Lags returns the backlog of every subscriber, by name.
+func (h *Hub[T]) Lags() []Lag {
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	lags := make([]Lag, 0, len(h.subs))
+	for s := range h.subs {
+		s.mu.Lock()
+		lags = append(lags, s.lagLocked(now))
+		s.mu.Unlock()
+	}
+	slices.SortFunc(lags, func(a, b Lag) int { return strings.Compare(a.Subscriber, b.Subscriber) })
+	return lags
+}
+
+// Close stops publishing. Every subscriber still receives what is queued
+// for it, then its channel is closed.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for s := range h.subs {
+		s.mu.Lock()
+		s.closing = true
+		s.mu.Unlock()
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package conc
+
+import (
+	"context"
+	"time"
+)
+
+// ============================================================================
+// WithTimeout / WithDeadline / RecvTimeout - TIMEOUTS THAT DON'T LEAK
+// ============================================================================
+// The textbook timeout
+//
+//	result := make(chan string)
+//	go func() { result <- slow() }()
+//	select {
+//	case r := <-result: ...
+//	case <-time.After(d): // give up
+//	}
+//
+// leaks the goroutine when the timeout wins: nobody will ever receive from
+// result, so the send blocks forever. Two changes fix it:
+//
+//   - the result channel has room for one value, so the send never blocks
+//     and the goroutine exits as soon as slow() returns, received or not
+//   - slow() gets a context that is cancelled on timeout, so it can stop
+//     early instead of finishing work nobody wants
+// ============================================================================
+
+// WithTimeout runs fn in a new goroutine with a context that expires after
+// d (or when ctx is done) and returns fn's result, or the context's error if
+// that comes first. On timeout WithTimeout returns at once; the goroutine
+// exits when fn returns - promptly, if fn watches its context.
+func WithTimeout[T any](ctx context.Context, d time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	return await(ctx, fn)
+}
+
+// WithDeadline is WithTimeout with an absolute deadline.
+func WithDeadline[T any](ctx context.Context, deadline time.Time, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	return await(ctx, fn)
+}
+
+type result[T any] struct {
+	v   T
+	err error
+}
+
+func await[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) (T, error) {
+	done := make(chan result[T], 1) // buffered: the send below never blocks
+	go func() {
+		v, err := fn(ctx)
+		done <- result[T]{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// RecvTimeout receives from ch, waiting at most d. ok is false if ch was
+// closed; err is context.DeadlineExceeded if d passed first. The timer is
+// stopped on return, so nothing is left behind either way.
+func RecvTimeout[T any](ch <-chan T, d time.Duration) (v T, ok bool, err error) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case v, ok = <-ch:
+		return v, ok, nil
+	case <-t.C:
+		return v, false, context.DeadlineExceeded
+	}
+}
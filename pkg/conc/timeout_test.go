@@ -0,0 +1,115 @@
+package conc
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// settles waits until runtime.NumGoroutine is back to at most want -
+// goroutines that are exiting take a moment to be gone - and fails the test
+// if it isn't within a second.
+func settles(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		n := runtime.NumGoroutine()
+		if n <= want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines, want %d: %d leaked", n, want, n-want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWithTimeoutDoesNotLeak(t *testing.T) {
+	const calls = 20
+	baseline := runtime.NumGoroutine()
+
+	// fn watches its context: it stops when the timeout fires.
+	for range calls {
+		_, err := WithTimeout(context.Background(), time.Millisecond, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("WithTimeout = %v, want DeadlineExceeded", err)
+		}
+	}
+	settles(t, baseline)
+
+	// fn ignores its context and finishes long after the timeout, with
+	// nobody left to receive its result: its send must not block.
+	release := make(chan struct{})
+	for range calls {
+		_, err := WithTimeout(context.Background(), time.Millisecond, func(context.Context) (int, error) {
+			<-release
+			return 42, nil
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("WithTimeout = %v, want DeadlineExceeded", err)
+		}
+	}
+	if n := runtime.NumGoroutine(); n < baseline+calls {
+		t.Fatalf("%d goroutines while fn is still running, want at least %d", n, baseline+calls)
+	}
+	close(release)
+	settles(t, baseline)
+
+	v, err := WithTimeout(context.Background(), time.Minute, func(context.Context) (int, error) { return 42, nil })
+	if v != 42 || err != nil {
+		t.Errorf("WithTimeout = %d, %v; want 42, nil", v, err)
+	}
+	settles(t, baseline)
+}
+
+func TestWithDeadlineDoesNotLeak(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	release := make(chan struct{})
+	for range 20 {
+		_, err := WithDeadline(context.Background(), time.Now().Add(time.Millisecond), func(context.Context) (string, error) {
+			<-release
+			return "late", nil
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("WithDeadline = %v, want DeadlineExceeded", err)
+		}
+	}
+	close(release)
+	settles(t, baseline)
+
+	// A deadline already past: fn may or may not run, but nothing stays.
+	_, err := WithDeadline(context.Background(), time.Now().Add(-time.Second), func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WithDeadline in the past = %v, want DeadlineExceeded", err)
+	}
+	settles(t, baseline)
+}
+
+func TestRecvTimeoutDoesNotLeak(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ch := make(chan int, 1)
+	for range 20 {
+		if _, ok, err := RecvTimeout(ch, time.Millisecond); ok || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("RecvTimeout on an empty channel = %v, %v; want false, DeadlineExceeded", ok, err)
+		}
+	}
+	settles(t, baseline)
+
+	ch <- 7
+	if v, ok, err := RecvTimeout(ch, time.Minute); v != 7 || !ok || err != nil {
+		t.Errorf("RecvTimeout = %d, %v, %v; want 7, true, nil", v, ok, err)
+	}
+	close(ch)
+	if _, ok, err := RecvTimeout(ch, time.Minute); ok || err != nil {
+		t.Errorf("RecvTimeout on a closed channel = %v, %v; want false, nil", ok, err)
+	}
+	settles(t, baseline)
+}
@@ -0,0 +1,106 @@
+package conc
+
+import (
+	"context"
+	"errors"
+)
+
+// ============================================================================
+// Walk - BOUNDED PARALLEL RECURSION
+// ============================================================================
+// The obvious way to walk a tree in parallel with a limit deadlocks:
+//
+//	func visit(n Node) {
+//		sem <- struct{}{}        // take a slot
+//		defer func() { <-sem }()
+//		var wg sync.WaitGroup
+//		for _, c := range n.Children() {
+//			wg.Go(func() { visit(c) }) // children need slots too...
+//		}
+//		wg.Wait() // ...while we hold ours and wait for them
+//	}
+//
+// Once every slot is held by a parent waiting for its children, no child
+// can start, and nothing ever finishes. It only shows up on trees deeper
+// than the limit - that is, in production.
+//
+// Walk never holds a slot while waiting. A visit returns its children
+// instead of recursing; the caller's goroutine keeps them on a stack and
+// hands them to a fixed set of `limit` workers. So the goroutine count is
+// bounded, and a worker is always free to take the next node.
+// ============================================================================
+
+// walked is what a worker sends back for one node.
+type walked[N, R any] struct {
+	result   R
+	children []N
+	err      error
+}
+
+// Walk calls visit for every node reachable from roots, with at most limit
+// calls running at once (limit <= 0 means 1). visit returns a result for
+// the node and the children to visit next; if it returns an error, its
+// children are not visited and the walk goes on with the other branches.
+//
+// Walk returns the results in no particular order, and every visit error
+// joined with errors.Join. If ctx is cancelled, no new visits start, ctx's
+// error is included, and Walk returns once the calls in flight are done.
+// A node reachable twice (a graph, not a tree) is visited twice: visit
+// should return only the children it hasn't seen.
+func Walk[N, R any](ctx context.Context, roots []N, limit int, visit func(ctx context.Context, node N) (R, []N, error)) ([]R, error) {
+	limit = max(limit, 1)
+	work := make(chan N)
+	done := make(chan walked[N, R])
+	for range limit {
+		go func() {
+			for n := range work {
+				r, children, err := visit(ctx, n)
+				done <- walked[N, R]{r, children, err}
+			}
+		}()
+	}
+	defer close(work) // the workers exit once nothing is in flight
+
+	stack := append([]N(nil), roots...)
+	var results []R
+	var errs []error
+	inFlight := 0
+	cancelled := ctx.Done() // set to nil once handled
+	stopped := false
+	ctxReported := false // a visit may return ctx's error before the select sees the cancel
+	for len(stack) > 0 || inFlight > 0 {
+		// Offer the top of the stack only when there is one: a nil channel
+		// disables that case of the select.
+		var send chan N
+		var next N
+		if len(stack) > 0 {
+			send, next = work, stack[len(stack)-1]
+		}
+		select {
+		case send <- next:
+			stack = stack[:len(stack)-1]
+			inFlight++
+		case w := <-done:
+			inFlight--
+			if w.err != nil {
+				isCtxErr := ctx.Err() != nil && errors.Is(w.err, ctx.Err())
+				if !isCtxErr || !ctxReported { // ctx's error is reported once
+					errs = append(errs, w.err)
+					ctxReported = ctxReported || isCtxErr
+				}
+				continue
+			}
+			results = append(results, w.result)
+			if !stopped {
+				stack = append(stack, w.children...)
+			}
+		case <-cancelled:
+			if !ctxReported {
+				errs = append(errs, ctx.Err())
+				ctxReported = true
+			}
+			stack, cancelled, stopped = nil, nil, true // start nothing more; wait for the rest
+		}
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,144 @@
+package conc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// node is a node of a complete ternary tree: id's children are 3id+1..3id+3.
+type node struct{ id, depth int }
+
+func (n node) children(maxDepth int) []node {
+	if n.depth == maxDepth {
+		return nil
+	}
+	return []node{{3*n.id + 1, n.depth + 1}, {3*n.id + 2, n.depth + 1}, {3*n.id + 3, n.depth + 1}}
+}
+
+// treeSize is the number of nodes in a complete ternary tree of maxDepth.
+func treeSize(maxDepth int) int {
+	size, level := 0, 1
+	for range maxDepth + 1 {
+		size += level
+		level *= 3
+	}
+	return size
+}
+
+// TestWalkVisitsEveryNode walks a tree much deeper than the limit - where
+// the semaphore-and-recursion walk deadlocks - and checks that every node
+// is visited exactly once and the limit holds.
+func TestWalkVisitsEveryNode(t *testing.T) {
+	const depth = 6
+	for _, limit := range []int{0, 1, 4} {
+		var p peakTracker
+		seen := make([]atomic.Int32, treeSize(depth))
+		ids, err := Walk(context.Background(), []node{{0, 0}}, limit, func(_ context.Context, n node) (int, []node, error) {
+			p.enter()
+			defer p.exit()
+			seen[n.id].Add(1)
+			runtime.Gosched()
+			return n.id, n.children(depth), nil
+		})
+		if err != nil {
+			t.Fatalf("limit %d: Walk: %v", limit, err)
+		}
+		if len(ids) != len(seen) {
+			t.Errorf("limit %d: %d results, want %d", limit, len(ids), len(seen))
+		}
+		for id := range seen {
+			if n := seen[id].Load(); n != 1 {
+				t.Errorf("limit %d: node %d visited %d times", limit, id, n)
+			}
+		}
+		if peak := p.peak.Load(); peak > int64(max(limit, 1)) {
+			t.Errorf("limit %d: %d visits at once", limit, peak)
+		}
+	}
+}
+
+func TestWalkNoRoots(t *testing.T) {
+	ids, err := Walk(context.Background(), nil, 4, func(context.Context, node) (int, []node, error) {
+		t.Error("visit called without roots")
+		return 0, nil, nil
+	})
+	if ids != nil || err != nil {
+		t.Errorf("Walk(nil) = %v, %v, want nil, nil", ids, err)
+	}
+}
+
+// TestWalkErrorPrunesBranch checks that a failing node's children are not
+// visited, that the other branches are, and that every error is returned.
+func TestWalkErrorPrunesBranch(t *testing.T) {
+	const depth = 4
+	failing := map[int]bool{1: true, 8: true} // 8 is under 2, so both fail
+	errFail := errors.New("fail")
+	var visited atomic.Int64
+	ids, err := Walk(context.Background(), []node{{0, 0}}, 3, func(_ context.Context, n node) (int, []node, error) {
+		visited.Add(1)
+		for a := n.id; a > 0; a = (a - 1) / 3 {
+			if a != n.id && failing[a] {
+				t.Errorf("node %d visited below failed node %d", n.id, a)
+			}
+		}
+		if failing[n.id] {
+			return 0, nil, fmt.Errorf("node %d: %w", n.id, errFail)
+		}
+		return n.id, n.children(depth), nil
+	})
+
+	subtree := treeSize(depth - 1) // the tree below a depth-1 node, itself included
+	want := treeSize(depth) - (subtree - 1) - (treeSize(depth-2) - 1)
+	if got := int(visited.Load()); got != want {
+		t.Errorf("visited %d nodes, want %d", got, want)
+	}
+	if len(ids) != want-len(failing) {
+		t.Errorf("%d results, want %d", len(ids), want-len(failing))
+	}
+	if !errors.Is(err, errFail) {
+		t.Fatalf("Walk = %v, want errFail", err)
+	}
+	for id := range failing {
+		if msg := fmt.Sprintf("node %d: fail", id); !slices.Contains(strings.Split(err.Error(), "\n"), msg) {
+			t.Errorf("Walk error %q does not include %q", err, msg)
+		}
+	}
+}
+
+// TestWalkStopsOnCancel cancels partway through a large tree: Walk must
+// start no more visits, report ctx's error once, and leave no workers.
+func TestWalkStopsOnCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var visited atomic.Int64
+	var after atomic.Bool
+	_, err := Walk(ctx, []node{{0, 0}}, 4, func(ctx context.Context, n node) (int, []node, error) {
+		if after.Load() {
+			t.Errorf("node %d visited after Walk returned", n.id)
+		}
+		if visited.Add(1) == 50 {
+			cancel()
+			return 0, nil, ctx.Err()
+		}
+		return n.id, n.children(10), nil
+	})
+	after.Store(true)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Walk = %v, want Canceled", err)
+	}
+	if err.Error() != context.Canceled.Error() {
+		t.Errorf("Walk = %q, want ctx's error reported once", err)
+	}
+	if n := visited.Load(); n >= int64(treeSize(10)) {
+		t.Errorf("all %d nodes visited despite the cancel", n)
+	}
+	settles(t, baseline)
+}
@@ -0,0 +1,501 @@
+package concvet
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// ============================================================================
+// copylock: A LOCK PASSED OR ASSIGNED BY VALUE
+// ============================================================================
+// A copied Mutex is a second, independent lock: the copy protects nothing.
+// A copied WaitGroup counts separately, so Wait on one ignores Done on the
+// other. Flags parameters, receivers, assignments, call arguments and range
+// variables whose type holds one of these by value.
+
+var CopyLock = &analysis.Analyzer{
+	Name: "copylock",
+	Doc:  "sync.Mutex, RWMutex, WaitGroup, Cond or Once copied by value",
+	Run:  runCopyLock,
+}
+
+// noCopy are the sync types that must not be copied after first use.
+var noCopy = map[string]bool{"Mutex": true, "RWMutex": true, "WaitGroup": true, "Cond": true, "Once": true}
+
+// lockPath returns the sync type held by value in t ("sync.Mutex", or
+// "contains sync.WaitGroup" for a struct or array holding one), or "".
+func lockPath(t types.Type) string {
+	return lockPathSeen(t, map[types.Type]bool{})
+}
+
+func lockPathSeen(t types.Type, seen map[types.Type]bool) string {
+	if t == nil || seen[t] {
+		return ""
+	}
+	seen[t] = true
+	if n, ok := t.(*types.Named); ok {
+		if obj := n.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == "sync" && noCopy[obj.Name()] {
+			return "sync." + obj.Name()
+		}
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		for i := range u.NumFields() {
+			if p := lockPathSeen(u.Field(i).Type(), seen); p != "" {
+				return "contains " + trimContains(p)
+			}
+		}
+	case *types.Array:
+		if p := lockPathSeen(u.Elem(), seen); p != "" {
+			return "contains " + trimContains(p)
+		}
+	}
+	return ""
+}
+
+func trimContains(p string) string {
+	const prefix = "contains "
+	if len(p) > len(prefix) && p[:len(prefix)] == prefix {
+		return p[len(prefix):]
+	}
+	return p
+}
+
+// copiesValue reports whether evaluating e yields a copy of an existing
+// variable, as opposed to a fresh value (a composite literal, a call).
+func copiesValue(e ast.Expr) bool {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		return e.Name != "_" && e.Name != "nil"
+	case *ast.SelectorExpr, *ast.IndexExpr, *ast.StarExpr:
+		return true
+	}
+	return false
+}
+
+func runCopyLock(pass *analysis.Pass) (any, error) {
+	typeOf := pass.TypesInfo.TypeOf
+	checkFields := func(fields *ast.FieldList, what string) {
+		if fields == nil {
+			return
+		}
+		for _, f := range fields.List {
+			if p := lockPath(typeOf(f.Type)); p != "" {
+				pass.Reportf(f.Type.Pos(), "%s passes a lock by value: %s %s; use a pointer", what, types.ExprString(f.Type), p)
+			}
+		}
+	}
+	checkCopy := func(e ast.Expr, what string) {
+		if !copiesValue(e) || pass.TypesInfo.Types[e].IsType() {
+			return // new(sync.Mutex) names the type, it copies nothing
+		}
+		if p := lockPath(typeOf(e)); p != "" {
+			pass.Reportf(e.Pos(), "%s copies a lock: %s %s", what, types.ExprString(e), p)
+		}
+	}
+
+	for _, f := range pass.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				checkFields(n.Recv, "receiver")
+				checkFields(n.Type.Params, "parameter")
+			case *ast.FuncLit:
+				checkFields(n.Type.Params, "parameter")
+			case *ast.AssignStmt:
+				for i, rhs := range n.Rhs {
+					if len(n.Lhs) == len(n.Rhs) && types.ExprString(n.Lhs[i]) == "_" {
+						continue // discarded, nothing keeps the copy
+					}
+					checkCopy(rhs, "assignment")
+				}
+			case *ast.ValueSpec:
+				for _, v := range n.Values {
+					checkCopy(v, "variable declaration")
+				}
+			case *ast.CompositeLit:
+				for _, elt := range n.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						elt = kv.Value
+					}
+					checkCopy(elt, "composite literal")
+				}
+			case *ast.CallExpr:
+				if tv, ok := pass.TypesInfo.Types[n.Fun]; ok && tv.IsType() {
+					return true // a conversion, not a call
+				}
+				for _, arg := range n.Args {
+					checkCopy(arg, "call argument")
+				}
+			case *ast.ReturnStmt:
+				for _, r := range n.Results {
+					checkCopy(r, "return")
+				}
+			case *ast.RangeStmt:
+				if n.Value != nil {
+					if p := lockPath(typeOf(n.Value)); p != "" {
+						pass.Reportf(n.Value.Pos(), "range variable copies a lock: %s %s; range over indexes or pointers", types.ExprString(n.Value), p)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// ============================================================================
+// wgadd: WaitGroup.Add INSIDE THE GOROUTINE
+// ============================================================================
+// wg.Add(1) as the first line of `go func() { ... }()` races with wg.Wait:
+// if Wait runs before the new goroutine is scheduled, the counter is still
+// zero and Wait returns at once. Add belongs before the go statement (or use
+// wg.Go). A WaitGroup declared inside the goroutine counts the goroutines it
+// starts itself, so its Adds are not flagged.
+
+var WGAdd = &analysis.Analyzer{
+	Name: "wgadd",
+	Doc:  "WaitGroup.Add called inside the goroutine it counts",
+	Run:  runWGAdd,
+}
+
+// syncMethod returns the name of the sync method call calls (e.g.
+// "WaitGroup.Add") and its receiver expression, or "" if it isn't one.
+func syncMethod(info *types.Info, call *ast.CallExpr) (string, ast.Expr) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return "", nil
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "sync" {
+		return "", nil
+	}
+	recv := fn.Signature().Recv()
+	if recv == nil {
+		return "", nil
+	}
+	t := recv.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	n, ok := t.(*types.Named)
+	if !ok {
+		return "", nil
+	}
+	return n.Obj().Name() + "." + fn.Name(), sel.X
+}
+
+// declaredIn reports whether the variable at the root of x (wg in wg,
+// s.wg or &wg) is declared inside lit. Such a WaitGroup counts goroutines
+// that lit starts itself, so its Adds are in the right place.
+func declaredIn(info *types.Info, x ast.Expr, lit *ast.FuncLit) bool {
+	for {
+		switch e := ast.Unparen(x).(type) {
+		case *ast.SelectorExpr:
+			x = e.X
+		case *ast.StarExpr:
+			x = e.X
+		case *ast.UnaryExpr:
+			x = e.X
+		case *ast.IndexExpr:
+			x = e.X
+		case *ast.Ident:
+			obj := info.Uses[e]
+			return obj != nil && lit.Pos() <= obj.Pos() && obj.Pos() < lit.End()
+		default:
+			return false
+		}
+	}
+}
+
+func runWGAdd(pass *analysis.Pass) (any, error) {
+	for _, f := range pass.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			g, ok := n.(*ast.GoStmt)
+			if !ok {
+				return true
+			}
+			lit, ok := ast.Unparen(g.Call.Fun).(*ast.FuncLit)
+			if !ok {
+				return true
+			}
+			ast.Inspect(lit.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.GoStmt:
+					return false // its own goroutine; visited by the outer walk
+				case *ast.CallExpr:
+					if name, recv := syncMethod(pass.TypesInfo, n); name == "WaitGroup.Add" && !declaredIn(pass.TypesInfo, recv, lit) {
+						pass.Reportf(n.Pos(), "%s.Add inside the goroutine it counts races with Wait; call Add before the go statement",
+							types.ExprString(recv))
+					}
+				}
+				return true
+			})
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// ============================================================================
+// unlock: A PATH THAT LEAVES THE FUNCTION WITH THE LOCK HELD
+// ============================================================================
+// After mu.Lock() (or RLock) without a deferred Unlock, every return that
+// follows it in the same block must come after an Unlock - an early return
+// on an error path is the classic way to leave a mutex locked forever.
+// Falling off the end of the function with the lock held is flagged too,
+// but only if the function unlocks on some other path: one that never
+// unlocks is taken to hand the held lock to its caller (a pickUp or acquire
+// helper).
+
+var Unlock = &analysis.Analyzer{
+	Name: "unlock",
+	Doc:  "return or end of function reached with a mutex still locked",
+	Run:  runUnlock,
+}
+
+func runUnlock(pass *analysis.Pass) (any, error) {
+	for _, f := range pass.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			var body *ast.BlockStmt
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				body = n.Body
+			case *ast.FuncLit:
+				body = n.Body
+			}
+			if body != nil {
+				checkUnlocks(pass, body)
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// lockCall reports whether s is `x.Lock()` or `x.RLock()` on a sync.Mutex or
+// RWMutex, returning the Unlock method that releases it and x.
+func lockCall(info *types.Info, s ast.Stmt) (unlock string, x string, ok bool) {
+	es, isExpr := s.(*ast.ExprStmt)
+	if !isExpr {
+		return "", "", false
+	}
+	call, isCall := es.X.(*ast.CallExpr)
+	if !isCall {
+		return "", "", false
+	}
+	name, recv := syncMethod(info, call)
+	switch name {
+	case "Mutex.Lock", "RWMutex.Lock":
+		return "Unlock", types.ExprString(recv), true
+	case "RWMutex.RLock":
+		return "RUnlock", types.ExprString(recv), true
+	}
+	return "", "", false
+}
+
+// isUnlock reports whether call is x.<unlock>().
+func isUnlock(call *ast.CallExpr, x, unlock string) bool {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == unlock && types.ExprString(sel.X) == x
+}
+
+// checkUnlocks looks at the functions's own statements (not nested
+// function literals, which runUnlock visits separately).
+func checkUnlocks(pass *analysis.Pass, body *ast.BlockStmt) {
+	deferred, unlocks := map[string]bool{}, map[string]bool{}
+	inspectOwn(body, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.DeferStmt:
+			if sel, ok := ast.Unparen(n.Call.Fun).(*ast.SelectorExpr); ok {
+				deferred[types.ExprString(sel.X)+"."+sel.Sel.Name] = true
+			}
+		case *ast.CallExpr:
+			if sel, ok := ast.Unparen(n.Fun).(*ast.SelectorExpr); ok {
+				unlocks[types.ExprString(sel.X)+"."+sel.Sel.Name] = true
+			}
+		}
+	})
+
+	var walk func(list []ast.Stmt, top bool)
+	walk = func(list []ast.Stmt, top bool) {
+		for i, s := range list {
+			if unlock, x, ok := lockCall(pass.TypesInfo, s); ok && !deferred[x+"."+unlock] {
+				lockPos := pass.Fset.Position(s.Pos())
+				if !pathsUnlock(pass, list[i+1:], x, unlock, lockPos.Line) && top && unlocks[x+"."+unlock] {
+					pass.Reportf(body.Rbrace, "function can end with %s locked (locked on line %d, no %s.%s() on this path)",
+						x, lockPos.Line, x, unlock)
+				}
+			}
+			for _, inner := range childLists(s) {
+				walk(inner, false)
+			}
+		}
+	}
+	walk(body.List, true)
+}
+
+// pathsUnlock scans the statements after a Lock, reporting returns reached
+// before x.<unlock>(). It reports whether the end of list is reached with
+// the lock released.
+func pathsUnlock(pass *analysis.Pass, list []ast.Stmt, x, unlock string, lockLine int) bool {
+	for _, s := range list {
+		if es, ok := s.(*ast.ExprStmt); ok {
+			if call, ok := es.X.(*ast.CallExpr); ok && isUnlock(call, x, unlock) {
+				return true
+			}
+		}
+		if r, ok := s.(*ast.ReturnStmt); ok {
+			pass.Reportf(r.Pos(), "return with %s still locked (locked on line %d)", x, lockLine)
+			return true // this path is done
+		}
+		for _, inner := range childLists(s) {
+			pathsUnlock(pass, inner, x, unlock, lockLine)
+		}
+	}
+	return false
+}
+
+// childLists returns the statement lists nested directly in s: the bodies
+// of if/else, for, range, switch and select cases, and plain blocks.
+func childLists(s ast.Stmt) [][]ast.Stmt {
+	switch s := s.(type) {
+	case *ast.BlockStmt:
+		return [][]ast.Stmt{s.List}
+	case *ast.IfStmt:
+		lists := [][]ast.Stmt{s.Body.List}
+		if s.Else != nil {
+			lists = append(lists, childLists(s.Else)...)
+		}
+		return lists
+	case *ast.ForStmt:
+		return [][]ast.Stmt{s.Body.List}
+	case *ast.RangeStmt:
+		return [][]ast.Stmt{s.Body.List}
+	case *ast.SwitchStmt:
+		return clauses(s.Body)
+	case *ast.TypeSwitchStmt:
+		return clauses(s.Body)
+	case *ast.SelectStmt:
+		return clauses(s.Body)
+	case *ast.LabeledStmt:
+		return childLists(s.Stmt)
+	}
+	return nil
+}
+
+func clauses(body *ast.BlockStmt) [][]ast.Stmt {
+	var lists [][]ast.Stmt
+	for _, c := range body.List {
+		switch c := c.(type) {
+		case *ast.CaseClause:
+			lists = append(lists, c.Body)
+		case *ast.CommClause:
+			lists = append(lists, c.Body)
+		}
+	}
+	return lists
+}
+
+// inspectOwn calls fn for every node under n that is not inside a nested
+// function literal.
+func inspectOwn(n ast.Node, fn func(ast.Node)) {
+	ast.Inspect(n, func(c ast.Node) bool {
+		if _, ok := c.(*ast.FuncLit); ok && c != n {
+			return false
+		}
+		if c != nil {
+			fn(c)
+		}
+		return true
+	})
+}
+
+// ============================================================================
+// sleepsync: time.Sleep STANDING IN FOR SYNCHRONIZATION
+// ============================================================================
+// `go work(); time.Sleep(100 * time.Millisecond)` hopes the goroutine is done
+// by then. On a loaded machine it isn't, and the program reads half-written
+// results or exits early. Flags a Sleep that follows a go statement in the
+// same block with no WaitGroup.Wait, channel receive or select in between.
+
+var SleepSync = &analysis.Analyzer{
+	Name: "sleepsync",
+	Doc:  "time.Sleep after a go statement used to wait for the goroutine",
+	Run:  runSleepSync,
+}
+
+func runSleepSync(pass *analysis.Pass) (any, error) {
+	for _, f := range pass.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			block, ok := n.(*ast.BlockStmt)
+			if !ok {
+				return true
+			}
+			var goLine int // line of the last go statement not yet waited for
+			for _, s := range block.List {
+				switch {
+				case isGoStmt(s):
+					goLine = pass.Fset.Position(s.Pos()).Line
+				case goLine > 0 && isSleep(pass.TypesInfo, s):
+					pass.Reportf(s.Pos(), "time.Sleep used to wait for the goroutine started on line %d; use a WaitGroup or a channel", goLine)
+					goLine = 0
+				case goLine > 0 && waits(pass.TypesInfo, s):
+					goLine = 0
+				}
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+func isGoStmt(s ast.Stmt) bool {
+	_, ok := s.(*ast.GoStmt)
+	return ok
+}
+
+func isSleep(info *types.Info, s ast.Stmt) bool {
+	es, ok := s.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := es.X.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == "time" && fn.Name() == "Sleep"
+}
+
+// waits reports whether s (outside nested function literals) blocks on
+// something that a goroutine can signal: a receive, a select, a range over a
+// channel or a sync wait.
+func waits(info *types.Info, s ast.Stmt) bool {
+	found := false
+	inspectOwn(s, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			found = found || n.Op == token.ARROW
+		case *ast.SelectStmt:
+			found = true
+		case *ast.RangeStmt:
+			if _, ok := info.TypeOf(n.X).Underlying().(*types.Chan); ok {
+				found = true
+			}
+		case *ast.CallExpr:
+			switch name, _ := syncMethod(info, n); name {
+			case "WaitGroup.Wait", "Cond.Wait", "Mutex.Lock", "RWMutex.Lock", "RWMutex.RLock":
+				found = true
+			}
+		}
+	})
+	return found
+}
@@ -0,0 +1,99 @@
+// Package concvet is a small static analyzer for the concurrency mistakes
+// this repo teaches:
+//
+//	copylock    a sync.Mutex, RWMutex, WaitGroup, Cond or Once copied by value
+//	wgadd       WaitGroup.Add called inside the goroutine it is counting
+//	unlock      a return (or the end of the function) reached with a mutex held
+//	sleepsync   time.Sleep right after a go statement, used to wait for it
+//
+// The checks are syntactic patterns over type-checked code, not proofs:
+// they miss bugs that are spread across functions and can flag code that is
+// correct for a reason they can't see (a lock handed to the caller, say).
+// Each check has a fixture package, testdata/src/<check>, whose lines carry
+// analysistest want comments; the tests hold every check to its fixture,
+// false positives included.
+//
+// Each check is a golang.org/x/tools/go/analysis Analyzer, so it can also
+// be plugged into singlechecker, multichecker or gopls. Check runs them
+// over packages of a module and returns what they report:
+//
+//	diags, err := concvet.Check(".", []string{"./..."}, concvet.Analyzers())
+//	for _, d := range diags {
+//		fmt.Println(d)
+//	}
+package concvet
+
+import (
+	"cmp"
+	"fmt"
+	"go/token"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Diagnostic is one finding.
+type Diagnostic struct {
+	Pos      token.Position
+	Analyzer string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: [%s] %s", d.Pos, d.Analyzer, d.Message)
+}
+
+// Analyzers returns every check in this package.
+func Analyzers() []*analysis.Analyzer {
+	return []*analysis.Analyzer{CopyLock, WGAdd, Unlock, SleepSync}
+}
+
+// Check loads the packages matching patterns (as the go command resolves
+// them from dir), type-checks them and runs the analyzers over each.
+// Dependencies are imported from the compiler's export data, so only the
+// packages under analysis are parsed.
+func Check(dir string, patterns []string, analyzers []*analysis.Analyzer) ([]Diagnostic, error) {
+	cfg := &packages.Config{Mode: packages.LoadSyntax, Dir: dir}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pkgs {
+		if len(p.Errors) > 0 {
+			return nil, fmt.Errorf("%s: %v", p.PkgPath, p.Errors[0])
+		}
+	}
+
+	graph, err := checker.Analyze(analyzers, pkgs, nil)
+	if err != nil {
+		return nil, err
+	}
+	var diags []Diagnostic
+	for act := range graph.All() {
+		if !act.IsRoot {
+			continue
+		}
+		if act.Err != nil {
+			return nil, fmt.Errorf("%s: %w", act, act.Err)
+		}
+		for _, d := range act.Diagnostics {
+			diags = append(diags, Diagnostic{
+				Pos:      act.Package.Fset.Position(d.Pos),
+				Analyzer: act.Analyzer.Name,
+				Message:  d.Message,
+			})
+		}
+	}
+
+	slices.SortFunc(diags, func(a, b Diagnostic) int {
+		return cmp.Or(
+			cmp.Compare(a.Pos.Filename, b.Pos.Filename),
+			cmp.Compare(a.Pos.Line, b.Pos.Line),
+			cmp.Compare(a.Pos.Column, b.Pos.Column),
+			cmp.Compare(a.Analyzer, b.Analyzer),
+		)
+	})
+	return diags, nil
+}
@@ -0,0 +1,18 @@
+package concvet
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// Each check runs over its fixture package in testdata/src: every
+// diagnostic must be announced by a want comment on its line, and every
+// want comment must be met.
+func TestFixtures(t *testing.T) {
+	for _, a := range Analyzers() {
+		t.Run(a.Name, func(t *testing.T) {
+			analysistest.Run(t, analysistest.TestData(), a, a.Name)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package concvet_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/concvet"
+	"golang.org/x/tools/go/analysis"
+)
+
+const buggy = `package buggy
+
+import "sync"
+
+type Counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c Counter) Get() int { // c, and its mutex, are copies
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+`
+
+func ExampleCheck() {
+	dir, err := os.MkdirTemp("", "concvet")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module buggy\n\ngo 1.21\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "buggy.go"), []byte(buggy), 0o644)
+
+	diags, err := concvet.Check(dir, []string{"."}, []*analysis.Analyzer{concvet.CopyLock})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, d := range diags {
+		fmt.Printf("%s:%d: [%s] %s\n", filepath.Base(d.Pos.Filename), d.Pos.Line, d.Analyzer, d.Message)
+	}
+	// Output:
+	// buggy.go:10: [copylock] receiver passes a lock by value: Counter contains sync.Mutex; use a pointer
+}
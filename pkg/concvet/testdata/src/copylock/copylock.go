@@ -0,0 +1,44 @@
+// Package copylock is a fixture for the copylock check: each line that
+// should be flagged carries a want comment with a pattern of the message.
+package copylock
+
+import "sync"
+
+type Counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c Counter) Get() int { // want `receiver passes a lock by value: Counter contains sync.Mutex`
+	return c.n
+}
+
+func (c *Counter) Inc() { // a pointer receiver: fine
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+}
+
+func wait(wg sync.WaitGroup) { // want `parameter passes a lock by value: sync.WaitGroup sync.WaitGroup`
+	wg.Wait()
+}
+
+func copies(c *Counter, cs []Counter) Counter {
+	snapshot := *c // want `assignment copies a lock: \*c contains sync.Mutex`
+	_ = snapshot.n
+	var again = cs[0] // want `variable declaration copies a lock: cs\[0\] contains sync.Mutex`
+	_ = again.n
+	pairs := []Counter{*c} // want `composite literal copies a lock: \*c contains sync.Mutex`
+	_ = pairs
+	for _, v := range cs { // want `range variable copies a lock: v contains sync.Mutex`
+		_ = v.n
+	}
+	for i := range cs { // by index: fine
+		cs[i].Inc()
+	}
+	_ = *c             // discarded: fine
+	fresh := Counter{} // a new value, not a copy: fine
+	mu := new(sync.Mutex)
+	_, _ = fresh, mu
+	return *c // want `return copies a lock: \*c contains sync.Mutex`
+}
@@ -0,0 +1,29 @@
+// Package sleepsync is a fixture for the sleepsync check.
+package sleepsync
+
+import (
+	"sync"
+	"time"
+)
+
+func work() {}
+
+func hopes() {
+	go work()
+	time.Sleep(100 * time.Millisecond) // want `time.Sleep used to wait for the goroutine started on line 12`
+}
+
+func waitsFirst() {
+	done := make(chan struct{})
+	go func() { work(); close(done) }()
+	<-done
+	time.Sleep(time.Millisecond) // after a receive: fine
+}
+
+func waitGroup() {
+	var wg sync.WaitGroup
+	wg.Go(work)
+	go work()
+	wg.Wait()
+	time.Sleep(time.Millisecond) // after Wait: fine
+}
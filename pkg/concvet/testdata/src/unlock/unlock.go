@@ -0,0 +1,64 @@
+// Package unlock is a fixture for the unlock check.
+package unlock
+
+import (
+	"errors"
+	"sync"
+)
+
+type store struct {
+	mu   sync.Mutex
+	rw   sync.RWMutex
+	data map[string]int
+}
+
+func (s *store) earlyReturn(k string) (int, error) {
+	s.mu.Lock()
+	v, ok := s.data[k]
+	if !ok {
+		return 0, errors.New("missing") // want `return with s.mu still locked \(locked on line 16\)`
+	}
+	s.mu.Unlock()
+	return v, nil
+}
+
+func (s *store) readReturn(k string) int {
+	s.rw.RLock()
+	if k == "" {
+		return 0 // want `return with s.rw still locked \(locked on line 26\)`
+	}
+	v := s.data[k]
+	s.rw.RUnlock()
+	return v
+}
+
+func (s *store) fallsOff(k string, v int) {
+	s.mu.Lock()
+	if v < 0 {
+		s.mu.Unlock()
+		return
+	}
+	s.data[k] = v
+} // want `function can end with s.mu locked \(locked on line 36, no s.mu.Unlock\(\) on this path\)`
+
+func (s *store) deferred(k string) int { // fine
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k == "" {
+		return 0
+	}
+	return s.data[k]
+}
+
+// lock hands the held lock to its caller. It never unlocks, which the
+// check takes to be deliberate.
+func (s *store) lock() {
+	s.mu.Lock()
+}
+
+// locked hands it over too, but with a return statement the check can't
+// tell from an early return: the known false positive.
+func (s *store) locked() *store {
+	s.mu.Lock()
+	return s // want `return with s.mu still locked \(locked on line 62\)`
+}
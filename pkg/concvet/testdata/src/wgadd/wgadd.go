@@ -0,0 +1,43 @@
+// Package wgadd is a fixture for the wgadd check.
+package wgadd
+
+import "sync"
+
+func racy(n int) {
+	var wg sync.WaitGroup
+	for range n {
+		go func() {
+			wg.Add(1) // want `wg.Add inside the goroutine it counts races with Wait`
+			defer wg.Done()
+		}()
+	}
+	wg.Wait()
+}
+
+func correct(n int) {
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1) // before the go statement: fine
+		go func() {
+			defer wg.Done()
+		}()
+	}
+	wg.Wait()
+}
+
+// spawner's goroutine counts the goroutines IT starts: fine, since the
+// Adds come before those go statements.
+func spawner(n int) {
+	var outer sync.WaitGroup
+	outer.Add(1)
+	go func() {
+		defer outer.Done()
+		var inner sync.WaitGroup
+		for range n {
+			inner.Add(1)
+			go func() { inner.Done() }()
+		}
+		inner.Wait()
+	}()
+	outer.Wait()
+}
@@ -0,0 +1,96 @@
+// Package counter provides a striped counter for hot, write-heavy counts,
+// modelled on Java's LongAdder.
+//
+// A single atomic.Int64 is correct, but under heavy contention every Add
+// from every core fights over ONE cache line: each core must take exclusive
+// ownership of the line, so the increments serialize in the cache-coherence
+// protocol even though no lock is involved.
+//
+// Adder spreads the writes over several cells, each on its own cache line,
+// and only combines them when somebody reads:
+//
+//	Add:   pick a cell, atomically add to it      (cheap, rarely shared)
+//	Load:  sum all the cells                      (O(cells), not a snapshot)
+//
+// The cell is picked "per P": Go doesn't expose which P (logical processor)
+// a goroutine runs on, but sync.Pool keeps a private cache per P, so a
+// pooled cell index tends to stay with the P that first got it. That is a
+// hint, not a guarantee - two Ps can end up sharing a cell, which is still
+// correct, just slower.
+//
+// Use it for counts that are written far more often than read (request
+// counters, metrics). If you need compare-and-swap, a consistent snapshot or
+// a cheap read, stick with atomic.Int64.
+package counter
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// cacheLineSize is a conservative cache line size (see package mcslock).
+const cacheLineSize = 128
+
+// cell is one stripe of the counter, padded to a full cache line so two
+// cells never share one (false sharing would bring the contention back).
+type cell struct {
+	n atomic.Int64
+	_ [cacheLineSize - 8]byte
+}
+
+// Adder is a striped int64 counter. The zero value is ready to use and
+// counts from zero. An Adder must not be copied after first use.
+type Adder struct {
+	once  sync.Once
+	cells []cell
+	mask  uint32
+	next  atomic.Uint32 // round-robin source of cell indexes for new Ps
+	slot  sync.Pool     // per-P cache of *uint32 cell indexes
+}
+
+func (a *Adder) init() {
+	// At least one cell per P, rounded up to a power of two so picking a
+	// cell is a mask instead of a modulo.
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n <<= 1
+	}
+	a.cells = make([]cell, n)
+	a.mask = uint32(n - 1)
+	a.slot.New = func() any {
+		i := a.next.Add(1) - 1
+		return &i
+	}
+}
+
+// Add adds delta to the counter.
+func (a *Adder) Add(delta int64) {
+	a.once.Do(a.init)
+	i := a.slot.Get().(*uint32)
+	a.cells[*i&a.mask].n.Add(delta)
+	a.slot.Put(i)
+}
+
+// Inc adds one to the counter.
+func (a *Adder) Inc() { a.Add(1) }
+
+// Load returns the sum of all cells. Adds that run concurrently with Load
+// may or may not be included; once they have all returned, Load is exact.
+func (a *Adder) Load() int64 {
+	a.once.Do(a.init)
+	var sum int64
+	for i := range a.cells {
+		sum += a.cells[i].n.Load()
+	}
+	return sum
+}
+
+// Reset sets every cell back to zero. Like Load it is not atomic with
+// respect to concurrent Adds; call it when the counter is quiet.
+func (a *Adder) Reset() {
+	a.once.Do(a.init)
+	for i := range a.cells {
+		a.cells[i].n.Store(0)
+	}
+}
@@ -0,0 +1,65 @@
+package counter
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// The benchmarks increment one shared counter from every P at once, the
+// case Adder is for. Its advantage grows with the core count:
+//
+//	go test -bench . -cpu 1,4,16,64 ./pkg/counter
+
+func BenchmarkAdder(b *testing.B) {
+	var a Adder
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			a.Inc()
+		}
+	})
+	if n := a.Load(); n != int64(b.N) {
+		b.Fatalf("Load = %d after %d Incs", n, b.N)
+	}
+}
+
+func BenchmarkAtomic(b *testing.B) {
+	var n atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n.Add(1)
+		}
+	})
+}
+
+func BenchmarkMutex(b *testing.B) {
+	var (
+		mu sync.Mutex
+		n  int64
+	)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mu.Lock()
+			n++
+			mu.Unlock()
+		}
+	})
+}
+
+// BenchmarkAdderLoad is the price Adder pays instead: a read sums every
+// cell, where atomic.Int64 reads one word.
+func BenchmarkAdderLoad(b *testing.B) {
+	var a Adder
+	a.Inc()
+	for b.Loop() {
+		a.Load()
+	}
+}
+
+func BenchmarkAtomicLoad(b *testing.B) {
+	var n atomic.Int64
+	n.Add(1)
+	for b.Loop() {
+		n.Load()
+	}
+}
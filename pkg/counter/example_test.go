@@ -0,0 +1,23 @@
+package counter_test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/counter"
+)
+
+func ExampleAdder() {
+	var requests counter.Adder
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 1000 {
+				requests.Inc() // hot path: no shared cache line
+			}
+		})
+	}
+	wg.Wait()
+	fmt.Println(requests.Load()) // exact once the Adds have returned
+	// Output: 8000
+}
@@ -0,0 +1,206 @@
+// Package csp writes Go programs in the notation of Hoare's 1978 paper
+// "Communicating Sequential Processes", compiled onto goroutines, channels
+// and select.
+//
+//	Hoare                           csp
+//	[P1 || P2 || P3]                csp.Par(p1, p2, p3)
+//	west!c                          west.Out(c)
+//	west?c                          c, ok := west.In()
+//	[g1 → S1 □ g2 → S2]             csp.Alt(g1, g2)
+//	*[g1 → S1 □ g2 → S2]            csp.Loop(func() []csp.Guard { return []csp.Guard{g1, g2} })
+//
+// A guard is an optional boolean condition plus an optional communication,
+// followed by the command it guards:
+//
+//	n < 10; west?c → S              csp.Input(west, func(c rune) { S }).If(n < 10)
+//	n > 0; east!v → S               csp.Output(east, v, func() { S }).If(n > 0)
+//	n = 0 → S                       csp.When(n == 0, func() { S })
+//
+// Alt waits until one of its guards can proceed and runs that one; if
+// several can, it picks one at random, as Hoare's alternative command does.
+// A guard FAILS if its condition is false or if it inputs from a process
+// that has terminated - a closed channel here. When every guard fails, Alt
+// returns false, and a Loop (Hoare's repetitive command) ends. That is
+// Hoare's "distributed termination": a COPY process stops by itself once
+// its source stops.
+//
+// Output to a terminated process also fails in the paper, but Go gives the
+// sender no safe way to see that the receiver is gone. Here only the sending
+// side closes a channel, so output guards only fail through their condition.
+package csp
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Chan is a named channel between two processes. The name is only used for
+// tracing.
+type Chan[T any] struct {
+	name string
+	c    chan T
+}
+
+// NewChan returns an unbuffered channel: every communication is a
+// rendezvous, as in the paper.
+func NewChan[T any](name string) *Chan[T] {
+	return &Chan[T]{name: name, c: make(chan T)}
+}
+
+// Out is the output command c!v: it waits until the process at the other
+// end inputs v.
+func (c *Chan[T]) Out(v T) {
+	c.c <- v
+}
+
+// In is the input command c?x. ok is false once the sending process has
+// terminated (closed the channel).
+func (c *Chan[T]) In() (v T, ok bool) {
+	v, ok = <-c.c
+	if ok {
+		trace(c.name, v)
+	}
+	return v, ok
+}
+
+// Close marks the sending process as terminated: inputs from c fail from
+// now on.
+func (c *Chan[T]) Close() { close(c.c) }
+
+// Par runs the processes in parallel and returns when all have terminated.
+func Par(procs ...func()) {
+	var wg sync.WaitGroup
+	for _, p := range procs {
+		wg.Go(p)
+	}
+	wg.Wait()
+}
+
+// Guard is one alternative of Alt or Loop: build it with Input, Output or
+// When, and add a condition with If.
+type Guard struct {
+	cond    bool
+	name    string        // channel name, for tracing
+	op      string        // "?" or "!", or "" for a pure boolean guard
+	ch      reflect.Value // the channel
+	send    reflect.Value // value to send, for "!"
+	onInput func(reflect.Value)
+	body    func()
+}
+
+// Input is the guard c?x → body(x).
+func Input[T any](c *Chan[T], body func(T)) Guard {
+	return Guard{cond: true, name: c.name, op: "?", ch: reflect.ValueOf(c.c),
+		onInput: func(v reflect.Value) { body(v.Interface().(T)) }}
+}
+
+// Output is the guard c!v → body. body may be nil.
+func Output[T any](c *Chan[T], v T, body func()) Guard {
+	return Guard{cond: true, name: c.name, op: "!", ch: reflect.ValueOf(c.c), send: reflect.ValueOf(&v).Elem(), body: body}
+}
+
+// When is the pure boolean guard cond → body.
+func When(cond bool, body func()) Guard {
+	return Guard{cond: cond, body: body}
+}
+
+// If adds a boolean condition to g: the guard cond; g.
+func (g Guard) If(cond bool) Guard {
+	g.cond = g.cond && cond
+	return g
+}
+
+// Alt is the alternative command: it runs exactly one guard that can
+// proceed, waiting for a communication if none can yet. It returns false
+// without running anything if every guard fails.
+func Alt(guards ...Guard) bool {
+	var cases []reflect.SelectCase
+	var live []Guard
+	var ready []Guard // pure boolean guards whose condition holds
+	for _, g := range guards {
+		switch {
+		case !g.cond:
+		case g.op == "":
+			ready = append(ready, g)
+		case g.op == "?":
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: g.ch})
+			live = append(live, g)
+		default:
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: g.ch, Send: g.send})
+			live = append(live, g)
+		}
+	}
+	if len(ready) > 0 {
+		// A true boolean guard can always proceed. Offer it as the default,
+		// so that a communication that is ready now still gets a chance.
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	}
+
+	for len(cases) > 0 {
+		i, v, ok := reflect.Select(cases)
+		if cases[i].Dir == reflect.SelectDefault {
+			run(ready[0].body)
+			return true
+		}
+		g := live[i]
+		if g.op == "?" && !ok {
+			// Input from a terminated process: this guard fails. Try the rest.
+			cases = append(cases[:i], cases[i+1:]...)
+			live = append(live[:i], live[i+1:]...)
+			continue
+		}
+		if g.op == "?" {
+			trace(g.name, v.Interface())
+			g.onInput(v)
+		}
+		run(g.body)
+		return true
+	}
+	return false
+}
+
+// Loop is the repetitive command *[...]: it runs Alt over the guards that
+// guards returns - called again before every round, because conditions and
+// values to send change - until every guard fails. It returns the number of
+// rounds run.
+func Loop(guards func() []Guard) int {
+	n := 0
+	for Alt(guards()...) {
+		n++
+	}
+	return n
+}
+
+func run(body func()) {
+	if body != nil {
+		body()
+	}
+}
+
+var (
+	traceMu sync.Mutex
+	traceW  io.Writer
+)
+
+// SetTrace makes every completed communication print a line such as
+// "west?'C'" to w, from the receiving side. nil turns tracing off.
+func SetTrace(w io.Writer) {
+	traceMu.Lock()
+	traceW = w
+	traceMu.Unlock()
+}
+
+func trace(name string, v any) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	if traceW == nil {
+		return
+	}
+	if r, ok := v.(rune); ok {
+		fmt.Fprintf(traceW, "  %s?%q\n", name, r)
+	} else {
+		fmt.Fprintf(traceW, "  %s?%v\n", name, v)
+	}
+}
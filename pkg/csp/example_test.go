@@ -0,0 +1,47 @@
+package csp_test
+
+import (
+	"fmt"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/csp"
+)
+
+// Hoare's COPY process, [west?c → east!c]*, between a producer and a
+// consumer: the copy stops by itself once the producer terminates.
+func Example() {
+	west, east := csp.NewChan[rune]("west"), csp.NewChan[rune]("east")
+	var got []rune
+	csp.Par(
+		func() { // producer
+			for _, c := range "CSP" {
+				west.Out(c)
+			}
+			west.Close()
+		},
+		func() { // COPY
+			csp.Loop(func() []csp.Guard {
+				return []csp.Guard{csp.Input(west, func(c rune) { east.Out(c) })}
+			})
+			east.Close()
+		},
+		func() { // consumer
+			for c, ok := east.In(); ok; c, ok = east.In() {
+				got = append(got, c)
+			}
+		},
+	)
+	fmt.Println(string(got))
+	// Output: CSP
+}
+
+func ExampleAlt() {
+	ready := csp.NewChan[int]("ready")
+	ready.Close() // a terminated process: its input guard fails
+	n := 0
+	ran := csp.Alt(
+		csp.Input(ready, func(int) { fmt.Println("input") }),
+		csp.When(n > 0, func() { fmt.Println("positive") }),
+	)
+	fmt.Println(ran)
+	// Output: false
+}
@@ -0,0 +1,129 @@
+// Package ctxtree records the parent/child relationships between derived
+// contexts so the shape of a cancellation tree can be printed while
+// debugging.
+//
+// The standard library deliberately hides a context's children: a
+// context.Context only knows its parent. When you are trying to work out
+// "which goroutines stop if I cancel THIS context?", that makes the tree
+// invisible. ctxtree wraps context.WithCancel / WithTimeout and remembers
+// every child it hands out, so Dump(ctx) can draw the subtree with the
+// current state of each node.
+//
+//	root, cancel := ctxtree.WithCancel(context.Background(), "root")
+//	svc, _ := ctxtree.WithCancel(root, "service-A")
+//	fmt.Print(ctxtree.Dump(root))
+//
+//	root [running]
+//	└── service-A [running]
+//
+// Nodes are never removed from the tree, so ctxtree is meant for demos and
+// debugging sessions, not for long-lived production contexts.
+package ctxtree
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// node is one registered context in the tree.
+type node struct {
+	name string
+	ctx  context.Context
+
+	mu       sync.Mutex // guards children
+	children []*node
+}
+
+// nodeKey is the context key under which a context's own node is stored.
+type nodeKey struct{}
+
+// WithCancel behaves like context.WithCancel but registers the returned
+// context under name as a child of the nearest registered ancestor of parent.
+func WithCancel(parent context.Context, name string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	return register(parent, ctx, name), cancel
+}
+
+// WithCancelCause behaves like context.WithCancelCause. The cause passed to
+// the returned function is shown by Dump next to the cancelled node.
+func WithCancelCause(parent context.Context, name string) (context.Context, context.CancelCauseFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	return register(parent, ctx, name), cancel
+}
+
+// WithTimeout behaves like context.WithTimeout and registers the result
+// under name.
+func WithTimeout(parent context.Context, name string, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(parent, d)
+	return register(parent, ctx, name), cancel
+}
+
+// register attaches ctx to the tree and returns a context that carries its
+// own node, so contexts derived from it can find their parent.
+func register(parent, ctx context.Context, name string) context.Context {
+	n := &node{name: name}
+	if p, ok := parent.Value(nodeKey{}).(*node); ok {
+		p.mu.Lock()
+		p.children = append(p.children, n)
+		p.mu.Unlock()
+	}
+	// Storing the node as a value does not change cancellation behaviour:
+	// WithValue contexts share their parent's Done channel.
+	n.ctx = context.WithValue(ctx, nodeKey{}, n)
+	return n.ctx
+}
+
+// Name returns the name ctx was registered under, or "" if ctx (and none of
+// its ancestors) was created by this package.
+func Name(ctx context.Context) string {
+	if n, ok := ctx.Value(nodeKey{}).(*node); ok {
+		return n.name
+	}
+	return ""
+}
+
+// Dump renders the registered subtree rooted at ctx (or at its nearest
+// registered ancestor) with the state of every node:
+//
+//	root [running]
+//	├── service-A [cancelled: context canceled]
+//	│   └── worker-A1 [cancelled: context canceled]
+//	└── service-B [running]
+//
+// Dump returns "" if ctx was not derived from a ctxtree context.
+func Dump(ctx context.Context) string {
+	n, ok := ctx.Value(nodeKey{}).(*node)
+	if !ok {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s\n", n.name, state(n.ctx))
+	dumpChildren(&sb, n, "")
+	return sb.String()
+}
+
+func dumpChildren(sb *strings.Builder, n *node, prefix string) {
+	n.mu.Lock()
+	children := append([]*node(nil), n.children...) // snapshot so we don't hold the lock while recursing
+	n.mu.Unlock()
+
+	for i, child := range children {
+		branch, indent := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintf(sb, "%s%s%s %s\n", prefix, branch, child.name, state(child.ctx))
+		dumpChildren(sb, child, prefix+indent)
+	}
+}
+
+// state describes whether ctx is still live and, if not, why it stopped.
+func state(ctx context.Context) string {
+	if ctx.Err() == nil {
+		return "[running]"
+	}
+	return fmt.Sprintf("[cancelled: %v]", context.Cause(ctx))
+}
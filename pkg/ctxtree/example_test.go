@@ -0,0 +1,25 @@
+package ctxtree_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/ctxtree"
+)
+
+func ExampleDump() {
+	root, cancelRoot := ctxtree.WithCancel(context.Background(), "root")
+	defer cancelRoot()
+	svcA, cancelA := ctxtree.WithCancelCause(root, "service-A")
+	ctxtree.WithCancel(svcA, "worker-A1")
+	ctxtree.WithCancel(root, "service-B")
+
+	cancelA(errors.New("config reload"))
+	fmt.Print(ctxtree.Dump(root))
+	// Output:
+	// root [running]
+	// ├── service-A [cancelled: config reload]
+	// │   └── worker-A1 [cancelled: config reload]
+	// └── service-B [running]
+}
@@ -0,0 +1,157 @@
+// Package event provides event-signalling primitives in the style of the
+// Win32 / .NET ManualResetEvent and AutoResetEvent.
+//
+// An event is a boolean flag that goroutines can wait on:
+//
+//   - ManualReset: once Set, EVERY waiter - current and future - passes
+//     until someone calls Reset. A re-closable "system is ready" latch.
+//   - AutoReset: each Set lets exactly ONE waiter through and the event
+//     resets itself. A turnstile: like Cond.Signal, but a Set with nobody
+//     waiting is remembered instead of lost.
+//
+// sync.Cond can express the same thing, but every caller has to write the
+// lock / for-loop / Wait dance and cannot give up on a context. Events are
+// that dance packaged up, with waits that honour ctx:
+//
+//	var ready event.ManualReset
+//	go func() { loadConfig(); ready.Set() }()
+//	if err := ready.Wait(ctx); err != nil {
+//		return err // gave up before the config was loaded
+//	}
+package event
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// ManualReset is an event that stays signalled once Set until Reset is
+// called. The zero value is an unset event. A ManualReset must not be copied
+// after first use.
+type ManualReset struct {
+	mu  sync.Mutex
+	ch  chan struct{} // closed while the event is set
+	set bool
+}
+
+// channel returns the channel for the current "generation" of the event.
+// Called with mu held.
+func (e *ManualReset) channel() chan struct{} {
+	if e.ch == nil {
+		e.ch = make(chan struct{})
+	}
+	return e.ch
+}
+
+// Set signals the event, releasing every goroutine blocked in Wait. Waits
+// started while the event is set return immediately. Setting an event that
+// is already set does nothing.
+func (e *ManualReset) Set() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.set {
+		return
+	}
+	e.set = true
+	close(e.channel())
+}
+
+// Reset returns the event to the unset state, so later calls to Wait block
+// again. Goroutines already released by Set are not affected.
+func (e *ManualReset) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.set {
+		return
+	}
+	e.set = false
+	e.ch = make(chan struct{}) // the closed channel can't be reopened: start a new one
+}
+
+// IsSet reports whether the event is currently set.
+func (e *ManualReset) IsSet() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.set
+}
+
+// Done returns a channel that is closed when the event is set. The channel
+// belongs to the current generation: after a Reset, call Done again.
+func (e *ManualReset) Done() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.channel()
+}
+
+// Wait blocks until the event is set or ctx is done, in which case it
+// returns ctx.Err().
+func (e *ManualReset) Wait(ctx context.Context) error {
+	select {
+	case <-e.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// AutoReset is an event that releases exactly one waiter per Set and then
+// resets itself. Waiters are released in FIFO order. If Set is called while
+// nobody is waiting, the event stays set and the next Wait consumes it; any
+// further Sets before that are absorbed (the event is a flag, not a counter).
+//
+// The zero value is an unset event. An AutoReset must not be copied after
+// first use.
+type AutoReset struct {
+	mu      sync.Mutex
+	set     bool
+	waiters []chan struct{} // oldest first
+}
+
+// Set releases the longest-waiting goroutine, or leaves the event set for
+// the next Wait if nobody is waiting.
+func (e *AutoReset) Set() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.waiters) == 0 {
+		e.set = true
+		return
+	}
+	close(e.waiters[0])
+	e.waiters[0] = nil
+	e.waiters = e.waiters[1:]
+}
+
+// Wait blocks until a Set releases this goroutine, or until ctx is done, in
+// which case it returns ctx.Err(). A Wait on a set event consumes the Set
+// and returns immediately.
+func (e *AutoReset) Wait(ctx context.Context) error {
+	e.mu.Lock()
+	if e.set {
+		e.set = false
+		e.mu.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	e.waiters = append(e.waiters, ch)
+	e.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	e.mu.Lock()
+	i := slices.Index(e.waiters, ch)
+	if i >= 0 {
+		e.waiters = slices.Delete(e.waiters, i, i+1)
+		e.mu.Unlock()
+		return ctx.Err()
+	}
+	e.mu.Unlock()
+	// A Set picked us just as ctx expired. Giving up is fine, swallowing
+	// the Set is not: hand it to the next waiter.
+	e.Set()
+	return ctx.Err()
+}
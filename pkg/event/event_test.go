@@ -0,0 +1,89 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func (e *AutoReset) queued() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.waiters)
+}
+
+func TestAutoResetRemembersOneSet(t *testing.T) {
+	var e AutoReset
+	e.Set()
+	e.Set() // absorbed: the event is a flag, not a counter
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := e.Wait(ctx); err != nil {
+		t.Fatalf("Wait on a set event = %v, want nil", err)
+	}
+	if err := e.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Wait = %v, want DeadlineExceeded: the event must have reset", err)
+	}
+	if n := e.queued(); n != 0 {
+		t.Errorf("%d waiters left registered after a Wait timed out", n)
+	}
+}
+
+// Many goroutines queue one at a time; each Set must release exactly one
+// of them, the one that has waited longest.
+func TestAutoResetFIFOUnderManyWaiters(t *testing.T) {
+	const waiters = 100
+	var e AutoReset
+	released := make(chan int, waiters)
+	for i := range waiters {
+		go func() {
+			if err := e.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+			released <- i
+		}()
+		for e.queued() != i+1 {
+			runtime.Gosched()
+		}
+	}
+	for want := range waiters {
+		e.Set()
+		if n := e.queued(); n != waiters-want-1 {
+			t.Fatalf("after Set %d: %d still waiting, want %d - Set must release exactly one", want+1, n, waiters-want-1)
+		}
+		if got := <-released; got != want {
+			t.Fatalf("Set %d released waiter %d, want %d (arrival order)", want+1, got, want)
+		}
+	}
+	if e.set {
+		t.Error("the event is set after every Set released a waiter")
+	}
+}
+
+// A waiter that gives up leaves the queue, so the next Set goes to the one
+// behind it.
+func TestAutoResetCancelledWaiterSkipped(t *testing.T) {
+	var e AutoReset
+	ctx, cancel := context.WithCancel(context.Background())
+	gaveUp := make(chan error)
+	go func() { gaveUp <- e.Wait(ctx) }()
+	for e.queued() != 1 {
+		runtime.Gosched()
+	}
+	released := make(chan struct{})
+	go func() {
+		e.Wait(context.Background())
+		close(released)
+	}()
+	for e.queued() != 2 {
+		runtime.Gosched()
+	}
+	cancel()
+	if err := <-gaveUp; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Wait = %v, want context.Canceled", err)
+	}
+	e.Set()
+	<-released
+}
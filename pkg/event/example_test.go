@@ -0,0 +1,42 @@
+package event_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/event"
+)
+
+func ExampleManualReset() {
+	var ready event.ManualReset
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			ready.Wait(context.Background()) // every waiter passes once set
+		})
+	}
+	ready.Set()
+	wg.Wait()
+	fmt.Println("all 3 released, still set:", ready.IsSet())
+
+	ready.Reset()
+	fmt.Println("after Reset:", ready.IsSet())
+	// Output:
+	// all 3 released, still set: true
+	// after Reset: false
+}
+
+func ExampleAutoReset() {
+	var turnstile event.AutoReset
+	turnstile.Set() // nobody waiting yet: remembered for the next Wait
+	turnstile.Set() // absorbed - a flag, not a counter
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fmt.Println(turnstile.Wait(context.Background()))
+	fmt.Println(turnstile.Wait(ctx)) // the one Set is used up
+	// Output:
+	// <nil>
+	// context canceled
+}
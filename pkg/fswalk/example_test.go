@@ -0,0 +1,48 @@
+package fswalk_test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/fswalk"
+)
+
+func ExampleWalk() {
+	root, err := os.MkdirTemp("", "fswalk")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(root)
+	for path, content := range map[string]string{
+		"a.txt":         "alpha",
+		"sub/b.txt":     "be",
+		"sub/deep/c.md": "c",
+		"z.txt":         "zulu!",
+	} {
+		path = filepath.Join(root, path)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+
+	sizes, err := fswalk.Walk(context.Background(), root, 4, func(ctx context.Context, path string, d fs.DirEntry) (int64, error) {
+		info, err := d.Info()
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	})
+	for _, f := range sizes { // in WalkDir's order, whoever finished first
+		rel, _ := filepath.Rel(root, f.Path)
+		fmt.Println(filepath.ToSlash(rel), f.Value)
+	}
+	fmt.Println(err)
+	// Output:
+	// a.txt 5
+	// sub/b.txt 2
+	// sub/deep/c.md 1
+	// z.txt 5
+	// <nil>
+}
@@ -0,0 +1,114 @@
+// Package fswalk walks a directory tree in parallel: directories are listed
+// concurrently and files are visited by the same bounded set of workers,
+// using conc.Walk.
+//
+// filepath.WalkDir does one thing at a time - list a directory, call fn on
+// an entry, list the next directory. When fn does I/O (hash a file, parse
+// it) or the tree sits on a network file system, almost all of that time
+// is waiting, and the waits can overlap. The results come back in whatever
+// order the workers finish, so Walk sorts them into WalkDir's order before
+// returning: the output of a parallel walk is the same on every run and the
+// same as a sequential walk's.
+//
+// In use:
+//
+//	sizes, err := fswalk.Walk(ctx, root, 8, func(ctx context.Context, path string, d fs.DirEntry) (int64, error) {
+//		info, err := d.Info()
+//		if err != nil {
+//			return 0, err
+//		}
+//		return info.Size(), nil
+//	})
+//	for _, f := range sizes { fmt.Println(f.Path, f.Value) }
+package fswalk
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/conc"
+)
+
+// File is fn's result for one file.
+type File[R any] struct {
+	Path  string
+	Value R
+}
+
+// node is a directory to list or a file to visit.
+type node struct {
+	path string
+	d    fs.DirEntry
+}
+
+// Walk calls fn for every entry under root that isn't a directory, with at
+// most parallelism directory listings and fn calls running at once. It
+// returns fn's results in the order filepath.WalkDir would have visited the
+// files, and every error (an unreadable directory, a failed fn) joined
+// together; the rest of the tree is still walked. Cancelling ctx stops the
+// walk early and adds ctx's error.
+//
+// Symbolic links are not followed, as with WalkDir.
+func Walk[R any](ctx context.Context, root string, parallelism int, fn func(ctx context.Context, path string, d fs.DirEntry) (R, error)) ([]File[R], error) {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return nil, err
+	}
+	visit := func(ctx context.Context, n node) (*File[R], []node, error) {
+		if !n.d.IsDir() {
+			v, err := fn(ctx, n.path, n.d)
+			if err != nil {
+				return nil, nil, err
+			}
+			return &File[R]{n.path, v}, nil, nil
+		}
+		entries, err := os.ReadDir(n.path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fswalk: %w", err)
+		}
+		children := make([]node, len(entries))
+		for i, e := range entries {
+			children[i] = node{filepath.Join(n.path, e.Name()), e}
+		}
+		return nil, children, nil
+	}
+
+	found, err := conc.Walk(ctx, []node{{root, fs.FileInfoToDirEntry(info)}}, parallelism, visit)
+	files := make([]File[R], 0, len(found))
+	for _, f := range found {
+		if f != nil { // directories have no result
+			files = append(files, *f)
+		}
+	}
+	slices.SortFunc(files, func(a, b File[R]) int { return comparePaths(a.Path, b.Path) })
+	return files, err
+}
+
+// comparePaths orders paths the way WalkDir visits them: element by
+// element, each directory's entries by name. Comparing whole strings is not
+// the same: "a-b" sorts before "a/c" because '-' < '/', but WalkDir visits
+// everything in directory "a" before the file "a-b".
+func comparePaths(a, b string) int {
+	sep := string(filepath.Separator)
+	for {
+		ai, arest, amore := strings.Cut(a, sep)
+		bi, brest, bmore := strings.Cut(b, sep)
+		if c := strings.Compare(ai, bi); c != 0 || !amore || !bmore {
+			if c == 0 { // one is a prefix of the other: the shorter first
+				switch {
+				case !amore && bmore:
+					return -1
+				case amore && !bmore:
+					return 1
+				}
+			}
+			return c
+		}
+		a, b = arest, brest
+	}
+}
@@ -0,0 +1,26 @@
+package guard_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/guard"
+)
+
+func ExampleMutex_LockCtx() {
+	var mu guard.Mutex
+	mu.Lock() // held elsewhere, and not coming back soon
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := mu.LockCtx(ctx); err != nil {
+		fmt.Println("gave up:", err) // release what you hold, back off, retry
+	}
+
+	mu.Unlock()
+	fmt.Println(mu.TryLock())
+	// Output:
+	// gave up: context deadline exceeded
+	// true
+}
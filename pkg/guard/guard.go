@@ -0,0 +1,73 @@
+// Package guard provides a mutex whose Lock can give up.
+//
+// sync.Mutex.Lock waits forever, which is what turns a lock-ordering
+// mistake into a deadlock. guard.Mutex adds LockCtx, which returns an error
+// once its context is done, so a goroutine that cannot get its second lock
+// can notice, release the first, back off and try again - see ch01's
+// deadlock recovery demo:
+//
+//	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+//	defer cancel()
+//	if err := mu.LockCtx(ctx); err != nil {
+//		return err // possibly deadlocked: release what you hold and retry
+//	}
+//	defer mu.Unlock()
+//
+// The lock is a channel with room for one token: locking sends the token,
+// unlocking takes it back, and a select puts the send in a race with
+// ctx.Done(). That is slower than sync.Mutex and not fair, but every wait
+// can be cancelled.
+package guard
+
+import (
+	"context"
+	"sync"
+)
+
+// Mutex is a mutual exclusion lock with a cancellable Lock. The zero value
+// is an unlocked mutex.
+//
+// A Mutex must not be copied after first use.
+type Mutex struct {
+	once  sync.Once
+	token chan struct{}
+}
+
+func (m *Mutex) ch() chan struct{} {
+	m.once.Do(func() { m.token = make(chan struct{}, 1) })
+	return m.token
+}
+
+// Lock locks m, waiting as long as it takes.
+func (m *Mutex) Lock() { m.ch() <- struct{}{} }
+
+// LockCtx locks m, or returns ctx.Err() if ctx is done first. On error m is
+// not locked by the caller.
+func (m *Mutex) LockCtx(ctx context.Context) error {
+	select {
+	case m.ch() <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryLock locks m if it is free and reports whether it did.
+func (m *Mutex) TryLock() bool {
+	select {
+	case m.ch() <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Unlock unlocks m. Unlocking an unlocked Mutex panics. As with sync.Mutex,
+// any goroutine may unlock a locked Mutex.
+func (m *Mutex) Unlock() {
+	select {
+	case <-m.ch():
+	default:
+		panic("guard: unlock of unlocked Mutex")
+	}
+}
@@ -0,0 +1,40 @@
+package inject_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/inject"
+)
+
+func ExampleFunc() {
+	lookup := func(_ context.Context, name string) (string, error) {
+		return strings.ToUpper(name), nil
+	}
+	in := inject.New(inject.Config{ErrorRate: 1}) // a backend that is down
+	flaky := inject.Func(in, lookup)
+
+	_, err := flaky(context.Background(), "gopher")
+	fmt.Println(errors.Is(err, inject.ErrInjected), in.Stats().Failed)
+	// Output: true 1
+}
+
+// A fixed Seed makes the same calls misbehave on every run.
+func ExampleConfig_seed() {
+	outcomes := func() string {
+		in := inject.New(inject.Config{ErrorRate: 0.5, Seed: 42})
+		var b strings.Builder
+		for range 20 {
+			if in.Do(context.Background()) != nil {
+				b.WriteByte('x')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		return b.String()
+	}
+	fmt.Println(outcomes() == outcomes())
+	// Output: true
+}
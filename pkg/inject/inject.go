@@ -0,0 +1,159 @@
+// Package inject adds configurable latency, jitter and failures to
+// simulated backends, so retries, hedged requests and timeouts can be
+// exercised against something that misbehaves the way real services do.
+//
+// A backend that always answers in 5ms makes every resilience pattern look
+// pointless. Real ones have a latency distribution with a long tail and
+// fail now and then:
+//
+//	in := inject.New(inject.Config{
+//		Latency:   5 * time.Millisecond,   // every call
+//		Jitter:    5 * time.Millisecond,   // plus [0, 5ms)
+//		SlowRate:  0.05,                   // 1 call in 20...
+//		Slow:      100 * time.Millisecond, // ...takes this much longer
+//		ErrorRate: 0.2,                    // 1 call in 5 fails
+//	})
+//	lookup := inject.Func(in, realLookup)
+//
+// Every injected delay honours the caller's context, so a timeout or a
+// cancelled hedge stops waiting immediately - exactly what a well-behaved
+// client library would do. An Injector is safe for concurrent use.
+package inject
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+// ErrInjected is the error returned by an injected failure when Config.Err
+// is nil.
+var ErrInjected = errors.New("inject: injected failure")
+
+// Config describes how a backend misbehaves. The zero value injects
+// nothing.
+type Config struct {
+	Latency   time.Duration // added to every call
+	Jitter    time.Duration // plus a uniform random delay in [0, Jitter)
+	SlowRate  float64       // probability that a call is slow...
+	Slow      time.Duration // ...and takes this much longer
+	ErrorRate float64       // probability that a call fails (after its delay)
+	Err       error         // the failure; ErrInjected if nil
+	Seed      uint64        // fixed seed for repeatable runs; 0 picks one at random
+}
+
+// Stats counts what an Injector has done so far.
+type Stats struct {
+	Calls    int64 // calls that reached the injector
+	Slow     int64 // calls that drew the slow tail
+	Failed   int64 // calls failed on purpose
+	Canceled int64 // calls whose context ended during the injected delay
+}
+
+// Injector draws delays and failures from a Config.
+type Injector struct {
+	cfg Config
+
+	mu  sync.Mutex // guards rng: *rand.Rand is not safe for concurrent use
+	rng *rand.Rand
+
+	calls, slow, failed, canceled atomic.Int64
+}
+
+// New returns an Injector for cfg.
+func New(cfg Config) *Injector {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	if cfg.Err == nil {
+		cfg.Err = ErrInjected
+	}
+	return &Injector{cfg: cfg, rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// draw decides one call's fate.
+func (in *Injector) draw() (delay time.Duration, slow, fail bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	delay = in.cfg.Latency
+	if in.cfg.Jitter > 0 {
+		delay += time.Duration(in.rng.Int64N(int64(in.cfg.Jitter)))
+	}
+	if in.rng.Float64() < in.cfg.SlowRate {
+		delay += in.cfg.Slow
+		slow = true
+	}
+	fail = in.rng.Float64() < in.cfg.ErrorRate
+	return delay, slow, fail
+}
+
+// Do applies one call's worth of misbehaviour: it waits the drawn delay
+// (or until ctx is done) and returns the injected error, if any. A nil
+// result means "go ahead and do the real work".
+func (in *Injector) Do(ctx context.Context) error {
+	in.calls.Add(1)
+	delay, slow, fail := in.draw()
+	if slow {
+		in.slow.Add(1)
+	}
+	if err := timeutil.Sleep(ctx, delay); err != nil {
+		in.canceled.Add(1)
+		return err
+	}
+	if fail {
+		in.failed.Add(1)
+		return in.cfg.Err
+	}
+	return nil
+}
+
+// Stats returns the counters so far.
+func (in *Injector) Stats() Stats {
+	return Stats{
+		Calls:    in.calls.Load(),
+		Slow:     in.slow.Load(),
+		Failed:   in.failed.Load(),
+		Canceled: in.canceled.Load(),
+	}
+}
+
+// Func wraps a context-aware call so that every invocation goes through in
+// first. The real call only runs if the injector lets it.
+func Func[Req, Resp any](in *Injector, fn func(context.Context, Req) (Resp, error)) func(context.Context, Req) (Resp, error) {
+	return func(ctx context.Context, req Req) (Resp, error) {
+		if err := in.Do(ctx); err != nil {
+			var zero Resp
+			return zero, err
+		}
+		return fn(ctx, req)
+	}
+}
+
+// RoundTripper wraps an HTTP transport (http.DefaultTransport if base is
+// nil) so that every request first goes through in. An injected failure
+// surfaces from client.Do as a transport error, like a reset connection.
+func (in *Injector) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{in, base}
+}
+
+type roundTripper struct {
+	in   *Injector
+	base http.RoundTripper
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := rt.in.Do(req.Context()); err != nil {
+		return nil, err
+	}
+	return rt.base.RoundTrip(req)
+}
@@ -0,0 +1,24 @@
+package litmus_test
+
+import (
+	"fmt"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/litmus"
+)
+
+// Atomics are sequentially consistent: their forbidden outcomes never
+// appear, on any hardware. (The plain variants' counts depend on the
+// machine, so they make a poor example.)
+func ExampleRun() {
+	for _, t := range litmus.Tests() {
+		if t.Variant != "atomic" {
+			continue
+		}
+		r := litmus.Run(t, 2000)
+		fmt.Printf("%s %s: forbidden %v seen %d times\n", t.Name, t.Variant, t.Forbidden, r.ForbiddenSeen())
+	}
+	// Output:
+	// SB atomic: forbidden r0=0 r1=0 seen 0 times
+	// MP atomic: forbidden r0=1 r1=0 seen 0 times
+	// LB atomic: forbidden r0=1 r1=1 seen 0 times
+}
@@ -0,0 +1,157 @@
+// Package litmus runs memory-model litmus tests: tiny two-goroutine
+// programs, executed millions of times, that count which final states
+// actually occur.
+//
+// Each test has an outcome that sequential consistency forbids - no
+// interleaving of the two goroutines' statements can produce it. With plain
+// (racy) variables the compiler and the CPU may reorder memory operations,
+// and the forbidden outcome can show up. With sync/atomic, which the Go
+// memory model makes sequentially consistent, it must never appear:
+//
+//	SB (store buffering)  T0: x=1; r0=y      T1: y=1; r1=x     forbidden r0=0 r1=0
+//	MP (message passing)  T0: x=1; y=1       T1: r0=y; r1=x    forbidden r0=1 r1=0
+//	LB (load buffering)   T0: r0=x; y=1      T1: r1=y; x=1     forbidden r0=1 r1=1
+//
+// What plain variables actually show depends on the hardware. x86 keeps
+// stores in order and loads in order but lets a load overtake an earlier
+// store to another address, so SB's forbidden outcome appears and MP's and
+// LB's (almost) never do. ARM and POWER reorder more and can show all three.
+// Seeing nothing proves nothing - but seeing a forbidden outcome even once
+// proves that racy code is broken.
+//
+// The goroutines need two CPUs to run at the same time. On one CPU they
+// take turns and every outcome is sequentially consistent.
+package litmus
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// State is the memory one iteration works on. Plain and atomic variables are
+// kept on separate cache lines so that the two goroutines don't serialize on
+// one line.
+type State struct {
+	X  int64
+	_  [56]byte
+	Y  int64
+	_  [56]byte
+	AX atomic.Int64
+	_  [56]byte
+	AY atomic.Int64
+	_  [56]byte
+	R0 int64 // written only by thread 0
+	_  [56]byte
+	R1 int64 // written only by thread 1
+}
+
+func (s *State) reset() {
+	s.X, s.Y, s.R0, s.R1 = 0, 0, 0, 0
+	s.AX.Store(0)
+	s.AY.Store(0)
+}
+
+// Outcome is the final (r0, r1) of one iteration.
+type Outcome [2]int64
+
+func (o Outcome) String() string { return fmt.Sprintf("r0=%d r1=%d", o[0], o[1]) }
+
+// Test is one litmus test in one synchronization variant.
+type Test struct {
+	Name      string // e.g. "SB"
+	Variant   string // e.g. "plain" or "atomic"
+	Forbidden Outcome
+	Threads   [2]func(*State)
+}
+
+// Result tallies the outcomes of running a Test.
+type Result struct {
+	Test       Test
+	Iterations int
+	Counts     map[Outcome]int
+}
+
+// ForbiddenSeen returns how many iterations ended in the forbidden outcome.
+func (r Result) ForbiddenSeen() int { return r.Counts[r.Test.Forbidden] }
+
+// Tests returns SB, MP and LB, each with plain variables and with
+// sync/atomic.
+func Tests() []Test {
+	return []Test{
+		{"SB", "plain", Outcome{0, 0}, [2]func(*State){
+			func(s *State) { s.X = 1; s.R0 = s.Y },
+			func(s *State) { s.Y = 1; s.R1 = s.X },
+		}},
+		{"SB", "atomic", Outcome{0, 0}, [2]func(*State){
+			func(s *State) { s.AX.Store(1); s.R0 = s.AY.Load() },
+			func(s *State) { s.AY.Store(1); s.R1 = s.AX.Load() },
+		}},
+		{"MP", "plain", Outcome{1, 0}, [2]func(*State){
+			func(s *State) { s.X = 1; s.Y = 1 },
+			func(s *State) { s.R0 = s.Y; s.R1 = s.X },
+		}},
+		{"MP", "atomic", Outcome{1, 0}, [2]func(*State){
+			func(s *State) { s.AX.Store(1); s.AY.Store(1) },
+			func(s *State) { s.R0 = s.AY.Load(); s.R1 = s.AX.Load() },
+		}},
+		{"LB", "plain", Outcome{1, 1}, [2]func(*State){
+			func(s *State) { s.R0 = s.X; s.Y = 1 },
+			func(s *State) { s.R1 = s.Y; s.X = 1 },
+		}},
+		{"LB", "atomic", Outcome{1, 1}, [2]func(*State){
+			func(s *State) { s.R0 = s.AX.Load(); s.AY.Store(1) },
+			func(s *State) { s.R1 = s.AY.Load(); s.AX.Store(1) },
+		}},
+	}
+}
+
+// barrier is a reusable spinning barrier for two goroutines. Parking on a
+// channel would take microseconds and desynchronize the threads; spinning
+// releases both within a few nanoseconds of each other, which is what makes
+// reorderings observable.
+type barrier struct {
+	arrived atomic.Int32
+	_       [60]byte
+	gen     atomic.Uint32
+}
+
+func (b *barrier) wait() {
+	gen := b.gen.Load()
+	if b.arrived.Add(1) == 2 {
+		b.arrived.Store(0)
+		b.gen.Add(1)
+		return
+	}
+	for spins := 0; b.gen.Load() == gen; spins++ {
+		if spins%1000 == 999 {
+			runtime.Gosched() // don't starve the other goroutine on one CPU
+		}
+	}
+}
+
+// Run executes t iterations times and tallies the outcomes.
+func Run(t Test, iterations int) Result {
+	var s State
+	var b barrier
+	counts := make(map[Outcome]int)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for range iterations {
+			b.wait() // start together
+			t.Threads[1](&s)
+			b.wait() // finished: thread 0 may look
+		}
+	})
+	for range iterations {
+		b.wait()
+		t.Threads[0](&s)
+		b.wait()
+		counts[Outcome{s.R0, s.R1}]++
+		s.reset() // before thread 1 can pass the next start barrier
+	}
+	wg.Wait()
+	return Result{Test: t, Iterations: iterations, Counts: counts}
+}
@@ -0,0 +1,46 @@
+package loadingcache_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/loadingcache"
+)
+
+func Example() {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // a fake clock
+	release := make(chan struct{})
+	users := loadingcache.New(loadingcache.Config[int, string]{
+		Load: func(ctx context.Context, id int) (string, error) {
+			<-release // a slow backend
+			return fmt.Sprintf("user-%d", id), nil
+		},
+		TTL: time.Minute,
+		Now: func() time.Time { return now },
+	})
+	defer users.Close()
+
+	// Ten callers miss at once: one load, nine wait for it.
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() { users.Get(context.Background(), 42) })
+	}
+	for users.Stats().Misses+users.Stats().Shared < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	u, _ := users.Get(context.Background(), 42) // fresh: a hit
+	st := users.Stats()
+	fmt.Println(u, "loads:", st.Loads, "shared:", st.Shared, "hits:", st.Hits)
+
+	now = now.Add(2 * time.Minute) // expired: the next Get loads again
+	users.Get(context.Background(), 42)
+	fmt.Println("loads:", users.Stats().Loads)
+	// Output:
+	// user-42 loads: 1 shared: 9 hits: 1
+	// loads: 2
+}
@@ -0,0 +1,275 @@
+// Package loadingcache is a read-through cache: Get returns the cached
+// value for a key, or calls the loader to produce it, with a time-to-live
+// per entry.
+//
+// Three problems come with a cache in front of a slow backend, and each is
+// handled here:
+//
+//   - Stampedes. When a popular key is missing or expires, every request
+//     for it misses at once and the backend gets N identical queries.
+//     Get deduplicates: one load per key is in flight, the other callers
+//     wait for its result (the "singleflight" pattern).
+//   - Latency spikes at expiry. Every TTL, some unlucky request waits for
+//     the reload. With StaleWhileRevalidate, a value that expired recently
+//     is still returned at once while one background refresh replaces it.
+//   - Refresh storms. When many entries expire together, their background
+//     refreshes would all hit the backend at the same moment. At most
+//     MaxRefreshes run at once; a stale Get that finds no free slot is
+//     served the stale value and the refresh is left to a later Get.
+//
+// The loader runs in its own goroutine with the cache's context, not the
+// caller's: a caller that gives up stops waiting, but the load finishes for
+// the others waiting on it. Errors are returned to every waiter and not
+// cached, so the next Get tries again.
+//
+// In use:
+//
+//	users := loadingcache.New(loadingcache.Config[int, User]{
+//		Load:                 func(ctx context.Context, id int) (User, error) { return db.User(ctx, id) },
+//		TTL:                  time.Minute,
+//		StaleWhileRevalidate: 10 * time.Second,
+//	})
+//	defer users.Close()
+//	u, err := users.Get(ctx, 42)
+package loadingcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by Get after Close.
+var ErrClosed = errors.New("loadingcache: closed")
+
+// DefaultMaxRefreshes is the background refresh limit when
+// Config.MaxRefreshes is zero.
+const DefaultMaxRefreshes = 4
+
+// Config describes how a Cache loads and expires entries. Only Load is
+// required.
+type Config[K comparable, V any] struct {
+	// Load produces the value for a key. It is called from a goroutine of
+	// the cache, at most once at a time per key, with a context that is
+	// cancelled by Close.
+	Load func(ctx context.Context, key K) (V, error)
+	// TTL is how long a loaded value is fresh. Zero means forever.
+	TTL time.Duration
+	// StaleWhileRevalidate is how long after TTL an expired value is still
+	// returned by Get, while a background refresh loads the new one. After
+	// that, Get waits for a load. Zero disables it.
+	StaleWhileRevalidate time.Duration
+	// MaxRefreshes limits the background refreshes running at once:
+	// DefaultMaxRefreshes if zero. Loads for missing keys are not limited,
+	// since a caller is waiting for them.
+	MaxRefreshes int
+	// Now is the clock entries are timed with; time.Now if nil. A fake
+	// clock makes expiry deterministic.
+	Now func() time.Time
+}
+
+// Stats counts what Get did.
+type Stats struct {
+	Hits      int64 // fresh value returned
+	StaleHits int64 // expired value returned within StaleWhileRevalidate
+	Misses    int64 // no usable value: the caller started a load
+	Shared    int64 // no usable value: the caller waited for a load already in flight
+	Loads     int64 // loads started for misses
+	Refreshes int64 // background refreshes started
+	Skipped   int64 // refreshes not started because MaxRefreshes were running
+	Errors    int64 // loads and refreshes that failed
+	Loading   int   // loads and refreshes in flight now
+	Entries   int   // keys cached now
+}
+
+// entry is one key's cached value and the load in flight for it, if any.
+type entry[V any] struct {
+	value   V
+	loaded  bool      // value is set
+	expires time.Time // the value is fresh until then; zero: forever
+	load    *load[V]  // the load or refresh in flight, or nil
+}
+
+// load is one call of Config.Load; done is closed when v and err are set.
+type load[V any] struct {
+	done chan struct{}
+	v    V
+	err  error
+}
+
+// Cache is a loading cache. Create it with New; it is safe for concurrent
+// use.
+type Cache[K comparable, V any] struct {
+	cfg       Config[K, V]
+	refreshes chan struct{} // semaphore for background refreshes
+	ctx       context.Context
+	cancel    context.CancelFunc
+	loads     sync.WaitGroup // every load goroutine
+
+	mu      sync.Mutex
+	entries map[K]*entry[V]
+	stats   Stats
+	closed  bool
+}
+
+// New returns an empty cache. It panics if cfg.Load is nil.
+func New[K comparable, V any](cfg Config[K, V]) *Cache[K, V] {
+	if cfg.Load == nil {
+		panic("loadingcache: Config.Load is nil")
+	}
+	if cfg.MaxRefreshes <= 0 {
+		cfg.MaxRefreshes = DefaultMaxRefreshes
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Cache[K, V]{
+		cfg:       cfg,
+		refreshes: make(chan struct{}, cfg.MaxRefreshes),
+		ctx:       ctx,
+		cancel:    cancel,
+		entries:   make(map[K]*entry[V]),
+	}
+}
+
+// Get returns the value for key: a fresh one from the cache, a stale one
+// while it is refreshed in the background, or the result of a load. If ctx
+// is done first, Get returns ctx's error; the load goes on for the others.
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	var zero V
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return zero, ErrClosed
+	}
+	e := c.entries[key]
+	if e == nil {
+		e = &entry[V]{}
+		c.entries[key] = e
+	}
+	now := c.cfg.Now()
+	if e.loaded {
+		switch {
+		case e.expires.IsZero() || now.Before(e.expires):
+			c.stats.Hits++
+			c.mu.Unlock()
+			return e.value, nil
+		case now.Before(e.expires.Add(c.cfg.StaleWhileRevalidate)):
+			c.stats.StaleHits++
+			if e.load == nil {
+				c.refresh(key, e)
+			}
+			c.mu.Unlock()
+			return e.value, nil
+		}
+	}
+	l := e.load
+	if l == nil {
+		c.stats.Misses++
+		c.stats.Loads++
+		l = c.start(key, e, nil)
+	} else {
+		c.stats.Shared++
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-l.done:
+		return l.v, l.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// refresh starts a background refresh of e if a slot is free. c.mu is held.
+func (c *Cache[K, V]) refresh(key K, e *entry[V]) {
+	select {
+	case c.refreshes <- struct{}{}:
+		c.stats.Refreshes++
+		c.start(key, e, func() { <-c.refreshes })
+	default:
+		c.stats.Skipped++ // the next stale Get tries again
+	}
+}
+
+// start runs Config.Load for key in a new goroutine and records it as e's
+// load in flight. release, if not nil, is called when the load is done.
+// c.mu is held.
+func (c *Cache[K, V]) start(key K, e *entry[V], release func()) *load[V] {
+	l := &load[V]{done: make(chan struct{})}
+	e.load = l
+	c.stats.Loading++
+	c.loads.Add(1)
+	go func() {
+		defer c.loads.Done()
+		v, err := c.cfg.Load(c.ctx, key)
+
+		c.mu.Lock()
+		l.v, l.err = v, err
+		e.load = nil
+		c.stats.Loading--
+		if release != nil {
+			release() // under the lock: once Loading drops, the slot is free
+		}
+		if err != nil {
+			c.stats.Errors++ // not cached: a stale value stays, a miss stays a miss
+		} else {
+			e.value, e.loaded = v, true
+			if c.cfg.TTL > 0 {
+				e.expires = c.cfg.Now().Add(c.cfg.TTL)
+			}
+		}
+		c.mu.Unlock()
+		close(l.done)
+	}()
+	return l
+}
+
+// Invalidate removes key, so the next Get loads it. A load already in
+// flight still answers the callers waiting for it, but is not cached.
+func (c *Cache[K, V]) Invalidate(key K) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// Purge removes the entries that are too old to be returned, even stale,
+// and have no load in flight. The cache does not do it by itself: call it
+// periodically if keys come and go.
+func (c *Cache[K, V]) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.cfg.Now()
+	n := 0
+	for k, e := range c.entries {
+		if e.load == nil && (!e.loaded || !e.expires.IsZero() && !now.Before(e.expires.Add(c.cfg.StaleWhileRevalidate))) {
+			delete(c.entries, k)
+			n++
+		}
+	}
+	return n
+}
+
+// Stats returns the cache's counters.
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = len(c.entries)
+	return s
+}
+
+// Close cancels the loads in flight, waits for their goroutines to return
+// and makes later Gets fail with ErrClosed.
+func (c *Cache[K, V]) Close() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.cancel()
+	c.loads.Wait()
+}
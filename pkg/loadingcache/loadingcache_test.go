@@ -0,0 +1,312 @@
+package loadingcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is the cache's clock in these tests: time moves only when the
+// test calls Advance, so expiry happens exactly where the test says.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// backend is a loader the test controls: it counts calls, returns key@vN
+// for the current version, fails while fail is set, and waits for gate
+// while one is installed.
+type backend struct {
+	calls   atomic.Int64
+	version atomic.Int64
+	fail    atomic.Bool
+	gate    atomic.Pointer[chan struct{}]
+}
+
+func (b *backend) load(ctx context.Context, key string) (string, error) {
+	b.calls.Add(1)
+	if g := b.gate.Load(); g != nil {
+		select {
+		case <-*g:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if b.fail.Load() {
+		return "", fmt.Errorf("%s unavailable", key)
+	}
+	return fmt.Sprintf("%s@v%d", key, b.version.Load()), nil
+}
+
+// hold makes loads wait until the returned func is called.
+func (b *backend) hold() (release func()) {
+	g := make(chan struct{})
+	b.gate.Store(&g)
+	return func() {
+		b.gate.Store(nil)
+		close(g)
+	}
+}
+
+func newTestCache(t *testing.T, swr time.Duration, maxRefreshes int) (*Cache[string, string], *backend, *fakeClock) {
+	b, clock := &backend{}, &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := New(Config[string, string]{
+		Load:                 b.load,
+		TTL:                  time.Minute,
+		StaleWhileRevalidate: swr,
+		MaxRefreshes:         maxRefreshes,
+		Now:                  clock.Now,
+	})
+	t.Cleanup(c.Close)
+	return c, b, clock
+}
+
+// waitFor polls cond until it holds, failing the test after a second. The
+// clock is fake, but the loads run on goroutines of their own.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+}
+
+// settle waits until no load or refresh is in flight.
+func settle(t *testing.T, c *Cache[string, string]) {
+	t.Helper()
+	waitFor(t, "loads to finish", func() bool { return c.Stats().Loading == 0 })
+}
+
+func get(t *testing.T, c *Cache[string, string], key, want string) {
+	t.Helper()
+	if v, err := c.Get(context.Background(), key); v != want || err != nil {
+		t.Fatalf("Get(%q) = %q, %v, want %q, nil", key, v, err, want)
+	}
+}
+
+func TestGetDeduplicatesLoads(t *testing.T) {
+	c, b, _ := newTestCache(t, 0, 0)
+	release := b.hold()
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Go(func() { results[i], _ = c.Get(context.Background(), "home") })
+	}
+	waitFor(t, "ten Gets", func() bool { s := c.Stats(); return s.Misses+s.Shared == 10 })
+	release()
+	wg.Wait()
+
+	if n := b.calls.Load(); n != 1 {
+		t.Errorf("%d loads for ten concurrent Gets, want 1", n)
+	}
+	for i, r := range results {
+		if r != "home@v0" {
+			t.Errorf("Get %d = %q, want home@v0", i, r)
+		}
+	}
+	if s := c.Stats(); s.Misses != 1 || s.Shared != 9 || s.Loads != 1 {
+		t.Errorf("Stats = %+v, want Misses 1, Shared 9, Loads 1", s)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	c, b, clock := newTestCache(t, 0, 0)
+	get(t, c, "k", "k@v0")
+	b.version.Add(1)
+
+	clock.Advance(time.Minute - time.Nanosecond)
+	get(t, c, "k", "k@v0") // still fresh, though the backend moved on
+	if n := b.calls.Load(); n != 1 {
+		t.Fatalf("%d loads before the TTL, want 1", n)
+	}
+	clock.Advance(time.Nanosecond)
+	get(t, c, "k", "k@v1") // expired at exactly TTL: Get waits for a load
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 2 || s.StaleHits != 0 {
+		t.Errorf("Stats = %+v, want Hits 1, Misses 2, StaleHits 0", s)
+	}
+}
+
+func TestZeroTTLNeverExpires(t *testing.T) {
+	b, clock := &backend{}, &fakeClock{}
+	c := New(Config[string, string]{Load: b.load, Now: clock.Now})
+	defer c.Close()
+	get(t, c, "k", "k@v0")
+	b.version.Add(1)
+	clock.Advance(1000 * time.Hour)
+	get(t, c, "k", "k@v0")
+	if n := b.calls.Load(); n != 1 {
+		t.Errorf("%d loads, want 1", n)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	c, b, clock := newTestCache(t, 30*time.Second, 0)
+	get(t, c, "k", "k@v0")
+	b.version.Add(1)
+
+	clock.Advance(70 * time.Second) // expired, within TTL + 30s
+	release := b.hold()
+	get(t, c, "k", "k@v0") // served stale at once, the refresh is held
+	get(t, c, "k", "k@v0")
+	if s := c.Stats(); s.StaleHits != 2 || s.Refreshes != 1 || s.Loading != 1 {
+		t.Errorf("Stats = %+v, want StaleHits 2, Refreshes 1, Loading 1", s)
+	}
+	release()
+	settle(t, c)
+	get(t, c, "k", "k@v1") // the refresh restarted the TTL from the fake now
+	if s := c.Stats(); s.Hits != 1 {
+		t.Errorf("Stats = %+v, want Hits 1 after the refresh", s)
+	}
+
+	clock.Advance(time.Minute + 30*time.Second) // past TTL + 30s: too stale
+	b.version.Add(1)
+	get(t, c, "k", "k@v2")
+	if s := c.Stats(); s.Misses != 2 || s.Refreshes != 1 {
+		t.Errorf("Stats = %+v, want Misses 2, Refreshes 1", s)
+	}
+}
+
+func TestMaxRefreshes(t *testing.T) {
+	c, b, clock := newTestCache(t, 30*time.Second, 2)
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	for _, k := range keys {
+		get(t, c, k, k+"@v0")
+	}
+	clock.Advance(70 * time.Second)
+	b.version.Add(1)
+	release := b.hold()
+	for _, k := range keys {
+		get(t, c, k, k+"@v0")
+	}
+	if s := c.Stats(); s.Refreshes != 2 || s.Skipped != 4 || s.Loading != 2 {
+		t.Fatalf("Stats = %+v, want Refreshes 2, Skipped 4, Loading 2", s)
+	}
+	release()
+
+	// Later stale Gets refresh the rest, never more than two at once.
+	for round := 1; round <= 2; round++ {
+		settle(t, c)
+		for _, k := range keys {
+			c.Get(context.Background(), k)
+		}
+		if s := c.Stats(); s.Loading > 2 {
+			t.Fatalf("round %d: %d refreshes at once, want at most 2", round, s.Loading)
+		}
+	}
+	settle(t, c)
+	if s := c.Stats(); s.Refreshes != 6 || b.calls.Load() != 12 {
+		t.Errorf("Stats = %+v, backend calls %d; want Refreshes 6, 12 calls", s, b.calls.Load())
+	}
+	for _, k := range keys {
+		get(t, c, k, k+"@v1")
+	}
+}
+
+func TestErrorsAreNotCached(t *testing.T) {
+	c, b, clock := newTestCache(t, 30*time.Second, 0)
+	b.fail.Store(true)
+	if _, err := c.Get(context.Background(), "k"); err == nil {
+		t.Fatal("Get with a failing backend returned no error")
+	}
+	b.fail.Store(false)
+	get(t, c, "k", "k@v0") // the error was not cached: this Get loads again
+
+	clock.Advance(70 * time.Second)
+	b.fail.Store(true)
+	get(t, c, "k", "k@v0") // stale; starts a refresh that fails
+	settle(t, c)
+	get(t, c, "k", "k@v0") // the failed refresh kept the stale value
+	settle(t, c)
+	if s := c.Stats(); s.Errors != 3 || s.Refreshes != 2 {
+		t.Errorf("Stats = %+v, want Errors 3, Refreshes 2", s)
+	}
+}
+
+// TestCallerGivesUp checks that a caller whose context ends stops waiting,
+// while the load goes on and answers the others.
+func TestCallerGivesUp(t *testing.T) {
+	c, b, _ := newTestCache(t, 0, 0)
+	release := b.hold()
+	patient := make(chan string, 1)
+	go func() {
+		v, _ := c.Get(context.Background(), "k")
+		patient <- v
+	}()
+	waitFor(t, "the first Get", func() bool { return c.Stats().Misses == 1 })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Get(ctx, "k"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Get with a done context = %v, want Canceled", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := c.Get(ctx, "k"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get = %v, want DeadlineExceeded", err)
+	}
+	release()
+	if v := <-patient; v != "k@v0" {
+		t.Errorf("the patient caller got %q, want k@v0", v)
+	}
+	if n := b.calls.Load(); n != 1 {
+		t.Errorf("%d loads, want 1", n)
+	}
+}
+
+func TestInvalidateAndPurge(t *testing.T) {
+	c, b, clock := newTestCache(t, 30*time.Second, 0)
+	get(t, c, "a", "a@v0")
+	get(t, c, "b", "b@v0")
+	b.version.Add(1)
+	c.Invalidate("a")
+	get(t, c, "a", "a@v1")
+
+	clock.Advance(time.Minute + 30*time.Second) // both too old even to serve stale
+	get(t, c, "a", "a@v1")                      // a is loaded again, b is left
+	if n := c.Purge(); n != 1 {
+		t.Errorf("Purge removed %d entries, want 1 (b)", n)
+	}
+	if s := c.Stats(); s.Entries != 1 {
+		t.Errorf("%d entries after Purge, want 1", s.Entries)
+	}
+}
+
+func TestClose(t *testing.T) {
+	c, b, _ := newTestCache(t, 0, 0)
+	b.hold() // never released: Close must cancel the load
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background(), "k")
+		errc <- err
+	}()
+	waitFor(t, "the load", func() bool { return c.Stats().Loading == 1 })
+	c.Close()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("Get during Close = %v, want the load's Canceled", err)
+	}
+	if s := c.Stats(); s.Loading != 0 {
+		t.Errorf("%d loads in flight after Close", s.Loading)
+	}
+	if _, err := c.Get(context.Background(), "k"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get after Close = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,20 @@
+package lockedthread_test
+
+import (
+	"fmt"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/lockedthread"
+)
+
+func ExampleThread_Do() {
+	var initOn int
+	t := lockedthread.Start(func() { initOn = lockedthread.ID() }) // set up thread-local state
+	defer t.Close()
+
+	same := true
+	for range 5 {
+		t.Do(func() { same = same && lockedthread.ID() == initOn })
+	}
+	fmt.Println("every call ran on init's thread:", same)
+	// Output: every call ran on init's thread: true
+}
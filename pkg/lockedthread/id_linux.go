@@ -0,0 +1,8 @@
+package lockedthread
+
+import "syscall"
+
+// ID returns the id of the OS thread the calling goroutine is running on.
+// Unless the goroutine is locked, the answer may be stale by the time the
+// caller looks at it.
+func ID() int { return syscall.Gettid() }
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package lockedthread
+
+// ID returns the id of the OS thread the calling goroutine is running on.
+// Thread ids are only available on Linux; elsewhere ID returns 0.
+func ID() int { return 0 }
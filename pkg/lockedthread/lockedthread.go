@@ -0,0 +1,119 @@
+// Package lockedthread runs functions on one dedicated OS thread.
+//
+// Most Go code never cares which OS thread it runs on: the scheduler moves
+// goroutines between threads whenever it likes. Some code does care:
+//
+//   - APIs with thread-local state: OpenGL contexts, some C libraries'
+//     errno-style "last error", Linux per-thread credentials and namespaces
+//     (setns, unshare), thread-scoped priorities.
+//   - APIs that must be called from one specific thread, classically the
+//     main thread of a GUI toolkit (Cocoa, Win32 message loops, SDL).
+//
+// runtime.LockOSThread wires the calling goroutine to its current thread:
+// the goroutine only ever runs there, and no other goroutine does. A Thread
+// is the usual way to use it: one goroutine locks itself to a thread for its
+// whole life and executes closures sent to it over a channel, so any
+// goroutine can call Do and have the work happen on the same thread:
+//
+//	ui := lockedthread.Start(toolkit.Init)
+//	defer ui.Close()
+//	err := ui.Do(func() { toolkit.Draw(widget) }) // runs on Init's thread
+package lockedthread
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ErrClosed is returned by Do and DoContext after Close.
+var ErrClosed = errors.New("lockedthread: thread closed")
+
+// Thread is a goroutine locked to its own OS thread, running the functions
+// it is given one at a time, in the order they arrive.
+type Thread struct {
+	work      chan func()
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	id        int
+}
+
+// Start starts a dedicated thread. init, if not nil, runs on the thread
+// before any work: the place to set up thread-local state.
+func Start(init func()) *Thread {
+	t := &Thread{
+		work:    make(chan func()),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	ready := make(chan struct{})
+	go t.loop(init, ready)
+	<-ready
+	return t
+}
+
+func (t *Thread) loop(init func(), ready chan<- struct{}) {
+	// Never unlocked: when a locked goroutine exits, the runtime terminates
+	// its thread instead of reusing it, so whatever thread-local state the
+	// work left behind cannot leak into unrelated goroutines.
+	runtime.LockOSThread()
+	defer close(t.stopped)
+
+	t.id = ID()
+	if init != nil {
+		init()
+	}
+	close(ready)
+
+	for {
+		select {
+		case fn := <-t.work:
+			fn()
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// ID returns the OS thread id of the dedicated thread (0 where thread ids
+// are not supported).
+func (t *Thread) ID() int { return t.id }
+
+// Do runs fn on the dedicated thread and waits for it to return. A panic in
+// fn is re-raised in the caller, not on the thread, so the thread survives
+// it.
+func (t *Thread) Do(fn func()) error {
+	return t.DoContext(context.Background(), fn)
+}
+
+// DoContext is Do, but gives up with ctx's error if ctx is done before the
+// thread gets to fn. Once fn has started it runs to completion: a goroutine
+// cannot be interrupted from outside.
+func (t *Thread) DoContext(ctx context.Context, fn func()) error {
+	finished := make(chan any, 1) // nil, or the value fn panicked with
+	job := func() {
+		defer func() { finished <- recover() }()
+		fn()
+	}
+
+	select {
+	case t.work <- job:
+	case <-t.stop:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if p := <-finished; p != nil {
+		panic(p)
+	}
+	return nil
+}
+
+// Close stops the thread once the function it is running (if any)
+// returns, and waits for that. Calls to Do after Close return ErrClosed.
+func (t *Thread) Close() {
+	t.closeOnce.Do(func() { close(t.stop) })
+	<-t.stopped
+}
@@ -0,0 +1,52 @@
+// Package lostupdate measures how many increments an unprotected counter
+// loses when several goroutines run counter++ at once.
+//
+// counter++ is a read, an add and a write. Two goroutines that read the same
+// value both write value+1, and one increment is gone. How often that
+// happens depends on how many goroutines overlap and on how many cores run
+// them, so one run is an anecdote; Measure repeats the race and summarises
+// the shortfall (expected − observed).
+//
+// The race is the point: run under -race, the detector reports it.
+package lostupdate
+
+import "sync"
+
+// Result summarises the trials of one Measure call.
+type Result struct {
+	Expected    int     // goroutines × increments
+	Worst, Best int     // the lowest and the highest total observed
+	AvgLost     float64 // increments lost per trial, on average
+}
+
+// AvgLostPercent is AvgLost as a percentage of Expected.
+func (r Result) AvgLostPercent() float64 {
+	return r.AvgLost / float64(r.Expected) * 100
+}
+
+// Measure runs an unprotected counter++ from goroutines goroutines, each
+// incrementing increments times, for trials rounds.
+func Measure(goroutines, increments, trials int) Result {
+	r := Result{Expected: goroutines * increments, Worst: goroutines * increments}
+	var totalLost int
+
+	for range trials {
+		var counter int
+		var wg sync.WaitGroup
+		for range goroutines {
+			wg.Go(func() {
+				for range increments {
+					counter++ // UNSAFE on purpose: the race being measured
+				}
+			})
+		}
+		wg.Wait()
+
+		r.Worst = min(r.Worst, counter)
+		r.Best = max(r.Best, counter)
+		totalLost += r.Expected - counter
+	}
+
+	r.AvgLost = float64(totalLost) / float64(trials)
+	return r
+}
@@ -0,0 +1,26 @@
+package mcslock_test
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/mcslock"
+)
+
+func ExampleMutex() {
+	var mu mcslock.Mutex
+	balance := 0
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 1000 {
+				mu.Lock()
+				balance++
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	fmt.Println(balance)
+	// Output: 4000
+}
@@ -0,0 +1,95 @@
+// Package mcslock provides an MCS queued spinlock (Mellor-Crummey & Scott,
+// 1991).
+//
+// Simple spinlocks, and the ticket lock in package ticketlock, make every
+// waiter spin on the SAME shared word. Each release writes that word, which
+// invalidates the cache line in every waiting core; all of them then miss
+// and re-fetch it ("cache-line bouncing"). The cost of one hand-off grows
+// with the number of waiters.
+//
+// An MCS lock gives each waiter its OWN node and links the nodes into a
+// queue:
+//
+//	tail ──► [C] ◄─next── [B] ◄─next── [A = holder]
+//	         spins on      spins on
+//	         C.locked      B.locked
+//
+// Two operations keep the queue moving:
+//
+//   - Lock appends a node with one atomic swap on tail, then spins only on
+//     its own node's locked flag.
+//   - Unlock clears the flag in its successor's node, so exactly one waiting
+//     core sees its cache line invalidated.
+//
+// Hand-off cost is constant no matter how many goroutines are queued, and
+// the queue is FIFO, so the lock is fair like a ticket lock.
+//
+// Go caveat: goroutines are not pinned to cores, so the "one cache line per
+// waiter" benefit is weaker than in C with one thread per core. Waiters also
+// yield (runtime.Gosched) while spinning so the holder can run when there
+// are more goroutines than Ps.
+package mcslock
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// cacheLineSize is a conservative cache line size for common CPUs (x86-64
+// and most arm64 cores use 64 bytes; some Apple cores use 128).
+const cacheLineSize = 128
+
+// qnode is one waiter's place in the queue. It is padded to a full cache
+// line so that two waiters' flags never share a line (false sharing would
+// bring the bouncing right back).
+type qnode struct {
+	locked atomic.Bool
+	next   atomic.Pointer[qnode]
+	_      [cacheLineSize - 16]byte
+}
+
+// Mutex is an MCS queued spinlock. The zero value is an unlocked mutex.
+//
+// A Mutex must not be copied after first use.
+type Mutex struct {
+	tail   atomic.Pointer[qnode]
+	_      [cacheLineSize - 8]byte // keep tail off the holder's line
+	holder *qnode                  // node of the current holder; only touched while locked
+}
+
+// Lock acquires m. Goroutines are granted the lock in the order in which
+// they swapped themselves onto the tail of the queue.
+func (m *Mutex) Lock() {
+	n := new(qnode)
+	n.locked.Store(true)
+
+	pred := m.tail.Swap(n) // join the queue with a single atomic op
+	if pred != nil {
+		pred.next.Store(n) // let the predecessor know whom to wake
+		for n.locked.Load() {
+			runtime.Gosched() // spin on OUR OWN node only
+		}
+	}
+	m.holder = n
+}
+
+// Unlock releases m, handing it directly to the next queued goroutine.
+func (m *Mutex) Unlock() {
+	n := m.holder
+	m.holder = nil
+
+	succ := n.next.Load()
+	if succ == nil {
+		// Nobody visible behind us. If we are still the tail, the queue is
+		// now empty and the lock is free.
+		if m.tail.CompareAndSwap(n, nil) {
+			return
+		}
+		// Someone swapped onto the tail but hasn't linked to us yet:
+		// wait for the link (this window is a few instructions long).
+		for succ = n.next.Load(); succ == nil; succ = n.next.Load() {
+			runtime.Gosched()
+		}
+	}
+	succ.locked.Store(false) // wake exactly one waiter
+}
@@ -0,0 +1,41 @@
+package mcslock
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestMutualExclusion runs more goroutines than Ps through a tiny critical
+// section. The count is a plain int, so under -race a hand-off that does
+// not order the previous holder's writes before the next holder's reads is
+// reported even when no update happens to be lost.
+func TestMutualExclusion(t *testing.T) {
+	const goroutines, iterations = 16, 2000
+	var (
+		mu     Mutex
+		count  int
+		inside atomic.Int32
+		wg     sync.WaitGroup
+	)
+	for range goroutines {
+		wg.Go(func() {
+			for range iterations {
+				mu.Lock()
+				if n := inside.Add(1); n != 1 {
+					t.Errorf("%d goroutines in the critical section", n)
+				}
+				count++
+				inside.Add(-1)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if count != goroutines*iterations {
+		t.Errorf("count = %d, want %d", count, goroutines*iterations)
+	}
+	if mu.tail.Load() != nil || mu.holder != nil {
+		t.Error("the queue is not empty after the last Unlock")
+	}
+}
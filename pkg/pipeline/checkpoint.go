@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// CHECKPOINT / RESUME
+// ============================================================================
+// A long run that dies at item 90,000 shouldn't start again at item 0. If
+// items have sequence numbers (a file offset, a line number, a position in
+// a deterministic walk), it is enough to remember one number: the first
+// item that is NOT known to be finished. A restart skips everything below
+// it.
+//
+// Items can finish out of order (parallel workers), so the checkpoint keeps
+// the finished numbers above that watermark in a set and only moves the
+// watermark over a contiguous run. Saving every item would make the store
+// the bottleneck, so it saves at most once per interval, plus on Flush.
+//
+// Resuming is at-least-once: items finished after the last save are done
+// again after a crash. Sinks should tolerate a few repeats.
+// ============================================================================
+
+// Store persists a checkpoint's watermark.
+type Store interface {
+	// Load returns the saved watermark, or 0 if nothing was saved yet.
+	Load() (int64, error)
+	Save(next int64) error
+}
+
+// FileStore keeps the watermark in a small JSON file. Save writes a
+// temporary file and renames it over the old one, so a crash mid-save
+// leaves the previous checkpoint intact.
+type FileStore string
+
+type fileState struct {
+	Next  int64     `json:"next"`
+	Saved time.Time `json:"saved"`
+}
+
+func (f FileStore) Load() (int64, error) {
+	data, err := os.ReadFile(string(f))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, err
+	}
+	return s.Next, nil
+}
+
+func (f FileStore) Save(next int64) error {
+	data, err := json.Marshal(fileState{Next: next, Saved: time.Now()})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(string(f)), filepath.Base(string(f))+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), string(f))
+}
+
+// Checkpoint tracks which numbered items are finished and saves the
+// watermark to a Store. It is safe for concurrent use.
+type Checkpoint struct {
+	store Store
+	every time.Duration
+
+	mu       sync.Mutex
+	next     int64          // every item below next is finished
+	finished map[int64]bool // finished items above next
+	saved    int64          // watermark in the store
+	lastSave time.Time
+}
+
+// OpenCheckpoint loads the watermark from store. Finished items are saved
+// at most once per every.
+func OpenCheckpoint(store Store, every time.Duration) (*Checkpoint, error) {
+	next, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Checkpoint{store: store, every: every, next: next, finished: map[int64]bool{},
+		saved: next, lastSave: time.Now()}, nil
+}
+
+// Resume returns the first item to process: everything below it was
+// finished by an earlier run.
+func (c *Checkpoint) Resume() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.next
+}
+
+// Done marks item seq finished, in any order, and saves the watermark if
+// the interval has passed. The error is the store's.
+func (c *Checkpoint) Done(seq int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if seq >= c.next {
+		c.finished[seq] = true
+	}
+	for c.finished[c.next] {
+		delete(c.finished, c.next)
+		c.next++
+	}
+	if time.Since(c.lastSave) < c.every {
+		return nil
+	}
+	return c.saveLocked()
+}
+
+// DoneThrough marks every item up to and including seq finished - for
+// sinks that see items in order but not all of them (a filter upstream
+// dropped some).
+func (c *Checkpoint) DoneThrough(seq int64) error {
+	c.mu.Lock()
+	for c.next <= seq {
+		delete(c.finished, c.next)
+		c.next++
+	}
+	c.mu.Unlock()
+	return c.Done(seq) // seq < next now: only the save logic runs
+}
+
+// Flush saves the watermark now if it moved since the last save. Call it
+// after a Drain, so that a clean shutdown resumes exactly where it stopped.
+func (c *Checkpoint) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.saveLocked()
+}
+
+func (c *Checkpoint) saveLocked() error {
+	c.lastSave = time.Now()
+	if c.next == c.saved {
+		return nil
+	}
+	if err := c.store.Save(c.next); err != nil {
+		return err
+	}
+	c.saved = c.next
+	return nil
+}
+
+// CheckpointSink wraps sink so that every item it handles is marked
+// finished in c - the optional last step of a Pipeline. A Pipeline keeps
+// items in order, so items a Stage filtered out before this one are
+// finished too. seq extracts an item's sequence number; save errors go to
+// onErr, which may be nil.
+func CheckpointSink[T any](c *Checkpoint, seq func(T) int64, sink func(T), onErr func(error)) func(T) {
+	return func(item T) {
+		sink(item)
+		if err := c.DoneThrough(seq(item)); err != nil && onErr != nil {
+			onErr(err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package pipeline_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/pipeline"
+)
+
+func Example() {
+	ctx := context.Background()
+	var out []string // only the sink goroutine touches it until Drain returns
+	p := pipeline.New(ctx, 4,
+		func(s string) { out = append(out, s) },
+		func(_ context.Context, s string) (string, bool) { return strings.TrimSpace(s), s != "" }, // filter blanks
+		func(_ context.Context, s string) (string, bool) { return strings.ToUpper(s), true },
+	)
+	for _, s := range []string{" alpha", "", "beta ", "gamma"} {
+		if err := p.Submit(ctx, s); err != nil {
+			fmt.Println(err)
+		}
+	}
+	if err := p.Drain(ctx); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(out)
+	fmt.Println(p.Submit(ctx, "late"))
+
+	st := p.Stats()
+	fmt.Printf("accepted %d, filtered %d, sunk %d, lost %d, rejected %d\n",
+		st.Accepted, st.Filtered, st.Sunk, st.Lost, st.Rejected)
+	// Output:
+	// [ALPHA BETA GAMMA]
+	// pipeline: draining, not accepting input
+	// accepted 4, filtered 1, sunk 3, lost 0, rejected 1
+}
+
+type memStore struct{ next int64 }
+
+func (m *memStore) Load() (int64, error) { return m.next, nil }
+func (m *memStore) Save(next int64) error {
+	m.next = next
+	return nil
+}
+
+func ExampleCheckpoint() {
+	store := &memStore{next: 3} // an earlier run finished items 0..2
+	c, err := pipeline.OpenCheckpoint(store, 0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("resume at", c.Resume())
+
+	c.Done(4) // out of order: the watermark waits for 3
+	fmt.Println("saved", store.next)
+	c.Done(3)
+	c.Flush()
+	fmt.Println("saved", store.next)
+	// Output:
+	// resume at 3
+	// saved 3
+	// saved 5
+}
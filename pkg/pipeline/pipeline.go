@@ -0,0 +1,191 @@
+// Package pipeline runs items through a chain of stages, one goroutine per
+// stage, into a sink - and can shut down without losing any of them.
+//
+// Cancelling a pipeline's context is the quick way to stop it, but every
+// item between stages at that moment is dropped. Drain is the graceful way:
+//
+//   - stop accepting input: Submit returns ErrDraining from now on
+//   - close the input channel; each stage finishes the items it has, then
+//     closes its output, so the close travels down behind the last item
+//   - return once the sink has seen everything - after which nothing is
+//     processed any more
+//
+// In use:
+//
+//	p := pipeline.New(ctx, 16, sink, parse, enrich)
+//	for _, it := range input {
+//		if err := p.Submit(ctx, it); err != nil { ... }
+//	}
+//	err := p.Drain(shutdownCtx) // every accepted item has reached sink
+//
+// If Drain's context expires first, the pipeline is cancelled and the items
+// still in flight are dropped; Stats says how many. Drain returns at once
+// then, without waiting for a sink call in progress: the sink has no
+// context, so a stalled one would hold Drain past its deadline.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrDraining is returned by Submit once Drain or Stop has been called.
+var ErrDraining = errors.New("pipeline: draining, not accepting input")
+
+// Stage transforms one item. Returning false drops it (a filter).
+type Stage[T any] func(ctx context.Context, item T) (T, bool)
+
+// Stats counts what happened to the items.
+type Stats struct {
+	Accepted int64 // Submit returned nil
+	Rejected int64 // Submit returned ErrDraining
+	Filtered int64 // dropped by a stage
+	Sunk     int64 // delivered to the sink
+	Lost     int64 // dropped because the pipeline was cancelled
+}
+
+// Pipeline is a running chain of stages. Create it with New.
+type Pipeline[T any] struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	in     chan T
+
+	mu       sync.RWMutex // Submit holds it for reading while it sends on in
+	draining bool
+
+	done chan struct{} // closed when the sink goroutine has returned
+
+	accepted, rejected, filtered, sunk, lost atomic.Int64
+}
+
+// New starts the stages and the sink. Channels between them have room for
+// buffer items. Cancelling ctx is the same as Stop, except that nothing
+// waits for the goroutines to exit.
+func New[T any](ctx context.Context, buffer int, sink func(T), stages ...Stage[T]) *Pipeline[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Pipeline[T]{ctx: ctx, cancel: cancel, in: make(chan T, buffer), done: make(chan struct{})}
+
+	src := p.in
+	for _, stage := range stages {
+		out := make(chan T, buffer)
+		go p.run(stage, src, out)
+		src = out
+	}
+	go func() {
+		defer close(p.done)
+		for item := range src {
+			if ctx.Err() != nil {
+				p.lost.Add(1)
+				continue
+			}
+			sink(item)
+			p.sunk.Add(1)
+		}
+	}()
+	// After a cancel, Submits in progress give up and release the read lock,
+	// so close gets through and the stages wind down behind the input.
+	go func() {
+		<-ctx.Done()
+		p.close()
+	}()
+	return p
+}
+
+// run is one stage: it ends when in is closed and drained, and only then
+// closes out. After a cancel it keeps reading, to count what it drops, so
+// that every stage still closes in order.
+func (p *Pipeline[T]) run(stage Stage[T], in <-chan T, out chan<- T) {
+	defer close(out)
+	for item := range in {
+		if p.ctx.Err() != nil {
+			p.lost.Add(1)
+			continue
+		}
+		v, keep := stage(p.ctx, item)
+		if !keep {
+			p.filtered.Add(1)
+			continue
+		}
+		select {
+		case out <- v:
+		case <-p.ctx.Done():
+			p.lost.Add(1)
+		}
+	}
+}
+
+// Submit sends item into the pipeline, waiting while it is full. It returns
+// ErrDraining after Drain or Stop, or ctx's error if ctx is done first.
+func (p *Pipeline[T]) Submit(ctx context.Context, item T) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.draining {
+		p.rejected.Add(1)
+		return ErrDraining
+	}
+	select {
+	case p.in <- item:
+		p.accepted.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.ctx.Done():
+		p.rejected.Add(1)
+		return ErrDraining
+	}
+}
+
+// close stops accepting input. It waits for Submits in progress, which is
+// why it is safe to close the input channel afterwards.
+func (p *Pipeline[T]) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.draining {
+		p.draining = true
+		close(p.in)
+	}
+}
+
+// Drain stops accepting input and waits until every accepted item has gone
+// through the stages and the sink. If ctx is done first, it cancels the
+// pipeline and returns ctx's error. It does not wait for the sink then: a
+// sink call in progress runs to its end, and the goroutines exit, dropping
+// the remaining items, as soon as it returns.
+func (p *Pipeline[T]) Drain(ctx context.Context) error {
+	// close waits for Submits blocked on a full pipeline, and with a stalled
+	// sink they never get through. So it runs on its own: if ctx expires
+	// first, cancel releases those Submits and close finishes. done is
+	// closed only after the input is, so it cannot fire before close.
+	go p.close()
+	select {
+	case <-p.done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// Stop cancels the pipeline at once: items in flight are dropped. It
+// returns when all of the pipeline's goroutines have exited, so it waits
+// for a sink call in progress.
+func (p *Pipeline[T]) Stop() {
+	p.cancel()
+	p.close()
+	<-p.done
+}
+
+// Stats returns the counts so far. After Stop, or a Drain that returned
+// nil, they are final, and Accepted = Filtered + Sunk + Lost.
+func (p *Pipeline[T]) Stats() Stats {
+	return Stats{
+		Accepted: p.accepted.Load(),
+		Rejected: p.rejected.Load(),
+		Filtered: p.filtered.Load(),
+		Sunk:     p.sunk.Load(),
+		Lost:     p.lost.Load(),
+	}
+}
@@ -0,0 +1,180 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func double(_ context.Context, n int) (int, bool) { return 2 * n, true }
+
+func dropMultiplesOf3(_ context.Context, n int) (int, bool) { return n, n%3 != 0 }
+
+// TestDrainLosesNothing races submitters against Drain: every item a Submit
+// accepted must reach the sink exactly once, and the sink must not run once
+// Drain has returned.
+func TestDrainLosesNothing(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu      sync.Mutex
+		got     = map[int]int{}
+		drained atomic.Bool
+		late    atomic.Int64
+		busy    = make(chan struct{}) // closed when the sink has seen 100 items
+	)
+	p := New(ctx, 2, func(n int) {
+		if drained.Load() {
+			late.Add(1)
+		}
+		mu.Lock()
+		got[n]++
+		if len(got) == 100 {
+			close(busy)
+		}
+		mu.Unlock()
+	}, double, dropMultiplesOf3)
+
+	const submitters = 8
+	accepted := make([][]int, submitters)
+	var wg sync.WaitGroup
+	for s := range submitters {
+		wg.Go(func() {
+			for i := 0; ; i++ {
+				n := s*1_000_000 + i
+				err := p.Submit(ctx, n)
+				if errors.Is(err, ErrDraining) {
+					return
+				}
+				if err != nil {
+					t.Errorf("Submit: %v", err)
+					return
+				}
+				accepted[s] = append(accepted[s], n)
+			}
+		})
+	}
+	<-busy // Drain with the pipeline full and every submitter running
+	if err := p.Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	drained.Store(true)
+	wg.Wait()
+
+	var total, filtered int
+	for _, ns := range accepted {
+		for _, n := range ns {
+			total++
+			want := 1
+			if (2*n)%3 == 0 {
+				want = 0
+				filtered++
+			}
+			if got[2*n] != want {
+				t.Errorf("item %d reached the sink %d times, want %d", n, got[2*n], want)
+			}
+		}
+	}
+	if len(got) != total-filtered {
+		t.Errorf("sink saw %d distinct items, want %d", len(got), total-filtered)
+	}
+	if err := p.Submit(ctx, -1); !errors.Is(err, ErrDraining) {
+		t.Errorf("Submit after Drain = %v, want ErrDraining", err)
+	}
+	if n := late.Load(); n > 0 {
+		t.Errorf("sink ran %d times after Drain returned", n)
+	}
+
+	st := p.Stats()
+	if st.Accepted != int64(total) || st.Filtered != int64(filtered) || st.Sunk != int64(total-filtered) || st.Lost != 0 {
+		t.Errorf("Stats = %+v, want Accepted %d, Filtered %d, Sunk %d, Lost 0", st, total, filtered, total-filtered)
+	}
+}
+
+// submitting is a context that reports when Submit has reached its select:
+// Submit holds the read lock by then, so it is blocked on a full pipeline.
+type submitting struct {
+	context.Context
+	once    sync.Once
+	waiting chan struct{}
+}
+
+func (c *submitting) Done() <-chan struct{} {
+	c.once.Do(func() { close(c.waiting) })
+	return c.Context.Done()
+}
+
+// TestDrainReturnsWithStalledSink fills the pipeline behind a sink that
+// never finishes, with a Submit blocked on the full input. Once Drain's
+// context expires, the blocked Submit must be released and Drain must
+// return at once, while the sink is still stalled: it used to wait for the
+// Submit, and then for the sink, forever.
+func TestDrainReturnsWithStalledSink(t *testing.T) {
+	release := make(chan struct{})
+	p := New(context.Background(), 1, func(int) { <-release }, double)
+
+	// One item in the sink, one per channel and one in the stage fill the
+	// pipeline for good: the sink never takes another.
+	for i := range 4 {
+		if err := p.Submit(context.Background(), i); err != nil {
+			t.Fatalf("Submit %d: %v", i, err)
+		}
+	}
+	sctx := &submitting{Context: context.Background(), waiting: make(chan struct{})}
+	blocked := make(chan error, 1)
+	go func() { blocked <- p.Submit(sctx, 4) }()
+	<-sctx.waiting
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	drained := make(chan error, 1)
+	go func() { drained <- p.Drain(ctx) }()
+
+	select {
+	case err := <-drained:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Drain = %v, want DeadlineExceeded", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Drain still waiting for the stalled sink after its context expired")
+	}
+	if err := <-blocked; !errors.Is(err, ErrDraining) {
+		t.Errorf("blocked Submit = %v, want ErrDraining", err)
+	}
+	select {
+	case <-p.done:
+		t.Fatal("the sink returned without being released")
+	default:
+	}
+
+	close(release) // the sink returns and the goroutines exit, dropping the rest
+	<-p.done
+	st := p.Stats()
+	if st.Accepted != 4 || st.Rejected != 1 || st.Sunk != 1 || st.Lost != 3 {
+		t.Errorf("Stats = %+v, want Accepted 4, Rejected 1, Sunk 1, Lost 3", st)
+	}
+}
+
+// TestCancelStopsPipeline checks that cancelling New's context closes the
+// input, so every goroutine exits without a Drain or Stop.
+func TestCancelStopsPipeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx, 4, func(int) {}, double, double)
+	if err := p.Submit(ctx, 1); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	cancel()
+	select {
+	case <-p.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("pipeline goroutines still running after cancel")
+	}
+	if err := p.Submit(context.Background(), 2); !errors.Is(err, ErrDraining) {
+		t.Errorf("Submit after cancel = %v, want ErrDraining", err)
+	}
+	if st := p.Stats(); st.Sunk+st.Lost != 1 {
+		t.Errorf("Stats = %+v, want Sunk+Lost 1", st)
+	}
+}
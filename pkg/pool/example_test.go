@@ -0,0 +1,60 @@
+package pool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/pool"
+)
+
+type conn struct {
+	id     int
+	broken bool
+}
+
+func Example() {
+	var dialed int
+	p := pool.New(pool.Config[*conn]{
+		New: func(context.Context) (*conn, error) {
+			dialed++
+			return &conn{id: dialed}, nil
+		},
+		Validate: func(c *conn) error {
+			if c.broken {
+				return errors.New("stale")
+			}
+			return nil
+		},
+		Finalize: func(c *conn) { fmt.Println("closing conn", c.id) },
+		MaxIdle:  1,
+	})
+	ctx := context.Background()
+
+	a, _ := p.Get(ctx)
+	b, _ := p.Get(ctx)
+	p.Put(a) // kept
+	p.Put(b) // over MaxIdle: finalized
+
+	c, _ := p.Get(ctx) // conn 1 again
+	fmt.Println("got conn", c.id)
+	c.broken = true // went stale while idle
+	p.Put(c)
+	d, _ := p.Get(ctx) // conn 1 fails Validate, a new one is dialed
+	fmt.Println("got conn", d.id)
+	p.Put(d)
+
+	p.Close()
+	_, err := p.Get(ctx)
+	fmt.Println(err)
+	st := p.Stats()
+	fmt.Printf("created %d, reused %d, invalid %d, finalized %d\n", st.Created, st.Reused, st.Invalid, st.Finalized)
+	// Output:
+	// closing conn 2
+	// got conn 1
+	// closing conn 1
+	// got conn 3
+	// closing conn 3
+	// pool: closed
+	// created 3, reused 1, invalid 1, finalized 3
+}
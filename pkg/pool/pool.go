@@ -0,0 +1,223 @@
+// Package pool keeps idle objects that are expensive to create - network
+// connections, sessions, decoders with large tables - for reuse, with the
+// control sync.Pool deliberately doesn't offer.
+//
+// sync.Pool is a cache for garbage: the GC may empty it at any time,
+// nothing is told when an object is dropped, and there is no way to say how
+// many to keep. That is right for buffers and wrong for a connection, which
+// must be closed rather than forgotten, may have gone stale while it sat
+// idle, and costs a socket on both ends for as long as it is kept. Pool
+// differs in each of those points:
+//
+//   - exactly MaxIdle objects are kept; a Put beyond that finalizes the
+//     object instead of keeping it, and the GC never takes one away
+//   - Validate runs on Get, so an object that broke or aged while idle is
+//     finalized and the next one tried, instead of being handed out
+//   - Reset runs on Put, so the next user gets a clean object
+//   - Close finalizes every idle object, and every object Put after it
+//   - Stats counts what happened: created, reused, discarded, finalized
+//
+// In use:
+//
+//	p := pool.New(pool.Config[*Conn]{
+//		New:      func(ctx context.Context) (*Conn, error) { return dial(ctx, addr) },
+//		Validate: func(c *Conn) error { return c.Ping() },
+//		Finalize: func(c *Conn) { c.Close() },
+//		MaxIdle:  4,
+//	})
+//	defer p.Close()
+//	c, err := p.Get(ctx)
+//	if err != nil { ... }
+//	defer p.Put(c) // or p.Discard(c) if it broke while in use
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned by Get after Close.
+var ErrClosed = errors.New("pool: closed")
+
+// DefaultMaxIdle is the number of idle objects kept when Config.MaxIdle is
+// zero.
+const DefaultMaxIdle = 2
+
+// Config describes the objects a Pool manages. Only New is required; the
+// hooks are called without the pool's lock held, so they may be slow (a
+// network round trip) and must be safe for concurrent use.
+type Config[T any] struct {
+	// New creates an object when no idle one is available.
+	New func(ctx context.Context) (T, error)
+	// Reset, if set, is called by Put before the object is kept.
+	Reset func(T)
+	// Validate, if set, is called by Get on an idle object before handing
+	// it out. If it returns an error the object is finalized and Get tries
+	// the next idle one, or creates one.
+	Validate func(T) error
+	// Finalize, if set, is called on every object the pool lets go of: over
+	// MaxIdle, failed Validate, Discard and Close.
+	Finalize func(T)
+	// MaxIdle is the most idle objects kept: DefaultMaxIdle if zero, none if
+	// negative.
+	MaxIdle int
+}
+
+// Stats is a snapshot of a Pool's counters.
+type Stats struct {
+	Gets      int64 // successful Gets
+	Created   int64 // objects made by New
+	Reused    int64 // Gets served by an idle object
+	Invalid   int64 // idle objects that failed Validate
+	Finalized int64 // objects passed to Finalize, for any reason
+	Idle      int   // objects in the pool now
+	InUse     int64 // objects handed out by Get and not yet Put or Discarded
+}
+
+// Pool is a pool of T. Create it with New; it is safe for concurrent use.
+type Pool[T any] struct {
+	cfg     Config[T]
+	maxIdle int
+
+	mu     sync.Mutex
+	idle   []T // a stack: the most recently used object is reused first
+	closed bool
+
+	gets, created, reused, invalid, finalized, inUse atomic.Int64
+}
+
+// New returns an empty pool. It panics if cfg.New is nil.
+func New[T any](cfg Config[T]) *Pool[T] {
+	if cfg.New == nil {
+		panic("pool: Config.New is nil")
+	}
+	maxIdle := cfg.MaxIdle
+	switch {
+	case maxIdle == 0:
+		maxIdle = DefaultMaxIdle
+	case maxIdle < 0:
+		maxIdle = 0
+	}
+	return &Pool[T]{cfg: cfg, maxIdle: maxIdle}
+}
+
+// Get returns a valid idle object, or a new one from Config.New. The caller
+// owns it until it calls Put or Discard.
+func (p *Pool[T]) Get(ctx context.Context) (T, error) {
+	if err := ctx.Err(); err != nil {
+		var zero T
+		return zero, err
+	}
+	for {
+		v, ok, err := p.popIdle()
+		if err != nil {
+			return v, err
+		}
+		if !ok {
+			break
+		}
+		if p.cfg.Validate != nil {
+			if err := p.cfg.Validate(v); err != nil {
+				p.invalid.Add(1)
+				p.finalize(v)
+				continue
+			}
+		}
+		p.reused.Add(1)
+		p.handOut()
+		return v, nil
+	}
+
+	v, err := p.cfg.New(ctx)
+	if err != nil {
+		return v, err
+	}
+	p.created.Add(1)
+	p.handOut()
+	return v, nil
+}
+
+// popIdle takes the most recently used idle object, if there is one.
+func (p *Pool[T]) popIdle() (v T, ok bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return v, false, ErrClosed
+	}
+	if n := len(p.idle); n > 0 {
+		v = p.idle[n-1]
+		var zero T
+		p.idle[n-1] = zero // don't keep it reachable from the backing array
+		p.idle = p.idle[:n-1]
+		return v, true, nil
+	}
+	return v, false, nil
+}
+
+func (p *Pool[T]) handOut() {
+	p.gets.Add(1)
+	p.inUse.Add(1)
+}
+
+// Put returns an object obtained from Get. It is reset and kept if there
+// is room, and finalized if the pool is full or closed.
+func (p *Pool[T]) Put(v T) {
+	p.inUse.Add(-1)
+	if p.cfg.Reset != nil {
+		p.cfg.Reset(v)
+	}
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.maxIdle {
+		p.idle = append(p.idle, v)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	p.finalize(v)
+}
+
+// Discard finalizes an object obtained from Get instead of returning it:
+// for an object that broke while in use.
+func (p *Pool[T]) Discard(v T) {
+	p.inUse.Add(-1)
+	p.finalize(v)
+}
+
+// Close finalizes the idle objects and makes later Gets fail with
+// ErrClosed. Objects in use are finalized when they are Put or Discarded.
+// Close is safe to call more than once.
+func (p *Pool[T]) Close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle, p.closed = nil, true
+	p.mu.Unlock()
+	for _, v := range idle {
+		p.finalize(v)
+	}
+}
+
+func (p *Pool[T]) finalize(v T) {
+	p.finalized.Add(1)
+	if p.cfg.Finalize != nil {
+		p.cfg.Finalize(v)
+	}
+}
+
+// Stats returns the pool's counters. They are read one at a time, so under
+// concurrent use they may be off by the operations in flight.
+func (p *Pool[T]) Stats() Stats {
+	p.mu.Lock()
+	idle := len(p.idle)
+	p.mu.Unlock()
+	return Stats{
+		Gets:      p.gets.Load(),
+		Created:   p.created.Load(),
+		Reused:    p.reused.Load(),
+		Invalid:   p.invalid.Load(),
+		Finalized: p.finalized.Load(),
+		Idle:      idle,
+		InUse:     p.inUse.Load(),
+	}
+}
@@ -0,0 +1,211 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// res is a pooled object that knows whether it was finalized; open counts
+// the ones created and not yet finalized.
+type res struct {
+	id        int64
+	stale     bool
+	data      []byte
+	finalized atomic.Int32
+}
+
+func counting(maxIdle int, open *atomic.Int64) *Pool[*res] {
+	var ids atomic.Int64
+	return New(Config[*res]{
+		New: func(context.Context) (*res, error) {
+			open.Add(1)
+			return &res{id: ids.Add(1)}, nil
+		},
+		Reset: func(r *res) { r.data = r.data[:0] },
+		Validate: func(r *res) error {
+			if r.stale {
+				return errors.New("stale")
+			}
+			return nil
+		},
+		Finalize: func(r *res) {
+			open.Add(-1)
+			r.finalized.Add(1)
+		},
+		MaxIdle: maxIdle,
+	})
+}
+
+// TestKeepsMaxIdle runs bursts of concurrent Gets and Puts: afterwards the
+// pool holds exactly MaxIdle objects, every other one has been finalized,
+// and the counters add up.
+func TestKeepsMaxIdle(t *testing.T) {
+	for _, tc := range []struct{ maxIdle, want int }{{-1, 0}, {0, DefaultMaxIdle}, {3, 3}, {16, 8}} {
+		var open atomic.Int64
+		p := counting(tc.maxIdle, &open)
+		for range 20 {
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+			for range 8 {
+				wg.Go(func() {
+					<-start
+					r, err := p.Get(context.Background())
+					if err != nil {
+						t.Errorf("Get: %v", err)
+						return
+					}
+					runtime.Gosched()
+					p.Put(r)
+				})
+			}
+			close(start)
+			wg.Wait()
+		}
+
+		st := p.Stats()
+		if st.Idle > tc.want || int64(st.Idle) != open.Load() {
+			t.Errorf("MaxIdle %d: %d idle, %d open, want at most %d idle, all of them open",
+				tc.maxIdle, st.Idle, open.Load(), tc.want)
+		}
+		if st.Gets != 160 || st.Created+st.Reused != st.Gets || st.InUse != 0 {
+			t.Errorf("MaxIdle %d: Stats = %+v, want 160 Gets = Created + Reused, none in use", tc.maxIdle, st)
+		}
+		if st.Finalized != st.Created-int64(st.Idle) {
+			t.Errorf("MaxIdle %d: %d finalized, want Created - Idle = %d", tc.maxIdle, st.Finalized, st.Created-int64(st.Idle))
+		}
+		p.Close()
+		if n := open.Load(); n != 0 {
+			t.Errorf("MaxIdle %d: %d objects still open after Close", tc.maxIdle, n)
+		}
+	}
+}
+
+// TestValidateOnGet makes every idle object stale: Get must finalize each
+// one and then create a new object rather than hand out a stale one.
+func TestValidateOnGet(t *testing.T) {
+	ctx := context.Background()
+	var open atomic.Int64
+	p := counting(4, &open)
+	var held []*res
+	for range 3 {
+		r, _ := p.Get(ctx)
+		held = append(held, r)
+	}
+	for _, r := range held {
+		r.stale = true
+		p.Put(r)
+	}
+
+	r, err := p.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if r.id != 4 {
+		t.Errorf("Get returned object %d, want a new one (4)", r.id)
+	}
+	for _, old := range held {
+		if old.finalized.Load() != 1 {
+			t.Errorf("stale object %d finalized %d times, want 1", old.id, old.finalized.Load())
+		}
+	}
+	if st := p.Stats(); st.Invalid != 3 || st.Created != 4 || st.Reused != 0 || st.Idle != 0 {
+		t.Errorf("Stats = %+v, want Invalid 3, Created 4, Reused 0, Idle 0", st)
+	}
+}
+
+// TestResetOnPut checks that a reused object comes back reset, and that the
+// most recently returned one is reused first.
+func TestResetOnPut(t *testing.T) {
+	ctx := context.Background()
+	var open atomic.Int64
+	p := counting(2, &open)
+	a, _ := p.Get(ctx)
+	b, _ := p.Get(ctx)
+	a.data = append(a.data, "half-written"...)
+	p.Put(b)
+	p.Put(a)
+
+	got, _ := p.Get(ctx)
+	if got != a {
+		t.Errorf("Get returned object %d, want the last one Put (%d)", got.id, a.id)
+	}
+	if len(got.data) != 0 {
+		t.Errorf("reused object holds %q, want it reset", got.data)
+	}
+}
+
+// TestClose checks that Close finalizes the idle objects at once, an object
+// in use when it is Put or Discarded, and makes Get fail.
+func TestClose(t *testing.T) {
+	ctx := context.Background()
+	var open atomic.Int64
+	p := counting(4, &open)
+	a, _ := p.Get(ctx)
+	b, _ := p.Get(ctx)
+	c, _ := p.Get(ctx)
+	p.Put(a)
+
+	p.Close()
+	if a.finalized.Load() != 1 || open.Load() != 2 {
+		t.Errorf("after Close: idle object finalized %d times, %d open; want 1, 2", a.finalized.Load(), open.Load())
+	}
+	p.Put(b)
+	p.Discard(c)
+	if b.finalized.Load() != 1 || c.finalized.Load() != 1 || open.Load() != 0 {
+		t.Errorf("Put and Discard after Close left %d open", open.Load())
+	}
+	if _, err := p.Get(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get after Close = %v, want ErrClosed", err)
+	}
+	p.Close() // a second Close is harmless
+	if st := p.Stats(); st.Finalized != 3 || st.InUse != 0 {
+		t.Errorf("Stats = %+v, want Finalized 3, InUse 0", st)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	ctx := context.Background()
+	var open atomic.Int64
+	p := counting(4, &open)
+	r, _ := p.Get(ctx)
+	p.Discard(r)
+	if r.finalized.Load() != 1 {
+		t.Error("Discard did not finalize the object")
+	}
+	again, _ := p.Get(ctx)
+	if again == r {
+		t.Error("a discarded object was handed out again")
+	}
+	if st := p.Stats(); st.Idle != 0 || st.InUse != 1 || st.Created != 2 {
+		t.Errorf("Stats = %+v, want Idle 0, InUse 1, Created 2", st)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	errDial := errors.New("dial failed")
+	p := New(Config[int]{New: func(context.Context) (int, error) { return 0, errDial }})
+	if _, err := p.Get(context.Background()); !errors.Is(err, errDial) {
+		t.Errorf("Get = %v, want New's error", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Get(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Get with a done context = %v, want Canceled", err)
+	}
+	if st := p.Stats(); st.Gets != 0 || st.Created != 0 || st.InUse != 0 {
+		t.Errorf("Stats = %+v after failed Gets, want all zero", st)
+	}
+}
+
+func TestNewPanicsWithoutNew(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New with a nil Config.New did not panic")
+		}
+	}()
+	New(Config[int]{})
+}
@@ -0,0 +1,59 @@
+package profiles_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/profiles"
+)
+
+func parked(started *sync.WaitGroup, release <-chan struct{}) {
+	started.Done()
+	<-release
+}
+
+func ExampleGoroutines() {
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	started.Add(3)
+	for range 3 {
+		go parked(&started, release)
+	}
+	started.Wait()
+
+	sites, err := profiles.Goroutines(0)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, s := range sites {
+		if strings.HasSuffix(s.Function, ".parked") {
+			fmt.Println(s.Count, "goroutines in parked")
+		}
+	}
+	close(release)
+	// Output:
+	// 3 goroutines in parked
+}
+
+func ExampleSince() {
+	before := []profiles.Site{
+		{Function: "main.handler", File: "/src/server.go", Line: 40, Count: 10, Delay: time.Second},
+		{Function: "main.flush", File: "/src/server.go", Line: 90, Count: 5, Delay: time.Millisecond},
+	}
+	now := []profiles.Site{
+		{Function: "main.handler", File: "/src/server.go", Line: 40, Count: 25, Delay: 4 * time.Second},
+		{Function: "main.flush", File: "/src/server.go", Line: 90, Count: 5, Delay: time.Millisecond},
+	}
+	diff := profiles.Since(before, now) // main.flush had nothing new
+	for _, s := range diff {
+		fmt.Println(s.Location(), s.Count, s.Delay)
+	}
+	count, delay := profiles.Total(diff)
+	fmt.Println("total:", count, delay)
+	// Output:
+	// main.handler (src/server.go:40) 15 3s
+	// total: 15 3s
+}
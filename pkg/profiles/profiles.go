@@ -0,0 +1,282 @@
+// Package profiles turns the runtime's goroutine, block and mutex profiles
+// into "top N" tables you can print from inside a program, without going
+// through `go tool pprof`.
+//
+// The three profiles answer different questions:
+//
+//   - goroutine: where is every goroutine RIGHT NOW? (leaks, pile-ups)
+//   - block: where did goroutines WAIT, and for how long? Covers channel
+//     operations, select, sync.Cond, WaitGroup.Wait and Mutex.Lock. Off by
+//     default: see Enable.
+//   - mutex: which Unlock calls made OTHER goroutines wait? The delay is
+//     charged to the holder that was slow to release, which is usually the
+//     critical section you need to shrink. Off by default: see Enable.
+//
+// The package reads the profiles' text form (pprof debug=1), which lists
+// every sampled stack with its counts, and attributes each sample to the
+// first frame that isn't inside the runtime, the sync package or this
+// package - the line of YOUR code that blocked.
+package profiles
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Enable turns on block and mutex profiling, sampling every event, and
+// returns a function that turns block profiling off again and restores the
+// previous mutex fraction (the runtime has no getter for the block rate).
+// Sampling every event is fine for demos; production code uses a higher
+// rate / fraction.
+func Enable() (restore func()) {
+	runtime.SetBlockProfileRate(1) // record every blocking event
+	prevFraction := runtime.SetMutexProfileFraction(1)
+	return func() {
+		runtime.SetBlockProfileRate(0)
+		runtime.SetMutexProfileFraction(prevFraction)
+	}
+}
+
+// Site is one aggregated line of code in a profile.
+type Site struct {
+	Function string
+	File     string
+	Line     int
+	Count    int64         // events (or goroutines, for the goroutine profile)
+	Delay    time.Duration // total time blocked; zero for the goroutine profile
+}
+
+func (s Site) key() string { return fmt.Sprintf("%s:%d", s.File, s.Line) }
+
+// Location formats the site as "pkg.function (dir/file.go:line)", without
+// the package's import path.
+func (s Site) Location() string {
+	fn := s.Function
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return fmt.Sprintf("%s (%s:%d)", fn, shortFile(s.File), s.Line)
+}
+
+// Block returns the n sites with the most time spent blocked since block
+// profiling was enabled. Like Mutex and Goroutines, it returns every site
+// when n <= 0.
+func Block(n int) ([]Site, error) { return top("block", n) }
+
+// Mutex returns the n sites whose critical sections made other goroutines
+// wait the longest.
+func Mutex(n int) ([]Site, error) { return top("mutex", n) }
+
+// Goroutines returns the n sites where the most goroutines currently are.
+func Goroutines(n int) ([]Site, error) { return top("goroutine", n) }
+
+func top(profile string, n int) ([]Site, error) {
+	p := pprof.Lookup(profile)
+	if p == nil {
+		return nil, fmt.Errorf("profiles: no %q profile", profile)
+	}
+	var buf bytes.Buffer
+	if err := p.WriteTo(&buf, 1); err != nil {
+		return nil, err
+	}
+	sites, err := parse(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("profiles: parsing %s profile: %w", profile, err)
+	}
+	if n > 0 && len(sites) > n {
+		sites = sites[:n]
+	}
+	return sites, nil
+}
+
+// Since returns what happened between two snapshots of the same cumulative
+// profile (block or mutex): each site's counts in now minus those in
+// before. Sites with nothing new are dropped. Pass complete snapshots
+// (n <= 0); a site cut from before would count its whole history as new.
+func Since(before, now []Site) []Site {
+	old := make(map[string]Site, len(before))
+	for _, s := range before {
+		old[s.key()] = s
+	}
+	var diff []Site
+	for _, s := range now {
+		if o, ok := old[s.key()]; ok {
+			s.Count -= o.Count
+			s.Delay -= o.Delay
+		}
+		if s.Count > 0 || s.Delay > 0 {
+			diff = append(diff, s)
+		}
+	}
+	sortSites(diff)
+	return diff
+}
+
+// Total sums the events and delay of sites.
+func Total(sites []Site) (count int64, delay time.Duration) {
+	for _, s := range sites {
+		count += s.Count
+		delay += s.Delay
+	}
+	return count, delay
+}
+
+// parse reads a debug=1 profile. Each sample is a header line
+//
+//	<cycles> <count> @ 0x... 0x...     (block, mutex)
+//	<count> @ 0x... 0x...              (goroutine)
+//
+// followed by "#\t<pc>\t<func>+0x..\t<file>:<line>" frame lines.
+func parse(r io.Reader) ([]Site, error) {
+	var cyclesPerSecond float64
+	bySite := make(map[string]*Site)
+
+	var cycles, count int64
+	attributed := true // the current sample already has its site
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "cycles/second="):
+			v, err := strconv.ParseFloat(strings.TrimPrefix(line, "cycles/second="), 64)
+			if err != nil {
+				return nil, err
+			}
+			cyclesPerSecond = v
+
+		case strings.Contains(line, " @ "):
+			fields := strings.Fields(line[:strings.Index(line, " @ ")])
+			var err error
+			switch len(fields) {
+			case 1: // goroutine profile
+				cycles = 0
+				count, err = strconv.ParseInt(fields[0], 10, 64)
+			case 2:
+				cycles, err = strconv.ParseInt(fields[0], 10, 64)
+				if err == nil {
+					count, err = strconv.ParseInt(fields[1], 10, 64)
+				}
+			default:
+				err = fmt.Errorf("unexpected sample line %q", line)
+			}
+			if err != nil {
+				return nil, err
+			}
+			attributed = false
+
+		case strings.HasPrefix(line, "#\t") && !attributed:
+			// "#\t0x4df0d9\tmain.main.func1+0x19\t\t/tmp/main.go:5"
+			parts := strings.Fields(line)
+			if len(parts) < 4 {
+				continue
+			}
+			fn, _, _ := strings.Cut(parts[2], "+")
+			if internal(fn) {
+				continue
+			}
+			file, lineNo := splitFileLine(parts[3])
+			site := Site{Function: fn, File: file, Line: lineNo}
+			s, ok := bySite[site.key()]
+			if !ok {
+				s = &site
+				bySite[site.key()] = s
+			}
+			s.Count += count
+			if cyclesPerSecond > 0 {
+				s.Delay += time.Duration(float64(cycles) / cyclesPerSecond * float64(time.Second))
+			}
+			attributed = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	sites := make([]Site, 0, len(bySite))
+	for _, s := range bySite {
+		sites = append(sites, *s)
+	}
+	sortSites(sites)
+	return sites, nil
+}
+
+// sortSites orders sites by delay, then count, biggest first.
+func sortSites(sites []Site) {
+	slices.SortFunc(sites, func(a, b Site) int {
+		if c := cmp.Compare(b.Delay, a.Delay); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Location(), b.Location())
+	})
+}
+
+// internal reports whether fn belongs to the machinery that does the
+// blocking rather than the code that asked for it.
+func internal(fn string) bool {
+	for _, prefix := range []string{"runtime.", "runtime/", "sync.", "internal/", "github.com/mintecr7/concurrency-with-go/pkg/profiles."} {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitFileLine(s string) (string, int) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return s, 0
+	}
+	n, _ := strconv.Atoi(s[i+1:])
+	return s[:i], n
+}
+
+// shortFile keeps the last two path elements: enough to find the file in
+// the repo, short enough for a table.
+func shortFile(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+	return strings.Join(parts, "/")
+}
+
+// Print writes sites as a table. Delay columns are left out when every site
+// has zero delay (the goroutine profile).
+func Print(w io.Writer, title string, sites []Site) {
+	fmt.Fprintf(w, "%s\n", title)
+	if len(sites) == 0 {
+		fmt.Fprintln(w, "  (no samples)")
+		return
+	}
+	withDelay := slices.ContainsFunc(sites, func(s Site) bool { return s.Delay > 0 })
+
+	tw := tabwriter.NewWriter(w, 0, 1, 2, ' ', 0)
+	if withDelay {
+		fmt.Fprintln(tw, "  Delay\tEvents\tAvg\tWhere")
+	} else {
+		fmt.Fprintln(tw, "  Count\tWhere")
+	}
+	for _, s := range sites {
+		if withDelay {
+			avg := s.Delay / time.Duration(max(s.Count, 1))
+			fmt.Fprintf(tw, "  %v\t%d\t%v\t%s\n", s.Delay.Round(time.Microsecond), s.Count, avg.Round(time.Microsecond), s.Location())
+		} else {
+			fmt.Fprintf(tw, "  %d\t%s\n", s.Count, s.Location())
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,41 @@
+package progress_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/progress"
+)
+
+func ExampleStart() {
+	var out strings.Builder
+	r := progress.Start(context.Background(), progress.Config{
+		Total: 100,
+		Unit:  "files",
+		Every: time.Hour, // no periodic line in this example, only the final one
+		Out:   &out,
+	})
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Go(func() {
+			if i%25 == 0 {
+				r.Fail(1)
+				return
+			}
+			r.Add(1)
+		})
+	}
+	wg.Wait()
+	r.Stop()
+
+	// The final line goes on with the elapsed time and rate.
+	line, _, _ := strings.Cut(out.String(), " in ")
+	fmt.Println(line)
+	fmt.Println(strings.HasSuffix(strings.TrimSpace(out.String()), "4 failed"))
+	// Output:
+	// done: 100/100 files
+	// true
+}
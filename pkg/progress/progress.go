@@ -0,0 +1,222 @@
+// Package progress reports how far a batch of concurrent work has got,
+// without the workers printing anything.
+//
+// A fmt.Printf per finished item is a "Printf storm": with 100,000 items
+// the terminal becomes the bottleneck, the lines from different goroutines
+// interleave, and the one number anybody wants - how much is left - is
+// nowhere. Instead, workers call Add, an atomic increment that never
+// blocks. One reporter goroutine wakes up every Config.Every, reads the
+// counters and prints a single line: count, percentage, rate and ETA.
+//
+//	r := progress.Start(ctx, progress.Config{Total: int64(len(files)), Unit: "files"})
+//	defer r.Stop()
+//	for _, f := range files {
+//		wg.Go(func() { process(f); r.Add(1) })
+//	}
+//	wg.Wait()
+//
+// The reporter stops by itself when the count reaches Total or ctx is
+// cancelled, and always prints a final line. Stop ends it early and waits
+// for that line, so nothing is printed after Stop returns.
+package progress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config describes what is counted and how it is shown.
+type Config struct {
+	Total int64         // expected items; 0 if unknown (no percentage or ETA)
+	Unit  string        // what an item is called; "items" if empty
+	Every time.Duration // time between lines; 200ms if zero
+	Out   io.Writer     // where lines go; os.Stderr if nil
+
+	// Extra, if set, is called by the reporter and its result appended to
+	// every line - for counters of its own ("3 retries"). It runs on the
+	// reporter goroutine, so it must be safe for concurrent use.
+	Extra func() string
+}
+
+// Reporter is a running reporter goroutine. Create it with Start.
+type Reporter struct {
+	cfg   Config
+	start time.Time
+	tty   bool // redraw one line with \r instead of printing new ones
+
+	done   atomic.Int64
+	failed atomic.Int64
+
+	complete     chan struct{} // closed when done reaches Total
+	completeOnce sync.Once
+	stop         chan struct{}
+	stopOnce     sync.Once
+	exited       chan struct{} // closed when the goroutine has printed its last line
+}
+
+// Start starts the reporter goroutine.
+func Start(ctx context.Context, cfg Config) *Reporter {
+	if cfg.Unit == "" {
+		cfg.Unit = "items"
+	}
+	if cfg.Every <= 0 {
+		cfg.Every = 200 * time.Millisecond
+	}
+	if cfg.Out == nil {
+		cfg.Out = os.Stderr
+	}
+	r := &Reporter{
+		cfg:      cfg,
+		start:    time.Now(),
+		tty:      isTerminal(cfg.Out),
+		complete: make(chan struct{}),
+		stop:     make(chan struct{}),
+		exited:   make(chan struct{}),
+	}
+	go r.run(ctx)
+	return r
+}
+
+// Add records n more items done.
+func (r *Reporter) Add(n int64) {
+	if r.done.Add(n) >= r.cfg.Total && r.cfg.Total > 0 {
+		r.completeOnce.Do(func() { close(r.complete) })
+	}
+}
+
+// Fail records n items that failed. They count towards Total, since they
+// are finished too.
+func (r *Reporter) Fail(n int64) {
+	r.failed.Add(n)
+	r.Add(n)
+}
+
+// Stop stops the reporter and waits for its final line. It is safe to call
+// more than once, and after the reporter stopped by itself.
+func (r *Reporter) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.exited
+}
+
+func (r *Reporter) run(ctx context.Context) {
+	defer close(r.exited)
+	t := time.NewTicker(r.cfg.Every)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			r.print(r.line(), false)
+		case <-r.complete:
+			r.print(r.final("done"), true)
+			return
+		case <-r.stop:
+			r.print(r.final(r.ending("stopped")), true)
+			return
+		case <-ctx.Done():
+			r.print(r.final(r.ending(ctx.Err().Error())), true)
+			return
+		}
+	}
+}
+
+// line is the periodic report: count, percentage, rate, ETA.
+func (r *Reporter) line() string {
+	done := r.done.Load()
+	elapsed := time.Since(r.start)
+	rate := float64(done) / elapsed.Seconds()
+	var b strings.Builder
+	if r.cfg.Total > 0 {
+		fmt.Fprintf(&b, "%d/%d %s (%3.0f%%)", done, r.cfg.Total, r.cfg.Unit, 100*float64(done)/float64(r.cfg.Total))
+	} else {
+		fmt.Fprintf(&b, "%d %s", done, r.cfg.Unit)
+	}
+	fmt.Fprintf(&b, "  %s/s", formatRate(rate))
+	if r.cfg.Total > 0 && done > 0 && done < r.cfg.Total {
+		eta := time.Duration(float64(r.cfg.Total-done) / rate * float64(time.Second))
+		fmt.Fprintf(&b, "  ETA %v", roundETA(eta))
+	}
+	r.extra(&b)
+	return b.String()
+}
+
+// ending is "done" if every item is, and otherwise why the reporter stopped
+// early - a Stop right after the last Add is still a completion.
+func (r *Reporter) ending(early string) string {
+	if r.cfg.Total > 0 && r.done.Load() >= r.cfg.Total {
+		return "done"
+	}
+	return early
+}
+
+// final is the last line: how it ended, and the totals.
+func (r *Reporter) final(how string) string {
+	done := r.done.Load()
+	elapsed := time.Since(r.start)
+	var b strings.Builder
+	if r.cfg.Total > 0 {
+		fmt.Fprintf(&b, "%s: %d/%d %s", how, done, r.cfg.Total, r.cfg.Unit)
+	} else {
+		fmt.Fprintf(&b, "%s: %d %s", how, done, r.cfg.Unit)
+	}
+	fmt.Fprintf(&b, " in %v (%s/s)", elapsed.Round(time.Millisecond), formatRate(float64(done)/elapsed.Seconds()))
+	r.extra(&b)
+	return b.String()
+}
+
+func (r *Reporter) extra(b *strings.Builder) {
+	if f := r.failed.Load(); f > 0 {
+		fmt.Fprintf(b, "  %d failed", f)
+	}
+	if r.cfg.Extra != nil {
+		b.WriteString("  ")
+		b.WriteString(r.cfg.Extra())
+	}
+}
+
+// print writes one line. On a terminal it overwrites the previous one, and
+// the final line ends with a newline.
+func (r *Reporter) print(s string, last bool) {
+	switch {
+	case !r.tty:
+		fmt.Fprintln(r.cfg.Out, s)
+	case last:
+		fmt.Fprintf(r.cfg.Out, "\r%s\033[K\n", s)
+	default:
+		fmt.Fprintf(r.cfg.Out, "\r%s\033[K", s) // \033[K clears the rest of the old line
+	}
+}
+
+// formatRate drops the decimal once it no longer matters.
+func formatRate(r float64) string {
+	if r >= 100 {
+		return fmt.Sprintf("%.0f", r)
+	}
+	return fmt.Sprintf("%.1f", r)
+}
+
+// roundETA keeps two significant figures or so: an ETA is a guess.
+func roundETA(d time.Duration) time.Duration {
+	switch {
+	case d >= 10*time.Second:
+		return d.Round(time.Second)
+	case d >= time.Second:
+		return d.Round(100 * time.Millisecond)
+	}
+	return d.Round(10 * time.Millisecond)
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,44 @@
+package racereport_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/racereport"
+)
+
+const report = `counter = 1998
+==================
+WARNING: DATA RACE
+Read at 0x000000640698 by main goroutine:
+  main.runRaceCondition()
+      /src/ch01_introduction/race.go:50 +0x3d
+
+Previous write at 0x000000640698 by goroutine 9:
+  main.increment()
+      /src/ch01_introduction/race.go:33 +0x3c
+
+Goroutine 9 (finished) created at:
+  main.runRaceCondition()
+      /src/ch01_introduction/race.go:39 +0x27
+==================
+Found 1 data race(s)
+`
+
+func ExampleParse() {
+	races, err := racereport.Parse(strings.NewReader(report))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, r := range races {
+		for _, a := range r.Accesses {
+			fmt.Printf("%s by %s at %s\n", a.Op, a.Goroutine, a.Frame.Location())
+		}
+		fmt.Println("goroutine 9 created at", r.Created["goroutine 9"].Location())
+	}
+	// Output:
+	// Read by main goroutine at race.go:50 (main.runRaceCondition)
+	// Previous write by goroutine 9 at race.go:33 (main.increment)
+	// goroutine 9 created at race.go:39 (main.runRaceCondition)
+}
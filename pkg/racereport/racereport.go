@@ -0,0 +1,147 @@
+// Package racereport parses the reports the race detector writes to stderr
+// into structured data.
+//
+// A -race build that hits a data race prints a block like this (trimmed):
+//
+//	==================
+//	WARNING: DATA RACE
+//	Read at 0x000000640698 by main goroutine:
+//	  main.runRaceCondition()
+//	      /src/ch01_introduction/race.go:50 +0x3d
+//
+//	Previous write at 0x000000640698 by goroutine 9:
+//	  main.increment()
+//	      /src/ch01_introduction/race.go:33 +0x3c
+//
+//	Goroutine 9 (finished) created at:
+//	  main.runRaceCondition()
+//	      /src/ch01_introduction/race.go:39 +0x27
+//	==================
+//
+// Parse turns each block into a Race: the two conflicting accesses (what,
+// where, which goroutine) and where the goroutines involved were started.
+package racereport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Access is one side of a race.
+type Access struct {
+	Op        string // "Read", "Write", "Previous write", "Atomic read"...
+	Goroutine string // "goroutine 7" or "main goroutine"
+	Frame     Frame  // innermost frame outside the runtime
+}
+
+// Frame is a function and source position.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Location returns "file.go:line (function)" with directories and the
+// package path trimmed, for tables.
+func (f Frame) Location() string {
+	if f.File == "" {
+		return "?"
+	}
+	fn := f.Function
+	if i := strings.LastIndex(fn, "/"); i >= 0 {
+		fn = fn[i+1:]
+	}
+	return fmt.Sprintf("%s:%d (%s)", filepath.Base(f.File), f.Line, fn)
+}
+
+// Race is one WARNING: DATA RACE report.
+type Race struct {
+	Accesses []Access         // normally two: the current and the previous access
+	Created  map[string]Frame // goroutine → where it was started
+}
+
+// Key identifies a race by its two source positions, so that the same race
+// reported many times can be counted once.
+func (r Race) Key() string {
+	parts := make([]string, len(r.Accesses))
+	for i, a := range r.Accesses {
+		parts[i] = fmt.Sprintf("%s %s:%d", a.Op, a.Frame.File, a.Frame.Line)
+	}
+	return strings.Join(parts, " | ")
+}
+
+var (
+	accessLine  = regexp.MustCompile(`^(.+?) at 0x[0-9a-f]+ by (main goroutine|goroutine \d+):$`)
+	createdLine = regexp.MustCompile(`^Goroutine (\d+) \(\w+\) created at:$`)
+	fileLine    = regexp.MustCompile(`^\s+(.+\.go):(\d+)`)
+)
+
+// Parse reads race detector output (other lines are ignored) and returns
+// every race in it, in order.
+func Parse(r io.Reader) ([]Race, error) {
+	var (
+		races   []Race
+		cur     *Race
+		frame   *Frame // frame being filled in: function line seen, file line next
+		pending func(Frame)
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "WARNING: DATA RACE":
+			races = append(races, Race{Created: map[string]Frame{}})
+			cur = &races[len(races)-1]
+			pending = nil
+			continue
+		case cur == nil:
+			continue
+		case strings.HasPrefix(line, "=================="):
+			cur, pending = nil, nil
+			continue
+		}
+
+		if m := accessLine.FindStringSubmatch(line); m != nil {
+			cur.Accesses = append(cur.Accesses, Access{Op: m[1], Goroutine: m[2]})
+			i := len(cur.Accesses) - 1
+			pending = func(f Frame) { cur.Accesses[i].Frame = f }
+			continue
+		}
+		if m := createdLine.FindStringSubmatch(line); m != nil {
+			g := "goroutine " + m[1]
+			pending = func(f Frame) { cur.Created[g] = f }
+			continue
+		}
+		if pending == nil {
+			continue
+		}
+
+		// Stack frames come in pairs: "  pkg.func()" then "      file:line +0x..".
+		// Keep the first pair outside the runtime and sync, so that a goroutine
+		// started by WaitGroup.Go is attributed to the caller of Go.
+		if m := fileLine.FindStringSubmatch(line); m != nil && frame != nil {
+			fmt.Sscan(m[2], &frame.Line)
+			frame.File = m[1]
+			pending(*frame)
+			frame, pending = nil, nil
+			continue
+		}
+		if fn, ok := strings.CutPrefix(line, "  "); ok && !strings.HasPrefix(fn, " ") {
+			fn = strings.TrimSuffix(fn, "()")
+			if i := strings.LastIndex(fn, "("); i > 0 && strings.HasSuffix(fn, ")") {
+				fn = fn[:i] // drop arguments
+			}
+			if strings.HasPrefix(fn, "runtime.") || strings.HasPrefix(fn, "sync.") || strings.HasPrefix(fn, "sync/atomic.") || strings.HasPrefix(fn, "internal/") {
+				frame = nil
+				continue
+			}
+			frame = &Frame{Function: fn}
+		}
+	}
+	return races, sc.Err()
+}
@@ -0,0 +1,39 @@
+package refcount_test
+
+import (
+	"fmt"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/refcount"
+)
+
+func Example() {
+	var opened int
+	db := refcount.New(refcount.Config[string]{
+		New: func() (string, error) {
+			opened++
+			return fmt.Sprintf("conn-%d", opened), nil
+		},
+		Teardown: func(c string) { fmt.Println("closing", c) },
+		// Grace 0: torn down on the last Release.
+	})
+
+	a, _ := db.Acquire() // builds conn-1
+	b, _ := db.Acquire() // shares it
+	fmt.Println(a.Value(), b.Value(), db.Stats().Refs)
+	a.Release()
+	b.Release() // the last one: conn-1 goes
+	b.Release() // a no-op
+
+	c, _ := db.Acquire() // a new lifetime
+	fmt.Println(c.Value())
+	c.Release()
+
+	st := db.Stats()
+	fmt.Println("builds:", st.Builds, "teardowns:", st.Teardowns)
+	// Output:
+	// conn-1 conn-1 2
+	// closing conn-1
+	// conn-2
+	// closing conn-2
+	// builds: 2 teardowns: 2
+}
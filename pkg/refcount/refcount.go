@@ -0,0 +1,189 @@
+// Package refcount shares one expensive resource - a connection, a
+// subprocess, a memory-mapped file - among the goroutines using it, and
+// keeps it only as long as someone does.
+//
+// A package-level sync.Once builds the resource on first use but never
+// tears it down: the connection stays open for the life of the program,
+// used or not. Closing it by hand races with whoever is about to use it.
+// Resource counts the references instead:
+//
+//   - the first Acquire builds the resource; concurrent first Acquires wait
+//     for the same build (a sync.Once per lifetime of the resource)
+//   - every Acquire returns a Ref; the resource lives while any Ref is
+//     unreleased
+//   - when the last Ref is released, the resource is torn down after a
+//     grace period - unless an Acquire comes first and takes it over, so a
+//     bursty user doesn't rebuild it on every burst
+//   - after a teardown, the next Acquire builds a new one
+//
+// In use:
+//
+//	var db = refcount.New(refcount.Config[*Conn]{
+//		New:      func() (*Conn, error) { return dial(addr) },
+//		Teardown: func(c *Conn) { c.Close() },
+//		Grace:    30 * time.Second,
+//	})
+//
+//	ref, err := db.Acquire()
+//	if err != nil { ... }
+//	defer ref.Release()
+//	ref.Value().Query(...)
+package refcount
+
+import (
+	"sync"
+	"time"
+)
+
+// Config describes how the resource is built and torn down. New is
+// required.
+type Config[T any] struct {
+	// New builds the resource. If it fails, every Acquire waiting for it
+	// gets the error and the next Acquire tries again.
+	New func() (T, error)
+	// Teardown, if set, destroys the resource once it is unreferenced and
+	// the grace period has passed. It runs without the Resource's lock, so
+	// a new resource may be built while the old one is torn down.
+	Teardown func(T)
+	// Grace is how long an unreferenced resource is kept for the next
+	// Acquire. Zero tears it down on the last Release.
+	Grace time.Duration
+}
+
+// Stats is a snapshot of a Resource's lifecycle counters.
+type Stats struct {
+	Refs      int // references held now
+	Builds    int // successful calls of New
+	Failures  int // failed calls of New
+	Teardowns int // calls of Teardown
+	Reprieves int // teardowns cancelled by an Acquire within the grace period
+}
+
+// lifetime is one built resource, from New to Teardown.
+type lifetime[T any] struct {
+	once  sync.Once
+	value T
+	err   error
+}
+
+// Resource is a reference-counted resource. Create it with New; it is safe
+// for concurrent use.
+type Resource[T any] struct {
+	cfg Config[T]
+
+	mu       sync.Mutex
+	current  *lifetime[T] // nil when there is no resource
+	refs     int
+	teardown *time.Timer // pending teardown during the grace period
+	stats    Stats
+}
+
+// New returns a Resource that builds nothing until the first Acquire. It
+// panics if cfg.New is nil.
+func New[T any](cfg Config[T]) *Resource[T] {
+	if cfg.New == nil {
+		panic("refcount: Config.New is nil")
+	}
+	return &Resource[T]{cfg: cfg}
+}
+
+// Ref is one reference to the resource. Release it when done.
+type Ref[T any] struct {
+	r        *Resource[T]
+	l        *lifetime[T]
+	released sync.Once
+}
+
+// Value returns the resource. It must not be used after Release.
+func (ref *Ref[T]) Value() T { return ref.l.value }
+
+// Release drops the reference. Releasing the same Ref again does nothing.
+func (ref *Ref[T]) Release() {
+	ref.released.Do(func() { ref.r.release(ref.l) })
+}
+
+// Acquire returns a reference to the resource, building it first if there
+// is none. If the build fails, Acquire returns its error and no reference.
+func (r *Resource[T]) Acquire() (*Ref[T], error) {
+	r.mu.Lock()
+	if r.teardown != nil { // taken over within the grace period
+		r.teardown.Stop() // if it already fired, the callback sees it lost
+		r.teardown = nil
+		r.stats.Reprieves++
+	}
+	if r.current == nil {
+		r.current = &lifetime[T]{}
+	}
+	l := r.current
+	r.refs++
+	r.mu.Unlock()
+
+	// Outside the lock: a slow New blocks only the Acquires waiting for
+	// this build, not Release or Stats.
+	l.once.Do(func() {
+		l.value, l.err = r.cfg.New()
+		r.mu.Lock()
+		if l.err != nil {
+			r.stats.Failures++
+		} else {
+			r.stats.Builds++
+		}
+		r.mu.Unlock()
+	})
+	if l.err != nil {
+		r.mu.Lock()
+		r.refs--
+		if r.current == l {
+			r.current = nil // the next Acquire builds again
+		}
+		r.mu.Unlock()
+		return nil, l.err
+	}
+	return &Ref[T]{r: r, l: l}, nil
+}
+
+func (r *Resource[T]) release(l *lifetime[T]) {
+	r.mu.Lock()
+	r.refs--
+	if r.refs > 0 || r.current != l {
+		r.mu.Unlock()
+		return
+	}
+	if r.cfg.Grace <= 0 {
+		r.current = nil
+		r.mu.Unlock()
+		r.destroy(l) // the last Release returns once it is gone
+		return
+	}
+	var t *time.Timer
+	t = time.AfterFunc(r.cfg.Grace, func() {
+		r.mu.Lock()
+		if r.teardown != t { // an Acquire took the resource over
+			r.mu.Unlock()
+			return
+		}
+		r.teardown, r.current = nil, nil
+		r.mu.Unlock()
+		r.destroy(l)
+	})
+	r.teardown = t
+	r.mu.Unlock()
+}
+
+func (r *Resource[T]) destroy(l *lifetime[T]) {
+	if r.cfg.Teardown != nil {
+		r.cfg.Teardown(l.value)
+	}
+	r.mu.Lock()
+	r.stats.Teardowns++
+	r.mu.Unlock()
+}
+
+// Stats returns the lifecycle counters.
+func (r *Resource[T]) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats
+	s.Refs = r.refs
+	return s
+}
@@ -0,0 +1,55 @@
+package replay_test
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/replay"
+)
+
+// race runs two producers against one consumer and returns what the
+// consumer received, in order. The buffer has room for both sends, so
+// neither blocks and each reaches the channel at its logged turn.
+func race(s *replay.Session) []string {
+	jobs := replay.NewChan[string](s, "jobs", 2)
+	var got []string
+	var wg sync.WaitGroup
+	wg.Add(3)
+	s.Go("producer-a", func() { defer wg.Done(); jobs.Send("a") })
+	s.Go("producer-b", func() { defer wg.Done(); jobs.Send("b") })
+	s.Go("consumer", func() {
+		defer wg.Done()
+		for range 2 {
+			v, _ := jobs.Recv()
+			got = append(got, v)
+		}
+	})
+	wg.Wait()
+	return got
+}
+
+func Example() {
+	var log bytes.Buffer
+	rec := replay.NewRecorder(&log)
+	recorded := race(rec)
+	if err := rec.Flush(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Whichever producer won the recorded run wins every replay.
+	for range 5 {
+		rep, err := replay.NewReplayer(bytes.NewReader(log.Bytes()))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if got := race(rep); fmt.Sprint(got) != fmt.Sprint(recorded) {
+			fmt.Println("replay diverged:", got, "recorded:", recorded)
+		}
+	}
+	fmt.Println(len(recorded), "values, same order in every replay")
+	// Output:
+	// 2 values, same order in every replay
+}
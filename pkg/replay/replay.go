@@ -0,0 +1,275 @@
+// Package replay records the order of channel operations in one run of a
+// program and makes a later run start them in that same order.
+//
+// A demo whose output depends on which goroutine wins a race is hard to
+// learn from: the interesting interleaving shows up once in twenty runs and
+// is gone when you look again. Wrap the demo's channels in a Chan and run
+// its goroutines with Session.Go:
+//
+//	s := replay.NewRecorder(f)            // or replay.NewReplayer(f)
+//	jobs := replay.NewChan[int](s, "jobs", 0)
+//	s.Go("producer-1", func() { jobs.Send(1) })
+//	s.Go("consumer", func() { v, _ := jobs.Recv(); ... })
+//
+// A recorder appends one JSON line per Send, Recv or Close as it starts:
+// the sequence number, the goroutine's runtime id, its actor name, the
+// channel and the operation. A replayer reads such a log back and makes
+// every operation wait at a barrier until all operations logged before it
+// have started. So the operations start in the recorded order.
+//
+// Starting in order is not quite completing in order. An operation that can
+// go ahead at once - a send with buffer room or a receiver waiting, a
+// receive with a value ready - is performed at its turn, so it reaches the
+// channel exactly where the log says. An operation that has to block is
+// granted its turn and then blocks on the channel outside the barrier, so
+// the next operation may start before it is queued. Go queues blocked
+// senders and receivers in FIFO order, so when two operations block on the
+// same channel back to back, values end up where they went in the recording
+// almost always, but not provably: the second can reach the channel first.
+// Enforcing it would need to see into the channel's wait queue, which the
+// runtime does not expose.
+//
+// Actors, not goroutine ids, tie a replay to its recording: runtime ids
+// differ from run to run, actor names do not. If an actor's next operation
+// is not the one the log expects (the program took another path), the
+// replay panics with ErrDiverged rather than hanging.
+//
+// Only operations made through Chan are ordered. A plain channel, a select
+// statement or a mutex in between is invisible to the log, and so is time:
+// replay fixes the interleaving, not the timing.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// ErrDiverged is the panic value (wrapped) when a replayed run stops
+// matching its log.
+var ErrDiverged = errors.New("replay: run diverged from the recording")
+
+// Op is a channel operation.
+type Op string
+
+const (
+	Send  Op = "send"
+	Recv  Op = "recv"
+	Close Op = "close"
+)
+
+// Event is one logged channel operation.
+type Event struct {
+	Seq       int    `json:"seq"`
+	Goroutine int64  `json:"goroutine"`
+	Actor     string `json:"actor"`
+	Chan      string `json:"chan"`
+	Op        Op     `json:"op"`
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("#%d %s: %s %s", e.Seq, e.Actor, e.Op, e.Chan)
+}
+
+// Session is one recording or one replay. Its methods are safe for
+// concurrent use.
+type Session struct {
+	mu     sync.Mutex
+	actors map[int64]string // runtime goroutine id → actor name
+
+	// Recording.
+	w      *bufio.Writer
+	events int
+	err    error // first write error
+
+	// Replaying.
+	replaying bool
+	log       []Event
+	next      int            // index of the next operation allowed to start
+	pending   map[string]int // actor → index of its next operation in log
+	turn      *sync.Cond
+}
+
+// NewRecorder returns a session that writes every operation to w.
+func NewRecorder(w io.Writer) *Session {
+	return &Session{actors: make(map[int64]string), w: bufio.NewWriter(w)}
+}
+
+// NewReplayer returns a session that enforces the order logged in r.
+func NewReplayer(r io.Reader) (*Session, error) {
+	s := &Session{actors: make(map[int64]string), replaying: true, pending: make(map[string]int)}
+	s.turn = sync.NewCond(&s.mu)
+	dec := json.NewDecoder(r)
+	for {
+		var e Event
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("replay: reading log: %w", err)
+		}
+		s.log = append(s.log, e)
+	}
+	return s, nil
+}
+
+// Replaying reports whether s enforces a recorded order.
+func (s *Session) Replaying() bool { return s.replaying }
+
+// Go runs fn in a new goroutine known to the session as actor. Actor names
+// must be unique and the same in the recording and the replay.
+func (s *Session) Go(actor string, fn func()) {
+	started := make(chan struct{})
+	go func() {
+		s.Label(actor)
+		close(started)
+		fn()
+	}()
+	<-started // the goroutine is labelled before anything can race it
+}
+
+// Label names the calling goroutine, for goroutines not started with Go
+// (typically main).
+func (s *Session) Label(actor string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actors[goroutineID()] = actor
+}
+
+// Flush writes any buffered events and returns the first error the
+// recorder ran into. It is a no-op when replaying.
+func (s *Session) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return nil
+	}
+	if err := s.w.Flush(); err != nil && s.err == nil {
+		s.err = err
+	}
+	return s.err
+}
+
+// actor returns the calling goroutine's id and actor name. Unlabelled
+// goroutines are named after their id, which only replays if ids happen to
+// match.
+func (s *Session) actor() (int64, string) {
+	id := goroutineID()
+	if name, ok := s.actors[id]; ok {
+		return id, name
+	}
+	return id, "g" + strconv.FormatInt(id, 10)
+}
+
+// do performs one operation. try attempts it without blocking and reports
+// whether it succeeded; block performs it for real.
+//
+// The attempt happens under the session lock, right where the operation is
+// logged (or granted its turn), so operations that do not have to wait hit
+// the channel in exactly the logged order. An operation that must wait is
+// logged as started and then blocks outside the lock. Two goroutines that
+// then wait on the same channel are queued in the order they reach it,
+// which is almost always, but not provably, their logged order.
+func (s *Session) do(ch string, op Op, try func() bool, block func()) {
+	s.mu.Lock()
+	id, actor := s.actor()
+	if s.replaying {
+		s.waitTurn(actor, ch, op)
+	} else {
+		s.events++
+		line, _ := json.Marshal(Event{Seq: s.events, Goroutine: id, Actor: actor, Chan: ch, Op: op})
+		if _, err := s.w.Write(append(line, '\n')); err != nil && s.err == nil {
+			s.err = err
+		}
+	}
+	done := try()
+	s.mu.Unlock()
+	if !done {
+		block()
+	}
+}
+
+// waitTurn blocks, with s.mu held, until every operation logged before
+// actor's next one has started, and then lets the following one start.
+func (s *Session) waitTurn(actor, ch string, op Op) {
+	// Find this actor's next logged operation and check it is this one.
+	i := s.pending[actor]
+	for i < len(s.log) && s.log[i].Actor != actor {
+		i++
+	}
+	if i == len(s.log) {
+		s.mu.Unlock()
+		panic(fmt.Errorf("%w: %s: %s %s was never recorded", ErrDiverged, actor, op, ch))
+	}
+	if e := s.log[i]; e.Chan != ch || e.Op != op {
+		s.mu.Unlock()
+		panic(fmt.Errorf("%w: %s: %s %s, but the log expects %v", ErrDiverged, actor, op, ch, e))
+	}
+	s.pending[actor] = i + 1
+
+	for s.next != i {
+		s.turn.Wait()
+	}
+	s.next++
+	s.turn.Broadcast()
+}
+
+// goroutineID parses the calling goroutine's id out of its stack trace
+// header ("goroutine 42 [running]:"). The runtime hides the id on purpose;
+// logging it is one of the few legitimate uses.
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	b, _, _ = bytes.Cut(b, []byte(" "))
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
+
+// Chan is a channel whose operations are logged or replayed by a Session.
+type Chan[T any] struct {
+	s    *Session
+	name string
+	ch   chan T
+}
+
+// NewChan makes a channel with the given buffer size. name identifies it in
+// the log and must be the same in the recording and the replay.
+func NewChan[T any](s *Session, name string, size int) *Chan[T] {
+	return &Chan[T]{s: s, name: name, ch: make(chan T, size)}
+}
+
+// Send sends v, like c <- v.
+func (c *Chan[T]) Send(v T) {
+	c.s.do(c.name, Send, func() bool {
+		select {
+		case c.ch <- v:
+			return true
+		default:
+			return false
+		}
+	}, func() { c.ch <- v })
+}
+
+// Recv receives a value, like v, ok := <-c.
+func (c *Chan[T]) Recv() (v T, ok bool) {
+	c.s.do(c.name, Recv, func() bool {
+		select {
+		case v, ok = <-c.ch:
+			return true
+		default:
+			return false
+		}
+	}, func() { v, ok = <-c.ch })
+	return v, ok
+}
+
+// Close closes the channel.
+func (c *Chan[T]) Close() {
+	c.s.do(c.name, Close, func() bool { close(c.ch); return true }, nil)
+}
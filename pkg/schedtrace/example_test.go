@@ -0,0 +1,27 @@
+package schedtrace_test
+
+import (
+	"fmt"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/schedtrace"
+)
+
+func ExampleParse() {
+	s, err := schedtrace.Parse("SCHED 104ms: gomaxprocs=4 idleprocs=0 threads=5 spinningthreads=0 " +
+		"needspinning=1 idlethreads=0 runqueue=71 [ 10 0 3 14 ] schedticks=[ 1 2 3 4 ]")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(s.At, s.GOMAXPROCS, s.Threads, s.GlobalRunQueue, s.LocalRunQueues)
+	fmt.Println("runnable:", s.Runnable(), "needspinning:", s.Fields["needspinning"])
+	fmt.Printf("|%-10s|\n", schedtrace.Bar(s.Runnable(), 196, 10))
+
+	_, err = schedtrace.Parse("hello from the program")
+	fmt.Println(err)
+	// Output:
+	// 104ms 4 5 71 [10 0 3 14]
+	// runnable: 98 needspinning: 1
+	// |█████     |
+	// schedtrace: not a SCHED line
+}
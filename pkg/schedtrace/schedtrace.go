@@ -0,0 +1,191 @@
+// Package schedtrace runs a program with GODEBUG=schedtrace and turns the
+// scheduler's periodic status lines into structs.
+//
+// With GODEBUG=schedtrace=X the Go runtime prints one line to stderr every
+// X milliseconds:
+//
+//	SCHED 104ms: gomaxprocs=4 idleprocs=0 threads=5 spinningthreads=0
+//	    needspinning=1 idlethreads=0 runqueue=71 [ 10 0 3 14 ] schedticks=[ ... ]
+//
+// (one line in reality). The fields describe the G-M-P scheduler at that
+// instant:
+//
+//   - gomaxprocs / idleprocs: Ps (logical processors) in total / with nothing to run
+//   - threads / idlethreads:  Ms (OS threads) created / parked for reuse
+//   - spinningthreads:        Ms busy-looking for work to steal
+//   - runqueue:               runnable Gs in the GLOBAL run queue
+//   - [ 10 0 3 14 ]:          runnable Gs in each P's LOCAL run queue
+//
+// Reading them live is the easiest way to SEE goroutines queueing for Ps
+// and the runtime creating threads when goroutines block in syscalls.
+package schedtrace
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sample is one parsed schedtrace line.
+type Sample struct {
+	At              time.Duration // time since the program started
+	GOMAXPROCS      int
+	IdleProcs       int
+	Threads         int
+	SpinningThreads int
+	IdleThreads     int
+	GlobalRunQueue  int
+	LocalRunQueues  []int // one entry per P
+
+	// Fields holds every key=value pair on the line, including ones this
+	// package doesn't know about (the format grows between Go releases).
+	Fields map[string]int
+}
+
+// Runnable returns the total number of runnable goroutines: the global run
+// queue plus every local one.
+func (s Sample) Runnable() int {
+	n := s.GlobalRunQueue
+	for _, q := range s.LocalRunQueues {
+		n += q
+	}
+	return n
+}
+
+// ErrNotSchedLine is returned by Parse for lines that are not schedtrace
+// summary lines (program output, scheddetail lines, ...).
+var ErrNotSchedLine = errors.New("schedtrace: not a SCHED line")
+
+// Parse parses one "SCHED ..." line.
+func Parse(line string) (Sample, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "SCHED ")
+	if !ok {
+		return Sample{}, ErrNotSchedLine
+	}
+	stamp, rest, ok := strings.Cut(rest, ": ")
+	if !ok {
+		return Sample{}, ErrNotSchedLine
+	}
+	at, err := time.ParseDuration(stamp)
+	if err != nil {
+		return Sample{}, fmt.Errorf("schedtrace: bad timestamp %q: %w", stamp, err)
+	}
+
+	s := Sample{At: at, Fields: make(map[string]int)}
+	tokens := strings.Fields(rest)
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		// A bracketed list: "[ 1 2 3 ]" on its own is the local run queues,
+		// "key=[ 1 2 3 ]" (e.g. schedticks) is skipped.
+		if tok == "[" || strings.HasSuffix(tok, "=[") {
+			var list []int
+			for i++; i < len(tokens) && tokens[i] != "]"; i++ {
+				n, err := strconv.Atoi(tokens[i])
+				if err != nil {
+					return Sample{}, fmt.Errorf("schedtrace: bad list entry %q", tokens[i])
+				}
+				list = append(list, n)
+			}
+			if tok == "[" {
+				s.LocalRunQueues = list
+			}
+			continue
+		}
+
+		key, val, ok := strings.Cut(tok, "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			continue // not a number we understand; keep going
+		}
+		s.Fields[key] = n
+	}
+
+	s.GOMAXPROCS = s.Fields["gomaxprocs"]
+	s.IdleProcs = s.Fields["idleprocs"]
+	s.Threads = s.Fields["threads"]
+	s.SpinningThreads = s.Fields["spinningthreads"]
+	s.IdleThreads = s.Fields["idlethreads"]
+	s.GlobalRunQueue = s.Fields["runqueue"]
+	return s, nil
+}
+
+// Run starts cmd with GODEBUG=schedtrace=<period> added to its environment,
+// calls onSample for every schedtrace line it writes to stderr, and waits
+// for it to exit. Other stderr lines are copied to passthrough if it is not
+// nil. Cancelling ctx kills the process.
+func Run(ctx context.Context, cmd *exec.Cmd, period time.Duration, onSample func(Sample), passthrough io.Writer) error {
+	if cmd.Process != nil {
+		return errors.New("schedtrace: command already started")
+	}
+	if period < time.Millisecond {
+		period = time.Millisecond
+	}
+	env := cmd.Env
+	if env == nil {
+		env = cmd.Environ()
+	}
+	cmd.Env = append(env, fmt.Sprintf("GODEBUG=schedtrace=%d", period.Milliseconds()))
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Kill the child if ctx is cancelled before it exits.
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-ctx.Done():
+			cmd.Process.Kill()
+		case <-exited:
+		}
+	}()
+
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		s, err := Parse(line)
+		switch {
+		case err == nil:
+			onSample(s)
+		case passthrough != nil:
+			fmt.Fprintln(passthrough, line)
+		}
+	}
+	// Read everything before Wait: Wait closes the pipe.
+	scanErr := scanner.Err()
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return scanErr
+}
+
+// Bar renders n as a bar of at most width characters, scaled so that full
+// fills the whole width. Useful for plotting samples in a terminal.
+func Bar(n, full, width int) string {
+	if full <= 0 || n <= 0 {
+		return ""
+	}
+	cells := n * width / full
+	if cells == 0 {
+		cells = 1 // show that there is SOMETHING
+	}
+	return strings.Repeat("█", min(cells, width))
+}
@@ -0,0 +1,46 @@
+package serialize_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/serialize"
+)
+
+type account struct{ balance int }
+
+func Example() {
+	acct := serialize.New(account{balance: 1000})
+	ctx := context.Background()
+
+	// Ten withdrawals of 200 race for 1000: exactly five succeed, with no
+	// lock anywhere.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := 0
+	for range 10 {
+		wg.Go(func() {
+			done, err := serialize.Do(ctx, acct, func(a *account) bool {
+				if a.balance < 200 {
+					return false
+				}
+				a.balance -= 200
+				return true
+			})
+			if err == nil && done {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	final := acct.Close()
+	fmt.Println("withdrawals:", ok, "balance:", final.balance)
+	fmt.Println(acct.Run(ctx, func(*account) {}))
+	// Output:
+	// withdrawals: 5 balance: 0
+	// serialize: executor closed
+}
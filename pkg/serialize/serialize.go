@@ -0,0 +1,124 @@
+// Package serialize runs closures, one at a time, on a goroutine that owns
+// a piece of state - the "monitor goroutine" or serialized dispatcher.
+//
+// A mutex protects state by convention: every access must remember to take
+// it, and nothing stops one from forgetting. An Executor makes the
+// convention structural. The state lives in one goroutine and is reachable
+// only from functions sent to it over a channel, so exactly one of them
+// runs at a time and no lock is taken anywhere - "don't communicate by
+// sharing memory; share memory by communicating".
+//
+// In use:
+//
+//	acct := serialize.New(Account{Balance: 1000})
+//	defer acct.Close()
+//	ok, err := serialize.Do(ctx, acct, func(a *Account) bool {
+//		if a.Balance < 200 {
+//			return false
+//		}
+//		a.Balance -= 200
+//		return true
+//	})
+//
+// The price: every operation is a channel round trip plus a goroutine
+// switch, and a slow function holds up everyone queued behind it, just as
+// a long critical section would. A function must not call Do on its own
+// Executor; it would wait for itself forever.
+package serialize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClosed is returned by Do and Run after Close.
+var ErrClosed = errors.New("serialize: executor closed")
+
+// op is one function to run on the state, and where to report that it ran.
+type op[S any] struct {
+	fn   func(*S)
+	done chan error // buffered: the loop never waits for the caller
+}
+
+// Executor owns a value of type S and runs functions on it one at a time.
+// Create it with New.
+type Executor[S any] struct {
+	ops       chan op[S]
+	quit      chan struct{}
+	exited    chan struct{} // closed when the loop has returned
+	closeOnce sync.Once
+	state     S // touched only by the loop, and by Close after it exited
+}
+
+// New starts the goroutine that owns state.
+func New[S any](state S) *Executor[S] {
+	e := &Executor[S]{
+		ops:    make(chan op[S]),
+		quit:   make(chan struct{}),
+		exited: make(chan struct{}),
+		state:  state,
+	}
+	go e.loop()
+	return e
+}
+
+func (e *Executor[S]) loop() {
+	defer close(e.exited)
+	for {
+		select {
+		case o := <-e.ops:
+			o.done <- e.call(o.fn)
+		case <-e.quit:
+			return
+		}
+	}
+}
+
+// call runs fn on the state. A panic is returned as an error, so one bad
+// function doesn't kill the goroutine every other caller depends on.
+func (e *Executor[S]) call(fn func(*S)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("serialize: function panicked: %v", r)
+		}
+	}()
+	fn(&e.state)
+	return nil
+}
+
+// Run runs fn on the state and waits until it has. If ctx is done or the
+// Executor closed before fn is accepted, fn does not run and Run returns
+// the reason. Once accepted, fn runs to completion and Run waits for it
+// regardless of ctx: a caller always knows whether its change happened.
+// fn must not keep the pointer it is given.
+func (e *Executor[S]) Run(ctx context.Context, fn func(state *S)) error {
+	if err := ctx.Err(); err != nil {
+		return err // checked first: select picks at random among ready cases
+	}
+	o := op[S]{fn: fn, done: make(chan error, 1)}
+	select {
+	case e.ops <- o:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-e.quit:
+		return ErrClosed
+	}
+	return <-o.done
+}
+
+// Do runs fn on e's state like Run and returns fn's result.
+func Do[S, R any](ctx context.Context, e *Executor[S], fn func(state *S) R) (R, error) {
+	var r R
+	err := e.Run(ctx, func(s *S) { r = fn(s) })
+	return r, err
+}
+
+// Close stops accepting functions, waits for the one running, if any, and
+// returns the final state. It is safe to call more than once.
+func (e *Executor[S]) Close() S {
+	e.closeOnce.Do(func() { close(e.quit) })
+	<-e.exited
+	return e.state
+}
@@ -0,0 +1,50 @@
+package shardedpool_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/shardedpool"
+)
+
+func Example() {
+	p := shardedpool.New(4, 16)
+	ctx := context.Background()
+
+	// Tasks for one key run one at a time, in order, so its state needs no
+	// lock; each key's slice is touched only by that key's shard.
+	history := map[string]*[]int{"alice": new([]int), "bob": new([]int)}
+	for i := range 6 {
+		for key, h := range history {
+			p.Submit(ctx, key, func() { *h = append(*h, i) })
+		}
+		if i == 2 {
+			p.Resize(7) // keys may move; their order survives
+		}
+	}
+	p.Close()
+	fmt.Println("alice", *history["alice"])
+	fmt.Println("bob", *history["bob"])
+	fmt.Println(p.Submit(ctx, "alice", func() {}))
+	// Output:
+	// alice [0 1 2 3 4 5]
+	// bob [0 1 2 3 4 5]
+	// shardedpool: closed
+}
+
+func ExampleShard() {
+	// Growing from 10 to 11 shards moves only the keys the new shard takes.
+	moved := 0
+	for i := range 10000 {
+		key := fmt.Sprint("user-", i)
+		if old, now := shardedpool.Shard(key, 10), shardedpool.Shard(key, 11); old != now {
+			if now != 10 {
+				fmt.Println("key moved between old shards:", key)
+			}
+			moved++
+		}
+	}
+	fmt.Println("moved about 1/11:", moved > 700 && moved < 1100)
+	// Output:
+	// moved about 1/11: true
+}
@@ -0,0 +1,66 @@
+package sketch
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// ============================================================================
+// Count-min sketch (Cormode & Muthukrishnan, 2005)
+// ============================================================================
+// d rows of w counters, each row with its own hash. Add increments one
+// counter per row; Estimate takes the MINIMUM over the rows. Collisions can
+// only ever add to a counter, so the estimate never undercounts - it
+// overcounts by at most ε·N (N = total adds) with probability 1-δ, where
+// w = ⌈e/ε⌉ and d = ⌈ln(1/δ)⌉.
+//
+// Concurrency: counters only increase, so Add is d independent atomic adds.
+// Two concurrent Adds of the same item may interleave row by row; that is
+// harmless because every row still ends up with both increments.
+// ============================================================================
+
+// CountMin estimates how many times each item was added. It is safe for
+// concurrent use.
+type CountMin struct {
+	width, depth uint64
+	counters     []atomic.Uint64 // depth rows of width counters
+}
+
+// NewCountMin returns a sketch whose estimates overcount by at most
+// epsilon × (total count) with probability at least 1-delta. epsilon must be
+// positive and delta between 0 and 1.
+func NewCountMin(epsilon, delta float64) *CountMin {
+	if !(epsilon > 0) || !(delta > 0 && delta < 1) {
+		panic("sketch: CountMin needs epsilon > 0 and 0 < delta < 1")
+	}
+	width := uint64(math.Ceil(math.E / epsilon))
+	depth := uint64(math.Ceil(math.Log(1 / delta)))
+	return &CountMin{width: width, depth: depth, counters: make([]atomic.Uint64, width*depth)}
+}
+
+// cells calls fn with the counter index for item in every row. The d row
+// hashes are derived from one 64-bit hash (Kirsch & Mitzenmacher): h1 + i·h2.
+func (c *CountMin) cells(item string, fn func(i uint64)) {
+	x := hash(item)
+	h1, h2 := x&0xffffffff, x>>32|1 // odd h2 so rows differ
+	for row := range c.depth {
+		fn(row*c.width + (h1+row*h2)%c.width)
+	}
+}
+
+// Add adds n to item's count.
+func (c *CountMin) Add(item string, n uint64) {
+	c.cells(item, func(i uint64) { c.counters[i].Add(n) })
+}
+
+// Estimate returns an upper bound on item's count.
+func (c *CountMin) Estimate(item string) uint64 {
+	est := uint64(math.MaxUint64)
+	c.cells(item, func(i uint64) { est = min(est, c.counters[i].Load()) })
+	return est
+}
+
+// SizeBytes returns the memory used by the counters.
+func (c *CountMin) SizeBytes() int {
+	return len(c.counters) * 8
+}
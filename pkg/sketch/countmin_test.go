@@ -0,0 +1,39 @@
+package sketch
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewCountMinPanicsOnBadBounds(t *testing.T) {
+	for _, tc := range []struct{ epsilon, delta float64 }{
+		{0, 0.01}, {-0.1, 0.01}, {math.NaN(), 0.01},
+		{0.01, 0}, {0.01, 1}, {0.01, -1}, {0.01, math.NaN()},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewCountMin(%v, %v) did not panic", tc.epsilon, tc.delta)
+				}
+			}()
+			NewCountMin(tc.epsilon, tc.delta)
+		}()
+	}
+}
+
+// TestCountMinNeverUndercounts checks the one guarantee the sketch makes
+// without probability: an estimate is never below the true count.
+func TestCountMinNeverUndercounts(t *testing.T) {
+	c := NewCountMin(0.01, 0.01)
+	want := map[string]uint64{}
+	for i := range 1000 {
+		item, n := string(rune('a'+i%26)), uint64(i%7)
+		c.Add(item, n)
+		want[item] += n
+	}
+	for item, n := range want {
+		if est := c.Estimate(item); est < n {
+			t.Errorf("Estimate(%q) = %d, below the true count %d", item, est, n)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package sketch_test
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/sketch"
+)
+
+func ExampleHyperLogLog_Merge() {
+	// Four workers, each with its own sketch: no sharing while counting.
+	perWorker := make([]*sketch.HyperLogLog, 4)
+	var wg sync.WaitGroup
+	for w := range perWorker {
+		h := sketch.NewHyperLogLog(14)
+		perWorker[w] = h
+		wg.Go(func() {
+			for i := range 50_000 {
+				h.Add(fmt.Sprint("user-", (w*25_000+i)%100_000)) // overlapping ranges
+			}
+		})
+	}
+	wg.Wait()
+
+	total := sketch.NewHyperLogLog(14)
+	for _, h := range perWorker {
+		total.Merge(h)
+	}
+	est := float64(total.Count())
+	fmt.Println("100000 distinct users, estimate within 3%:", math.Abs(est-100_000) < 3_000)
+	fmt.Println(total.Merge(sketch.NewHyperLogLog(10)))
+	// Output:
+	// 100000 distinct users, estimate within 3%: true
+	// sketch: incompatible sketches
+}
+
+func ExampleCountMin() {
+	c := sketch.NewCountMin(0.001, 0.01)
+	c.Add("hot", 5000)
+	for i := range 10_000 {
+		c.Add(fmt.Sprint("cold-", i), 1)
+	}
+	// Never under; over by at most 0.1% of the 15000 total with 99%
+	// probability, and by far less than 1% in practice.
+	hot := c.Estimate("hot")
+	fmt.Println("hot:", hot >= 5000 && hot < 5150)
+	fmt.Println("cold-7:", c.Estimate("cold-7") < 150)
+	// Output:
+	// hot: true
+	// cold-7: true
+}
@@ -0,0 +1,108 @@
+package sketch
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// ============================================================================
+// HyperLogLog (Flajolet et al., 2007)
+// ============================================================================
+// Hash every item. Use the first p bits to pick one of m = 2^p registers
+// and remember, per register, the longest run of leading zeros seen in the
+// rest of the hash. Seeing k leading zeros is a 1-in-2^k event, so long runs
+// mean many distinct items. Averaging over m registers (harmonic mean)
+// tames the noise: the standard error is about 1.04/√m - 0.8% for p = 14,
+// in 16K registers, for ANY number of items.
+//
+// Concurrency: a register only ever moves UP. An update is "set r to
+// max(r, v)", which is a CAS loop, and once the sketch has warmed up almost
+// every update finds r already ≥ v and returns after a single atomic load.
+// Reads-mostly atomics scale across cores far better than a mutex.
+// ============================================================================
+
+// ErrIncompatible is returned when merging sketches with different shapes.
+var ErrIncompatible = errors.New("sketch: incompatible sketches")
+
+// HyperLogLog estimates the number of distinct items added to it. It is safe
+// for concurrent use.
+type HyperLogLog struct {
+	p         uint8
+	registers []atomic.Uint32 // uint8 would do; 32 bits is the smallest atomic
+}
+
+// NewHyperLogLog returns a sketch with 2^precision registers. precision must
+// be between 4 and 18; 14 (16K registers, ~0.8% error) is a good default.
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < 4 || precision > 18 {
+		panic("sketch: HyperLogLog precision must be between 4 and 18")
+	}
+	return &HyperLogLog{p: precision, registers: make([]atomic.Uint32, 1<<precision)}
+}
+
+// Add records item.
+func (h *HyperLogLog) Add(item string) {
+	x := hash(item)
+	idx := x >> (64 - h.p)
+	// Leading zeros in the remaining 64-p bits, plus one. The sentinel bit
+	// caps the run if the remaining bits are all zero.
+	rank := uint32(bits.LeadingZeros64(x<<h.p|1<<(h.p-1))) + 1
+
+	r := &h.registers[idx]
+	for {
+		old := r.Load()
+		if rank <= old || r.CompareAndSwap(old, rank) {
+			return // the common case after warm-up: nothing to write
+		}
+	}
+}
+
+// Count returns the estimated number of distinct items added. It may run
+// concurrently with Add; the estimate then reflects some of those Adds.
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(len(h.registers))
+	var sum float64
+	zeros := 0
+	for i := range h.registers {
+		r := h.registers[i].Load()
+		sum += math.Ldexp(1, -int(r)) // 2^-r
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Small cardinalities: many registers are still empty and "linear
+		// counting" on the empty ones is more accurate.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// Merge folds other into h, so h estimates the distinct items added to
+// either sketch. Both must have the same precision.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h.p != other.p {
+		return ErrIncompatible
+	}
+	for i := range other.registers {
+		v := other.registers[i].Load()
+		r := &h.registers[i]
+		for {
+			old := r.Load()
+			if v <= old || r.CompareAndSwap(old, v) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// SizeBytes returns the memory used by the registers.
+func (h *HyperLogLog) SizeBytes() int {
+	return len(h.registers) * 4
+}
@@ -0,0 +1,36 @@
+// Package sketch provides concurrent approximate counting structures: a
+// HyperLogLog for "how many DIFFERENT items?" and a count-min sketch for
+// "how often did I see THIS item?".
+//
+// Both answer in fixed memory no matter how many items stream through, and
+// both are safe for concurrent use without a lock:
+//
+//   - HyperLogLog registers only ever grow, so an update is an atomic
+//     "store the max" (a CAS loop that usually doesn't even write).
+//   - Count-min counters only ever increase, so an update is an atomic add
+//     in each row.
+//
+// Sketches built in the same process share one hash seed, so per-goroutine
+// sketches can be merged at the end instead of sharing one - the
+// synchronization-free option the ch03 approximate-counting demo compares
+// against:
+//
+//	// each worker, with its own h := sketch.NewHyperLogLog(14):
+//	h.Add(userID)
+//	// at the end:
+//	total := sketch.NewHyperLogLog(14)
+//	for _, h := range perWorker {
+//		total.Merge(h) // same precision, so no error
+//	}
+//	fmt.Println("distinct users ≈", total.Count())
+package sketch
+
+import "hash/maphash"
+
+// seed is shared by every sketch in the process so that sketches can be
+// merged: the same item must hash to the same value in all of them.
+var seed = maphash.MakeSeed()
+
+func hash(item string) uint64 {
+	return maphash.String(seed, item)
+}
@@ -0,0 +1,42 @@
+package stats_test
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/stats"
+)
+
+func ExampleHistogram() {
+	h := stats.NewHistogram(stats.ExponentialBounds(1, 2, 10)...) // 1, 2, 4 ... 512
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Go(func() {
+			for v := g * 25; v < (g+1)*25; v++ {
+				h.Observe(float64(v + 1)) // 1..100 across the goroutines
+			}
+		})
+	}
+	wg.Wait()
+
+	s := h.Snapshot()
+	fmt.Println("count", s.Count, "min", s.Min, "max", s.Max, "mean", s.Mean())
+	fmt.Printf("median ≈ %.0f\n", s.Quantile(0.5))
+	// Output:
+	// count 100 min 1 max 100 mean 50.5
+	// median ≈ 50
+}
+
+func ExampleNewLatencyHistogram() {
+	h := stats.NewLatencyHistogram()
+	for range 99 {
+		h.ObserveDuration(2 * time.Millisecond)
+	}
+	h.ObserveDuration(time.Second) // one slow request
+	s := h.Snapshot()
+	p99, max := s.QuantileDuration(0.99), time.Duration(s.Max)
+	fmt.Println("p99 under 3ms:", p99 < 3*time.Millisecond, "max:", max)
+	// Output:
+	// p99 under 3ms: true max: 1s
+}
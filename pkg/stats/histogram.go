@@ -0,0 +1,181 @@
+// Package stats provides a histogram that many goroutines can record into
+// at once, with quantile estimates read from a snapshot.
+//
+// Collecting every latency into a slice and sorting it (as the first
+// producer-consumer benchmark did) is exact, but memory grows with the
+// number of observations and every recorder has to append somewhere. A
+// histogram keeps a fixed set of buckets instead:
+//
+//   - Observe finds the bucket with a binary search and does one atomic
+//     add. No lock, constant memory, safe from any number of goroutines.
+//   - Snapshot copies the counters; Quantile then walks the buckets and
+//     interpolates inside the one holding the requested rank.
+//
+// With exponential bucket bounds the relative error of a quantile is at
+// most the growth factor between bounds (≈20% worst case, usually far less,
+// for NewLatencyHistogram).
+//
+//	h := stats.NewLatencyHistogram()
+//	// in any number of goroutines:
+//	h.ObserveDuration(time.Since(start))
+//	// when reporting:
+//	s := h.Snapshot()
+//	fmt.Println(s.Count, s.QuantileDuration(0.99))
+package stats
+
+import (
+	"math"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Histogram counts observations into fixed buckets. It is safe for
+// concurrent use.
+type Histogram struct {
+	bounds []float64       // upper bound of each bucket, ascending
+	counts []atomic.Uint64 // len(bounds)+1: the last bucket is overflow
+
+	count   atomic.Uint64
+	sumBits atomic.Uint64 // float64 bits of the running sum
+	minBits atomic.Uint64 // float64 bits, +Inf until the first Observe
+	maxBits atomic.Uint64 // float64 bits, -Inf until the first Observe
+}
+
+// NewHistogram returns a histogram whose buckets end at the given upper
+// bounds, which must be in increasing order. Values above the last bound go
+// into an overflow bucket.
+func NewHistogram(bounds ...float64) *Histogram {
+	if len(bounds) == 0 || !slices.IsSorted(bounds) {
+		panic("stats: histogram bounds must be non-empty and sorted")
+	}
+	h := &Histogram{
+		bounds: slices.Clone(bounds),
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+	h.minBits.Store(math.Float64bits(math.Inf(1)))
+	h.maxBits.Store(math.Float64bits(math.Inf(-1)))
+	return h
+}
+
+// ExponentialBounds returns n bucket bounds start, start·factor,
+// start·factor², ...
+func ExponentialBounds(start, factor float64, n int) []float64 {
+	bounds := make([]float64, n)
+	for i := range bounds {
+		bounds[i] = start * math.Pow(factor, float64(i))
+	}
+	return bounds
+}
+
+// NewLatencyHistogram returns a histogram for durations recorded with
+// ObserveDuration: buckets grow by 20% from 1µs to about a minute.
+func NewLatencyHistogram() *Histogram {
+	return NewHistogram(ExponentialBounds(float64(time.Microsecond), 1.2, 100)...)
+}
+
+// Observe records one value.
+func (h *Histogram) Observe(v float64) {
+	i, _ := slices.BinarySearch(h.bounds, v) // first bucket whose bound is ≥ v
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	updateFloat(&h.sumBits, func(old float64) (float64, bool) { return old + v, true })
+	updateFloat(&h.minBits, func(old float64) (float64, bool) { return v, v < old })
+	updateFloat(&h.maxBits, func(old float64) (float64, bool) { return v, v > old })
+}
+
+// ObserveDuration records d in nanoseconds.
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(float64(d))
+}
+
+// updateFloat applies fn to the float64 stored in bits with a CAS loop. fn
+// returns the new value and whether to store it at all.
+func updateFloat(bits *atomic.Uint64, fn func(old float64) (float64, bool)) {
+	for {
+		oldBits := bits.Load()
+		v, ok := fn(math.Float64frombits(oldBits))
+		if !ok || bits.CompareAndSwap(oldBits, math.Float64bits(v)) {
+			return
+		}
+	}
+}
+
+// Snapshot is a point-in-time copy of a Histogram. Observations that race
+// with Snapshot may be partly included (e.g. in Count but not yet in a
+// bucket); Quantile tolerates that.
+type Snapshot struct {
+	Count    uint64
+	Sum      float64
+	Min, Max float64
+
+	bounds []float64
+	counts []uint64
+}
+
+// Snapshot copies the histogram's current state.
+func (h *Histogram) Snapshot() Snapshot {
+	s := Snapshot{
+		Count:  h.count.Load(),
+		Sum:    math.Float64frombits(h.sumBits.Load()),
+		Min:    math.Float64frombits(h.minBits.Load()),
+		Max:    math.Float64frombits(h.maxBits.Load()),
+		bounds: h.bounds,
+		counts: make([]uint64, len(h.counts)),
+	}
+	for i := range h.counts {
+		s.counts[i] = h.counts[i].Load()
+	}
+	return s
+}
+
+// Mean returns the average observed value, or 0 if nothing was observed.
+func (s Snapshot) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Quantile estimates the q-quantile (0 ≤ q ≤ 1) by linear interpolation
+// inside the bucket that holds it. It returns 0 if nothing was observed.
+func (s Snapshot) Quantile(q float64) float64 {
+	var total uint64
+	for _, c := range s.counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+	var seen uint64
+	for i, c := range s.counts {
+		if c == 0 || float64(seen+c) < rank {
+			seen += c
+			continue
+		}
+		// The bucket spans (lower, upper]; tighten the open ends with the
+		// observed min and max.
+		lower, upper := s.Min, s.Max
+		if i > 0 {
+			lower = max(lower, s.bounds[i-1])
+		}
+		if i < len(s.bounds) {
+			upper = min(upper, s.bounds[i])
+		}
+		frac := (rank - float64(seen)) / float64(c)
+		return lower + frac*(upper-lower)
+	}
+	return s.Max
+}
+
+// QuantileDuration is Quantile for histograms filled with ObserveDuration.
+func (s Snapshot) QuantileDuration(q float64) time.Duration {
+	return time.Duration(s.Quantile(q))
+}
+
+// MeanDuration is Mean for histograms filled with ObserveDuration.
+func (s Snapshot) MeanDuration() time.Duration {
+	return time.Duration(s.Mean())
+}
@@ -0,0 +1,34 @@
+package ticketlock_test
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/ticketlock"
+)
+
+func ExampleMutex() {
+	var mu ticketlock.Mutex
+	var order []string
+	var wg sync.WaitGroup
+
+	mu.Lock()
+	for i, name := range []string{"first", "second", "third"} {
+		wg.Go(func() {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		})
+		for mu.Waiting() < i+1 { // queued before the next one starts
+			runtime.Gosched()
+		}
+	}
+	fmt.Println("waiting:", mu.Waiting(), "TryLock:", mu.TryLock())
+	mu.Unlock()
+	wg.Wait()
+	fmt.Println(order) // granted in arrival order
+	// Output:
+	// waiting: 3 TryLock: false
+	// [first second third]
+}
@@ -0,0 +1,74 @@
+// Package ticketlock provides a fair, FIFO mutual exclusion lock.
+//
+// A ticket lock works like the "take a number" machine at a deli counter:
+//
+//   - Lock takes the next ticket (an atomic increment of next) and waits
+//     until the "now serving" counter shows that number.
+//   - Unlock advances "now serving" by one, handing the lock to whoever
+//     holds the next ticket.
+//
+// Because tickets are handed out in arrival order, the lock is granted in
+// arrival order too: no goroutine can barge in ahead of one that has been
+// waiting longer. sync.Mutex makes no such promise (it lets a running
+// goroutine re-grab the lock it just released, and only switches to FIFO
+// "starvation mode" after a waiter has been stuck for 1ms), which is why the
+// greedy worker in ch01's starvation demo can hog it.
+//
+// The price of fairness: waiters spin (yielding the processor between
+// checks) instead of parking, and every hand-off must go to one specific
+// goroutine even if another runnable one could have used the lock sooner.
+// Use it to learn from, not as a drop-in replacement for sync.Mutex. The API
+// is sync.Mutex's:
+//
+//	var mu ticketlock.Mutex
+//	mu.Lock()
+//	defer mu.Unlock()
+package ticketlock
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// Mutex is a FIFO ticket lock. The zero value is an unlocked mutex.
+//
+// A Mutex must not be copied after first use.
+type Mutex struct {
+	next    atomic.Uint32 // next ticket to hand out
+	serving atomic.Uint32 // ticket currently allowed to hold the lock
+}
+
+// Lock acquires m, waiting behind every goroutine that called Lock earlier.
+func (m *Mutex) Lock() {
+	ticket := m.next.Add(1) - 1 // take a number
+	for m.serving.Load() != ticket {
+		// Yield instead of burning the whole time slice: the holder may
+		// need this very processor to finish its critical section.
+		runtime.Gosched()
+	}
+}
+
+// TryLock acquires m only if nobody holds it and nobody is queued for it.
+// It reports whether it succeeded.
+func (m *Mutex) TryLock() bool {
+	serving := m.serving.Load()
+	// Only take a ticket if it would be served immediately.
+	return m.next.CompareAndSwap(serving, serving+1)
+}
+
+// Unlock releases m, passing it to the goroutine holding the next ticket.
+// Unlike sync.Mutex, unlocking an unlocked Mutex is not detected: it skips a
+// ticket and lets two goroutines into the critical section.
+func (m *Mutex) Unlock() {
+	m.serving.Add(1)
+}
+
+// Waiting returns how many goroutines are currently queued behind the
+// holder (0 if the lock is free or held with nobody waiting).
+func (m *Mutex) Waiting() int {
+	n := int(m.next.Load() - m.serving.Load())
+	if n <= 1 {
+		return 0
+	}
+	return n - 1
+}
@@ -0,0 +1,103 @@
+package ticketlock
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMutualExclusion(t *testing.T) {
+	const goroutines, iterations = 8, 2000
+	var (
+		mu     Mutex
+		count  int // plain: -race reports it if two goroutines get in
+		inside atomic.Int32
+		wg     sync.WaitGroup
+	)
+	for range goroutines {
+		wg.Go(func() {
+			for range iterations {
+				mu.Lock()
+				if n := inside.Add(1); n != 1 {
+					t.Errorf("%d goroutines in the critical section", n)
+				}
+				count++
+				inside.Add(-1)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if count != goroutines*iterations {
+		t.Errorf("count = %d, want %d", count, goroutines*iterations)
+	}
+}
+
+// queue starts n goroutines that wait for mu, which the caller holds, one
+// at a time: each is queued before the next one starts, so they hold
+// tickets 1..n in order. Each appends its index to order in its critical
+// section.
+func queue(mu *Mutex, n int, order *[]int, wg *sync.WaitGroup) {
+	for i := range n {
+		wg.Go(func() {
+			mu.Lock()
+			*order = append(*order, i)
+			mu.Unlock()
+		})
+		for mu.Waiting() != i+1 {
+			runtime.Gosched()
+		}
+	}
+}
+
+func TestFIFOOrder(t *testing.T) {
+	const n = 16
+	var (
+		mu    Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	mu.Lock()
+	queue(&mu, n, &order, &wg)
+	mu.Unlock()
+	wg.Wait()
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("lock granted in order %v, want arrival order 0..%d", order, n-1)
+		}
+	}
+	if len(order) != n {
+		t.Fatalf("%d goroutines got the lock, want %d", len(order), n)
+	}
+}
+
+func TestTryLock(t *testing.T) {
+	var mu Mutex
+	if !mu.TryLock() {
+		t.Fatal("TryLock failed on a free mutex")
+	}
+	if mu.TryLock() {
+		t.Fatal("TryLock succeeded on a held mutex")
+	}
+
+	// Held, with a goroutine queued: TryLock must not jump the queue,
+	// neither now nor after the hand-off to the waiter.
+	var (
+		order []int
+		wg    sync.WaitGroup
+	)
+	queue(&mu, 1, &order, &wg)
+	if mu.TryLock() {
+		t.Fatal("TryLock succeeded on a held mutex with a waiter")
+	}
+	mu.Unlock()
+	wg.Wait()
+	if !mu.TryLock() {
+		t.Fatal("TryLock failed once the waiter had come and gone")
+	}
+	mu.Unlock()
+	if mu.Waiting() != 0 {
+		t.Errorf("Waiting() = %d on a free mutex", mu.Waiting())
+	}
+}
@@ -0,0 +1,34 @@
+package timeutil_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/timeutil"
+)
+
+func ExampleSleep() {
+	fmt.Println(timeutil.Sleep(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fmt.Println(timeutil.Sleep(ctx, time.Hour)) // returns at once
+	// Output:
+	// <nil>
+	// context canceled
+}
+
+func ExampleTick() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ticks := 0
+	for range timeutil.Tick(ctx, time.Millisecond) {
+		if ticks++; ticks == 3 {
+			cancel() // the channel closes and the loop ends
+		}
+	}
+	fmt.Println(ticks, "ticks")
+	// Output:
+	// 3 ticks
+}
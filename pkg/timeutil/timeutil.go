@@ -0,0 +1,68 @@
+// Package timeutil holds the timer helpers the demos kept writing inline.
+package timeutil
+
+import (
+	"context"
+	"time"
+)
+
+// Sleep pauses for d, or until ctx is done, whichever comes first. It
+// returns nil after a full sleep and ctx.Err() if ctx ended it early, so a
+// loop can write
+//
+//	if err := timeutil.Sleep(ctx, backoff); err != nil {
+//		return err
+//	}
+//
+// Unlike a select on time.After, the timer is stopped as soon as Sleep
+// returns instead of lingering until d has passed.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SleepCtx is the old name of Sleep.
+//
+// Deprecated: Use Sleep.
+func SleepCtx(ctx context.Context, d time.Duration) error {
+	return Sleep(ctx, d)
+}
+
+// Tick sends the current time every d until ctx is done, then closes the
+// channel, so
+//
+//	for now := range timeutil.Tick(ctx, time.Second) { ... }
+//
+// ends by itself on cancellation. time.Tick has no way to stop: its ticker
+// and whoever ranges over it live as long as the program. Like a
+// time.Ticker, Tick drops ticks for a receiver that falls behind rather
+// than queueing them.
+func Tick(ctx context.Context, d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	go func() {
+		defer close(ch)
+		t := time.NewTicker(d)
+		defer t.Stop()
+		for {
+			select {
+			case now := <-t.C:
+				select {
+				case ch <- now:
+				default: // the receiver is behind: drop this tick
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
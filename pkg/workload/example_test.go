@@ -0,0 +1,32 @@
+package workload_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/workload"
+)
+
+func ExampleProfile() {
+	for _, w := range []workload.Profile{
+		workload.CPU(1000),
+		workload.IO(time.Millisecond),
+		workload.Mixed(1000, time.Millisecond),
+	} {
+		fmt.Println(w.Kind, w.Run())
+	}
+	// Output:
+	// cpu 499500
+	// io 0
+	// mixed 499500
+}
+
+func ExampleCalibrate() {
+	n := workload.Calibrate(10 * time.Millisecond)
+	start := time.Now()
+	workload.Spin(n)
+	took := time.Since(start)
+	fmt.Println("iterations found:", n > 0, "roughly 10ms:", took < 100*time.Millisecond)
+	// Output:
+	// iterations found: true roughly 10ms: true
+}
@@ -0,0 +1,86 @@
+// Package workload provides synthetic units of work with a known shape:
+// CPU-bound (a busy loop), IO-bound (a sleep standing in for a network or
+// disk wait) or a mix of both. The demos use them to show how each kind
+// responds to more goroutines and more cores.
+//
+//	w := workload.Mixed(2_000_000, 5*time.Millisecond)
+//	w.Run() // spins 2M iterations, then waits 5ms
+//
+// The key difference: a goroutine in a Sleep (or a real read) gives its
+// core away, so IO-bound work overlaps even on GOMAXPROCS=1; a spinning
+// goroutine needs the core the whole time.
+package workload
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kind is the shape of a Profile.
+type Kind int
+
+const (
+	KindCPU Kind = iota
+	KindIO
+	KindMixed
+)
+
+func (k Kind) String() string {
+	return [...]string{"cpu", "io", "mixed"}[k]
+}
+
+// Profile is one unit of work: Iterations of busy loop followed by a wait
+// of Wait.
+type Profile struct {
+	Kind       Kind
+	Iterations int
+	Wait       time.Duration
+}
+
+// CPU returns a CPU-bound profile of iterations loop iterations.
+func CPU(iterations int) Profile { return Profile{KindCPU, iterations, 0} }
+
+// IO returns an IO-bound profile that waits for d.
+func IO(d time.Duration) Profile { return Profile{KindIO, 0, d} }
+
+// Mixed returns a profile that computes, then waits.
+func Mixed(iterations int, d time.Duration) Profile { return Profile{KindMixed, iterations, d} }
+
+// Run does the work and returns the loop's result, so the compiler cannot
+// drop the loop.
+func (p Profile) Run() int {
+	sum := Spin(p.Iterations)
+	if p.Wait > 0 {
+		time.Sleep(p.Wait)
+	}
+	return sum
+}
+
+func (p Profile) String() string {
+	switch p.Kind {
+	case KindCPU:
+		return fmt.Sprintf("cpu(%d)", p.Iterations)
+	case KindIO:
+		return fmt.Sprintf("io(%v)", p.Wait)
+	}
+	return fmt.Sprintf("mixed(%d, %v)", p.Iterations, p.Wait)
+}
+
+// Spin is CPU-intensive work (not just sleeping): it sums 0..iterations-1,
+// which forces the runtime to keep a core busy.
+func Spin(iterations int) int {
+	sum := 0
+	for i := range iterations {
+		sum += i
+	}
+	return sum
+}
+
+// Calibrate returns about how many Spin iterations take d on this machine.
+func Calibrate(d time.Duration) int {
+	const probe = 10_000_000
+	start := time.Now()
+	Spin(probe)
+	elapsed := max(time.Since(start), time.Microsecond)
+	return int(float64(probe) * float64(d) / float64(elapsed))
+}
@@ -0,0 +1,26 @@
+package yield_test
+
+import (
+	"fmt"
+
+	"github.com/mintecr7/concurrency-with-go/pkg/yield"
+)
+
+func ExampleEvery() {
+	y := yield.Every(100)
+	sum := 0
+	for i := range 1000 {
+		sum += i
+		y.Tick() // lets goroutines waiting for this P run, every 100 steps
+	}
+	fmt.Println(sum, "yields:", y.Yields())
+
+	never := yield.Every(0) // the baseline: never yields
+	for range 1000 {
+		never.Tick()
+	}
+	fmt.Println("baseline yields:", never.Yields())
+	// Output:
+	// 499500 yields: 10
+	// baseline yields: 0
+}
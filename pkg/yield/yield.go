@@ -0,0 +1,74 @@
+// Package yield lets a long CPU-bound loop give up its processor now and
+// then, so that goroutines waiting for the same P get to run sooner.
+//
+// Go's scheduler preempts, but coarsely: a goroutine is switched out when
+// it blocks, calls into the scheduler, or - since Go 1.14 - has run for
+// about 10ms and sysmon interrupts it. A goroutine that must react within a
+// millisecond and shares a P with a number-crunching loop waits up to that
+// 10ms slice every time (or for the whole loop, with asyncpreemptoff=1).
+//
+// runtime.Gosched hands the P back at once: the loop goes to the back of
+// the run queue and whatever is waiting runs. It costs a trip through the
+// scheduler, though, so calling it on every iteration of a fine-grained
+// loop wastes much of the CPU the loop was after. Every calls it on every
+// nth step only. Choose n so that n steps take about as long as the others
+// can afford to wait: steps of 1µs and a 100µs budget make n = 100.
+//
+// In use:
+//
+//	y := yield.Every(100)
+//	for _, block := range blocks {
+//		compress(block)
+//		y.Tick()
+//	}
+//
+// Yielding is cooperation, not priority: it helps only the goroutines that
+// are runnable on the same P when the loop yields. With spare Ps they run
+// elsewhere anyway, and nothing here makes the loop itself run less often.
+package yield
+
+import "runtime"
+
+// Yielder counts the steps of one loop and yields every nth. Create it with
+// Every. It belongs to the goroutine running the loop and is not safe for
+// concurrent use.
+type Yielder struct {
+	n, left int
+	yields  int64
+}
+
+// Every returns a Yielder that yields on every nth call of Tick. With
+// n <= 0 it never yields, which makes a handy baseline.
+func Every(n int) Yielder {
+	return Yielder{n: n, left: n}
+}
+
+// Tick counts one step and, on every nth, yields the processor. It reports
+// whether it yielded.
+//
+// The common path is small enough to be inlined, so a loop calling Tick
+// makes no function call between yields - and so, like any call-free loop,
+// gains no cooperative preemption point from it (see the ch03 yielding
+// demo).
+func (y *Yielder) Tick() bool {
+	if y.n <= 0 {
+		return false
+	}
+	if y.left--; y.left > 0 {
+		return false
+	}
+	return y.yield()
+}
+
+// yield is the slow path, kept out of line so that Tick stays inlinable.
+//
+//go:noinline
+func (y *Yielder) yield() bool {
+	y.left = y.n
+	y.yields++
+	runtime.Gosched()
+	return true
+}
+
+// Yields returns how many times Tick has yielded.
+func (y *Yielder) Yields() int64 { return y.yields }